@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"encoding/xml"
 	"fmt"
+	"math"
 	"os"
 )
 
@@ -16,14 +17,30 @@ type ThreeMFModel struct {
 	Schema  string   `xml:"xmlns,attr"`
 	Unit    string   `xml:"unit,attr"`
 	// These aren't grouping into the array field
-	Resources []ThreeMFObject `xml:"resources>object"`
-	Build     []ThreeMFItem   `xml:"build>item"`
+	Materials []ThreeMFBaseMaterials `xml:"resources>basematerials"`
+	Resources []ThreeMFObject        `xml:"resources>object"`
+	Build     []ThreeMFItem          `xml:"build>item"`
+}
+
+type ThreeMFBaseMaterials struct {
+	XMLName xml.Name      `xml:"basematerials"`
+	ID      string        `xml:"id,attr"`
+	Bases   []ThreeMFBase `xml:"base"`
+}
+
+type ThreeMFBase struct {
+	XMLName      xml.Name `xml:"base"`
+	Name         string   `xml:"name,attr"`
+	DisplayColor string   `xml:"displaycolor,attr"`
 }
 
 type ThreeMFObject struct {
 	XMLName xml.Name    `xml:"object"`
 	ID      string      `xml:"id,attr"`
 	Type    string      `xml:"type,attr"`
+	Name    string      `xml:"name,attr,omitempty"`
+	PID     string      `xml:"pid,attr,omitempty"`
+	PIndex  string      `xml:"pindex,attr,omitempty"`
 	Mesh    ThreeMFMesh `xml:"mesh"`
 }
 
@@ -47,20 +64,15 @@ type ThreeMFTriangle struct {
 }
 
 type ThreeMFItem struct {
-	XMLName  xml.Name `xml:"item"`
-	ObjectID string   `xml:"objectid,attr"`
+	XMLName   xml.Name `xml:"item"`
+	ObjectID  string   `xml:"objectid,attr"`
+	Transform string   `xml:"transform,attr,omitempty"`
 }
 
 //-----------------------------------------------------------------------------
 
-// Save3MF writes a triangle mesh to an STL file.
-func Save3MF(path string, mesh []*Triangle3) error {
-	file, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
+// threeMFMesh returns the 3MF mesh for a set of triangles.
+func threeMFMesh(mesh []*Triangle3) ThreeMFMesh {
 	// dedupe the vertices
 	vertices := map[V3]int{}
 	for _, t := range mesh {
@@ -84,21 +96,39 @@ func Save3MF(path string, mesh []*Triangle3) error {
 		outputTriangles[i].V3 = vertices[t.V[2]]
 	}
 
+	return ThreeMFMesh{
+		Vertices:  outputVertices,
+		Triangles: outputTriangles,
+	}
+}
+
+// threeMFTransform returns the 3MF transform attribute for a matrix.
+// 3MF uses row vectors so the matrix is transposed.
+func threeMFTransform(m M44) string {
+	return fmt.Sprintf("%g %g %g %g %g %g %g %g %g %g %g %g",
+		m.x00, m.x10, m.x20,
+		m.x01, m.x11, m.x21,
+		m.x02, m.x12, m.x22,
+		m.x03, m.x13, m.x23)
+}
+
+// write3MF writes a 3MF model to a file.
+func write3MF(path string, materials []ThreeMFBaseMaterials, objects []ThreeMFObject, items []ThreeMFItem) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
 	buf := bufio.NewWriter(file)
 	fmt.Fprintln(buf, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>")
 	err = xml.NewEncoder(buf).Encode(ThreeMFModel{
-		Lang:   "en-US",
-		Schema: "http://schemas.microsoft.com/3dmanufacturing/core/2015/02",
-		Unit:   "mm",
-		Resources: []ThreeMFObject{
-			{ID: "1", Type: "model", Mesh: ThreeMFMesh{
-				Vertices:  outputVertices,
-				Triangles: outputTriangles,
-			}},
-		},
-		Build: []ThreeMFItem{
-			{ObjectID: "1"},
-		},
+		Lang:      "en-US",
+		Schema:    "http://schemas.microsoft.com/3dmanufacturing/core/2015/02",
+		Unit:      "mm",
+		Materials: materials,
+		Resources: objects,
+		Build:     items,
 	})
 	if err != nil {
 		return err
@@ -106,4 +136,114 @@ func Save3MF(path string, mesh []*Triangle3) error {
 	return buf.Flush()
 }
 
+// Save3MF writes a triangle mesh to a 3MF file.
+func Save3MF(path string, mesh []*Triangle3) error {
+	objects := []ThreeMFObject{{ID: "1", Type: "model", Mesh: threeMFMesh(mesh)}}
+	items := []ThreeMFItem{{ObjectID: "1"}}
+	return write3MF(path, nil, objects, items)
+}
+
+// Render3MF renders an SDF3 as a 3MF file. Sub-trees that are placed many
+// times (see FindInstances3) are meshed once and instanced in the file.
+// Materials (see Material3D) are written as 3MF base materials.
+func Render3MF(
+	s SDF3, //sdf3 to render
+	meshCells int, //number of cells on the longest axis. e.g 200 (<= 0 for AutoMeshCells)
+	path string, //path to filename
+) error {
+	if meshCells <= 0 {
+		meshCells = AutoMeshCells(s)
+	}
+	resolution := s.BoundingBox().Size().MaxComponent() / float64(meshCells)
+	instances := FindInstances3(s)
+	// The base materials group is the first resource.
+	var materials []ThreeMFBaseMaterials
+	pindex := map[*Material]string{}
+	for _, x := range instances {
+		if _, ok := pindex[x.Material]; ok || x.Material == nil {
+			continue
+		}
+		if materials == nil {
+			materials = []ThreeMFBaseMaterials{{ID: "1"}}
+		}
+		pindex[x.Material] = fmt.Sprintf("%d", len(materials[0].Bases))
+		materials[0].Bases = append(materials[0].Bases, ThreeMFBase{
+			Name:         x.Material.Name,
+			DisplayColor: colorString(x.Material.Color),
+		})
+	}
+	type key struct {
+		sdf      SDF3
+		material *Material
+	}
+	var objects []ThreeMFObject
+	var items []ThreeMFItem
+	ids := map[key]string{}
+	meshes := map[SDF3]ThreeMFMesh{}
+	for _, x := range instances {
+		k := key{x.SDF, x.Material}
+		id, ok := ids[k]
+		if !ok {
+			mesh, ok := meshes[x.SDF]
+			if !ok {
+				// mesh the sub-tree at the same resolution as the whole object
+				cells := int(math.Ceil(x.SDF.BoundingBox().Size().MaxComponent() / resolution))
+				mesh = threeMFMesh(RenderMesh(x.SDF, cells))
+				meshes[x.SDF] = mesh
+			}
+			id = fmt.Sprintf("%d", len(objects)+len(materials)+1)
+			ids[k] = id
+			obj := ThreeMFObject{ID: id, Type: "model", Mesh: mesh}
+			if x.Material != nil {
+				obj.Name = x.Material.Name
+				obj.PID = materials[0].ID
+				obj.PIndex = pindex[x.Material]
+			}
+			objects = append(objects, obj)
+		}
+		item := ThreeMFItem{ObjectID: id}
+		if !x.Matrix.Equals(Identity3d(), tolerance) {
+			item.Transform = threeMFTransform(x.Matrix)
+		}
+		items = append(items, item)
+	}
+	return write3MF(path, materials, objects, items)
+}
+
+//-----------------------------------------------------------------------------
+
+// Render3MFObjects renders SDF3s as separate objects in a single 3MF file.
+// The objects are meshed at a common resolution and keep their relative positions.
+// Empty objects are not written. See SplitByRegion.
+func Render3MFObjects(
+	objects []SDF3, //sdf3s to render
+	meshCells int, //number of cells on the longest axis of all the objects. e.g 200 (<= 0 for AutoMeshCells)
+	path string, //path to filename
+) error {
+	if len(objects) == 0 {
+		return fmt.Errorf("no objects to render")
+	}
+	bb := objects[0].BoundingBox()
+	for _, s := range objects[1:] {
+		bb = bb.Extend(s.BoundingBox())
+	}
+	if meshCells <= 0 {
+		meshCells = AutoMeshCells(Union3D(objects...))
+	}
+	resolution := bb.Size().MaxComponent() / float64(meshCells)
+	var out []ThreeMFObject
+	var items []ThreeMFItem
+	for _, s := range objects {
+		cells := int(math.Ceil(s.BoundingBox().Size().MaxComponent() / resolution))
+		mesh := RenderMesh(s, cells)
+		if len(mesh) == 0 {
+			continue
+		}
+		id := fmt.Sprintf("%d", len(out)+1)
+		out = append(out, ThreeMFObject{ID: id, Type: "model", Mesh: threeMFMesh(mesh)})
+		items = append(items, ThreeMFItem{ObjectID: id})
+	}
+	return write3MF(path, nil, out, items)
+}
+
 //-----------------------------------------------------------------------------
@@ -0,0 +1,199 @@
+//-----------------------------------------------------------------------------
+/*
+
+Adaptive Meshing
+
+Render an SDF3 to a mesh with a maximum chordal deviation (the distance
+between the mesh and the surface) rather than a grid cell size.
+
+A coarse mesh is generated with marching cubes to capture the topology (at a
+resolution from the smallest feature, see EstimateMinFeatureSize), the
+vertices are moved onto the surface and then triangles are refined where the
+surface deviates from them. The deviation is measured with the distance field
+at the edge midpoints and triangle centroids. Each edge is split (at the
+surface) if its midpoint is too far from the surface, a triangle with a
+centroid too far from the surface has all of its edges split. The split
+decisions are per edge so adjacent triangles agree and the mesh stays closed.
+
+Flat regions keep the coarse triangles, curved regions get smaller ones.
+Sharp edges are refined down to the level limit.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// adaptiveCells is the minimum number of cells on the longest axis of the coarse mesh.
+const adaptiveCells = 32
+
+// adaptiveLevels is the maximum number of refinement levels.
+const adaptiveLevels = 8
+
+// meshEdge is a mesh edge with the vertices in a canonical order.
+type meshEdge [2]V3
+
+func newMeshEdge(a, b V3) meshEdge {
+	if v3Less(b, a) {
+		a, b = b, a
+	}
+	return meshEdge{a, b}
+}
+
+// adaptiveMesh generates a mesh with a maximum chordal deviation from a
+// coarse mesh with the given resolution.
+func adaptiveMesh(s SDF3, resolution, chordError float64) []*Triangle3 {
+	// the coarse mesh
+	output := make(chan *Triangle3)
+	done := make(chan []*Triangle3)
+	go func() {
+		var mesh []*Triangle3
+		for t := range output {
+			mesh = append(mesh, t)
+		}
+		done <- mesh
+	}()
+	marchingCubesOctree(s, resolution, output)
+	close(output)
+	coarse := <-done
+
+	// weld the vertices (adjacent cubes may differ in the last bits) and
+	// move them onto the surface
+	weld := 1e-6 * resolution
+	onSurface := make(map[V3i]V3)
+	project := func(p V3) V3 {
+		k := V3i{int(math.Round(p.X / weld)), int(math.Round(p.Y / weld)), int(math.Round(p.Z / weld))}
+		q, ok := onSurface[k]
+		if !ok {
+			q = ClosestPoint3(s, p)
+			onSurface[k] = q
+		}
+		return q
+	}
+	eps := 1e-9 * s.BoundingBox().Size().MaxComponent()
+	var mesh []*Triangle3
+	for _, t := range coarse {
+		t = NewTriangle3(project(t.V[0]), project(t.V[1]), project(t.V[2]))
+		if !t.Degenerate(eps) {
+			mesh = append(mesh, t)
+		}
+	}
+
+	deviation := func(p V3) bool {
+		return Abs(s.Evaluate(p)) > chordError
+	}
+	for level := 0; level < adaptiveLevels; level++ {
+		// mark the edges to be split
+		split := make(map[meshEdge]V3)
+		for _, t := range mesh {
+			c := t.V[0].Add(t.V[1]).Add(t.V[2]).DivScalar(3)
+			all := deviation(c)
+			for i := 0; i < 3; i++ {
+				a, b := t.V[i], t.V[(i+1)%3]
+				e := newMeshEdge(a, b)
+				if _, ok := split[e]; ok {
+					continue
+				}
+				if all || deviation(a.Add(b).MulScalar(0.5)) {
+					split[e] = V3{}
+				}
+			}
+		}
+		if len(split) == 0 {
+			break
+		}
+		for e := range split {
+			split[e] = ClosestPoint3(s, e[0].Add(e[1]).MulScalar(0.5))
+		}
+		// split the triangles
+		var refined []*Triangle3
+		for _, t := range mesh {
+			refined = append(refined, splitTriangle(t, split)...)
+		}
+		mesh = refined
+	}
+	return mesh
+}
+
+// splitTriangle splits a triangle on the marked edges.
+func splitTriangle(t *Triangle3, split map[meshEdge]V3) []*Triangle3 {
+	var m [3]V3
+	var ok [3]bool
+	n := 0
+	for i := 0; i < 3; i++ {
+		m[i], ok[i] = split[newMeshEdge(t.V[i], t.V[(i+1)%3])]
+		if ok[i] {
+			n++
+		}
+	}
+	// rotate the triangle so the split edges come first
+	r := 0
+	for i := 0; i < 3; i++ {
+		if n == 1 && ok[i] || n == 2 && !ok[(i+2)%3] {
+			r = i
+			break
+		}
+	}
+	v0, v1, v2 := t.V[r], t.V[(r+1)%3], t.V[(r+2)%3]
+	m0, m1, m2 := m[r], m[(r+1)%3], m[(r+2)%3]
+	switch n {
+	case 1:
+		// v0-v1 is split
+		return []*Triangle3{
+			NewTriangle3(v0, m0, v2),
+			NewTriangle3(m0, v1, v2),
+		}
+	case 2:
+		// v0-v1 and v1-v2 are split
+		return []*Triangle3{
+			NewTriangle3(m0, v1, m1),
+			NewTriangle3(v0, m0, m1),
+			NewTriangle3(v0, m1, v2),
+		}
+	case 3:
+		return []*Triangle3{
+			NewTriangle3(v0, m0, m2),
+			NewTriangle3(m0, v1, m1),
+			NewTriangle3(m2, m1, v2),
+			NewTriangle3(m0, m1, m2),
+		}
+	}
+	return []*Triangle3{t}
+}
+
+//-----------------------------------------------------------------------------
+
+// RenderMeshAdaptive renders an SDF3 as a triangle mesh with a maximum chordal deviation.
+func RenderMeshAdaptive(
+	s SDF3, //sdf3 to render
+	chordError float64, //maximum distance from the mesh to the surface. e.g 0.05
+) []*Triangle3 {
+	// the coarse mesh resolves the smallest feature
+	resolution := s.BoundingBox().Size().MaxComponent() / adaptiveCells
+	resolution = math.Min(resolution, EstimateMinFeatureSize(s)/autoFeatureCells)
+	mesh := adaptiveMesh(s, resolution, chordError)
+	if DeterministicMesh {
+		SortMesh(mesh)
+	}
+	return mesh
+}
+
+// RenderSTLAdaptive renders an SDF3 as an STL file with a maximum chordal deviation.
+func RenderSTLAdaptive(
+	s SDF3, //sdf3 to render
+	chordError float64, //maximum distance from the mesh to the surface. e.g 0.05
+	path string, //path to filename
+) {
+	fmt.Printf("rendering %s (chord error %g)\n", path, chordError)
+	if err := SaveSTL(path, RenderMeshAdaptive(s, chordError)); err != nil {
+		fmt.Printf("%s", err)
+	}
+}
+
+//-----------------------------------------------------------------------------
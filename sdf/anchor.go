@@ -0,0 +1,110 @@
+//-----------------------------------------------------------------------------
+/*
+
+Anchors and Mating
+
+Parts declare named anchors (a point with an optional axis) in their own
+coordinate frame. Mate computes the transform that brings an anchor on one
+part onto an anchor on another part.
+
+The anchor axis points away from the part (E.g. out of a mounting face or
+along a shaft). Mated anchors have coincident points and opposed axes.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "fmt"
+
+//-----------------------------------------------------------------------------
+
+// Anchor is a named point with an optional (non-zero) axis.
+type Anchor struct {
+	Name     string
+	Position V3
+	Axis     V3
+}
+
+// Transform returns the anchor transformed by a matrix.
+func (a Anchor) Transform(m M44) Anchor {
+	t := Anchor{
+		Name:     a.Name,
+		Position: m.MulPosition(a.Position),
+	}
+	if a.Axis != (V3{}) {
+		t.Axis = m.MulPosition(a.Axis).Sub(m.MulPosition(V3{})).Normalize()
+	}
+	return t
+}
+
+// Mate returns the transform that moves anchor b onto anchor a.
+// The anchor points are made coincident and the axes opposed.
+// If either anchor has no axis only the points are made coincident.
+func Mate(a, b Anchor) M44 {
+	m := Translate3d(b.Position.Neg())
+	if a.Axis != (V3{}) && b.Axis != (V3{}) {
+		m = RotateToVector(b.Axis, a.Axis.Neg()).Mul(m)
+	}
+	return Translate3d(a.Position).Mul(m)
+}
+
+//-----------------------------------------------------------------------------
+
+// AddAnchor adds a named anchor to a part. The anchor is in the part coordinate frame.
+func (p *Part) AddAnchor(name string, position, axis V3) error {
+	if _, ok := p.anchors[name]; ok {
+		return fmt.Errorf("anchor \"%s\" already exists on part \"%s\"", name, p.Name)
+	}
+	if p.anchors == nil {
+		p.anchors = make(map[string]Anchor)
+	}
+	if axis != (V3{}) {
+		axis = axis.Normalize()
+	}
+	p.anchors[name] = Anchor{name, position, axis}
+	return nil
+}
+
+// LocalAnchor returns the named anchor in the part coordinate frame.
+func (p *Part) LocalAnchor(name string) (Anchor, error) {
+	a, ok := p.anchors[name]
+	if !ok {
+		return Anchor{}, fmt.Errorf("anchor \"%s\" not found on part \"%s\"", name, p.Name)
+	}
+	return a, nil
+}
+
+// Anchor returns the named anchor placed within the assembly.
+func (p *Part) Anchor(name string) (Anchor, error) {
+	a, err := p.LocalAnchor(name)
+	if err != nil {
+		return Anchor{}, err
+	}
+	return a.Transform(p.Matrix), nil
+}
+
+// Mate places part b so that its anchor mates with the anchor on part a.
+// Part a keeps its current placement.
+func (asm *Assembly) Mate(partA, anchorA, partB, anchorB string) error {
+	pa, err := asm.Part(partA)
+	if err != nil {
+		return err
+	}
+	pb, err := asm.Part(partB)
+	if err != nil {
+		return err
+	}
+	a, err := pa.Anchor(anchorA)
+	if err != nil {
+		return err
+	}
+	b, err := pb.LocalAnchor(anchorB)
+	if err != nil {
+		return err
+	}
+	pb.Matrix = Mate(a, b)
+	return nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,215 @@
+//-----------------------------------------------------------------------------
+/*
+
+Assemblies
+
+An assembly is a set of named parts, each with a placement transform,
+a display color and an export flag.
+
+The assembly can be exported as a single multi-object 3MF file (E.g. a print
+plate), as an STL file per part, or moved into an exploded view.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"path/filepath"
+)
+
+//-----------------------------------------------------------------------------
+
+// Part is a named SDF3 within an assembly.
+type Part struct {
+	Name   string
+	SDF    SDF3       // part geometry in the part coordinate frame
+	Matrix M44        // placement of the part within the assembly
+	Color  color.RGBA // display color, the zero value is no color
+	Export bool       // export the part
+	// named anchors in the part coordinate frame (see AddAnchor)
+	anchors map[string]Anchor
+}
+
+// Placed returns the part SDF3 placed within the assembly.
+func (p *Part) Placed() SDF3 {
+	return Transform3D(p.SDF, p.Matrix)
+}
+
+// Assembly is a set of named parts.
+type Assembly struct {
+	parts []*Part
+}
+
+// NewAssembly returns an empty assembly.
+func NewAssembly() *Assembly {
+	return &Assembly{}
+}
+
+// Add adds a named part to the assembly. The part is exported by default.
+func (a *Assembly) Add(name string, s SDF3, m M44) (*Part, error) {
+	if s == nil {
+		return nil, fmt.Errorf("part \"%s\" has no SDF3", name)
+	}
+	if _, err := a.Part(name); err == nil {
+		return nil, fmt.Errorf("part \"%s\" already exists", name)
+	}
+	p := &Part{
+		Name:   name,
+		SDF:    s,
+		Matrix: m,
+		Export: true,
+	}
+	a.parts = append(a.parts, p)
+	return p, nil
+}
+
+// Part returns the named part.
+func (a *Assembly) Part(name string) (*Part, error) {
+	for _, p := range a.parts {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("part \"%s\" not found", name)
+}
+
+// Parts returns the parts of the assembly.
+func (a *Assembly) Parts() []*Part {
+	return a.parts
+}
+
+// SDF3 returns the union of all the placed parts.
+func (a *Assembly) SDF3() SDF3 {
+	s := make([]SDF3, len(a.parts))
+	for i, p := range a.parts {
+		s[i] = p.Placed()
+	}
+	return Union3D(s...)
+}
+
+// BoundingBox returns the bounding box of the placed parts.
+func (a *Assembly) BoundingBox() Box3 {
+	var bb Box3
+	for i, p := range a.parts {
+		x := p.Matrix.MulBox(p.SDF.BoundingBox())
+		if i == 0 {
+			bb = x
+		} else {
+			bb = bb.Extend(x)
+		}
+	}
+	return bb
+}
+
+// Exploded returns a copy of the assembly with each part moved away from the
+// assembly center. The parts move by k times their offset from the center.
+func (a *Assembly) Exploded(k float64) *Assembly {
+	center := a.BoundingBox().Center()
+	e := NewAssembly()
+	for _, p := range a.parts {
+		x := *p
+		// the anchors are per-part, don't share them with the original
+		if p.anchors != nil {
+			x.anchors = make(map[string]Anchor, len(p.anchors))
+			for name, anchor := range p.anchors {
+				x.anchors[name] = anchor
+			}
+		}
+		ofs := p.Matrix.MulBox(p.SDF.BoundingBox()).Center().Sub(center)
+		x.Matrix = Translate3d(ofs.MulScalar(k)).Mul(p.Matrix)
+		e.parts = append(e.parts, &x)
+	}
+	return e
+}
+
+//-----------------------------------------------------------------------------
+
+// exportParts returns the parts to be exported.
+func (a *Assembly) exportParts() []*Part {
+	var parts []*Part
+	for _, p := range a.parts {
+		if p.Export {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// Render3MF renders the exported parts of an assembly as objects in a 3MF file.
+// Each part is meshed in its own coordinate frame and placed with its transform.
+func (a *Assembly) Render3MF(
+	meshCells int, //number of cells on the longest axis of the assembly. e.g 200 (<= 0 for AutoMeshCells)
+	path string, //path to filename
+) error {
+	parts := a.exportParts()
+	if len(parts) == 0 {
+		return fmt.Errorf("no parts to export")
+	}
+	if meshCells <= 0 {
+		meshCells = AutoMeshCells(a.SDF3())
+	}
+	resolution := a.BoundingBox().Size().MaxComponent() / float64(meshCells)
+	// part colors
+	var materials []ThreeMFBaseMaterials
+	pindex := map[*Part]int{}
+	for _, p := range parts {
+		if p.Color == (color.RGBA{}) {
+			continue
+		}
+		if materials == nil {
+			materials = []ThreeMFBaseMaterials{{ID: fmt.Sprintf("%d", len(parts)+1)}}
+		}
+		pindex[p] = len(materials[0].Bases)
+		materials[0].Bases = append(materials[0].Bases, ThreeMFBase{Name: p.Name, DisplayColor: colorString(p.Color)})
+	}
+	var objects []ThreeMFObject
+	var items []ThreeMFItem
+	for i, p := range parts {
+		id := fmt.Sprintf("%d", i+1)
+		cells := int(math.Ceil(p.SDF.BoundingBox().Size().MaxComponent() / resolution))
+		obj := ThreeMFObject{
+			ID:   id,
+			Type: "model",
+			Name: p.Name,
+			Mesh: threeMFMesh(RenderMesh(p.SDF, cells)),
+		}
+		if k, ok := pindex[p]; ok {
+			obj.PID = materials[0].ID
+			obj.PIndex = fmt.Sprintf("%d", k)
+		}
+		objects = append(objects, obj)
+		item := ThreeMFItem{ObjectID: id}
+		if !p.Matrix.Equals(Identity3d(), tolerance) {
+			item.Transform = threeMFTransform(p.Matrix)
+		}
+		items = append(items, item)
+	}
+	return write3MF(path, materials, objects, items)
+}
+
+// RenderSTLs renders each exported part of an assembly as an STL file (<dir>/<name>.stl).
+// The parts are placed with their transforms.
+func (a *Assembly) RenderSTLs(
+	meshCells int, //number of cells on the longest axis of the assembly. e.g 200 (<= 0 for AutoMeshCells)
+	dir string, //output directory
+) error {
+	if meshCells <= 0 {
+		meshCells = AutoMeshCells(a.SDF3())
+	}
+	resolution := a.BoundingBox().Size().MaxComponent() / float64(meshCells)
+	for _, p := range a.exportParts() {
+		s := p.Placed()
+		cells := int(math.Ceil(s.BoundingBox().Size().MaxComponent() / resolution))
+		err := SaveSTL(filepath.Join(dir, p.Name+".stl"), RenderMesh(s, cells))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,157 @@
+//-----------------------------------------------------------------------------
+/*
+
+Hose Barbs and Tube Couplers
+
+A hose barb is a solid of revolution with a series of conical ramps that are
+pushed into a flexible hose. The sharp step at the back of each barb resists
+the hose being pulled off.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// HoseBarbParms defines the parameters for a hose barb.
+type HoseBarbParms struct {
+	OuterRadius float64 // radius at the base of the barbs (~ hose inner radius)
+	InnerRadius float64 // radius of the bore
+	NumberBarbs int     // number of barbs
+	BarbLength  float64 // axial length of each barb
+	BarbAngle   float64 // angle of the barb ramp to the axis (radians)
+	Taper       float64 // reduction in the barb radius from the base to the tip
+}
+
+// validate checks the hose barb parameters.
+func (k *HoseBarbParms) validate() error {
+	if k.OuterRadius <= 0 {
+		return errors.New("OuterRadius <= 0")
+	}
+	if k.InnerRadius < 0 {
+		return errors.New("InnerRadius < 0")
+	}
+	if k.NumberBarbs <= 0 {
+		return errors.New("NumberBarbs <= 0")
+	}
+	if k.BarbLength <= 0 {
+		return errors.New("BarbLength <= 0")
+	}
+	if k.BarbAngle <= 0 || k.BarbAngle >= 0.5*Pi {
+		return errors.New("invalid BarbAngle")
+	}
+	if k.Taper < 0 {
+		return errors.New("Taper < 0")
+	}
+	if k.OuterRadius-k.Taper <= k.InnerRadius {
+		return errors.New("no wall thickness at the tip")
+	}
+	return nil
+}
+
+// length returns the total length of the barbed section.
+func (k *HoseBarbParms) length() float64 {
+	return float64(k.NumberBarbs) * k.BarbLength
+}
+
+// radius returns the ramp radius at height z.
+func (k *HoseBarbParms) radius(z float64) float64 {
+	return k.OuterRadius - k.Taper*z/k.length()
+}
+
+// hoseBarbProfile returns the 2D profile of a hose barb with a given bore radius.
+// The base of the barb is at y = 0 and the tip is at y = length.
+func hoseBarbProfile(k *HoseBarbParms, bore float64) SDF2 {
+	h := k.BarbLength * math.Tan(k.BarbAngle)
+	p := NewPolygon()
+	p.Add(bore, 0)
+	for i := 0; i < k.NumberBarbs; i++ {
+		z := float64(i) * k.BarbLength
+		r := k.radius(z)
+		p.Add(r, z)
+		p.Add(r+h, z)
+	}
+	l := k.length()
+	p.Add(k.radius(l), l)
+	p.Add(bore, l)
+	return Polygon2D(p.Vertices())
+}
+
+// HoseBarb2D returns the 2D profile for a hose barb.
+func HoseBarb2D(k *HoseBarbParms) (SDF2, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	return hoseBarbProfile(k, k.InnerRadius), nil
+}
+
+// HoseBarb3D returns a hose barb along the z-axis with the base at z = 0.
+func HoseBarb3D(k *HoseBarbParms) (SDF3, error) {
+	s, err := HoseBarb2D(k)
+	if err != nil {
+		return nil, err
+	}
+	return Revolve3D(s), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// HoseCouplerParms defines the parameters for a tube coupler.
+type HoseCouplerParms struct {
+	Barb0        *HoseBarbParms // barb on the +z side
+	Barb1        *HoseBarbParms // barb on the -z side
+	CollarRadius float64        // radius of the central collar
+	CollarLength float64        // length of the central collar
+}
+
+// HoseCoupler3D returns a coupler (or reducer) joining two hoses.
+// The coupler is along the z-axis with the collar centered on z = 0.
+func HoseCoupler3D(k *HoseCouplerParms) (SDF3, error) {
+	// validate parameters
+	if k.Barb0 == nil || k.Barb1 == nil {
+		return nil, errors.New("barb parameters not defined")
+	}
+	if err := k.Barb0.validate(); err != nil {
+		return nil, err
+	}
+	if err := k.Barb1.validate(); err != nil {
+		return nil, err
+	}
+	if k.CollarLength < 0 {
+		return nil, errors.New("CollarLength < 0")
+	}
+	if k.CollarLength > 0 && k.CollarRadius <= Max(k.Barb0.InnerRadius, k.Barb1.InnerRadius) {
+		return nil, errors.New("CollarRadius too small")
+	}
+
+	zOfs := 0.5 * k.CollarLength
+
+	// outside profile
+	b0 := hoseBarbProfile(k.Barb0, 0)
+	b0 = Transform2D(b0, Translate2d(V2{0, zOfs}))
+	b1 := hoseBarbProfile(k.Barb1, 0)
+	b1 = Transform2D(b1, MirrorX().Mul(Translate2d(V2{0, zOfs})))
+	var collar SDF2
+	if k.CollarLength > 0 {
+		collar = Box2D(V2{k.CollarRadius, k.CollarLength}, 0)
+		collar = Transform2D(collar, Translate2d(V2{0.5 * k.CollarRadius, 0}))
+	}
+	s := Revolve3D(Union2D(b0, b1, collar))
+
+	// bores
+	l0 := zOfs + k.Barb0.length()
+	l1 := zOfs + k.Barb1.length()
+	bore0 := Cylinder3D(l0, k.Barb0.InnerRadius, 0)
+	bore0 = Transform3D(bore0, Translate3d(V3{0, 0, 0.5 * l0}))
+	bore1 := Cylinder3D(l1, k.Barb1.InnerRadius, 0)
+	bore1 = Transform3D(bore1, Translate3d(V3{0, 0, -0.5 * l1}))
+	return Difference3D(s, Union3D(bore0, bore1)), nil
+}
+
+//-----------------------------------------------------------------------------
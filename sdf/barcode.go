@@ -0,0 +1,98 @@
+//-----------------------------------------------------------------------------
+/*
+
+Barcodes
+
+Code 128 barcodes (code set B) for embossing or engraving onto parts.
+
+See: https://en.wikipedia.org/wiki/Code_128
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+//-----------------------------------------------------------------------------
+
+// code128Patterns are the bar/space widths for the Code 128 symbol values.
+var code128Patterns = []string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128Stop   = 106
+)
+
+// Code128 returns the bar/space widths (in modules) of a Code 128 barcode for the text.
+// The first width is a bar and the widths alternate between bars and spaces.
+func Code128(text string) ([]int, error) {
+	if len(text) == 0 {
+		return nil, errors.New("no text")
+	}
+	values := []int{code128StartB}
+	sum := code128StartB
+	for i, c := range []byte(text) {
+		if c < 32 || c > 127 {
+			return nil, fmt.Errorf("character %d (0x%02x) is not in code set B", i, c)
+		}
+		v := int(c) - 32
+		values = append(values, v)
+		sum += (i + 1) * v
+	}
+	values = append(values, sum%103, code128Stop)
+	var widths []int
+	for _, v := range values {
+		for _, w := range code128Patterns[v] {
+			widths = append(widths, int(w-'0'))
+		}
+	}
+	return widths, nil
+}
+
+// Code128Barcode2D returns an SDF2 for the bars of a Code 128 barcode.
+// The barcode is centered on the origin. Leave a quiet zone of 10 modules on either side.
+func Code128Barcode2D(text string, moduleWidth, height float64) (SDF2, error) {
+	if moduleWidth <= 0 {
+		return nil, errors.New("moduleWidth <= 0")
+	}
+	if height <= 0 {
+		return nil, errors.New("height <= 0")
+	}
+	widths, err := Code128(text)
+	if err != nil {
+		return nil, err
+	}
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	var bars []SDF2
+	x := -0.5 * float64(total) * moduleWidth
+	for i, w := range widths {
+		bw := float64(w) * moduleWidth
+		if i%2 == 0 {
+			bar := Box2D(V2{bw, height}, 0)
+			bars = append(bars, Transform2D(bar, Translate2d(V2{x + 0.5*bw, 0})))
+		}
+		x += bw
+	}
+	return Union2D(bars...), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,227 @@
+//-----------------------------------------------------------------------------
+/*
+
+Bearing Seats and Shafts
+
+Generate pockets and shafts that fit standard ball bearings.
+
+The bearing seat is the volume to be removed from a part to house the bearing.
+It has a lip at the bottom of the pocket that supports the outer race while
+leaving clearance for the inner race and shaft.
+
+A press fit makes the pocket/shaft slightly undersize/oversize so the bearing
+is gripped. A slip fit gives some clearance so the bearing can be installed
+and removed by hand.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+//-----------------------------------------------------------------------------
+// Bearing Database - lookup standard bearings by name
+
+// BearingParameters stores the dimensions of a ball bearing (mm).
+type BearingParameters struct {
+	Name  string  // name of bearing
+	Inner float64 // bore (inner) diameter
+	Outer float64 // outer diameter
+	Width float64 // bearing width
+}
+
+type bearingDatabase map[string]*BearingParameters
+
+var bearingDB = initBearingLookup()
+
+// add adds a bearing to the bearing database.
+func (m bearingDatabase) add(
+	name string, // bearing name
+	inner float64, // bore diameter
+	outer float64, // outer diameter
+	width float64, // bearing width
+) {
+	m[name] = &BearingParameters{
+		Name:  name,
+		Inner: inner,
+		Outer: outer,
+		Width: width,
+	}
+}
+
+// initBearingLookup adds a collection of standard bearings to the bearing database.
+func initBearingLookup() bearingDatabase {
+	m := make(bearingDatabase)
+	// miniature
+	m.add("623", 3, 10, 4)
+	m.add("624", 4, 13, 5)
+	m.add("625", 5, 16, 5)
+	m.add("626", 6, 19, 6)
+	m.add("608", 8, 22, 7)
+	m.add("609", 9, 24, 7)
+	m.add("683", 3, 7, 2)
+	m.add("684", 4, 9, 2.5)
+	m.add("685", 5, 11, 3)
+	m.add("686", 6, 13, 3.5)
+	m.add("688", 8, 16, 4)
+	m.add("MR63", 3, 6, 2.5)
+	m.add("MR85", 5, 8, 2.5)
+	m.add("MR105", 5, 10, 4)
+	m.add("MR115", 5, 11, 4)
+	// 60xx
+	m.add("6000", 10, 26, 8)
+	m.add("6001", 12, 28, 8)
+	m.add("6002", 15, 32, 9)
+	m.add("6003", 17, 35, 10)
+	m.add("6004", 20, 42, 12)
+	m.add("6005", 25, 47, 12)
+	// 62xx
+	m.add("6200", 10, 30, 9)
+	m.add("6201", 12, 32, 10)
+	m.add("6202", 15, 35, 11)
+	m.add("6203", 17, 40, 12)
+	m.add("6204", 20, 47, 14)
+	m.add("6205", 25, 52, 15)
+	// 68xx (thin section)
+	m.add("6800", 10, 19, 5)
+	m.add("6801", 12, 21, 5)
+	m.add("6802", 15, 24, 5)
+	m.add("6803", 17, 26, 5)
+	m.add("6804", 20, 32, 7)
+	m.add("6805", 25, 37, 7)
+	m.add("6806", 30, 42, 7)
+	return m
+}
+
+// BearingLookup lookups the parameters for a bearing by name.
+func BearingLookup(name string) (*BearingParameters, error) {
+	if b, ok := bearingDB[name]; ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("bearing \"%s\" not found", name)
+}
+
+//-----------------------------------------------------------------------------
+// Fits
+
+// radial allowances for fits (mm)
+const pressFitAllowance = 0.02
+const slipFitAllowance = 0.1
+
+// fitAllowance returns the radial clearance for a fit (> 0 is a clearance).
+func fitAllowance(fit string) (float64, error) {
+	switch fit {
+	case "press":
+		return -pressFitAllowance, nil
+	case "slip":
+		return slipFitAllowance, nil
+	}
+	return 0, fmt.Errorf("unknown fit \"%s\"", fit)
+}
+
+//-----------------------------------------------------------------------------
+
+// BearingSeatParms defines the parameters for a bearing seat.
+type BearingSeatParms struct {
+	Bearing      string  // name of bearing
+	Fit          string  // "press" or "slip"
+	Tolerance    float64 // add to the pocket radius (printer compensation)
+	Depth        float64 // pocket depth (0 for the bearing width)
+	LipWidth     float64 // radial width of the retention lip (0 for no lip)
+	LipThickness float64 // thickness of the retention lip (the lip hole depth)
+}
+
+// BearingSeat3D returns the volume to be removed from a part to seat a bearing.
+// The top of the pocket is at z = 0 and the pocket extends in the -z direction.
+func BearingSeat3D(k *BearingSeatParms) (SDF3, error) {
+	// validate parameters
+	b, err := BearingLookup(k.Bearing)
+	if err != nil {
+		return nil, err
+	}
+	allowance, err := fitAllowance(k.Fit)
+	if err != nil {
+		return nil, err
+	}
+	if k.Depth < 0 {
+		return nil, errors.New("Depth < 0")
+	}
+	if k.LipWidth < 0 || k.LipThickness < 0 {
+		return nil, errors.New("lip dimensions < 0")
+	}
+	// the lip must not touch the inner race
+	if k.LipWidth > 0 && k.LipWidth >= 0.5*(b.Outer-b.Inner) {
+		return nil, errors.New("LipWidth too large")
+	}
+
+	depth := k.Depth
+	if depth == 0 {
+		depth = b.Width
+	}
+	r := 0.5*b.Outer + allowance + k.Tolerance
+	pocket := Cylinder3D(depth, r, 0)
+	pocket = Transform3D(pocket, Translate3d(V3{0, 0, -0.5 * depth}))
+
+	if k.LipWidth == 0 || k.LipThickness == 0 {
+		return pocket, nil
+	}
+
+	// the lip hole gives clearance for the inner race
+	lip := Cylinder3D(k.LipThickness, 0.5*b.Outer-k.LipWidth, 0)
+	lip = Transform3D(lip, Translate3d(V3{0, 0, -depth - 0.5*k.LipThickness}))
+	return Union3D(pocket, lip), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// BearingShaftParms defines the parameters for a bearing shaft.
+type BearingShaftParms struct {
+	Bearing        string  // name of bearing
+	Fit            string  // "press" or "slip"
+	Tolerance      float64 // subtract from the shaft radius (printer compensation)
+	Length         float64 // length of the shaft
+	ShoulderWidth  float64 // radial width of the shoulder (0 for no shoulder)
+	ShoulderLength float64 // length of the shoulder
+}
+
+// BearingShaft3D returns a shaft that fits the bore of a bearing.
+// The shaft extends from z = 0 in the +z direction, the shoulder (if any) is below z = 0.
+func BearingShaft3D(k *BearingShaftParms) (SDF3, error) {
+	// validate parameters
+	b, err := BearingLookup(k.Bearing)
+	if err != nil {
+		return nil, err
+	}
+	allowance, err := fitAllowance(k.Fit)
+	if err != nil {
+		return nil, err
+	}
+	if k.Length <= 0 {
+		return nil, errors.New("Length <= 0")
+	}
+	if k.ShoulderWidth < 0 || k.ShoulderLength < 0 {
+		return nil, errors.New("shoulder dimensions < 0")
+	}
+	// the shoulder must not touch the outer race
+	if k.ShoulderWidth >= 0.5*(b.Outer-b.Inner) {
+		return nil, errors.New("ShoulderWidth too large")
+	}
+
+	r := 0.5*b.Inner - allowance - k.Tolerance
+	shaft := Cylinder3D(k.Length, r, 0)
+	shaft = Transform3D(shaft, Translate3d(V3{0, 0, 0.5 * k.Length}))
+
+	if k.ShoulderWidth == 0 || k.ShoulderLength == 0 {
+		return shaft, nil
+	}
+
+	shoulder := Cylinder3D(k.ShoulderLength, 0.5*b.Inner+k.ShoulderWidth, 0)
+	shoulder = Transform3D(shoulder, Translate3d(V3{0, 0, -0.5 * k.ShoulderLength}))
+	return Union3D(shaft, shoulder), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -157,6 +157,11 @@ func (s *BezierSpline) f0(t float64) V2 {
 	return V2{s.px.f0(t), s.py.f0(t)}
 }
 
+// Position returns the position on a bezier spline.
+func (s *BezierSpline) Position(t float64) V2 {
+	return s.f0(t)
+}
+
 // Sample generates polygon samples for a bezier spline.
 func (s *BezierSpline) Sample(p *Polygon, t0, t1 float64, p0, p1 V2, n int) {
 
@@ -0,0 +1,160 @@
+//-----------------------------------------------------------------------------
+/*
+
+Storage Bins
+
+Parametric storage bins that fit a Gridfinity style baseplate.
+
+The grid pitch is 42 mm and the height unit is 7 mm. Each grid unit of the
+bin has a stepped foot (0.8 mm 45 degree chamfer, 1.8 mm vertical, 2.15 mm 45
+degree chamfer) that seats in the baseplate. The bin is 0.5 mm smaller than
+the grid for clearance and has 3.75 mm corner radii.
+
+Magnet holes (6.5 mm x 2.4 mm) and screw holes (3 mm x 6 mm) are on a 26 mm
+square in the bottom of each foot.
+
+The bin is centered on the xy origin with the bottom of the feet on z = 0.
+The label ledge runs along the +y wall.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+const (
+	binPitch       = 42.0 // grid pitch
+	binHeightUnit  = 7.0  // height unit
+	binClearance   = 0.5  // bin size reduction for fit
+	binRadius      = 3.75 // outer corner radius
+	binFootHeight  = 4.75 // height of the stepped foot
+	binHoleSpacing = 26.0 // spacing of the magnet/screw holes
+	binMagnetDia   = 6.5  // magnet hole diameter
+	binMagnetDepth = 2.4  // magnet hole depth
+	binScrewDia    = 3.0  // screw hole diameter
+	binScrewDepth  = 6.0  // screw hole depth
+	binLedgeLip    = 1.0  // thickness of the label ledge at its edge
+)
+
+// BinParms defines the parameters for a storage bin.
+type BinParms struct {
+	UnitsX      int     // number of grid units along x
+	UnitsY      int     // number of grid units along y
+	HeightUnits int     // bin height in 7 mm units (includes the feet)
+	Wall        float64 // wall thickness
+	Floor       float64 // floor thickness above the feet
+	Fillet      float64 // radius of the fillet between the floor and the walls
+	MagnetHoles bool    // add magnet holes to the feet
+	ScrewHoles  bool    // add screw holes to the feet
+	LabelLedge  float64 // width of the label ledge (0 = none)
+}
+
+func (k *BinParms) validate() error {
+	if k.UnitsX <= 0 || k.UnitsY <= 0 {
+		return errors.New("UnitsX and UnitsY must be > 0")
+	}
+	if k.Wall <= 0 || k.Wall >= binRadius {
+		return errors.New("Wall out of range")
+	}
+	if k.Floor <= 0 {
+		return errors.New("Floor <= 0")
+	}
+	if float64(k.HeightUnits)*binHeightUnit <= binFootHeight+k.Floor {
+		return errors.New("HeightUnits is too small")
+	}
+	if k.Fillet < 0 || k.Fillet >= binRadius-k.Wall {
+		return errors.New("Fillet out of range")
+	}
+	if k.LabelLedge < 0 || k.LabelLedge >= 0.5*(float64(k.UnitsY)*binPitch-binClearance)-k.Wall {
+		return errors.New("LabelLedge out of range")
+	}
+	return nil
+}
+
+// binFoot returns the stepped foot for a single grid unit.
+func binFoot() SDF3 {
+	core := binPitch - binClearance - 2*binRadius
+	size := V3{core, core, 0}
+	// bottom chamfer, vertical step, top chamfer
+	s0 := Elongate3D(Cone3D(0.8, 0.8, 1.6, 0), size)
+	s0 = Transform3D(s0, Translate3d(V3{0, 0, 0.4}))
+	s1 := Elongate3D(Cylinder3D(1.8, 1.6, 0), size)
+	s1 = Transform3D(s1, Translate3d(V3{0, 0, 0.8 + 0.9}))
+	s2 := Elongate3D(Cone3D(2.15, 1.6, binRadius, 0), size)
+	s2 = Transform3D(s2, Translate3d(V3{0, 0, 2.6 + 1.075}))
+	return Union3D(s0, s1, s2)
+}
+
+// binHoles returns the magnet and/or screw holes for a single grid unit.
+func binHoles(k *BinParms) SDF3 {
+	var s []SDF3
+	if k.MagnetHoles {
+		s = append(s, Cylinder3D(2*binMagnetDepth, 0.5*binMagnetDia, 0))
+	}
+	if k.ScrewHoles {
+		s = append(s, Cylinder3D(2*binScrewDepth, 0.5*binScrewDia, 0))
+	}
+	if len(s) == 0 {
+		return nil
+	}
+	hole := Union3D(s...)
+	d := 0.5 * binHoleSpacing
+	hole = Transform3D(hole, Translate3d(V3{-d, -d, 0}))
+	return Array3D(hole, V3i{2, 2, 1}, V3{binHoleSpacing, binHoleSpacing, 0})
+}
+
+// Bin3D returns a storage bin with Gridfinity style feet.
+func Bin3D(k *BinParms) (SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	nx, ny := k.UnitsX, k.UnitsY
+	sx := float64(nx)*binPitch - binClearance
+	sy := float64(ny)*binPitch - binClearance
+	h := float64(k.HeightUnits) * binHeightUnit
+
+	// feet, arrayed over the grid units
+	x0 := -0.5 * float64(nx-1) * binPitch
+	y0 := -0.5 * float64(ny-1) * binPitch
+	m := Translate3d(V3{x0, y0, 0})
+	feet := Transform3D(Array3D(binFoot(), V3i{nx, ny, 1}, V3{binPitch, binPitch, 0}), m)
+
+	// body, shelled with a filleted cavity
+	outline := Box2D(V2{sx, sy}, binRadius)
+	bh := h - binFootHeight
+	body := Extrude3D(outline, bh)
+	body = Transform3D(body, Translate3d(V3{0, 0, binFootHeight + 0.5*bh}))
+	z0 := binFootHeight + k.Floor
+	ch := 2 * (h - z0)
+	cavity := ExtrudeRounded3D(Offset2D(outline, -k.Wall-k.Fillet), ch, k.Fillet)
+	cavity = Transform3D(cavity, Translate3d(V3{0, 0, z0 + 0.5*ch}))
+	s := Difference3D(body, cavity)
+
+	// label ledge with a 45 degree underside along the +y wall
+	if k.LabelLedge > 0 {
+		yi := 0.5*sy - k.Wall
+		w := k.LabelLedge
+		p := NewPolygon()
+		p.Add(yi+k.Wall, h)
+		p.Add(yi-w, h)
+		p.Add(yi-w, h-binLedgeLip)
+		p.Add(yi, h-binLedgeLip-w)
+		p.Add(yi+k.Wall, h-binLedgeLip-w)
+		ledge := Extrude3D(Polygon2D(p.Vertices()), sx)
+		ledge = Transform3D(ledge, RotateZ(0.5*Pi).Mul(RotateX(0.5*Pi)))
+		ledge = Intersect3D(body, ledge)
+		s = Union3D(s, ledge)
+	}
+
+	s = Union3D(feet, s)
+	if holes := binHoles(k); holes != nil {
+		holes = Transform3D(Array3D(holes, V3i{nx, ny, 1}, V3{binPitch, binPitch, 0}), m)
+		s = Difference3D(s, holes)
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -58,6 +58,13 @@ func (a Box2) Extend(b Box2) Box2 {
 	return Box2{a.Min.Min(b.Min), a.Max.Max(b.Max)}
 }
 
+// Intersect returns the overlap of two 2d boxes.
+// Disjoint boxes give a box with zero size on the non-overlapping axes.
+func (a Box2) Intersect(b Box2) Box2 {
+	min := a.Min.Max(b.Min)
+	return Box2{min, a.Max.Min(b.Max).Max(min)}
+}
+
 //-----------------------------------------------------------------------------
 
 // Translate translates a 3d box.
@@ -92,6 +99,19 @@ func (a Box2) Center() V2 {
 	return a.Min.Add(a.Size().MulScalar(0.5))
 }
 
+// Contains returns true if a point is within a 3d box.
+func (a Box3) Contains(p V3) bool {
+	return p.X >= a.Min.X && p.X <= a.Max.X &&
+		p.Y >= a.Min.Y && p.Y <= a.Max.Y &&
+		p.Z >= a.Min.Z && p.Z <= a.Max.Z
+}
+
+// Contains returns true if a point is within a 2d box.
+func (a Box2) Contains(p V2) bool {
+	return p.X >= a.Min.X && p.X <= a.Max.X &&
+		p.Y >= a.Min.Y && p.Y <= a.Max.Y
+}
+
 //-----------------------------------------------------------------------------
 
 // ScaleAboutCenter returns a new 2d box scaled about the center of a box.
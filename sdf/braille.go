@@ -0,0 +1,238 @@
+//-----------------------------------------------------------------------------
+/*
+
+Braille
+
+Convert strings to uncontracted (grade 1) English Braille and emboss them as
+dome shaped dots.
+
+A Braille cell has two columns of three dots, numbered 1, 2, 3 down the left
+column and 4, 5, 6 down the right column. A cell is stored as a bit mask with
+bit n-1 set for dot n.
+
+Standard dimensions (ADA/Marburg Medium): 1.5 mm dot base diameter, 0.5 mm
+dot height, 2.5 mm between dots in a cell, 6.2 mm between cells and 10 mm
+between lines.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+//-----------------------------------------------------------------------------
+
+// BrailleCell is a Braille cell, bit n-1 is set for dot n.
+type BrailleCell uint8
+
+// brailleDots returns a cell from a dot list, e.g. 1245.
+func brailleDots(dots int) BrailleCell {
+	var c BrailleCell
+	for ; dots > 0; dots /= 10 {
+		c |= 1 << uint(dots%10-1)
+	}
+	return c
+}
+
+var brailleLetters = [26]int{
+	1, 12, 14, 145, 15, 124, 1245, 125, 24, 245, // a-j
+	13, 123, 134, 1345, 135, 1234, 12345, 1235, 234, 2345, // k-t
+	136, 1236, 2456, 1346, 13456, 1356, // u-z
+}
+
+var braillePunctuation = map[rune]int{
+	',':  2,
+	';':  23,
+	':':  25,
+	'.':  256,
+	'!':  235,
+	'?':  236,
+	'\'': 3,
+	'-':  36,
+}
+
+const (
+	brailleCapital = 6    // capital sign
+	brailleNumber  = 3456 // number sign
+	brailleLetter  = 56   // letter sign
+)
+
+// brailleLine converts a line of text to Braille cells.
+func brailleLine(s string) ([]BrailleCell, error) {
+	var cells []BrailleCell
+	number := false
+	for _, r := range s {
+		switch {
+		case r == ' ':
+			cells = append(cells, 0)
+			number = false
+		case r >= '0' && r <= '9':
+			if !number {
+				cells = append(cells, brailleDots(brailleNumber))
+				number = true
+			}
+			// digits 1-9 and 0 are the letters a-j
+			cells = append(cells, brailleDots(brailleLetters[(r-'0'+9)%10]))
+		case unicode.IsLetter(r) && r < unicode.MaxASCII:
+			l := unicode.ToLower(r)
+			if number && l <= 'j' {
+				// a-j after a number would be read as digits
+				cells = append(cells, brailleDots(brailleLetter))
+			}
+			number = false
+			if unicode.IsUpper(r) {
+				cells = append(cells, brailleDots(brailleCapital))
+			}
+			cells = append(cells, brailleDots(brailleLetters[l-'a']))
+		default:
+			dots, ok := braillePunctuation[r]
+			if !ok {
+				return nil, fmt.Errorf("no Braille for %q", r)
+			}
+			cells = append(cells, brailleDots(dots))
+			number = false
+		}
+	}
+	return cells, nil
+}
+
+// BrailleCells converts text to lines of Braille cells.
+func BrailleCells(s string) ([][]BrailleCell, error) {
+	var lines [][]BrailleCell
+	for _, l := range strings.Split(s, "\n") {
+		cells, err := brailleLine(l)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, cells)
+	}
+	return lines, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// BrailleParms defines the dot size and spacing for Braille.
+type BrailleParms struct {
+	DotDiameter float64 // base diameter of a dot
+	DotHeight   float64 // height of a dot
+	DotSpacing  float64 // distance between dots in a cell
+	CellSpacing float64 // distance between corresponding dots of adjacent cells
+	LineSpacing float64 // distance between corresponding dots of adjacent lines
+}
+
+// BrailleStandard is the standard Braille dot size and spacing.
+var BrailleStandard = BrailleParms{
+	DotDiameter: 1.5,
+	DotHeight:   0.5,
+	DotSpacing:  2.5,
+	CellSpacing: 6.2,
+	LineSpacing: 10,
+}
+
+func (k *BrailleParms) validate() error {
+	if k.DotDiameter <= 0 {
+		return errors.New("DotDiameter <= 0")
+	}
+	if k.DotHeight <= 0 || k.DotHeight > 0.5*k.DotDiameter {
+		return errors.New("DotHeight must be > 0 and <= DotDiameter / 2")
+	}
+	if k.DotSpacing <= k.DotDiameter {
+		return errors.New("DotSpacing <= DotDiameter")
+	}
+	if k.CellSpacing <= k.DotSpacing+k.DotDiameter {
+		return errors.New("CellSpacing <= DotSpacing + DotDiameter")
+	}
+	if k.LineSpacing <= 2*k.DotSpacing+k.DotDiameter {
+		return errors.New("LineSpacing <= 2 * DotSpacing + DotDiameter")
+	}
+	return nil
+}
+
+// BrailleSDF3 is embossed Braille text.
+type BrailleSDF3 struct {
+	lines  [][]BrailleCell
+	k      BrailleParms
+	radius float64 // sphere radius of a dome
+	far    float64 // lower bound on the distance to dots outside the neighbouring cells
+	bb     Box3
+}
+
+// Braille3D returns Braille text as domes on the z = 0 plane. Dot 1 of the
+// first cell is at the origin, the text runs along +x and the lines along -y.
+func Braille3D(s string, k *BrailleParms) (SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	lines, err := BrailleCells(s)
+	if err != nil {
+		return nil, err
+	}
+	b := BrailleSDF3{
+		lines: lines,
+		k:     *k,
+	}
+	a := 0.5 * k.DotDiameter
+	h := k.DotHeight
+	b.radius = (a*a + h*h) / (2 * h)
+	b.far = math.Min(math.Min(k.CellSpacing, 2*k.CellSpacing-k.DotSpacing), math.Min(k.LineSpacing, 2*(k.LineSpacing-k.DotSpacing))) - a
+	n := 0
+	for _, l := range lines {
+		if len(l) > n {
+			n = len(l)
+		}
+	}
+	if n == 0 {
+		return nil, errors.New("no Braille cells")
+	}
+	x := float64(n-1)*k.CellSpacing + k.DotSpacing + a
+	y := float64(len(lines)-1)*k.LineSpacing + 2*k.DotSpacing + a
+	b.bb = Box3{V3{-a, -y, 0}, V3{x, a, h}}
+	return &b, nil
+}
+
+// Evaluate returns the minimum distance to Braille text.
+func (s *BrailleSDF3) Evaluate(p V3) float64 {
+	k := &s.k
+	// check the cells around p, dots in other cells are further away
+	c0 := int(math.Floor(p.X / k.CellSpacing))
+	l0 := int(math.Floor(-p.Y / k.LineSpacing))
+	d := s.far
+	for l := l0 - 1; l <= l0+1; l++ {
+		if l < 0 || l >= len(s.lines) {
+			continue
+		}
+		line := s.lines[l]
+		for c := c0 - 1; c <= c0+1; c++ {
+			if c < 0 || c >= len(line) {
+				continue
+			}
+			for i := 0; i < 6; i++ {
+				if line[c]&(1<<uint(i)) == 0 {
+					continue
+				}
+				center := V3{
+					float64(c)*k.CellSpacing + float64(i/3)*k.DotSpacing,
+					-float64(l)*k.LineSpacing - float64(i%3)*k.DotSpacing,
+					k.DotHeight - s.radius,
+				}
+				// a spherical cap above z = 0
+				d = math.Min(d, math.Max(p.Sub(center).Length()-s.radius, -p.Z))
+			}
+		}
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of Braille text.
+func (s *BrailleSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
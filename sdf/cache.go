@@ -0,0 +1,222 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mesh Cache
+
+Hash the structure and parameters of an SDF tree and cache rendered meshes
+on disk, keyed by the hash and the mesh resolution. Iterative design sessions
+can then skip re-meshing unchanged parts.
+
+Hashing walks the SDF tree with reflection. Blending functions (MinFunc,
+MaxFunc, etc.) are hashed by name. Closures (E.g. RoundMin(k)) capture
+parameters that can't be hashed, so trees containing them return an error.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+)
+
+//-----------------------------------------------------------------------------
+
+// closureName matches the runtime names of closures and method values.
+var closureName = regexp.MustCompile(`\.func\d+(\.\d+)*$|-fm$`)
+
+// hashString adds a string to the hash with a length prefix, so adjacent strings can't collide.
+func hashString(h hash.Hash, s string) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(len(s)))
+	h.Write(buf[:])
+	h.Write([]byte(s))
+}
+
+// hashValue adds a value to the hash.
+func hashValue(h hash.Hash, v reflect.Value) error {
+	var buf [8]byte
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			hashString(h, "nil")
+			return nil
+		}
+		return hashValue(h, v.Elem())
+	case reflect.Struct:
+		hashString(h, v.Type().String())
+		for i := 0; i < v.NumField(); i++ {
+			if err := hashValue(h, v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Len()))
+		h.Write(buf[:])
+		for i := 0; i < v.Len(); i++ {
+			if err := hashValue(h, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v.Float()))
+		h.Write(buf[:])
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Int()))
+		h.Write(buf[:])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		binary.LittleEndian.PutUint64(buf[:], v.Uint())
+		h.Write(buf[:])
+	case reflect.Bool:
+		if v.Bool() {
+			h.Write([]byte{1})
+		} else {
+			h.Write([]byte{0})
+		}
+	case reflect.String:
+		hashString(h, v.String())
+	case reflect.Func:
+		if v.IsNil() {
+			hashString(h, "nil")
+			return nil
+		}
+		name := runtime.FuncForPC(v.Pointer()).Name()
+		if closureName.MatchString(name) {
+			return fmt.Errorf("can't hash closure %s", name)
+		}
+		hashString(h, name)
+	case reflect.Map, reflect.Chan:
+		// maps and channels are used for evaluation caches, skip them
+	default:
+		return fmt.Errorf("can't hash %s", v.Type())
+	}
+	return nil
+}
+
+// hashSDF returns the hex encoded hash of an SDF.
+func hashSDF(s interface{}) (string, error) {
+	h := sha256.New()
+	if err := hashValue(h, reflect.ValueOf(s)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashSDF3 returns a hash of the structure and parameters of an SDF3.
+func HashSDF3(s SDF3) (string, error) {
+	return hashSDF(s)
+}
+
+// HashSDF2 returns a hash of the structure and parameters of an SDF2.
+func HashSDF2(s SDF2) (string, error) {
+	return hashSDF(s)
+}
+
+//-----------------------------------------------------------------------------
+
+// MeshCache is an on-disk cache of rendered meshes.
+type MeshCache struct {
+	dir string
+}
+
+// NewMeshCache returns a mesh cache stored in a directory.
+func NewMeshCache(dir string) (*MeshCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &MeshCache{dir: dir}, nil
+}
+
+// path returns the cache file path for an SDF3 and mesh resolution.
+func (c *MeshCache) path(s SDF3, meshCells int) (string, error) {
+	h, err := HashSDF3(s)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(c.dir, fmt.Sprintf("%s_%d.mesh", h, meshCells)), nil
+}
+
+// load reads a cached mesh.
+func (c *MeshCache) load(path string) ([]*Triangle3, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	var n uint64
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, err
+	}
+	mesh := make([]*Triangle3, n)
+	for i := range mesh {
+		t := Triangle3{}
+		if err := binary.Read(r, binary.LittleEndian, &t.V); err != nil {
+			return nil, err
+		}
+		mesh[i] = &t
+	}
+	return mesh, nil
+}
+
+// store writes a mesh to the cache.
+func (c *MeshCache) store(path string, mesh []*Triangle3) error {
+	// write to a temporary file and rename, so an interrupted write won't be used
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = binary.Write(w, binary.LittleEndian, uint64(len(mesh)))
+	for _, t := range mesh {
+		if err != nil {
+			break
+		}
+		err = binary.Write(w, binary.LittleEndian, &t.V)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	f.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Mesh returns the triangle mesh for an SDF3, rendering it only if it isn't in the cache.
+func (c *MeshCache) Mesh(s SDF3, meshCells int) ([]*Triangle3, error) {
+	path, err := c.path(s, meshCells)
+	if err != nil {
+		return nil, err
+	}
+	if mesh, err := c.load(path); err == nil {
+		return mesh, nil
+	}
+	mesh := RenderMesh(s, meshCells)
+	return mesh, c.store(path, mesh)
+}
+
+// RenderSTL renders an SDF3 as an STL file using the cached mesh if possible.
+func (c *MeshCache) RenderSTL(s SDF3, meshCells int, path string) error {
+	mesh, err := c.Mesh(s, meshCells)
+	if err != nil {
+		return err
+	}
+	return SaveSTL(path, mesh)
+}
+
+//-----------------------------------------------------------------------------
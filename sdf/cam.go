@@ -0,0 +1,344 @@
+//-----------------------------------------------------------------------------
+/*
+
+CNC Pocketing
+
+Generate pocket toolpaths directly from the distance field of an SDF2.
+Each pass follows an iso-contour of the field, so the stepover is constant
+and the tool radius compensation is just a contour offset.
+
+Strategies:
+
+Contour parallel: each offset contour is cut as a closed loop, retracting
+between loops.
+
+Offset spiral: where each offset level is a single loop, the loops are
+linked by a spiral that blends from one contour to the next, so the tool
+stays in the cut.
+
+The pocket is cleared from the inside out and finishes with a full pass
+around the wall.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+// Iso-contours
+
+// isoContours returns the closed contours of an SDF2 at a given level.
+// Contours have the inside (values < level) on the left.
+func isoContours(s SDF2, level, resolution float64) [][]V2 {
+	bb := s.BoundingBox()
+	// enlarge the box so the contours are closed
+	pad := V2{2 * resolution, 2 * resolution}
+	bb = Box2{bb.Min.Sub(pad), bb.Max.Add(pad)}
+	f := Offset2D(s, level)
+	lines := marchingSquares(f, bb, resolution)
+	// join the line segments, points shared by adjacent squares are keyed by rounding
+	q := 1e-6 * resolution
+	key := func(p V2) [2]int64 {
+		return [2]int64{int64(math.Round(p.X / q)), int64(math.Round(p.Y / q))}
+	}
+	ends := make(map[[2]int64][]int)
+	for i, l := range lines {
+		ends[key(l[0])] = append(ends[key(l[0])], i)
+		ends[key(l[1])] = append(ends[key(l[1])], i)
+	}
+	used := make([]bool, len(lines))
+	var contours [][]V2
+	for i := range lines {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		start := key(lines[i][0])
+		c := []V2{lines[i][0]}
+		p := lines[i][1]
+		for key(p) != start {
+			c = append(c, p)
+			k := -1
+			for _, j := range ends[key(p)] {
+				if !used[j] {
+					k = j
+					break
+				}
+			}
+			if k < 0 {
+				// open chain
+				break
+			}
+			used[k] = true
+			if key(lines[k][0]) == key(p) {
+				p = lines[k][1]
+			} else {
+				p = lines[k][0]
+			}
+		}
+		if len(c) < 3 {
+			continue
+		}
+		// orient the contour with the inside on the left
+		d := c[1].Sub(c[0])
+		m := c[0].Add(d.MulScalar(0.5)).Add(V2{-d.Y, d.X}.Normalize().MulScalar(0.1 * resolution))
+		if f.Evaluate(m) > 0 {
+			for i, j := 0, len(c)-1; i < j; i, j = i+1, j-1 {
+				c[i], c[j] = c[j], c[i]
+			}
+		}
+		contours = append(contours, c)
+	}
+	return contours
+}
+
+// contourLength returns the length of a closed contour.
+func contourLength(c []V2) float64 {
+	l := 0.0
+	for _, s := range contourSegments(c, true) {
+		l += s[1].Sub(s[0]).Length()
+	}
+	return l
+}
+
+// closeContour returns a closed contour starting at the vertex closest to p.
+// The first vertex is repeated at the end.
+func closeContour(c []V2, p V2) []V2 {
+	k := 0
+	dmin := math.Inf(1)
+	for i, v := range c {
+		if d := v.Sub(p).Length2(); d < dmin {
+			dmin = d
+			k = i
+		}
+	}
+	out := make([]V2, 0, len(c)+1)
+	out = append(out, c[k:]...)
+	out = append(out, c[:k]...)
+	return append(out, c[k])
+}
+
+// spiralLink returns a spiral that blends from the closed contour a to b.
+// a and b have the same orientation. The spiral starts at a[0].
+func spiralLink(a, b []V2, resolution float64) []V2 {
+	n := int(math.Max(8, math.Ceil(contourLength(b)/resolution)))
+	a = ResampleContour(a, contourLength(a)/float64(n), true)
+	b = ResampleContour(b, contourLength(b)/float64(n), true)
+	b = closeContour(b, a[0])
+	out := make([]V2, 0, n+1)
+	for i := 0; i <= n; i++ {
+		t := float64(i) / float64(n)
+		pa := a[i%len(a)]
+		pb := b[i%len(b)]
+		out = append(out, pa.Add(pb.Sub(pa).MulScalar(t)))
+	}
+	return out
+}
+
+//-----------------------------------------------------------------------------
+// G-Code
+
+// gcodeWriter writes G-code moves.
+type gcodeWriter struct {
+	w *bufio.Writer
+}
+
+func newGcodeWriter(w io.Writer) *gcodeWriter {
+	return &gcodeWriter{bufio.NewWriter(w)}
+}
+
+// start writes the program header.
+func (g *gcodeWriter) start(safeZ, spindle float64) {
+	fmt.Fprintf(g.w, "G21\nG90\nG17\n")
+	g.rapidZ(safeZ)
+	if spindle > 0 {
+		fmt.Fprintf(g.w, "M3 S%.0f\n", spindle)
+	}
+}
+
+// end writes the program trailer.
+func (g *gcodeWriter) end(safeZ, spindle float64) error {
+	g.rapidZ(safeZ)
+	if spindle > 0 {
+		fmt.Fprintf(g.w, "M5\n")
+	}
+	fmt.Fprintf(g.w, "M2\n")
+	return g.w.Flush()
+}
+
+func (g *gcodeWriter) rapidZ(z float64) {
+	fmt.Fprintf(g.w, "G0 Z%.4f\n", z)
+}
+
+func (g *gcodeWriter) rapidXY(p V2) {
+	fmt.Fprintf(g.w, "G0 X%.4f Y%.4f\n", p.X, p.Y)
+}
+
+func (g *gcodeWriter) feedZ(z, feed float64) {
+	fmt.Fprintf(g.w, "G1 Z%.4f F%.0f\n", z, feed)
+}
+
+func (g *gcodeWriter) feedXY(p V2, feed float64) {
+	fmt.Fprintf(g.w, "G1 X%.4f Y%.4f F%.0f\n", p.X, p.Y, feed)
+}
+
+func (g *gcodeWriter) feedXYZ(p V3, feed float64) {
+	fmt.Fprintf(g.w, "G1 X%.4f Y%.4f Z%.4f F%.0f\n", p.X, p.Y, p.Z, feed)
+}
+
+//-----------------------------------------------------------------------------
+// Pocketing
+
+// PocketStrategy is the pocket clearing strategy.
+type PocketStrategy int
+
+const (
+	// PocketContour cuts each offset contour as a separate loop.
+	PocketContour PocketStrategy = iota
+	// PocketSpiral links the offset contours with a spiral.
+	PocketSpiral
+)
+
+// PocketParms defines the parameters for a pocket toolpath.
+type PocketParms struct {
+	ToolRadius float64        // radius of the cutter
+	Stepover   float64        // radial distance between passes (<= 2 * ToolRadius)
+	Depth      float64        // depth of the pocket
+	StepDown   float64        // maximum depth of cut per pass
+	SafeZ      float64        // retract height above the work
+	Feed       float64        // cutting feed rate
+	PlungeFeed float64        // plunge feed rate
+	Spindle    float64        // spindle speed (0 = no spindle commands)
+	Strategy   PocketStrategy // contour parallel or offset spiral
+	Climb      bool           // climb (vs conventional) milling for a clockwise spindle
+	Resolution float64        // contour sampling resolution (0 = ToolRadius/10)
+}
+
+func (k *PocketParms) validate() error {
+	if k.ToolRadius <= 0 {
+		return errors.New("ToolRadius <= 0")
+	}
+	if k.Stepover <= 0 || k.Stepover > 2*k.ToolRadius {
+		return errors.New("Stepover must be > 0 and <= 2 * ToolRadius")
+	}
+	if k.Depth <= 0 {
+		return errors.New("Depth <= 0")
+	}
+	if k.StepDown <= 0 {
+		return errors.New("StepDown <= 0")
+	}
+	if k.SafeZ <= 0 {
+		return errors.New("SafeZ <= 0")
+	}
+	if k.Feed <= 0 || k.PlungeFeed <= 0 {
+		return errors.New("Feed/PlungeFeed <= 0")
+	}
+	if k.Resolution < 0 {
+		return errors.New("Resolution < 0")
+	}
+	return nil
+}
+
+// PocketToolpath returns the 2D toolpaths for a single depth of a pocket.
+// The region inside the SDF2 is cleared. Each path is cut with a plunge at the
+// start and a retract at the end.
+func PocketToolpath(s SDF2, k *PocketParms) ([][]V2, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	res := k.Resolution
+	if res == 0 {
+		res = k.ToolRadius / 10
+	}
+
+	// offset contours: levels[0] is the wall pass
+	var levels [][][]V2
+	for o := k.ToolRadius; ; o += k.Stepover {
+		loops := isoContours(s, -o, res)
+		if len(loops) == 0 {
+			break
+		}
+		// climb milling a pocket with a clockwise spindle runs clockwise
+		if k.Climb {
+			for _, c := range loops {
+				for i, j := 0, len(c)-1; i < j; i, j = i+1, j-1 {
+					c[i], c[j] = c[j], c[i]
+				}
+			}
+		}
+		levels = append(levels, loops)
+	}
+	if len(levels) == 0 {
+		return nil, errors.New("the pocket is smaller than the tool")
+	}
+
+	// cut from the inside out
+	var paths [][]V2
+	var cur []V2  // current spiral path
+	var last []V2 // last loop of the current spiral
+	var end V2    // end of the previous path
+	for i := len(levels) - 1; i >= 0; i-- {
+		loops := levels[i]
+		if cur != nil && len(loops) == 1 {
+			cur = append(cur, spiralLink(last, loops[0], res)...)
+			last = loops[0]
+			continue
+		}
+		if cur != nil {
+			// finish the spiral with a full loop
+			cur = append(cur, closeContour(last, cur[len(cur)-1])...)
+			paths = append(paths, cur)
+			end = cur[len(cur)-1]
+			cur = nil
+		}
+		if k.Strategy == PocketSpiral && len(loops) == 1 {
+			cur = closeContour(loops[0], end)
+			last = closeContour(loops[0], end)
+			last = last[:len(last)-1]
+			continue
+		}
+		for _, c := range loops {
+			p := closeContour(c, end)
+			paths = append(paths, p)
+			end = p[len(p)-1]
+		}
+	}
+	if cur != nil {
+		cur = append(cur, closeContour(last, cur[len(cur)-1])...)
+		paths = append(paths, cur)
+	}
+	return paths, nil
+}
+
+// PocketGCode writes the G-code to cut a pocket. The top of the work is at z = 0.
+func PocketGCode(s SDF2, k *PocketParms, w io.Writer) error {
+	paths, err := PocketToolpath(s, k)
+	if err != nil {
+		return err
+	}
+	g := newGcodeWriter(w)
+	g.start(k.SafeZ, k.Spindle)
+	n := int(math.Ceil(k.Depth/k.StepDown - tolerance))
+	for i := 1; i <= n; i++ {
+		z := -k.Depth * float64(i) / float64(n)
+		for _, p := range paths {
+			g.rapidXY(p[0])
+			g.feedZ(z, k.PlungeFeed)
+			for _, v := range p[1:] {
+				g.feedXY(v, k.Feed)
+			}
+			g.rapidZ(k.SafeZ)
+		}
+	}
+	return g.end(k.SafeZ, k.Spindle)
+}
+
+//-----------------------------------------------------------------------------
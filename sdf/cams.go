@@ -9,6 +9,7 @@ Cams
 package sdf
 
 import (
+	"errors"
 	"fmt"
 	"math"
 )
@@ -322,3 +323,157 @@ func MakeGenevaCam(
 }
 
 //-----------------------------------------------------------------------------
+// Displacement Diagram Cams
+
+// CamMotion is the motion law of a cam rise or fall.
+type CamMotion int
+
+// Cam motion laws.
+const (
+	CamUniform    CamMotion = iota // constant velocity
+	CamHarmonic                    // simple harmonic
+	CamCycloidal                   // cycloidal (zero acceleration at the ends)
+	CamPolynomial                  // 3-4-5 polynomial
+)
+
+// displacement returns the normalized displacement (0..1) at x (0..1).
+func (m CamMotion) displacement(x float64) float64 {
+	switch m {
+	case CamHarmonic:
+		return 0.5 * (1 - math.Cos(Pi*x))
+	case CamCycloidal:
+		return x - math.Sin(Tau*x)/Tau
+	case CamPolynomial:
+		return x * x * x * (10 + x*(-15+6*x))
+	}
+	return x
+}
+
+// CamSegment is a segment of a cam displacement diagram.
+type CamSegment struct {
+	Angle  float64   // cam rotation over the segment (radians)
+	Lift   float64   // change in follower displacement (> 0 rise, < 0 fall, 0 dwell)
+	Motion CamMotion // motion law for a rise or fall
+}
+
+// CamParms defines the parameters for a cam with an in-line translating follower.
+type CamParms struct {
+	Segments     []CamSegment // displacement diagram, the angles sum to a full turn
+	BaseRadius   float64      // base circle radius (follower at zero displacement)
+	RollerRadius float64      // follower roller radius (0 for a knife edge follower)
+	Steps        int          // number of profile points (0 = 360)
+	Thickness    float64      // cam plate thickness (3D)
+	HubDiameter  float64      // hub diameter (3D, 0 for no hub)
+	HubLength    float64      // hub length beyond the cam plate (3D)
+	BoreDiameter float64      // shaft bore diameter (3D, 0 for no bore)
+}
+
+func (k *CamParms) validate() error {
+	if len(k.Segments) == 0 {
+		return errors.New("no segments")
+	}
+	angle, lift := 0.0, 0.0
+	for _, seg := range k.Segments {
+		if seg.Angle <= 0 {
+			return errors.New("segment Angle <= 0")
+		}
+		angle += seg.Angle
+		lift += seg.Lift
+	}
+	if Abs(angle-Tau) > 1e-6 {
+		return errors.New("the segment angles don't sum to a full turn")
+	}
+	if Abs(lift) > 1e-6 {
+		return errors.New("the segment lifts don't sum to zero")
+	}
+	if k.BaseRadius <= 0 {
+		return errors.New("BaseRadius <= 0")
+	}
+	if k.RollerRadius < 0 {
+		return errors.New("RollerRadius < 0")
+	}
+	if k.Steps < 0 {
+		return errors.New("Steps < 0")
+	}
+	return nil
+}
+
+// CamDisplacement returns the follower displacement at a cam angle (radians).
+func CamDisplacement(segments []CamSegment, theta float64) float64 {
+	theta = math.Mod(theta, Tau)
+	if theta < 0 {
+		theta += Tau
+	}
+	s := 0.0
+	for _, seg := range segments {
+		if theta < seg.Angle {
+			return s + seg.Lift*seg.Motion.displacement(theta/seg.Angle)
+		}
+		theta -= seg.Angle
+		s += seg.Lift
+	}
+	return s
+}
+
+// CamProfile2D returns a cam profile from a displacement diagram.
+// The cam rotates counterclockwise about the origin and the follower moves
+// along the +y axis. The follower is at the start of the diagram when the cam
+// is at zero rotation. Undercutting is not checked.
+func CamProfile2D(k *CamParms) (SDF2, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	n := k.Steps
+	if n == 0 {
+		n = 360
+	}
+	// The pitch curve is traced by the follower (roller center). After the
+	// cam turns by theta the follower touches the cam at angle Pi/2 - theta.
+	pitch := make([]V2, n)
+	for i := range pitch {
+		theta := Tau * float64(i) / float64(n)
+		r := k.BaseRadius + k.RollerRadius + CamDisplacement(k.Segments, theta)
+		if r <= k.RollerRadius {
+			return nil, errors.New("the displacement is below the base circle")
+		}
+		a := 0.5*Pi - theta
+		pitch[n-1-i] = V2{r * math.Cos(a), r * math.Sin(a)}
+	}
+	s := Polygon2D(pitch)
+	if k.RollerRadius > 0 {
+		// the cam profile is the inner envelope of the roller
+		s = Offset2D(s, -k.RollerRadius)
+	}
+	return s, nil
+}
+
+// Cam3D returns a cam plate from a displacement diagram with a hub and a shaft bore.
+// The cam plate is on z = 0 to Thickness with the hub extending along +z.
+func Cam3D(k *CamParms) (SDF3, error) {
+	if k.Thickness <= 0 {
+		return nil, errors.New("Thickness <= 0")
+	}
+	if k.HubDiameter < 0 || k.HubLength < 0 || k.BoreDiameter < 0 {
+		return nil, errors.New("hub and bore dimensions must be >= 0")
+	}
+	if k.BoreDiameter >= 2*k.BaseRadius || (k.HubDiameter > 0 && k.BoreDiameter >= k.HubDiameter) {
+		return nil, errors.New("BoreDiameter is too large")
+	}
+	profile, err := CamProfile2D(k)
+	if err != nil {
+		return nil, err
+	}
+	s := Transform3D(Extrude3D(profile, k.Thickness), Translate3d(V3{0, 0, 0.5 * k.Thickness}))
+	if k.HubDiameter > 0 && k.HubLength > 0 {
+		hub := Cylinder3D(k.HubLength, 0.5*k.HubDiameter, 0)
+		s = Union3D(s, Transform3D(hub, Translate3d(V3{0, 0, k.Thickness + 0.5*k.HubLength})))
+	}
+	if k.BoreDiameter > 0 {
+		l := k.Thickness + k.HubLength
+		bore := Cylinder3D(2*l, 0.5*k.BoreDiameter, 0)
+		s = Difference3D(s, Transform3D(bore, Translate3d(V3{0, 0, 0.5 * l})))
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,180 @@
+//-----------------------------------------------------------------------------
+/*
+
+Cable Management
+
+Cable clips, zip-tie mounts and hook-and-loop strap saddles.
+
+The parts sit on the z = 0 plane (the mounting face) and the cable runs
+along the y-axis. Screw holes are countersunk from the top.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+// screwHoles returns countersunk screw holes (ScrewDiameter = 0 for none).
+func screwHoles(length, diameter float64, positions V2Set) SDF3 {
+	if diameter == 0 {
+		return nil
+	}
+	hole := CounterSunkHole3D(length, 0.5*diameter)
+	hole = Transform3D(hole, Translate3d(V3{0, 0, 0.5 * length}))
+	var s []SDF3
+	for _, p := range positions {
+		s = append(s, Transform3D(hole, Translate3d(p.ToV3(0))))
+	}
+	return Union3D(s...)
+}
+
+//-----------------------------------------------------------------------------
+// Cable Clips
+
+// CableClipParms defines the parameters for a snap-in cable clip.
+type CableClipParms struct {
+	Diameter      float64 // cable diameter
+	Wall          float64 // wall thickness of the clip ring
+	Width         float64 // width of the clip along the cable
+	Opening       float64 // width of the snap-in opening (< Diameter)
+	BaseLength    float64 // length of the base across the cable
+	BaseThickness float64 // thickness of the base
+	ScrewDiameter float64 // diameter of the two screw holes in the base (0 = none)
+}
+
+// CableClip3D returns a snap-in cable clip on a base with optional screw holes.
+func CableClip3D(k *CableClipParms) (SDF3, error) {
+	if k.Diameter <= 0 || k.Wall <= 0 || k.Width <= 0 {
+		return nil, errors.New("Diameter, Wall and Width must be > 0")
+	}
+	if k.Opening <= 0 || k.Opening >= k.Diameter {
+		return nil, errors.New("Opening out of range")
+	}
+	if k.BaseThickness <= 0 {
+		return nil, errors.New("BaseThickness <= 0")
+	}
+	r := 0.5 * k.Diameter
+	outer := r + k.Wall
+	if k.ScrewDiameter < 0 {
+		return nil, errors.New("ScrewDiameter < 0")
+	}
+	if 0.5*k.BaseLength-outer < 2*k.ScrewDiameter {
+		return nil, errors.New("BaseLength is too short")
+	}
+	// profile in the xz plane
+	h := k.BaseThickness + r
+	ring := Difference2D(Circle2D(outer), Circle2D(r))
+	ring = Transform2D(ring, Translate2d(V2{0, h}))
+	gap := Box2D(V2{k.Opening, 2 * k.Wall}, 0)
+	gap = Transform2D(gap, Translate2d(V2{0, h + r + 0.5*k.Wall}))
+	ring = Difference2D(ring, gap)
+	base := Box2D(V2{k.BaseLength, k.BaseThickness}, 0)
+	base = Transform2D(base, Translate2d(V2{0, 0.5 * k.BaseThickness}))
+	profile := Difference2D(Union2D(ring, base), Transform2D(Circle2D(r), Translate2d(V2{0, h})))
+	s := Transform3D(Extrude3D(profile, k.Width), RotateX(0.5*Pi))
+	// screw holes centered on the base tabs
+	x := 0.5 * (outer + 0.5*k.BaseLength)
+	return Difference3D(s, screwHoles(k.BaseThickness, k.ScrewDiameter, V2Set{{-x, 0}, {x, 0}})), nil
+}
+
+//-----------------------------------------------------------------------------
+// Zip-Tie Mounts
+
+// ZipTieMountParms defines the parameters for a zip-tie mount.
+type ZipTieMountParms struct {
+	Size          float64 // side length of the square mount
+	Height        float64 // total height of the mount
+	BaseThickness float64 // floor thickness under the tie channel
+	TieWidth      float64 // width of the tie channel
+	TieThickness  float64 // height of the tie channel
+	Round         float64 // corner rounding radius
+	ScrewDiameter float64 // diameter of the center screw hole (0 = none)
+}
+
+// ZipTieMount3D returns a square zip-tie mount with a channel for the tie
+// along the y-axis. The center screw hole has a clearance bore for the screw
+// head through the roof of the channel.
+func ZipTieMount3D(k *ZipTieMountParms) (SDF3, error) {
+	if k.Size <= 0 || k.Height <= 0 || k.BaseThickness <= 0 {
+		return nil, errors.New("Size, Height and BaseThickness must be > 0")
+	}
+	if k.TieWidth <= 0 || k.TieWidth >= k.Size {
+		return nil, errors.New("TieWidth out of range")
+	}
+	if k.TieThickness <= 0 || k.BaseThickness+k.TieThickness >= k.Height {
+		return nil, errors.New("TieThickness out of range")
+	}
+	if k.Round < 0 || 2*k.Round > k.Size {
+		return nil, errors.New("Round out of range")
+	}
+	if k.ScrewDiameter < 0 || 2*k.ScrewDiameter >= k.TieWidth {
+		return nil, errors.New("ScrewDiameter out of range")
+	}
+	s := Extrude3D(Box2D(V2{k.Size, k.Size}, k.Round), k.Height)
+	s = Transform3D(s, Translate3d(V3{0, 0, 0.5 * k.Height}))
+	// tie channel
+	channel := Box3D(V3{k.TieWidth, 2 * k.Size, k.TieThickness}, 0)
+	channel = Transform3D(channel, Translate3d(V3{0, 0, k.BaseThickness + 0.5*k.TieThickness}))
+	s = Difference3D(s, channel)
+	if k.ScrewDiameter > 0 {
+		s = Difference3D(s, screwHoles(k.BaseThickness, k.ScrewDiameter, V2Set{{0, 0}}))
+		head := Cylinder3D(k.Height, k.ScrewDiameter, 0)
+		head = Transform3D(head, Translate3d(V3{0, 0, k.BaseThickness + 0.5*k.Height}))
+		s = Difference3D(s, head)
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
+// Hook-and-Loop Strap Saddles
+
+// StrapSaddleParms defines the parameters for a hook-and-loop strap saddle.
+type StrapSaddleParms struct {
+	Diameter       float64 // diameter of the cable bundle
+	Wall           float64 // wall thickness around the saddle and strap tunnel
+	Width          float64 // width of the saddle along the cable
+	StrapWidth     float64 // width of the strap
+	StrapThickness float64 // thickness of the strap tunnel
+	BaseThickness  float64 // floor thickness under the strap tunnel
+	ScrewDiameter  float64 // diameter of the two screw holes (0 = none)
+}
+
+// StrapSaddle3D returns a saddle that cradles a cable bundle. The strap runs
+// across the saddle through a tunnel under the cradle and wraps over the
+// bundle. The screw holes are either side of the tunnel under the cradle.
+func StrapSaddle3D(k *StrapSaddleParms) (SDF3, error) {
+	if k.Diameter <= 0 || k.Wall <= 0 || k.BaseThickness <= 0 {
+		return nil, errors.New("Diameter, Wall and BaseThickness must be > 0")
+	}
+	if k.StrapWidth <= 0 || k.StrapThickness <= 0 {
+		return nil, errors.New("StrapWidth and StrapThickness must be > 0")
+	}
+	if k.ScrewDiameter < 0 {
+		return nil, errors.New("ScrewDiameter < 0")
+	}
+	if k.Width < k.StrapWidth+2*k.Wall+4*k.ScrewDiameter {
+		return nil, errors.New("Width is too small for the strap and screw holes")
+	}
+	r := 0.5 * k.Diameter
+	// the cradle bottom is a wall above the strap tunnel
+	z0 := k.BaseThickness + k.StrapThickness + k.Wall
+	h := z0 + r
+	l := k.Diameter + 2*k.Wall
+	s := Box3D(V3{l, k.Width, h}, 0)
+	s = Transform3D(s, Translate3d(V3{0, 0, 0.5 * h}))
+	cradle := Cylinder3D(2*k.Width, r, 0)
+	cradle = Transform3D(cradle, Translate3d(V3{0, 0, h}).Mul(RotateX(0.5*Pi)))
+	s = Difference3D(s, cradle)
+	// strap tunnel across the saddle
+	tunnel := Box3D(V3{2 * l, k.StrapWidth, k.StrapThickness}, 0)
+	tunnel = Transform3D(tunnel, Translate3d(V3{0, 0, k.BaseThickness + 0.5*k.StrapThickness}))
+	s = Difference3D(s, tunnel)
+	// screws go in from the cradle
+	y := 0.5*k.StrapWidth + k.Wall + k.ScrewDiameter
+	return Difference3D(s, screwHoles(z0, k.ScrewDiameter, V2Set{{0, -y}, {0, y}})), nil
+}
+
+//-----------------------------------------------------------------------------
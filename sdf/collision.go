@@ -0,0 +1,483 @@
+//-----------------------------------------------------------------------------
+/*
+
+Collision Shapes
+
+Export simplified collision geometry for game and physics engines.
+
+1) Primitive fits: bounding sphere, oriented box and capsule. The box and
+capsule axes come from a principal component analysis of the mesh vertices.
+
+2) Approximate convex decomposition (in the style of V-HACD): the model is
+recursively split with axis aligned planes until the concavity of each
+piece (the fraction of its convex hull volume it doesn't fill) is small.
+Each piece is replaced by its convex hull.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+// Convex Hull
+
+// hullFace is a triangular face of a convex hull (counter clockwise from outside).
+type hullFace struct {
+	v [3]int
+	n V3 // outward normal
+}
+
+// ConvexHull returns the triangles of the convex hull of a set of points.
+// It returns nil if the points are degenerate (E.g. all coplanar).
+func ConvexHull(points []V3) []*Triangle3 {
+	// dedupe the points
+	seen := make(map[V3]bool)
+	var p []V3
+	for _, x := range points {
+		if !seen[x] {
+			seen[x] = true
+			p = append(p, x)
+		}
+	}
+	if len(p) < 4 {
+		return nil
+	}
+	vs := V3Set(p)
+	eps := 1e-9 * Max(1, vs.Max().Sub(vs.Min()).MaxComponent())
+	// initial tetrahedron: extreme x points, then furthest from the line, then from the plane
+	i0, i1 := 0, 0
+	for i := range p {
+		if p[i].X < p[i0].X {
+			i0 = i
+		}
+		if p[i].X > p[i1].X {
+			i1 = i
+		}
+	}
+	i2 := -1
+	dmax := eps
+	for i := range p {
+		if d := p[i].Sub(p[i0]).Cross(p[i1].Sub(p[i0])).Length(); d > dmax {
+			dmax = d
+			i2 = i
+		}
+	}
+	if i2 < 0 {
+		return nil
+	}
+	n := p[i1].Sub(p[i0]).Cross(p[i2].Sub(p[i0])).Normalize()
+	i3 := -1
+	dmax = eps
+	for i := range p {
+		if d := Abs(p[i].Sub(p[i0]).Dot(n)); d > dmax {
+			dmax = d
+			i3 = i
+		}
+	}
+	if i3 < 0 {
+		return nil
+	}
+	center := p[i0].Add(p[i1]).Add(p[i2]).Add(p[i3]).DivScalar(4)
+	newFace := func(a, b, c int) hullFace {
+		n := p[b].Sub(p[a]).Cross(p[c].Sub(p[a])).Normalize()
+		if n.Dot(p[a].Sub(center)) < 0 {
+			// face the normal outwards
+			b, c = c, b
+			n = n.Neg()
+		}
+		return hullFace{[3]int{a, b, c}, n}
+	}
+	faces := []hullFace{
+		newFace(i0, i1, i2), newFace(i0, i1, i3),
+		newFace(i0, i2, i3), newFace(i1, i2, i3),
+	}
+	// add the points one at a time
+	for i := range p {
+		if i == i0 || i == i1 || i == i2 || i == i3 {
+			continue
+		}
+		visible := make([]bool, len(faces))
+		any := false
+		for j, f := range faces {
+			if f.n.Dot(p[i].Sub(p[f.v[0]])) > eps {
+				visible[j] = true
+				any = true
+			}
+		}
+		if !any {
+			// inside the hull
+			continue
+		}
+		// the horizon is the edges of visible faces not shared with another visible face
+		edges := make(map[[2]int]bool)
+		for j, f := range faces {
+			if visible[j] {
+				for k := 0; k < 3; k++ {
+					edges[[2]int{f.v[k], f.v[(k+1)%3]}] = true
+				}
+			}
+		}
+		var next []hullFace
+		for j, f := range faces {
+			if !visible[j] {
+				next = append(next, f)
+			}
+		}
+		for e := range edges {
+			if !edges[[2]int{e[1], e[0]}] {
+				a, b := e[0], e[1]
+				nf := p[b].Sub(p[a]).Cross(p[i].Sub(p[a])).Normalize()
+				next = append(next, hullFace{[3]int{a, b, i}, nf})
+			}
+		}
+		faces = next
+	}
+	mesh := make([]*Triangle3, len(faces))
+	for i, f := range faces {
+		mesh[i] = NewTriangle3(p[f.v[0]], p[f.v[1]], p[f.v[2]])
+	}
+	return mesh
+}
+
+// meshVertices returns the unique vertices of a mesh.
+func meshVertices(mesh []*Triangle3) []V3 {
+	seen := make(map[V3]bool)
+	var v []V3
+	for _, t := range mesh {
+		for _, x := range t.V {
+			if !seen[x] {
+				seen[x] = true
+				v = append(v, x)
+			}
+		}
+	}
+	return v
+}
+
+//-----------------------------------------------------------------------------
+// Convex Decomposition
+
+// ConvexParms defines the parameters for a convex decomposition.
+type ConvexParms struct {
+	MeshCells    int     // number of cells on the longest axis of the model. e.g 50
+	MaxConcavity float64 // maximum fraction of a hull volume not filled by its piece. e.g 0.05
+	MaxHulls     int     // maximum number of convex hulls
+	MaxDepth     int     // maximum recursion depth
+}
+
+// convexPiece is a region of the model being decomposed.
+type convexPiece struct {
+	region Box3
+	mesh   []*Triangle3
+	hull   []*Triangle3
+	volume float64 // piece volume
+	hullV  float64 // hull volume
+	depth  int
+}
+
+// concavity returns the fraction of the hull volume not filled by the piece.
+func (c *convexPiece) concavity() float64 {
+	if c.hullV <= 0 {
+		return 0
+	}
+	return (c.hullV - c.volume) / c.hullV
+}
+
+// newConvexPiece meshes the model within a region.
+func newConvexPiece(s SDF3, region Box3, resolution float64, depth int) *convexPiece {
+	size := region.Size()
+	box := Transform3D(Box3D(size, 0), Translate3d(region.Center()))
+	cells := int(math.Ceil(size.MaxComponent() / resolution))
+	mesh := RenderMesh(Intersect3D(box, s), cells)
+	if len(mesh) == 0 {
+		return nil
+	}
+	c := &convexPiece{region: region, mesh: mesh, depth: depth}
+	c.volume = MeshVolume(mesh)
+	c.hull = ConvexHull(meshVertices(mesh))
+	c.hullV = MeshVolume(c.hull)
+	return c
+}
+
+// split returns the best split of a piece with an axis aligned plane through its center.
+func (c *convexPiece) split(s SDF3, resolution float64) []*convexPiece {
+	var best []*convexPiece
+	bestV := math.Inf(1)
+	bb := MeshBoundingBox(c.mesh)
+	center := bb.Center()
+	for axis := 0; axis < 3; axis++ {
+		r0, r1 := c.region, c.region
+		switch axis {
+		case 0:
+			r0.Max.X, r1.Min.X = center.X, center.X
+		case 1:
+			r0.Max.Y, r1.Min.Y = center.Y, center.Y
+		case 2:
+			r0.Max.Z, r1.Min.Z = center.Z, center.Z
+		}
+		if r0.Size().MinComponent() < resolution || r1.Size().MinComponent() < resolution {
+			continue
+		}
+		var pieces []*convexPiece
+		v := 0.0
+		for _, r := range []Box3{r0, r1} {
+			if p := newConvexPiece(s, r, resolution, c.depth+1); p != nil {
+				pieces = append(pieces, p)
+				v += p.hullV
+			}
+		}
+		if len(pieces) > 0 && v < bestV {
+			best = pieces
+			bestV = v
+		}
+	}
+	return best
+}
+
+// ConvexDecomposition returns approximately convex hulls covering an SDF3.
+func ConvexDecomposition(s SDF3, k *ConvexParms) ([][]*Triangle3, error) {
+	if k.MeshCells <= 0 {
+		return nil, errors.New("MeshCells <= 0")
+	}
+	maxHulls := k.MaxHulls
+	if maxHulls <= 0 {
+		maxHulls = 32
+	}
+	maxDepth := k.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = 6
+	}
+	region := s.BoundingBox().ScaleAboutCenter(1.02)
+	resolution := region.Size().MaxComponent() / float64(k.MeshCells)
+	root := newConvexPiece(s, region, resolution, 0)
+	if root == nil {
+		return nil, errors.New("empty model")
+	}
+	pieces := []*convexPiece{root}
+	for len(pieces) < maxHulls {
+		// split the most concave piece
+		worst := -1
+		for i, p := range pieces {
+			if p.depth < maxDepth && p.concavity() > k.MaxConcavity && (worst < 0 || p.concavity() > pieces[worst].concavity()) {
+				worst = i
+			}
+		}
+		if worst < 0 {
+			break
+		}
+		split := pieces[worst].split(s, resolution)
+		if split == nil || len(pieces)-1+len(split) > maxHulls {
+			// can't split it
+			pieces[worst].depth = maxDepth
+			continue
+		}
+		pieces = append(append(pieces[:worst:worst], pieces[worst+1:]...), split...)
+	}
+	var hulls [][]*Triangle3
+	for _, p := range pieces {
+		if p.hull != nil {
+			hulls = append(hulls, p.hull)
+		}
+	}
+	return hulls, nil
+}
+
+// RenderConvexOBJ renders the convex decomposition of an SDF3 as an OBJ file (one group per hull).
+func RenderConvexOBJ(s SDF3, k *ConvexParms, path string) error {
+	hulls, err := ConvexDecomposition(s, k)
+	if err != nil {
+		return err
+	}
+	groups := make([]OBJGroup, len(hulls))
+	for i, h := range hulls {
+		groups[i].Mesh = h
+	}
+	return SaveOBJ(path, groups)
+}
+
+//-----------------------------------------------------------------------------
+// Primitive Fits
+
+// SphereFit is a bounding sphere.
+type SphereFit struct {
+	Center V3
+	Radius float64
+}
+
+// BoxFit is an oriented bounding box.
+type BoxFit struct {
+	Center   V3
+	Axes     [3]V3 // unit axes, the first is the principal axis
+	HalfSize V3    // half size along each axis
+}
+
+// CapsuleFit is a bounding capsule (a line segment swept by a sphere).
+type CapsuleFit struct {
+	P0, P1 V3 // end points of the axis segment
+	Radius float64
+}
+
+// symmetricEigen3 returns the eigenvalues and eigenvectors of a symmetric 3x3 matrix
+// (Jacobi rotations), sorted by decreasing eigenvalue.
+func symmetricEigen3(a [3][3]float64) ([3]float64, [3]V3) {
+	v := [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	for sweep := 0; sweep < 50; sweep++ {
+		off := a[0][1]*a[0][1] + a[0][2]*a[0][2] + a[1][2]*a[1][2]
+		if off < 1e-30 {
+			break
+		}
+		for p := 0; p < 2; p++ {
+			for q := p + 1; q < 3; q++ {
+				if Abs(a[p][q]) < 1e-300 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := 1 / (Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < 3; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p] = c*akp - s*akq
+					a[k][q] = s*akp + c*akq
+				}
+				for k := 0; k < 3; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k] = c*apk - s*aqk
+					a[q][k] = s*apk + c*aqk
+				}
+				for k := 0; k < 3; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+	vals := [3]float64{a[0][0], a[1][1], a[2][2]}
+	vecs := [3]V3{{v[0][0], v[1][0], v[2][0]}, {v[0][1], v[1][1], v[2][1]}, {v[0][2], v[1][2], v[2][2]}}
+	// sort by decreasing eigenvalue
+	for i := 0; i < 2; i++ {
+		for j := i + 1; j < 3; j++ {
+			if vals[j] > vals[i] {
+				vals[i], vals[j] = vals[j], vals[i]
+				vecs[i], vecs[j] = vecs[j], vecs[i]
+			}
+		}
+	}
+	return vals, vecs
+}
+
+// principalAxes returns the mean and principal axes of a point set.
+func principalAxes(p []V3) (V3, [3]V3) {
+	mean := V3{}
+	for _, x := range p {
+		mean = mean.Add(x)
+	}
+	mean = mean.DivScalar(float64(len(p)))
+	var c [3][3]float64
+	for _, x := range p {
+		d := x.Sub(mean)
+		e := [3]float64{d.X, d.Y, d.Z}
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				c[i][j] += e[i] * e[j]
+			}
+		}
+	}
+	_, axes := symmetricEigen3(c)
+	// make a right handed frame
+	axes[2] = axes[0].Cross(axes[1])
+	return mean, axes
+}
+
+// FitSphere3 returns a bounding sphere for an SDF3 (Ritter's algorithm on the mesh vertices).
+func FitSphere3(s SDF3, meshCells int) SphereFit {
+	p := meshVertices(RenderMesh(s, meshCells))
+	if len(p) == 0 {
+		return SphereFit{}
+	}
+	// start with the sphere through a far apart pair
+	far := func(x V3) V3 {
+		best := x
+		for _, y := range p {
+			if y.Sub(x).Length2() > best.Sub(x).Length2() {
+				best = y
+			}
+		}
+		return best
+	}
+	a := far(p[0])
+	b := far(a)
+	c := a.Add(b).MulScalar(0.5)
+	r := b.Sub(a).Length() * 0.5
+	// grow the sphere to include every point
+	for _, x := range p {
+		if d := x.Sub(c).Length(); d > r {
+			r1 := 0.5 * (r + d)
+			c = c.Add(x.Sub(c).MulScalar((r1 - r) / d))
+			r = r1
+		}
+	}
+	return SphereFit{c, r}
+}
+
+// FitBox3 returns an oriented bounding box for an SDF3 aligned with its principal axes.
+func FitBox3(s SDF3, meshCells int) BoxFit {
+	p := meshVertices(RenderMesh(s, meshCells))
+	if len(p) == 0 {
+		return BoxFit{}
+	}
+	mean, axes := principalAxes(p)
+	lo := V3{math.Inf(1), math.Inf(1), math.Inf(1)}
+	hi := lo.Neg()
+	for _, x := range p {
+		d := x.Sub(mean)
+		q := V3{d.Dot(axes[0]), d.Dot(axes[1]), d.Dot(axes[2])}
+		lo = lo.Min(q)
+		hi = hi.Max(q)
+	}
+	mid := lo.Add(hi).MulScalar(0.5)
+	center := mean.Add(axes[0].MulScalar(mid.X)).Add(axes[1].MulScalar(mid.Y)).Add(axes[2].MulScalar(mid.Z))
+	return BoxFit{center, axes, hi.Sub(lo).MulScalar(0.5)}
+}
+
+// FitCapsule3 returns a bounding capsule for an SDF3 along its principal axis.
+func FitCapsule3(s SDF3, meshCells int) CapsuleFit {
+	p := meshVertices(RenderMesh(s, meshCells))
+	if len(p) == 0 {
+		return CapsuleFit{}
+	}
+	mean, axes := principalAxes(p)
+	u := axes[0]
+	// the radius covers the points around the axis
+	r := 0.0
+	for _, x := range p {
+		d := x.Sub(mean)
+		r = Max(r, d.Sub(u.MulScalar(d.Dot(u))).Length())
+	}
+	// the end points are pulled in so the end caps cover the points
+	t0 := math.Inf(1)
+	t1 := math.Inf(-1)
+	for _, x := range p {
+		d := x.Sub(mean)
+		t := d.Dot(u)
+		h := math.Sqrt(Max(0, r*r-d.Sub(u.MulScalar(t)).Length2()))
+		t0 = Min(t0, t+h)
+		t1 = Max(t1, t-h)
+	}
+	if t0 > t1 {
+		t0 = 0.5 * (t0 + t1)
+		t1 = t0
+	}
+	return CapsuleFit{mean.Add(u.MulScalar(t0)), mean.Add(u.MulScalar(t1)), r}
+}
+
+//-----------------------------------------------------------------------------
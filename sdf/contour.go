@@ -0,0 +1,162 @@
+//-----------------------------------------------------------------------------
+/*
+
+Contour Utilities
+
+Simplify, resample and smooth 2D contours. These are useful for cleaning up
+noisy imported outlines (SVG/DXF/image traces) before building SDF2s.
+
+A contour is either closed (the last vertex connects to the first) or open.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "math"
+
+//-----------------------------------------------------------------------------
+
+// douglasPeucker marks the vertices to keep between v[i0] and v[i1].
+func douglasPeucker(v []V2, i0, i1 int, tol float64, keep []bool) {
+	if i1 <= i0+1 {
+		return
+	}
+	// find the vertex furthest from the line segment
+	dmax := -1.0
+	imax := i0
+	for i := i0 + 1; i < i1; i++ {
+		d := pointSegmentDistance(v[i], v[i0], v[i1])
+		if d > dmax {
+			dmax = d
+			imax = i
+		}
+	}
+	if dmax > tol {
+		keep[imax] = true
+		douglasPeucker(v, i0, imax, tol, keep)
+		douglasPeucker(v, imax, i1, tol, keep)
+	}
+}
+
+// SimplifyContour removes vertices from a contour using the Douglas-Peucker algorithm.
+// No removed vertex is further than tol from the simplified contour.
+func SimplifyContour(v []V2, tol float64, closed bool) []V2 {
+	n := len(v)
+	if n < 3 {
+		return v
+	}
+	keep := make([]bool, n)
+	keep[0] = true
+	if closed {
+		// split the contour at the vertex furthest from the first vertex
+		imax := 0
+		dmax := 0.0
+		for i := range v {
+			d := v[i].Sub(v[0]).Length2()
+			if d > dmax {
+				dmax = d
+				imax = i
+			}
+		}
+		keep[imax] = true
+		douglasPeucker(v, 0, imax, tol, keep)
+		// the second half wraps around to the first vertex
+		w := append(append([]V2{}, v[imax:]...), v[0])
+		kw := make([]bool, len(w))
+		douglasPeucker(w, 0, len(w)-1, tol, kw)
+		for i := range kw[:len(kw)-1] {
+			keep[imax+i] = keep[imax+i] || kw[i]
+		}
+	} else {
+		keep[n-1] = true
+		douglasPeucker(v, 0, n-1, tol, keep)
+	}
+	var out []V2
+	for i := range v {
+		if keep[i] {
+			out = append(out, v[i])
+		}
+	}
+	return out
+}
+
+//-----------------------------------------------------------------------------
+
+// contourSegments returns the line segments of a contour.
+func contourSegments(v []V2, closed bool) [][2]V2 {
+	n := len(v)
+	var s [][2]V2
+	for i := 0; i < n-1; i++ {
+		s = append(s, [2]V2{v[i], v[i+1]})
+	}
+	if closed && n > 2 {
+		s = append(s, [2]V2{v[n-1], v[0]})
+	}
+	return s
+}
+
+// ResampleContour returns a contour with vertices evenly spaced along the
+// original contour. The spacing is adjusted to fit a whole number of segments.
+func ResampleContour(v []V2, spacing float64, closed bool) []V2 {
+	segs := contourSegments(v, closed)
+	if len(segs) == 0 || spacing <= 0 {
+		return v
+	}
+	length := 0.0
+	for _, s := range segs {
+		length += s[1].Sub(s[0]).Length()
+	}
+	n := int(math.Max(1, math.Round(length/spacing)))
+	step := length / float64(n)
+	if !closed {
+		// include the end point
+		n++
+	}
+	out := make([]V2, 0, n)
+	k := 0    // current segment
+	s0 := 0.0 // arc length at the start of the current segment
+	for i := 0; i < n; i++ {
+		x := float64(i) * step
+		l := segs[k][1].Sub(segs[k][0]).Length()
+		for x > s0+l && k < len(segs)-1 {
+			s0 += l
+			k++
+			l = segs[k][1].Sub(segs[k][0]).Length()
+		}
+		t := 0.0
+		if l > 0 {
+			t = Clamp((x-s0)/l, 0, 1)
+		}
+		out = append(out, segs[k][0].Add(segs[k][1].Sub(segs[k][0]).MulScalar(t)))
+	}
+	return out
+}
+
+//-----------------------------------------------------------------------------
+
+// SmoothContour smooths a contour by repeatedly moving each vertex towards
+// the average of its neighbours. The end points of an open contour are fixed.
+func SmoothContour(v []V2, iterations int, closed bool) []V2 {
+	n := len(v)
+	if n < 3 {
+		return v
+	}
+	out := append([]V2{}, v...)
+	tmp := make([]V2, n)
+	for k := 0; k < iterations; k++ {
+		for i := range out {
+			if !closed && (i == 0 || i == n-1) {
+				tmp[i] = out[i]
+				continue
+			}
+			a := out[(i+n-1)%n]
+			b := out[(i+1)%n]
+			tmp[i] = out[i].MulScalar(0.5).Add(a.Add(b).MulScalar(0.25))
+		}
+		out, tmp = tmp, out
+	}
+	return out
+}
+
+//-----------------------------------------------------------------------------
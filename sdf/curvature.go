@@ -0,0 +1,134 @@
+//-----------------------------------------------------------------------------
+/*
+
+Curvature
+
+Estimate the mean and Gaussian curvature of an SDF3 surface from second
+order finite differences of the distance field. For a field F with gradient
+g and Hessian H:
+
+mean = (|g|^2 trace(H) - g.H.g) / (2 |g|^3)
+gaussian = g.adj(H).g / |g|^4
+
+See: "Curvature formulas for implicit curves and surfaces", Ron Goldman, 2005
+
+A mesh can be exported with vertex colors showing the curvature, which
+makes kinks and unintended creases easy to spot.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"image/color"
+	"math"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// CurvatureType selects mean or Gaussian curvature.
+type CurvatureType int
+
+const (
+	// MeanCurvature is the average of the principal curvatures (> 0 for convex).
+	MeanCurvature CurvatureType = iota
+	// GaussianCurvature is the product of the principal curvatures.
+	GaussianCurvature
+)
+
+// Curvature3 returns the mean and Gaussian curvature of an SDF3 at a point.
+// h is the finite difference step, it should be small relative to the surface features.
+func Curvature3(s SDF3, p V3, h float64) (float64, float64) {
+	f := func(x, y, z float64) float64 {
+		return s.Evaluate(p.Add(V3{x, y, z}))
+	}
+	f0 := f(0, 0, 0)
+	// gradient
+	gx := (f(h, 0, 0) - f(-h, 0, 0)) / (2 * h)
+	gy := (f(0, h, 0) - f(0, -h, 0)) / (2 * h)
+	gz := (f(0, 0, h) - f(0, 0, -h)) / (2 * h)
+	// hessian
+	h2 := h * h
+	hxx := (f(h, 0, 0) - 2*f0 + f(-h, 0, 0)) / h2
+	hyy := (f(0, h, 0) - 2*f0 + f(0, -h, 0)) / h2
+	hzz := (f(0, 0, h) - 2*f0 + f(0, 0, -h)) / h2
+	hxy := (f(h, h, 0) - f(h, -h, 0) - f(-h, h, 0) + f(-h, -h, 0)) / (4 * h2)
+	hxz := (f(h, 0, h) - f(h, 0, -h) - f(-h, 0, h) + f(-h, 0, -h)) / (4 * h2)
+	hyz := (f(0, h, h) - f(0, h, -h) - f(0, -h, h) + f(0, -h, -h)) / (4 * h2)
+	g2 := gx*gx + gy*gy + gz*gz
+	if g2 == 0 {
+		return 0, 0
+	}
+	g := math.Sqrt(g2)
+	// g.H.g
+	gHg := gx*(hxx*gx+hxy*gy+hxz*gz) + gy*(hxy*gx+hyy*gy+hyz*gz) + gz*(hxz*gx+hyz*gy+hzz*gz)
+	mean := (g2*(hxx+hyy+hzz) - gHg) / (2 * g2 * g)
+	// g.adj(H).g
+	axx := hyy*hzz - hyz*hyz
+	ayy := hxx*hzz - hxz*hxz
+	azz := hxx*hyy - hxy*hxy
+	axy := hyz*hxz - hxy*hzz
+	axz := hxy*hyz - hyy*hxz
+	ayz := hxy*hxz - hxx*hyz
+	gAg := gx*(axx*gx+axy*gy+axz*gz) + gy*(axy*gx+ayy*gy+ayz*gz) + gz*(axz*gx+ayz*gy+azz*gz)
+	gaussian := gAg / (g2 * g2)
+	return mean, gaussian
+}
+
+//-----------------------------------------------------------------------------
+
+// CurvatureColor maps a curvature to a color. Negative values are blue, zero is
+// white and positive values are red. scale is the curvature with full color.
+func CurvatureColor(k, scale float64) color.RGBA {
+	x := Clamp(k/scale, -1, 1)
+	c := uint8(255 * (1 - Abs(x)))
+	if x > 0 {
+		return color.RGBA{255, c, c, 255}
+	}
+	return color.RGBA{c, c, 255, 255}
+}
+
+// RenderCurvaturePLY renders an SDF3 as a PLY mesh with vertex colors showing the curvature.
+// If scale <= 0 it is set to the 95th percentile of the absolute curvature.
+func RenderCurvaturePLY(
+	s SDF3, //sdf3 to render
+	meshCells int, //number of cells on the longest axis. e.g 200 (<= 0 for AutoMeshCells)
+	kind CurvatureType, // mean or gaussian curvature
+	scale float64, // curvature with full color
+	path string, //path to filename
+) error {
+	if meshCells <= 0 {
+		meshCells = AutoMeshCells(s)
+	}
+	mesh := RenderMesh(s, meshCells)
+	h := 0.5 * s.BoundingBox().Size().MaxComponent() / float64(meshCells)
+	k := make(map[V3]float64)
+	for _, v := range meshVertices(mesh) {
+		mean, gaussian := Curvature3(s, v, h)
+		if kind == GaussianCurvature {
+			k[v] = gaussian
+		} else {
+			k[v] = mean
+		}
+	}
+	if scale <= 0 {
+		a := make([]float64, 0, len(k))
+		for _, x := range k {
+			a = append(a, Abs(x))
+		}
+		sort.Float64s(a)
+		if len(a) > 0 {
+			scale = a[len(a)*95/100]
+		}
+		if scale <= 0 {
+			scale = 1
+		}
+	}
+	return SaveMeshPLY(path, mesh, func(v V3) color.RGBA {
+		return CurvatureColor(k[v], scale)
+	})
+}
+
+//-----------------------------------------------------------------------------
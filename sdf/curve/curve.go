@@ -0,0 +1,274 @@
+//-----------------------------------------------------------------------------
+/*
+
+Package curve provides 2D parametric curves.
+
+Quadratic/cubic Bezier curves, circular arcs and B-splines with adaptive
+flattening to a distance tolerance and arc-length parameterization.
+
+Polygon2D and Polyline2D build SDF2s from the flattened curves.
+
+*/
+//-----------------------------------------------------------------------------
+
+package curve
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+// Curve is a parametric 2d curve defined for t in [0,1].
+// An sdf.BezierSpline is also a Curve.
+type Curve interface {
+	Position(t float64) sdf.V2
+}
+
+//-----------------------------------------------------------------------------
+
+// QuadraticBezier is a quadratic bezier curve.
+type QuadraticBezier struct {
+	P0, P1, P2 sdf.V2 // end, control, end points
+}
+
+// Position returns the position on a quadratic bezier curve.
+func (c *QuadraticBezier) Position(t float64) sdf.V2 {
+	s := 1 - t
+	p := c.P0.MulScalar(s * s)
+	p = p.Add(c.P1.MulScalar(2 * s * t))
+	return p.Add(c.P2.MulScalar(t * t))
+}
+
+// CubicBezier is a cubic bezier curve.
+type CubicBezier struct {
+	P0, P1, P2, P3 sdf.V2 // end, control, control, end points
+}
+
+// Position returns the position on a cubic bezier curve.
+func (c *CubicBezier) Position(t float64) sdf.V2 {
+	s := 1 - t
+	p := c.P0.MulScalar(s * s * s)
+	p = p.Add(c.P1.MulScalar(3 * s * s * t))
+	p = p.Add(c.P2.MulScalar(3 * s * t * t))
+	return p.Add(c.P3.MulScalar(t * t * t))
+}
+
+// Arc is a circular arc from angle Theta0 to Theta1 (radians).
+type Arc struct {
+	Center         sdf.V2
+	Radius         float64
+	Theta0, Theta1 float64
+}
+
+// Position returns the position on a circular arc.
+func (c *Arc) Position(t float64) sdf.V2 {
+	theta := sdf.Mix(c.Theta0, c.Theta1, t)
+	return sdf.PolarToXY(c.Radius, theta).Add(c.Center)
+}
+
+//-----------------------------------------------------------------------------
+
+// BSpline is a clamped uniform B-spline. The curve passes through the first and last points.
+type BSpline struct {
+	points []sdf.V2
+	degree int
+	knots  []float64
+}
+
+// NewBSpline returns a clamped uniform B-spline of the given degree for the control points.
+func NewBSpline(points []sdf.V2, degree int) (*BSpline, error) {
+	if degree < 1 {
+		return nil, errors.New("degree < 1")
+	}
+	if len(points) <= degree {
+		return nil, errors.New("not enough control points for the degree")
+	}
+	n := len(points)
+	// clamped uniform knot vector over [0,1]
+	knots := make([]float64, n+degree+1)
+	spans := n - degree
+	for i := range knots {
+		knots[i] = sdf.Clamp(float64(i-degree)/float64(spans), 0, 1)
+	}
+	return &BSpline{
+		points: points,
+		degree: degree,
+		knots:  knots,
+	}, nil
+}
+
+// Position returns the position on a B-spline (de Boor's algorithm).
+func (c *BSpline) Position(t float64) sdf.V2 {
+	t = sdf.Clamp(t, 0, 1)
+	p := c.degree
+	// find the knot span containing t
+	k := p
+	for k < len(c.points)-1 && t >= c.knots[k+1] {
+		k++
+	}
+	d := make([]sdf.V2, p+1)
+	for j := 0; j <= p; j++ {
+		d[j] = c.points[j+k-p]
+	}
+	for r := 1; r <= p; r++ {
+		for j := p; j >= r; j-- {
+			i := j + k - p
+			a := (t - c.knots[i]) / (c.knots[i+1+p-r] - c.knots[i])
+			d[j] = d[j-1].MulScalar(1 - a).Add(d[j].MulScalar(a))
+		}
+	}
+	return d[p]
+}
+
+//-----------------------------------------------------------------------------
+// Flattening
+
+// maxFlattenDepth limits the recursion when flattening a curve.
+const maxFlattenDepth = 16
+
+// segmentDistance returns the minimum distance from p to the line segment ab.
+func segmentDistance(p, a, b sdf.V2) float64 {
+	pa := p.Sub(a)
+	ba := b.Sub(a)
+	l2 := ba.Length2()
+	if l2 == 0 {
+		return pa.Length()
+	}
+	t := sdf.Clamp(pa.Dot(ba)/l2, 0, 1)
+	return pa.Sub(ba.MulScalar(t)).Length()
+}
+
+// flatten recursively subdivides a curve until it is within tolerance of the chord.
+// It appends the t value of the end of each line segment.
+func flatten(c Curve, t0, t1 float64, p0, p1 sdf.V2, tol float64, depth int, out []float64) []float64 {
+	if depth >= maxFlattenDepth {
+		return append(out, t1)
+	}
+	tm := 0.5 * (t0 + t1)
+	pm := c.Position(tm)
+	// Check the midpoint and the quarter points. The quarter points catch
+	// curves that cross back over the chord at the midpoint.
+	if depth > 0 &&
+		segmentDistance(pm, p0, p1) <= tol &&
+		segmentDistance(c.Position(0.5*(t0+tm)), p0, p1) <= tol &&
+		segmentDistance(c.Position(0.5*(tm+t1)), p0, p1) <= tol {
+		return append(out, t1)
+	}
+	out = flatten(c, t0, tm, p0, pm, tol, depth+1, out)
+	return flatten(c, tm, t1, pm, p1, tol, depth+1, out)
+}
+
+// flattenT returns the t values for the vertices of a flattened curve.
+func flattenT(c Curve, tol float64) []float64 {
+	return flatten(c, 0, 1, c.Position(0), c.Position(1), tol, 0, []float64{0})
+}
+
+// Flatten returns vertices approximating a curve such that no point on
+// the curve is further than tol from the line segments.
+func Flatten(c Curve, tol float64) []sdf.V2 {
+	t := flattenT(c, tol)
+	v := make([]sdf.V2, len(t))
+	for i := range t {
+		v[i] = c.Position(t[i])
+	}
+	return v
+}
+
+// FlattenCurves returns the joined vertices for a sequence of connected curves.
+func FlattenCurves(curves []Curve, tol float64) []sdf.V2 {
+	var out []sdf.V2
+	for i, c := range curves {
+		v := Flatten(c, tol)
+		if i != 0 {
+			// the first vertex is the last vertex of the previous curve
+			v = v[1:]
+		}
+		out = append(out, v...)
+	}
+	return out
+}
+
+//-----------------------------------------------------------------------------
+// SDF2s from Curves
+
+// Polygon2D returns an SDF2 for the closed region bounded by a sequence of
+// connected curves. The curves are flattened to tolerance tol.
+func Polygon2D(curves []Curve, tol float64) sdf.SDF2 {
+	v := FlattenCurves(curves, tol)
+	if len(v) > 1 && v[0].Equals(v[len(v)-1], tol) {
+		// the polygon is closed implicitly
+		v = v[:len(v)-1]
+	}
+	return sdf.Polygon2D(v)
+}
+
+// Polyline2D returns an SDF2 for a sequence of connected curves stroked with
+// the given width. The curves are flattened to tolerance tol.
+func Polyline2D(curves []Curve, tol, width float64) sdf.SDF2 {
+	return sdf.Polyline2D(FlattenCurves(curves, tol), width)
+}
+
+//-----------------------------------------------------------------------------
+// Arc Length Parameterization
+
+// ArcLength maps arc length along a curve to the curve parameter.
+type ArcLength struct {
+	curve  Curve
+	t      []float64 // curve parameter samples
+	length []float64 // cumulative arc length at the samples
+}
+
+// NewArcLength returns an arc length parameterization for a curve.
+// The curve is flattened to tolerance tol to measure the arc length.
+func NewArcLength(c Curve, tol float64) *ArcLength {
+	a := ArcLength{curve: c}
+	a.t = flattenT(c, tol)
+	a.length = make([]float64, len(a.t))
+	p0 := c.Position(0)
+	for i := 1; i < len(a.t); i++ {
+		p1 := c.Position(a.t[i])
+		a.length[i] = a.length[i-1] + p1.Sub(p0).Length()
+		p0 = p1
+	}
+	return &a
+}
+
+// Length returns the total arc length of the curve.
+func (a *ArcLength) Length() float64 {
+	return a.length[len(a.length)-1]
+}
+
+// Parameter returns the curve parameter at arc length s along the curve.
+func (a *ArcLength) Parameter(s float64) float64 {
+	n := len(a.length)
+	if s <= 0 {
+		return a.t[0]
+	}
+	if s >= a.length[n-1] {
+		return a.t[n-1]
+	}
+	i := sort.SearchFloat64s(a.length, s)
+	k := (s - a.length[i-1]) / (a.length[i] - a.length[i-1])
+	return sdf.Mix(a.t[i-1], a.t[i], k)
+}
+
+// Position returns the position at arc length s along the curve.
+func (a *ArcLength) Position(s float64) sdf.V2 {
+	return a.curve.Position(a.Parameter(s))
+}
+
+// Sample returns n+1 positions evenly spaced by arc length along the curve.
+func (a *ArcLength) Sample(n int) []sdf.V2 {
+	out := make([]sdf.V2, n+1)
+	l := a.Length()
+	for i := range out {
+		out[i] = a.Position(l * float64(i) / float64(n))
+	}
+	return out
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,97 @@
+//-----------------------------------------------------------------------------
+/*
+
+Curve Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package curve
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+const tolerance = 1e-9
+
+// a bezier spline is a curve
+var _ Curve = (*sdf.BezierSpline)(nil)
+
+//-----------------------------------------------------------------------------
+
+func Test_Curves(t *testing.T) {
+	// quarter circle
+	c := &Arc{Radius: 1, Theta1: 0.5 * sdf.Pi}
+	tol := 0.001
+	v := Flatten(c, tol)
+	for i := 0; i < len(v)-1; i++ {
+		// the chord midpoint sagitta
+		m := v[i].Add(v[i+1]).MulScalar(0.5)
+		if 1-m.Length() > tol {
+			t.Error("FAIL")
+		}
+	}
+	a := NewArcLength(c, tol)
+	if math.Abs(a.Length()-0.5*sdf.Pi) > tol {
+		t.Logf("length %f\n", a.Length())
+		t.Error("FAIL")
+	}
+	if !a.Position(0.25*sdf.Pi).Equals(sdf.PolarToXY(1, 0.25*sdf.Pi), tol) {
+		t.Error("FAIL")
+	}
+	// a linear b-spline passes through the control points
+	points := []sdf.V2{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 1}}
+	b, err := NewBSpline(points, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range points {
+		if !b.Position(float64(i)/3).Equals(p, tolerance) {
+			t.Error("FAIL")
+		}
+	}
+	// a cubic b-spline with 4 points is a cubic bezier
+	b, _ = NewBSpline(points, 3)
+	bz := &CubicBezier{points[0], points[1], points[2], points[3]}
+	for _, x := range []float64{0, 0.3, 0.7, 1} {
+		if !b.Position(x).Equals(bz.Position(x), tolerance) {
+			t.Error("FAIL")
+		}
+	}
+	// joined curves share their end vertices
+	q := &QuadraticBezier{points[0], points[1], points[2]}
+	l := &CubicBezier{points[2], points[1], points[2], points[3]}
+	v = FlattenCurves([]Curve{q, l}, tol)
+	if !v[0].Equals(points[0], tolerance) || !v[len(v)-1].Equals(points[3], tolerance) {
+		t.Error("FAIL")
+	}
+	for i := 0; i < len(v)-1; i++ {
+		if v[i].Equals(v[i+1], tolerance) {
+			t.Errorf("duplicate vertex %v", v[i])
+		}
+	}
+
+	// a disc bounded by two semicircles
+	disc := Polygon2D([]Curve{
+		&Arc{Radius: 2, Theta0: 0, Theta1: sdf.Pi},
+		&Arc{Radius: 2, Theta0: sdf.Pi, Theta1: 2 * sdf.Pi},
+	}, tol)
+	for _, p := range []sdf.V2{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 3, Y: 0}, {X: 0, Y: -2.5}} {
+		d := disc.Evaluate(p)
+		if math.Abs(d-(p.Length()-2)) > 2*tol {
+			t.Errorf("disc %v expected %f, actual %f", p, p.Length()-2, d)
+		}
+	}
+	// a stroked arc
+	line := Polyline2D([]Curve{c}, tol, 0.2)
+	if d := line.Evaluate(sdf.PolarToXY(1, 0.25*sdf.Pi)); math.Abs(d+0.1) > tol {
+		t.Errorf("stroked arc %f", d)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,529 @@
+//-----------------------------------------------------------------------------
+/*
+
+Terrain from Digital Elevation Models
+
+Load elevation grids from GeoTIFF or ESRI ASCII grid files and turn them
+into printable terrain.
+
+GeoTIFF support covers single band images (integer or floating point
+samples) in strips or tiles, either uncompressed or Deflate compressed with
+optional horizontal differencing. That covers most DEM downloads (e.g. from
+GDAL with COMPRESS=DEFLATE). For geographic (lat/lon) GeoTIFFs the cell size
+in degrees is converted to meters at the center of the grid.
+
+The terrain is a height field: its distance is the vertical distance to the
+surface scaled by the steepest slope of the grid, so it is a conservative
+distance bound.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// DEM is a digital elevation model: a grid of elevation samples.
+type DEM struct {
+	Cols, Rows int       // grid size
+	CellSize   V2        // ground distance between samples in x (east) and y (north)
+	Elevation  []float64 // samples in row order, the first row is north, NaN for no data
+}
+
+// At returns the elevation at a column and row.
+func (d *DEM) At(col, row int) float64 {
+	return d.Elevation[row*d.Cols+col]
+}
+
+// Range returns the minimum and maximum elevations (ignoring no data).
+func (d *DEM) Range() (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, h := range d.Elevation {
+		if !math.IsNaN(h) {
+			lo = math.Min(lo, h)
+			hi = math.Max(hi, h)
+		}
+	}
+	return
+}
+
+// Crop returns the columns col0 to col1 and rows row0 to row1 (inclusive) of a DEM.
+func (d *DEM) Crop(col0, row0, col1, row1 int) (*DEM, error) {
+	if col0 < 0 || row0 < 0 || col1 >= d.Cols || row1 >= d.Rows || col1 <= col0 || row1 <= row0 {
+		return nil, errors.New("bad crop")
+	}
+	c := DEM{
+		Cols:     col1 - col0 + 1,
+		Rows:     row1 - row0 + 1,
+		CellSize: d.CellSize,
+	}
+	for row := row0; row <= row1; row++ {
+		c.Elevation = append(c.Elevation, d.Elevation[row*d.Cols+col0:row*d.Cols+col1+1]...)
+	}
+	return &c, nil
+}
+
+//-----------------------------------------------------------------------------
+// ESRI ASCII grid
+
+// ReadASCIIGrid reads a DEM in ESRI ASCII grid (*.asc) format.
+func ReadASCIIGrid(r io.Reader) (*DEM, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	next := func() (string, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", errors.New("unexpected end of file")
+		}
+		return scanner.Text(), nil
+	}
+	d := DEM{}
+	noData := math.NaN()
+	var word string
+	var err error
+	// header
+	for {
+		if word, err = next(); err != nil {
+			return nil, err
+		}
+		if _, err := strconv.ParseFloat(word, 64); err == nil {
+			break
+		}
+		s, err := next()
+		if err != nil {
+			return nil, err
+		}
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(word) {
+		case "ncols":
+			d.Cols = int(x)
+		case "nrows":
+			d.Rows = int(x)
+		case "cellsize":
+			d.CellSize = V2{x, x}
+		case "dx":
+			d.CellSize.X = x
+		case "dy":
+			d.CellSize.Y = x
+		case "nodata_value":
+			noData = x
+		}
+	}
+	if d.Cols <= 0 || d.Rows <= 0 || d.CellSize.X <= 0 || d.CellSize.Y <= 0 {
+		return nil, errors.New("bad ASCII grid header")
+	}
+	// data (word is the first value)
+	d.Elevation = make([]float64, d.Cols*d.Rows)
+	for i := range d.Elevation {
+		if i != 0 {
+			if word, err = next(); err != nil {
+				return nil, err
+			}
+		}
+		h, err := strconv.ParseFloat(word, 64)
+		if err != nil {
+			return nil, err
+		}
+		if h == noData {
+			h = math.NaN()
+		}
+		d.Elevation[i] = h
+	}
+	return &d, nil
+}
+
+//-----------------------------------------------------------------------------
+// GeoTIFF
+
+// TIFF tags
+const (
+	tiffImageWidth       = 256
+	tiffImageLength      = 257
+	tiffBitsPerSample    = 258
+	tiffCompression      = 259
+	tiffStripOffsets     = 273
+	tiffSamplesPerPixel  = 277
+	tiffRowsPerStrip     = 278
+	tiffStripByteCounts  = 279
+	tiffPredictor        = 317
+	tiffTileWidth        = 322
+	tiffTileLength       = 323
+	tiffTileOffsets      = 324
+	tiffTileByteCounts   = 325
+	tiffSampleFormat     = 339
+	tiffModelPixelScale  = 33550
+	tiffModelTiepoint    = 33922
+	tiffGeoKeyDirectory  = 34735
+	tiffGDALNoData       = 42113
+	geoKeyModelType      = 1024
+	geoModelTypeGeograph = 2
+)
+
+// tiffSizes are the sizes of the TIFF field types.
+var tiffSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 6: 1, 7: 1, 8: 2, 9: 4, 11: 4, 12: 8}
+
+// tiffFile is a parsed TIFF image file directory.
+type tiffFile struct {
+	b      []byte
+	bo     binary.ByteOrder
+	fields map[uint16][]float64
+	ascii  map[uint16]string
+}
+
+// value returns the value of a field as a float64.
+func (t *tiffFile) value(typ uint16, b []byte) float64 {
+	switch typ {
+	case 3:
+		return float64(t.bo.Uint16(b))
+	case 4:
+		return float64(t.bo.Uint32(b))
+	case 6:
+		return float64(int8(b[0]))
+	case 8:
+		return float64(int16(t.bo.Uint16(b)))
+	case 9:
+		return float64(int32(t.bo.Uint32(b)))
+	case 11:
+		return float64(math.Float32frombits(t.bo.Uint32(b)))
+	case 12:
+		return math.Float64frombits(t.bo.Uint64(b))
+	}
+	return float64(b[0])
+}
+
+// parse reads the header and first image file directory.
+func (t *tiffFile) parse() error {
+	b := t.b
+	if len(b) < 8 {
+		return errors.New("not a TIFF file")
+	}
+	switch string(b[0:2]) {
+	case "II":
+		t.bo = binary.LittleEndian
+	case "MM":
+		t.bo = binary.BigEndian
+	default:
+		return errors.New("not a TIFF file")
+	}
+	if t.bo.Uint16(b[2:]) != 42 {
+		return errors.New("not a TIFF file (or BigTIFF)")
+	}
+	ofs := int(t.bo.Uint32(b[4:]))
+	if ofs+2 > len(b) {
+		return errors.New("bad TIFF directory offset")
+	}
+	t.fields = make(map[uint16][]float64)
+	t.ascii = make(map[uint16]string)
+	n := int(t.bo.Uint16(b[ofs:]))
+	for i := 0; i < n; i++ {
+		e := ofs + 2 + 12*i
+		if e+12 > len(b) {
+			return errors.New("bad TIFF directory")
+		}
+		tag := t.bo.Uint16(b[e:])
+		typ := t.bo.Uint16(b[e+2:])
+		count := int(t.bo.Uint32(b[e+4:]))
+		size, ok := tiffSizes[typ]
+		if !ok {
+			continue
+		}
+		data := b[e+8 : e+12]
+		if size*count > 4 {
+			p := int(t.bo.Uint32(data))
+			if p+size*count > len(b) {
+				return errors.New("bad TIFF field offset")
+			}
+			data = b[p : p+size*count]
+		}
+		if typ == 2 {
+			t.ascii[tag] = strings.TrimRight(string(data[:count]), "\x00")
+			continue
+		}
+		v := make([]float64, count)
+		for j := range v {
+			v[j] = t.value(typ, data[j*size:])
+		}
+		t.fields[tag] = v
+	}
+	return nil
+}
+
+// field returns the first value of a field, or a default value.
+func (t *tiffFile) field(tag uint16, def float64) float64 {
+	if v, ok := t.fields[tag]; ok && len(v) > 0 {
+		return v[0]
+	}
+	return def
+}
+
+// ReadGeoTIFF reads a DEM from a single band GeoTIFF.
+func ReadGeoTIFF(r io.Reader) (*DEM, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	t := &tiffFile{b: b}
+	if err := t.parse(); err != nil {
+		return nil, err
+	}
+	d := DEM{
+		Cols: int(t.field(tiffImageWidth, 0)),
+		Rows: int(t.field(tiffImageLength, 0)),
+	}
+	if d.Cols <= 0 || d.Rows <= 0 {
+		return nil, errors.New("bad TIFF image size")
+	}
+	if t.field(tiffSamplesPerPixel, 1) != 1 {
+		return nil, errors.New("only single band TIFFs are supported")
+	}
+	bits := int(t.field(tiffBitsPerSample, 1))
+	format := int(t.field(tiffSampleFormat, 1))
+	compression := int(t.field(tiffCompression, 1))
+	predictor := int(t.field(tiffPredictor, 1))
+	size := bits / 8
+	// the TIFF field type of the samples
+	typ, ok := map[int]uint16{11: 1, 12: 3, 14: 4, 21: 6, 22: 8, 24: 9, 34: 11, 38: 12}[10*format+size]
+	if !ok || bits%8 != 0 {
+		return nil, fmt.Errorf("%d bit samples with format %d are not supported", bits, format)
+	}
+	if compression != 1 && compression != 8 && compression != 32946 {
+		return nil, fmt.Errorf("compression %d is not supported", compression)
+	}
+	if predictor != 1 && (predictor != 2 || format == 3) {
+		return nil, fmt.Errorf("predictor %d is not supported", predictor)
+	}
+
+	// chunks are strips (the full image width) or tiles
+	w, h := d.Cols, int(t.field(tiffRowsPerStrip, float64(d.Rows)))
+	offsets, counts := t.fields[tiffStripOffsets], t.fields[tiffStripByteCounts]
+	if _, ok := t.fields[tiffTileWidth]; ok {
+		w, h = int(t.field(tiffTileWidth, 0)), int(t.field(tiffTileLength, 0))
+		offsets, counts = t.fields[tiffTileOffsets], t.fields[tiffTileByteCounts]
+	}
+	if w <= 0 || h <= 0 || len(offsets) == 0 || len(offsets) != len(counts) {
+		return nil, errors.New("bad TIFF strips/tiles")
+	}
+	across := (d.Cols + w - 1) / w
+
+	d.Elevation = make([]float64, d.Cols*d.Rows)
+	for i := range offsets {
+		ofs, n := int(offsets[i]), int(counts[i])
+		if ofs+n > len(b) {
+			return nil, errors.New("bad TIFF strip/tile offset")
+		}
+		chunk := b[ofs : ofs+n]
+		if compression != 1 {
+			z, err := zlib.NewReader(bytes.NewReader(chunk))
+			if err != nil {
+				return nil, err
+			}
+			chunk, err = ioutil.ReadAll(z)
+			if err != nil {
+				return nil, err
+			}
+		}
+		col0, row0 := (i%across)*w, (i/across)*h
+		for y := 0; y < h && row0+y < d.Rows; y++ {
+			if len(chunk) < (y+1)*w*size {
+				return nil, errors.New("short TIFF strip/tile")
+			}
+			row := chunk[y*w*size:]
+			prev := 0.0
+			for x := 0; x < w; x++ {
+				v := t.value(typ, row[x*size:])
+				if predictor == 2 {
+					// horizontal differencing, wrapping at the sample size
+					v += prev
+					if format == 2 {
+						m := math.Exp2(float64(bits))
+						v = math.Mod(v+0.5*m, m)
+						if v < 0 {
+							v += m
+						}
+						v -= 0.5 * m
+					} else {
+						v = math.Mod(v, math.Exp2(float64(bits)))
+					}
+					prev = v
+				}
+				if col0+x < d.Cols {
+					d.Elevation[(row0+y)*d.Cols+col0+x] = v
+				}
+			}
+		}
+	}
+
+	// no data
+	if s, ok := t.ascii[tiffGDALNoData]; ok {
+		if noData, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			for i, v := range d.Elevation {
+				if v == noData {
+					d.Elevation[i] = math.NaN()
+				}
+			}
+		}
+	}
+
+	// cell size
+	scale := t.fields[tiffModelPixelScale]
+	if len(scale) < 2 {
+		return nil, errors.New("no GeoTIFF pixel scale")
+	}
+	d.CellSize = V2{scale[0], scale[1]}
+	if keys := t.fields[tiffGeoKeyDirectory]; len(keys) >= 4 {
+		for i := 4; i+3 < len(keys); i += 4 {
+			if keys[i] == geoKeyModelType && keys[i+1] == 0 && keys[i+3] == geoModelTypeGeograph {
+				// convert degrees to meters at the center latitude
+				tie := t.fields[tiffModelTiepoint]
+				if len(tie) < 6 {
+					return nil, errors.New("no GeoTIFF tiepoint")
+				}
+				lat := tie[4] - (0.5*float64(d.Rows)-tie[1])*scale[1]
+				d.CellSize = V2{scale[0] * 111320 * math.Cos(DtoR(lat)), scale[1] * 110540}
+			}
+		}
+	}
+	return &d, nil
+}
+
+// LoadDEM loads a DEM from a GeoTIFF (*.tif, *.tiff) or ESRI ASCII grid (*.asc) file.
+func LoadDEM(path string) (*DEM, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tif", ".tiff":
+		return ReadGeoTIFF(file)
+	case ".asc":
+		return ReadASCIIGrid(file)
+	}
+	return nil, fmt.Errorf("unknown DEM file type \"%s\"", path)
+}
+
+//-----------------------------------------------------------------------------
+
+// TerrainParms defines the parameters for a terrain model.
+type TerrainParms struct {
+	Width        float64 // model width (x), the depth (y) keeps the aspect ratio of the DEM
+	Exaggeration float64 // vertical exaggeration (0 = 1)
+	Base         float64 // base thickness below the lowest point
+}
+
+// TerrainSDF3 is a terrain model from a DEM.
+type TerrainSDF3 struct {
+	dem       *DEM
+	h         []float64 // model heights of the samples
+	step      V2        // model distance between the samples
+	size      V2        // model size
+	lipschitz float64   // distance correction for the steepest slope
+	bb        Box3
+}
+
+// Terrain3D returns a terrain model of a DEM with its base on z = 0 and the
+// north west corner at (0, depth). No data samples are set to the lowest
+// elevation.
+func Terrain3D(d *DEM, k *TerrainParms) (SDF3, error) {
+	if d.Cols < 2 || d.Rows < 2 || len(d.Elevation) != d.Cols*d.Rows {
+		return nil, errors.New("bad DEM size")
+	}
+	if d.CellSize.X <= 0 || d.CellSize.Y <= 0 {
+		return nil, errors.New("bad DEM cell size")
+	}
+	if k.Width <= 0 {
+		return nil, errors.New("Width <= 0")
+	}
+	if k.Exaggeration < 0 {
+		return nil, errors.New("Exaggeration < 0")
+	}
+	if k.Base <= 0 {
+		return nil, errors.New("Base <= 0")
+	}
+	zmin, zmax := d.Range()
+	if math.IsInf(zmin, 0) {
+		return nil, errors.New("no elevation data")
+	}
+	scale := k.Width / (float64(d.Cols-1) * d.CellSize.X)
+	zscale := scale
+	if k.Exaggeration > 0 {
+		zscale *= k.Exaggeration
+	}
+	s := TerrainSDF3{
+		dem:  d,
+		h:    make([]float64, len(d.Elevation)),
+		step: d.CellSize.MulScalar(scale),
+	}
+	s.size = V2{float64(d.Cols - 1), float64(d.Rows - 1)}.Mul(s.step)
+	for i, h := range d.Elevation {
+		if math.IsNaN(h) {
+			h = zmin
+		}
+		s.h[i] = k.Base + (h-zmin)*zscale
+	}
+	// the steepest slope between samples
+	var gx, gy float64
+	for row := 0; row < d.Rows; row++ {
+		for col := 0; col < d.Cols; col++ {
+			h := s.h[row*d.Cols+col]
+			if col > 0 {
+				gx = math.Max(gx, Abs(h-s.h[row*d.Cols+col-1])/s.step.X)
+			}
+			if row > 0 {
+				gy = math.Max(gy, Abs(h-s.h[(row-1)*d.Cols+col])/s.step.Y)
+			}
+		}
+	}
+	s.lipschitz = math.Sqrt(1 + gx*gx + gy*gy)
+	s.bb = Box3{V3{0, 0, 0}, V3{s.size.X, s.size.Y, k.Base + (zmax-zmin)*zscale}}
+	return &s, nil
+}
+
+// height returns the bilinear interpolated model height at (x, y).
+func (s *TerrainSDF3) height(x, y float64) float64 {
+	cols, rows := s.dem.Cols, s.dem.Rows
+	u := Clamp(x/s.step.X, 0, float64(cols-1))
+	v := Clamp(float64(rows-1)-y/s.step.Y, 0, float64(rows-1))
+	c0, r0 := int(math.Min(math.Floor(u), float64(cols-2))), int(math.Min(math.Floor(v), float64(rows-2)))
+	fu, fv := u-float64(c0), v-float64(r0)
+	h00 := s.h[r0*cols+c0]
+	h10 := s.h[r0*cols+c0+1]
+	h01 := s.h[(r0+1)*cols+c0]
+	h11 := s.h[(r0+1)*cols+c0+1]
+	return Mix(Mix(h00, h10, fu), Mix(h01, h11, fu), fv)
+}
+
+// Evaluate returns the minimum distance to a terrain model.
+func (s *TerrainSDF3) Evaluate(p V3) float64 {
+	side := math.Max(math.Max(-p.X, p.X-s.size.X), math.Max(-p.Y, p.Y-s.size.Y))
+	top := (p.Z - s.height(p.X, p.Y)) / s.lipschitz
+	return math.Max(math.Max(side, -p.Z), top)
+}
+
+// BoundingBox returns the bounding box of a terrain model.
+func (s *TerrainSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,234 @@
+//-----------------------------------------------------------------------------
+/*
+
+Dice
+
+Dice are regular or Catalan polyhedra with rounded edges and engraved
+faces. The faces are marked with pips (up to 9) or with caller supplied 2D
+labels (E.g. numerals from TextSDF2).
+
+For dice with opposite faces the numbers on opposite faces sum to the number
+of faces plus one.
+
+The engraving removes different amounts of material from each face. A
+balanced die scales the engraving depth on each face so the same volume is
+removed from every face.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// DiceParms defines the parameters for a die.
+type DiceParms struct {
+	Shape     Polyhedron // polyhedron for the die
+	Size      float64    // twice the inradius (the distance between opposite faces)
+	Round     float64    // edge and vertex rounding radius
+	Depth     float64    // engraving depth
+	PipRadius float64    // pip radius (0 = sized to the face)
+	Labels    []SDF2     // face labels for 1..n, centered on the origin with +y up (nil = pips)
+	Balanced  bool       // equalize the engraved volume on each face
+}
+
+// pipPositions returns the pip positions on a unit grid for a face value.
+func pipPositions(n int) V2Set {
+	switch n {
+	case 1:
+		return V2Set{{0, 0}}
+	case 2:
+		return V2Set{{-1, -1}, {1, 1}}
+	case 3:
+		return V2Set{{-1, -1}, {0, 0}, {1, 1}}
+	case 4:
+		return V2Set{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}}
+	case 5:
+		return V2Set{{-1, -1}, {-1, 1}, {0, 0}, {1, -1}, {1, 1}}
+	case 6:
+		return V2Set{{-1, -1}, {-1, 0}, {-1, 1}, {1, -1}, {1, 0}, {1, 1}}
+	case 7:
+		return V2Set{{-1, -1}, {-1, 0}, {-1, 1}, {0, 0}, {1, -1}, {1, 0}, {1, 1}}
+	case 8:
+		return V2Set{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+	case 9:
+		return V2Set{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 0}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+	}
+	return nil
+}
+
+// Pips2D returns the pip pattern for a face value (1..9).
+func Pips2D(n int, radius, spacing float64) (SDF2, error) {
+	p := pipPositions(n)
+	if p == nil {
+		return nil, errors.New("pips are only defined for 1..9")
+	}
+	for i := range p {
+		p[i] = p[i].MulScalar(spacing)
+	}
+	return MultiCircle2D(radius, p), nil
+}
+
+// area2D returns the area of an SDF2 by sampling its bounding box.
+func area2D(s SDF2, cells int) float64 {
+	bb := s.BoundingBox()
+	size := bb.Size()
+	dx := size.X / float64(cells)
+	dy := size.Y / float64(cells)
+	n := 0
+	for i := 0; i < cells; i++ {
+		for j := 0; j < cells; j++ {
+			p := bb.Min.Add(V2{(float64(i) + 0.5) * dx, (float64(j) + 0.5) * dy})
+			if s.Evaluate(p) < 0 {
+				n++
+			}
+		}
+	}
+	return float64(n) * dx * dy
+}
+
+// faceNumbers returns the number (1..n) for each face.
+// Opposite faces are given numbers that sum to n + 1.
+func faceNumbers(normals []V3) []int {
+	n := len(normals)
+	numbers := make([]int, n)
+	lo, hi := 1, n
+	for i := range normals {
+		if numbers[i] != 0 {
+			continue
+		}
+		numbers[i] = lo
+		lo++
+		for j := i + 1; j < n; j++ {
+			if numbers[j] == 0 && normals[j].Add(normals[i]).Length() < tolerance {
+				numbers[j] = hi
+				hi--
+				break
+			}
+		}
+	}
+	// faces without an opposite face take the remaining numbers in order
+	if lo <= hi {
+		for i := range numbers {
+			if numbers[i] > hi {
+				numbers[i] -= hi - lo + 1
+			}
+		}
+	}
+	return numbers
+}
+
+// faceInradius returns the radius of the largest circle centered on c within a face polygon.
+func faceInradius(f []V3, c V3) float64 {
+	r := math.MaxFloat64
+	for i := range f {
+		a := f[i]
+		e := f[(i+1)%len(f)].Sub(a)
+		t := Clamp(c.Sub(a).Dot(e)/e.Dot(e), 0, 1)
+		r = Min(r, c.Sub(a.Add(e.MulScalar(t))).Length())
+	}
+	return r
+}
+
+// Dice3D returns a die with rounded edges and engraved faces.
+func Dice3D(k *DiceParms) (SDF3, error) {
+	if k.Size <= 0 {
+		return nil, errors.New("Size <= 0")
+	}
+	r := 0.5 * k.Size
+	if k.Round < 0 || k.Round >= r {
+		return nil, errors.New("Round out of range")
+	}
+	if k.Depth <= 0 || k.Depth >= r {
+		return nil, errors.New("Depth out of range")
+	}
+	if k.PipRadius < 0 {
+		return nil, errors.New("PipRadius < 0")
+	}
+	poly, err := Polyhedron3D(k.Shape, r)
+	if err != nil {
+		return nil, err
+	}
+	body, err := RoundPolyhedron3D(poly, k.Round)
+	if err != nil {
+		return nil, err
+	}
+	normals := poly.Normals()
+	faces := poly.Faces()
+	nfaces := len(normals)
+	if k.Labels != nil && len(k.Labels) != nfaces {
+		return nil, errors.New("the number of labels doesn't match the number of faces")
+	}
+	if k.Labels == nil && nfaces > 9 {
+		return nil, errors.New("pips are only defined for up to 9 faces, use labels")
+	}
+	numbers := faceNumbers(normals)
+
+	// the label for each face
+	labels := make([]SDF2, nfaces)
+	for i, n := range normals {
+		if k.Labels != nil {
+			labels[i] = k.Labels[numbers[i]-1]
+			continue
+		}
+		// size the pips to fit the face
+		fr := faceInradius(faces[i], n.MulScalar(r)) - k.Round
+		radius := k.PipRadius
+		if radius == 0 {
+			radius = 0.18 * fr
+		}
+		labels[i], err = Pips2D(numbers[i], radius, 0.45*fr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// engraving depth for each face
+	depth := make([]float64, nfaces)
+	for i := range depth {
+		depth[i] = k.Depth
+	}
+	if k.Balanced {
+		area := make([]float64, nfaces)
+		minArea := math.MaxFloat64
+		for i, l := range labels {
+			area[i] = area2D(l, 200)
+			if area[i] > 0 {
+				minArea = Min(minArea, area[i])
+			}
+		}
+		for i := range depth {
+			if area[i] > 0 {
+				depth[i] = k.Depth * minArea / area[i]
+			}
+		}
+	}
+
+	// engrave the faces
+	var cuts []SDF3
+	for i, n := range normals {
+		// face frame: v is up on the face, u is across
+		ref := V3{0, 0, 1}
+		if Abs(n.Z) > 0.99 {
+			ref = V3{0, 1, 0}
+		}
+		v := ref.Sub(n.MulScalar(ref.Dot(n))).Normalize()
+		u := v.Cross(n)
+		c := n.MulScalar(r)
+		m := M44{
+			u.X, v.X, n.X, c.X,
+			u.Y, v.Y, n.Y, c.Y,
+			u.Z, v.Z, n.Z, c.Z,
+			0, 0, 0, 1,
+		}
+		cuts = append(cuts, Transform3D(Extrude3D(labels[i], 2*depth[i]), m))
+	}
+	return Difference3D(body, Union3D(cuts...)), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,264 @@
+//-----------------------------------------------------------------------------
+/*
+
+2D Drawings
+
+A simple vector drawing (lines, polygons and text) in real world units (mm)
+for section views, shop drawings and drawing sheets. Drawings are written as
+SVG with the y-axis pointing up.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// DrawingStyle is the stroke and fill style of a drawing element.
+type DrawingStyle struct {
+	Stroke color.RGBA // stroke color (A = 0 for no stroke)
+	Width  float64    // stroke width
+	Fill   color.RGBA // fill color (A = 0 for no fill)
+}
+
+// Drawing styles.
+var (
+	// OutlineStyle is for visible outlines.
+	OutlineStyle = DrawingStyle{Stroke: color.RGBA{0, 0, 0, 255}, Width: 0.35}
+	// ThinStyle is for hatching, dimensions and construction lines.
+	ThinStyle = DrawingStyle{Stroke: color.RGBA{0, 0, 0, 255}, Width: 0.18}
+)
+
+// TextAnchor is the horizontal alignment of drawing text.
+type TextAnchor int
+
+// Text anchors.
+const (
+	TextStart TextAnchor = iota
+	TextMiddle
+	TextEnd
+)
+
+// drawingItem is a drawing element.
+type drawingItem struct {
+	paths  [][]V2 // polylines
+	closed bool   // the paths are closed
+	style  DrawingStyle
+	text   string // text (at paths[0][0])
+	size   float64
+	anchor TextAnchor
+	image  image.Image // raster image (in the box paths[0][0], paths[0][1])
+}
+
+// Drawing is a 2D vector drawing.
+type Drawing struct {
+	items []drawingItem
+	bb    Box2
+	empty bool
+}
+
+// NewDrawing returns an empty drawing.
+func NewDrawing() *Drawing {
+	return &Drawing{empty: true}
+}
+
+// extend adds points to the drawing extents.
+func (d *Drawing) extend(points ...V2) {
+	for _, p := range points {
+		if d.empty {
+			d.bb = Box2{p, p}
+			d.empty = false
+		} else {
+			d.bb = d.bb.Extend(Box2{p, p})
+		}
+	}
+}
+
+// Extents returns the bounding box of the drawing.
+func (d *Drawing) Extents() Box2 {
+	return d.bb
+}
+
+// Line adds a line segment.
+func (d *Drawing) Line(a, b V2, style DrawingStyle) {
+	d.Polyline([]V2{a, b}, style)
+}
+
+// Polyline adds an open polyline.
+func (d *Drawing) Polyline(points []V2, style DrawingStyle) {
+	d.extend(points...)
+	d.items = append(d.items, drawingItem{paths: [][]V2{points}, style: style})
+}
+
+// Polygons adds closed contours. Filled contours use the even-odd rule, so
+// holes can be given as separate contours.
+func (d *Drawing) Polygons(contours [][]V2, style DrawingStyle) {
+	for _, c := range contours {
+		d.extend(c...)
+	}
+	d.items = append(d.items, drawingItem{paths: contours, closed: true, style: style})
+}
+
+// Text adds text with its baseline at p.
+func (d *Drawing) Text(p V2, size float64, text string, anchor TextAnchor) {
+	d.extend(p, p.Add(V2{0, size}))
+	d.items = append(d.items, drawingItem{paths: [][]V2{{p}}, text: text, size: size, anchor: anchor})
+}
+
+// Image adds a raster image scaled to fill a box.
+func (d *Drawing) Image(box Box2, img image.Image) {
+	d.extend(box.Min, box.Max)
+	d.items = append(d.items, drawingItem{paths: [][]V2{{box.Min, box.Max}}, image: img})
+}
+
+// Add adds the elements of another drawing transformed by a matrix.
+func (d *Drawing) Add(x *Drawing, m M33) {
+	for _, item := range x.items {
+		paths := make([][]V2, len(item.paths))
+		for i, p := range item.paths {
+			paths[i] = make([]V2, len(p))
+			for j, v := range p {
+				paths[i][j] = m.MulPosition(v)
+				d.extend(paths[i][j])
+			}
+		}
+		if item.image != nil {
+			// keep the image box corners ordered
+			a, b := paths[0][0], paths[0][1]
+			paths[0] = []V2{a.Min(b), a.Max(b)}
+		}
+		item.paths = paths
+		if item.text != "" {
+			d.extend(paths[0][0].Add(V2{0, item.size}))
+		}
+		d.items = append(d.items, item)
+	}
+}
+
+// Dimension adds an aligned linear dimension between points a and b. The
+// dimension line is offset to the left of a->b. If text is empty the distance
+// is used.
+func (d *Drawing) Dimension(a, b V2, offset float64, text string) {
+	const arrow = 2.0    // arrowhead length
+	const textSize = 2.5 // text height
+	const gap = 1.0      // gap between the part and the extension lines
+	l := b.Sub(a)
+	if l.Length() == 0 {
+		return
+	}
+	u := l.Normalize()
+	n := V2{-u.Y, u.X}
+	if offset < 0 {
+		n = n.Neg()
+		offset = -offset
+	}
+	if text == "" {
+		text = fmt.Sprintf("%.2f", l.Length())
+	}
+	a1 := a.Add(n.MulScalar(offset))
+	b1 := b.Add(n.MulScalar(offset))
+	// extension lines
+	d.Line(a.Add(n.MulScalar(gap)), a1.Add(n.MulScalar(gap)), ThinStyle)
+	d.Line(b.Add(n.MulScalar(gap)), b1.Add(n.MulScalar(gap)), ThinStyle)
+	// dimension line and arrowheads
+	d.Line(a1, b1, ThinStyle)
+	w := n.MulScalar(arrow / 6)
+	head := DrawingStyle{Fill: ThinStyle.Stroke}
+	h := u.MulScalar(arrow)
+	d.Polygons([][]V2{{a1, a1.Add(h).Add(w), a1.Add(h).Sub(w)}}, head)
+	d.Polygons([][]V2{{b1, b1.Sub(h).Add(w), b1.Sub(h).Sub(w)}}, head)
+	// text above the middle of the dimension line
+	m := a1.Add(b1).MulScalar(0.5).Add(n.MulScalar(gap))
+	if n.Y < 0 {
+		m = m.Sub(V2{0, textSize})
+	}
+	d.Text(m, textSize, text, TextMiddle)
+}
+
+//-----------------------------------------------------------------------------
+
+// svgColor returns an SVG color attribute value.
+func svgColor(c color.RGBA) string {
+	if c.A == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// WriteSVG writes the drawing as SVG with a margin around the extents.
+// The SVG units are mm.
+func (d *Drawing) WriteSVG(w io.Writer, margin float64) error {
+	bb := d.bb
+	size := bb.Size().AddScalar(2 * margin)
+	// flip y so the drawing y-axis points up
+	x := func(v V2) float64 { return v.X - bb.Min.X + margin }
+	y := func(v V2) float64 { return bb.Max.Y - v.Y + margin }
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(buf, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%gmm\" height=\"%gmm\" viewBox=\"0 0 %g %g\">\n", size.X, size.Y, size.X, size.Y)
+	for _, item := range d.items {
+		if item.text != "" {
+			anchor := [...]string{"start", "middle", "end"}[item.anchor]
+			p := item.paths[0][0]
+			fmt.Fprintf(buf, "<text x=\"%.3f\" y=\"%.3f\" font-family=\"sans-serif\" font-size=\"%g\" text-anchor=\"%s\">%s</text>\n",
+				x(p), y(p), item.size, anchor, html.EscapeString(item.text))
+			continue
+		}
+		if item.image != nil {
+			var img bytes.Buffer
+			if err := png.Encode(&img, item.image); err != nil {
+				return err
+			}
+			a, b := item.paths[0][0], item.paths[0][1]
+			fmt.Fprintf(buf, "<image x=\"%.3f\" y=\"%.3f\" width=\"%.3f\" height=\"%.3f\" preserveAspectRatio=\"none\" href=\"data:image/png;base64,%s\"/>\n",
+				x(a), y(b), b.X-a.X, b.Y-a.Y, base64.StdEncoding.EncodeToString(img.Bytes()))
+			continue
+		}
+		var sb strings.Builder
+		for _, p := range item.paths {
+			for i, v := range p {
+				cmd := "L"
+				if i == 0 {
+					cmd = "M"
+				}
+				fmt.Fprintf(&sb, "%s%.3f %.3f ", cmd, x(v), y(v))
+			}
+			if item.closed {
+				sb.WriteString("Z ")
+			}
+		}
+		fmt.Fprintf(buf, "<path d=\"%s\" fill=\"%s\" fill-rule=\"evenodd\" stroke=\"%s\" stroke-width=\"%g\"/>\n",
+			strings.TrimSpace(sb.String()), svgColor(item.style.Fill), svgColor(item.style.Stroke), item.style.Width)
+	}
+	fmt.Fprintf(buf, "</svg>\n")
+	return buf.Flush()
+}
+
+// SaveSVG writes the drawing to an SVG file with a margin around the extents.
+func (d *Drawing) SaveSVG(path string, margin float64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := d.WriteSVG(f, margin); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+//-----------------------------------------------------------------------------
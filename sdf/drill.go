@@ -0,0 +1,225 @@
+//-----------------------------------------------------------------------------
+/*
+
+Drill Lists
+
+Find the vertical (z-axis) through-holes of an SDF3 and export them as an
+Excellon drill file or a CSV file of hole centers and diameters. This is
+useful for hybrid parts: print the part undersize and drill the holes.
+
+Holes are found by projecting the part onto the XY plane. Columns with no
+material at any height that are enclosed by material are holes. Each region
+is measured along rays from its center and checked for roundness.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+//-----------------------------------------------------------------------------
+
+// DrillHole is a hole to be drilled.
+type DrillHole struct {
+	Position V2      // center of the hole
+	Diameter float64 // hole diameter
+}
+
+// Holes returns the drill holes for the cylinders of a MultiCylinderSDF3.
+func (s *MultiCylinderSDF3) Holes() []DrillHole {
+	holes := make([]DrillHole, len(s.positions))
+	for i, p := range s.positions {
+		holes[i] = DrillHole{p, 2 * s.radius}
+	}
+	return holes
+}
+
+//-----------------------------------------------------------------------------
+
+// FindHoles returns the round z-axis through-holes in an SDF3.
+// resolution is the sampling grid size, it should be a fraction of the smallest hole diameter.
+func FindHoles(s SDF3, resolution float64) ([]DrillHole, error) {
+	if resolution <= 0 {
+		return nil, errors.New("resolution <= 0")
+	}
+	bb := s.BoundingBox()
+	size := bb.Size()
+	nx := int(math.Ceil(size.X/resolution)) + 1
+	ny := int(math.Ceil(size.Y/resolution)) + 1
+	nz := int(math.Ceil(size.Z/resolution)) + 1
+	dz := size.Z / float64(nz)
+	pos := func(i, j int) V2 {
+		return V2{bb.Min.X + float64(i)*resolution, bb.Min.Y + float64(j)*resolution}
+	}
+
+	// is there no material at any height?
+	columnEmpty := func(p V2) bool {
+		for k := 0; k < nz; k++ {
+			if s.Evaluate(V3{p.X, p.Y, bb.Min.Z + (float64(k)+0.5)*dz}) <= 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	// find the empty columns
+	empty := make([][]bool, nx)
+	for i := range empty {
+		empty[i] = make([]bool, ny)
+		for j := range empty[i] {
+			empty[i][j] = columnEmpty(pos(i, j))
+		}
+	}
+
+	// label the connected empty regions
+	label := make([][]int, nx)
+	for i := range label {
+		label[i] = make([]int, ny)
+	}
+	var regions [][]V2i
+	var outside []bool
+	for i := 0; i < nx; i++ {
+		for j := 0; j < ny; j++ {
+			if !empty[i][j] || label[i][j] != 0 {
+				continue
+			}
+			n := len(regions) + 1
+			var cells []V2i
+			open := false
+			stack := []V2i{{i, j}}
+			label[i][j] = n
+			for len(stack) != 0 {
+				c := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				cells = append(cells, c)
+				for _, d := range []V2i{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+					x, y := c[0]+d[0], c[1]+d[1]
+					if x < 0 || y < 0 || x >= nx || y >= ny {
+						// the region reaches the edge of the bounding box
+						open = true
+						continue
+					}
+					if empty[x][y] && label[x][y] == 0 {
+						label[x][y] = n
+						stack = append(stack, V2i{x, y})
+					}
+				}
+			}
+			regions = append(regions, cells)
+			outside = append(outside, open)
+		}
+	}
+
+	// the enclosed regions are holes
+	var holes []DrillHole
+	for n, cells := range regions {
+		if outside[n] {
+			continue
+		}
+		center := V2{}
+		for _, c := range cells {
+			center = center.Add(pos(c[0], c[1]))
+		}
+		center = center.DivScalar(float64(len(cells)))
+		// measure the distance to the wall along rays from the center
+		const nRays = 16
+		var t [nRays]float64
+		for iter := 0; iter < 2; iter++ {
+			offset := V2{}
+			for i := range t {
+				a := Tau * float64(i) / nRays
+				d := V2{math.Cos(a), math.Sin(a)}
+				t0, t1 := 0.0, resolution
+				for columnEmpty(center.Add(d.MulScalar(t1))) && t1 < size.Length() {
+					t0, t1 = t1, t1+resolution
+				}
+				for k := 0; k < 20; k++ {
+					tm := 0.5 * (t0 + t1)
+					if columnEmpty(center.Add(d.MulScalar(tm))) {
+						t0 = tm
+					} else {
+						t1 = tm
+					}
+				}
+				t[i] = t0
+				offset = offset.Add(d.MulScalar(t0))
+			}
+			// move the center to the middle of the wall points
+			center = center.Add(offset.MulScalar(2.0 / nRays))
+		}
+		// check the region is round
+		rmin, rmax, r := t[0], t[0], 0.0
+		for _, x := range t {
+			rmin = math.Min(rmin, x)
+			rmax = math.Max(rmax, x)
+			r += x / nRays
+		}
+		if (rmax-rmin)/r > 0.05 {
+			continue
+		}
+		holes = append(holes, DrillHole{center, 2 * r})
+	}
+	return holes, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// drillTools returns the distinct hole diameters (rounded to 0.01 mm) in ascending order.
+func drillTools(holes []DrillHole) []float64 {
+	set := make(map[float64]bool)
+	for _, h := range holes {
+		set[math.Round(h.Diameter*100)/100] = true
+	}
+	tools := make([]float64, 0, len(set))
+	for d := range set {
+		tools = append(tools, d)
+	}
+	sort.Float64s(tools)
+	return tools
+}
+
+// WriteExcellon writes holes as an Excellon drill file (metric).
+func WriteExcellon(w io.Writer, holes []DrillHole) error {
+	tools := drillTools(holes)
+	fmt.Fprintf(w, "M48\nMETRIC\n")
+	for i, d := range tools {
+		fmt.Fprintf(w, "T%dC%.3f\n", i+1, d)
+	}
+	fmt.Fprintf(w, "%%\nG90\nG05\n")
+	for i, d := range tools {
+		fmt.Fprintf(w, "T%d\n", i+1)
+		for _, h := range holes {
+			if math.Round(h.Diameter*100)/100 == d {
+				fmt.Fprintf(w, "X%.3fY%.3f\n", h.Position.X, h.Position.Y)
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "M30\n")
+	return err
+}
+
+// WriteDrillCSV writes holes as CSV with columns x, y, diameter.
+func WriteDrillCSV(w io.Writer, holes []DrillHole) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"x", "y", "diameter"})
+	for _, h := range holes {
+		cw.Write([]string{
+			strconv.FormatFloat(h.Position.X, 'f', 3, 64),
+			strconv.FormatFloat(h.Position.Y, 'f', 3, 64),
+			strconv.FormatFloat(h.Diameter, 'f', 3, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,158 @@
+//-----------------------------------------------------------------------------
+/*
+
+Escape Holes
+
+Hollow parts printed with resin (SLA/DLP) need holes to drain the uncured
+resin from internal voids. This finds the voids by sampling the field and
+drills a hole straight down (-z, the build direction) from the lowest point
+of each void through the wall below it.
+
+The field is sampled on a grid, the outside samples connected to the edge of
+the grid are the exterior, any others are internal voids. Voids smaller than
+a grid cell may be missed.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// findVoids returns the lowest point of each internal void of an SDF3.
+func findVoids(s SDF3, cells int) []V3 {
+	bb := s.BoundingBox()
+	h := bb.Size().MaxComponent() / float64(cells)
+	// pad the grid so the exterior surrounds the part
+	n := bb.Size().DivScalar(h).Ceil().ToV3i().AddScalar(3)
+	nx, ny, nz := n[0], n[1], n[2]
+	base := bb.Min.SubScalar(h)
+	pos := func(i, j, k int) V3 {
+		return base.Add(V3{float64(i), float64(j), float64(k)}.MulScalar(h))
+	}
+	idx := func(i, j, k int) int {
+		return (k*ny+j)*nx + i
+	}
+	// sample the field
+	outside := make([]bool, nx*ny*nz)
+	for k := 0; k < nz; k++ {
+		for j := 0; j < ny; j++ {
+			for i := 0; i < nx; i++ {
+				outside[idx(i, j, k)] = s.Evaluate(pos(i, j, k)) > 0
+			}
+		}
+	}
+	// label the connected outside regions, the exterior contains sample 0
+	label := make([]int, len(outside))
+	var voids [][]V3i
+	next := 1
+	for start := range outside {
+		if !outside[start] || label[start] != 0 {
+			continue
+		}
+		var region []V3i
+		label[start] = next
+		queue := []int{start}
+		for len(queue) > 0 {
+			x := queue[0]
+			queue = queue[1:]
+			i, j, k := x%nx, (x/nx)%ny, x/(nx*ny)
+			region = append(region, V3i{i, j, k})
+			for _, d := range []V3i{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}} {
+				a, b, c := i+d[0], j+d[1], k+d[2]
+				if a < 0 || b < 0 || c < 0 || a >= nx || b >= ny || c >= nz {
+					continue
+				}
+				y := idx(a, b, c)
+				if outside[y] && label[y] == 0 {
+					label[y] = next
+					queue = append(queue, y)
+				}
+			}
+		}
+		if next != 1 {
+			voids = append(voids, region)
+		}
+		next++
+	}
+	// the lowest point of each void, centered on a flat floor
+	var points []V3
+	for _, region := range voids {
+		zmin := region[0][2]
+		for _, v := range region {
+			if v[2] < zmin {
+				zmin = v[2]
+			}
+		}
+		var floor []V3
+		var center V3
+		for _, v := range region {
+			if v[2] == zmin {
+				p := pos(v[0], v[1], v[2])
+				floor = append(floor, p)
+				center = center.Add(p)
+			}
+		}
+		center = center.DivScalar(float64(len(floor)))
+		best := floor[0]
+		for _, p := range floor {
+			if p.Sub(center).Length2() < best.Sub(center).Length2() {
+				best = p
+			}
+		}
+		// move down to the floor of the void
+		for i := 0; i < 64; i++ {
+			d := s.Evaluate(best)
+			if d <= 0.01*h {
+				break
+			}
+			best.Z -= d
+		}
+		points = append(points, best)
+	}
+	return points
+}
+
+// EscapeHoles3D returns an SDF3 with a drain hole drilled straight down (-z)
+// from the lowest point of each internal void, and the positions of the holes
+// on the void floors. The field is sampled with cells along the longest side
+// of the bounding box. A hole drains into whatever is below the wall, for
+// nested voids that is the next void out.
+func EscapeHoles3D(s SDF3, diameter float64, cells int) (SDF3, []V3, error) {
+	if diameter <= 0 {
+		return nil, nil, errors.New("diameter <= 0")
+	}
+	if cells <= 0 {
+		return nil, nil, errors.New("cells <= 0")
+	}
+	points := findVoids(s, cells)
+	if len(points) == 0 {
+		return s, nil, nil
+	}
+	bb := s.BoundingBox()
+	step := 1e-3 * bb.Size().MaxComponent()
+	holes := make([]SDF3, len(points))
+	for i, p := range points {
+		// march down through the wall
+		z := p.Z - step
+		for z > bb.Min.Z {
+			d := s.Evaluate(V3{p.X, p.Y, z})
+			if d > 0 {
+				break
+			}
+			z -= math.Max(-d, step)
+		}
+		// extend the hole into the void and below the wall
+		z0, z1 := z-diameter, p.Z+diameter
+		hole := Cylinder3D(z1-z0, 0.5*diameter, 0)
+		holes[i] = Transform3D(hole, Translate3d(V3{p.X, p.Y, 0.5 * (z0 + z1)}))
+	}
+	return Difference3D(s, Union3D(holes...)), points, nil
+}
+
+//-----------------------------------------------------------------------------
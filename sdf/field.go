@@ -0,0 +1,236 @@
+//-----------------------------------------------------------------------------
+/*
+
+Scalar Fields
+
+A scalar field maps a position to a value. Fields are used to vary the
+parameters of an object over space (E.g. hole radius, lattice thickness or
+displacement amplitude) to give graded patterns.
+
+Attractor fields have a value of 1 at the attractor, falling off smoothly to 0
+at a given radius from the attractor. Use the remap functions to map the [0,1]
+range onto the range of the parameter being driven.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// Field2 is a scalar field defined over 2d space.
+type Field2 func(p V2) float64
+
+// Field3 is a scalar field defined over 3d space.
+type Field3 func(p V3) float64
+
+// ConstantField2 returns a 2d scalar field with the same value everywhere.
+func ConstantField2(k float64) Field2 {
+	return func(p V2) float64 {
+		return k
+	}
+}
+
+// ConstantField3 returns a 3d scalar field with the same value everywhere.
+func ConstantField3(k float64) Field3 {
+	return func(p V3) float64 {
+		return k
+	}
+}
+
+// RemapField2 maps the [0,1] values of a 2d field onto [a,b].
+func RemapField2(f Field2, a, b float64) Field2 {
+	return func(p V2) float64 {
+		return Mix(a, b, f(p))
+	}
+}
+
+// RemapField3 maps the [0,1] values of a 3d field onto [a,b].
+func RemapField3(f Field3, a, b float64) Field3 {
+	return func(p V3) float64 {
+		return Mix(a, b, f(p))
+	}
+}
+
+// ExtrudeField2 returns a 3d field with the values of a 2d field in the xy plane.
+func ExtrudeField2(f Field2) Field3 {
+	return func(p V3) float64 {
+		return f(V2{p.X, p.Y})
+	}
+}
+
+//-----------------------------------------------------------------------------
+// Attractors
+
+// falloff returns a smooth falloff from 1 at d = 0 to 0 at d = radius.
+func falloff(d, radius float64) float64 {
+	x := Clamp(1-d/radius, 0, 1)
+	return x * x * (3 - 2*x)
+}
+
+// PointAttractor2 returns a 2d field attracted to a point.
+func PointAttractor2(a V2, radius float64) Field2 {
+	return func(p V2) float64 {
+		return falloff(p.Sub(a).Length(), radius)
+	}
+}
+
+// PointAttractor3 returns a 3d field attracted to a point.
+func PointAttractor3(a V3, radius float64) Field3 {
+	return func(p V3) float64 {
+		return falloff(p.Sub(a).Length(), radius)
+	}
+}
+
+// CurveAttractor2 returns a 2d field attracted to a polyline.
+func CurveAttractor2(points []V2, radius float64) Field2 {
+	return func(p V2) float64 {
+		d := math.MaxFloat64
+		for i := 0; i < len(points)-1; i++ {
+			d = Min(d, pointSegmentDistance(p, points[i], points[i+1]))
+		}
+		if len(points) == 1 {
+			d = p.Sub(points[0]).Length()
+		}
+		return falloff(d, radius)
+	}
+}
+
+// CurveAttractor3 returns a 3d field attracted to a polyline.
+func CurveAttractor3(points []V3, radius float64) Field3 {
+	struts := make([]strut, 0, len(points))
+	for i := 0; i < len(points)-1; i++ {
+		struts = append(struts, newStrut(points[i], points[i+1]))
+	}
+	return func(p V3) float64 {
+		d := math.MaxFloat64
+		for i := range struts {
+			d = Min(d, struts[i].distance(p))
+		}
+		if len(points) == 1 {
+			d = p.Sub(points[0]).Length()
+		}
+		return falloff(d, radius)
+	}
+}
+
+// ImageField2 returns a 2d field from the brightness of an image mapped onto a box.
+// White is 1, black is 0. Points outside the box take the value of the nearest edge pixel.
+func ImageField2(img image.Image, bb Box2) Field2 {
+	r := img.Bounds()
+	size := bb.Size()
+	w := float64(r.Dx())
+	h := float64(r.Dy())
+	return func(p V2) float64 {
+		// image y is down, field y is up
+		u := (p.X - bb.Min.X) / size.X
+		v := (bb.Max.Y - p.Y) / size.Y
+		x := r.Min.X + int(Clamp(u*w, 0, w-1))
+		y := r.Min.Y + int(Clamp(v*h, 0, h-1))
+		return luminance(img.At(x, y))
+	}
+}
+
+// luminance returns the brightness [0,1] of a color (Rec. 601 luma).
+func luminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	return (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0xffff
+}
+
+//-----------------------------------------------------------------------------
+// Field Driven Objects
+
+// VariableOffsetSDF2 offsets an SDF2 by a spatially varying amount.
+type VariableOffsetSDF2 struct {
+	sdf    SDF2
+	offset Field2
+	bb     Box2
+}
+
+// VariableOffset2D returns an SDF2 offset by a field. maxOffset is the largest offset
+// the field produces and is used to work out the bounding box.
+// Distance is *not* preserved.
+func VariableOffset2D(sdf SDF2, offset Field2, maxOffset float64) SDF2 {
+	s := VariableOffsetSDF2{}
+	s.sdf = sdf
+	s.offset = offset
+	bb := sdf.BoundingBox()
+	s.bb = NewBox2(bb.Center(), bb.Size().AddScalar(2*Max(maxOffset, 0)))
+	return &s
+}
+
+// Evaluate returns the minimum distance to a variable offset SDF2.
+func (s *VariableOffsetSDF2) Evaluate(p V2) float64 {
+	return s.sdf.Evaluate(p) - s.offset(p)
+}
+
+// BoundingBox returns the bounding box of a variable offset SDF2.
+func (s *VariableOffsetSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+// VariableOffsetSDF3 offsets (displaces) an SDF3 by a spatially varying amount.
+type VariableOffsetSDF3 struct {
+	sdf       SDF3
+	offset    Field3
+	maxOffset float64
+	bb        Box3
+}
+
+// VariableOffset3D returns an SDF3 offset (displaced) by a field. maxOffset is the
+// largest offset the field produces and is used to work out the bounding box.
+// Distance is *not* preserved.
+func VariableOffset3D(sdf SDF3, offset Field3, maxOffset float64) SDF3 {
+	s := VariableOffsetSDF3{}
+	s.sdf = sdf
+	s.offset = offset
+	s.maxOffset = maxOffset
+	bb := sdf.BoundingBox()
+	s.bb = NewBox3(bb.Center(), bb.Size().AddScalar(2*Max(maxOffset, 0)))
+	return &s
+}
+
+// Evaluate returns the minimum distance to a variable offset SDF3.
+func (s *VariableOffsetSDF3) Evaluate(p V3) float64 {
+	return s.sdf.Evaluate(p) - s.offset(p)
+}
+
+// BoundingBox returns the bounding box of a variable offset SDF3.
+func (s *VariableOffsetSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// ThickenBelow returns an SDF3 with the walls that are thinner than
+// minThickness (see WallThickness3) thickened to minThickness. Each surface
+// of a thin wall is offset by half the shortfall. Thicker walls are unchanged.
+// Distance is *not* preserved.
+func ThickenBelow(sdf SDF3, minThickness float64) SDF3 {
+	offset := func(p V3) float64 {
+		// only the field near the surface needs to move
+		if Abs(sdf.Evaluate(p)) > minThickness {
+			return 0
+		}
+		return Max(0, 0.5*(minThickness-WallThickness3(sdf, p)))
+	}
+	return VariableOffset3D(sdf, offset, 0.5*minThickness)
+}
+
+//-----------------------------------------------------------------------------
+
+// GradedMultiCircle2D returns an SDF2 for multiple circles (E.g. hole patterns)
+// with the radius of each circle set by a field evaluated at its position.
+func GradedMultiCircle2D(positions V2Set, radius Field2) SDF2 {
+	circles := make([]SDF2, len(positions))
+	for i, p := range positions {
+		circles[i] = Transform2D(Circle2D(radius(p)), Translate2d(p))
+	}
+	return Union2D(circles...)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,181 @@
+//-----------------------------------------------------------------------------
+/*
+
+Field Checks
+
+Sample the distance field of an SDF3 to find common bugs in Evaluate and
+BoundingBox functions:
+
+Lipschitz: the field changes faster than distance (|grad| > 1). Mesh
+generation and ray marching skip past surfaces in these regions. Bound fields
+(that under estimate the distance) are fine.
+
+Sign flip: the sign changes between samples that are both too far from the
+surface (more than two grid cells) for the surface to be between them. E.g. a
+region with the wrong sign.
+
+Bounding box: the solid extends outside the bounding box, so mesh generation
+will cut it off.
+
+The field is sampled on a grid over the bounding box grown by a margin, so
+issues smaller than a grid cell may be missed.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// FieldIssueType is the type of a field issue.
+type FieldIssueType int
+
+// Field issue types.
+const (
+	FieldLipschitz   FieldIssueType = iota // the gradient is > 1
+	FieldSignFlip                          // the sign changes away from the surface
+	FieldBoundingBox                       // the solid is outside the bounding box
+)
+
+func (t FieldIssueType) String() string {
+	switch t {
+	case FieldLipschitz:
+		return "lipschitz"
+	case FieldSignFlip:
+		return "sign flip"
+	case FieldBoundingBox:
+		return "bounding box"
+	}
+	return "unknown"
+}
+
+// FieldIssue is a problem with a distance field at a location.
+type FieldIssue struct {
+	Type  FieldIssueType
+	Point V3      // location of the issue
+	Value float64 // gradient (lipschitz), |distance| (sign flip) or -distance (bounding box)
+}
+
+func (i *FieldIssue) String() string {
+	return fmt.Sprintf("%s %g at %v", i.Type, i.Value, i.Point)
+}
+
+// FieldReport describes the issues found in a distance field.
+type FieldReport struct {
+	MaxGradient float64      // maximum gradient between samples
+	Issues      []FieldIssue // worst issues of each type
+}
+
+// Valid returns true if no issues were found.
+func (r *FieldReport) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *FieldReport) String() string {
+	s := fmt.Sprintf("max gradient %g, %d issues", r.MaxGradient, len(r.Issues))
+	for i := range r.Issues {
+		s += "\n" + r.Issues[i].String()
+	}
+	return s
+}
+
+// fieldMargin is the margin (relative to the bounding box size) around the
+// bounding box that is sampled.
+const fieldMargin = 0.25
+
+// fieldLipschitz is the gradient above which a field is reported.
+// Allow for the errors of finite differences and minor scaling.
+const fieldLipschitz = 1.1
+
+// fieldMaxIssues is the maximum number of issues reported for each type.
+const fieldMaxIssues = 8
+
+// CheckField3 samples the distance field of an SDF3 on a grid with cells on
+// the longest axis and reports any issues found.
+func CheckField3(s SDF3, cells int) (*FieldReport, error) {
+	if cells <= 0 {
+		return nil, errors.New("cells <= 0")
+	}
+	bb := s.BoundingBox()
+	size := bb.Size()
+	margin := size.MulScalar(fieldMargin)
+	region := Box3{bb.Min.Sub(margin), bb.Max.Add(margin)}
+	h := region.Size().MaxComponent() / float64(cells)
+	n := region.Size().DivScalar(h).Ceil().ToV3i().AddScalar(1)
+	nx, ny, nz := n[0], n[1], n[2]
+	pos := func(i, j, k int) V3 {
+		return region.Min.Add(V3{float64(i), float64(j), float64(k)}.MulScalar(h))
+	}
+	idx := func(i, j, k int) int {
+		return (k*ny+j)*nx + i
+	}
+	// sample the field
+	d := make([]float64, nx*ny*nz)
+	for k := 0; k < nz; k++ {
+		for j := 0; j < ny; j++ {
+			for i := 0; i < nx; i++ {
+				d[idx(i, j, k)] = s.Evaluate(pos(i, j, k))
+			}
+		}
+	}
+	r := &FieldReport{}
+	issues := make(map[FieldIssueType][]FieldIssue)
+	add := func(t FieldIssueType, p V3, v float64) {
+		issues[t] = append(issues[t], FieldIssue{t, p, v})
+	}
+	// the solid is allowed to touch the bounding box
+	tol := 1e-6 * size.MaxComponent()
+	inside := Box3{bb.Min.SubScalar(tol), bb.Max.AddScalar(tol)}
+	for k := 0; k < nz; k++ {
+		for j := 0; j < ny; j++ {
+			for i := 0; i < nx; i++ {
+				p := pos(i, j, k)
+				d0 := d[idx(i, j, k)]
+				if d0 < -tol && !inside.Contains(p) {
+					add(FieldBoundingBox, p, -d0)
+				}
+				// compare with the neighbours on +x, +y and +z
+				for _, o := range []V3i{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}} {
+					a, b, c := i+o[0], j+o[1], k+o[2]
+					if a >= nx || b >= ny || c >= nz {
+						continue
+					}
+					d1 := d[idx(a, b, c)]
+					g := Abs(d1-d0) / h
+					r.MaxGradient = Max(r.MaxGradient, g)
+					if (d0 < 0) != (d1 < 0) && Min(Abs(d0), Abs(d1)) > 2*h {
+						// the surface can't be between the samples (unless the
+						// gradient is > 4)
+						q := p
+						if Abs(d1) > Abs(d0) {
+							q = pos(a, b, c)
+						}
+						add(FieldSignFlip, q, Max(Abs(d0), Abs(d1)))
+					} else if g > fieldLipschitz {
+						add(FieldLipschitz, p.Add(pos(a, b, c)).MulScalar(0.5), g)
+					}
+				}
+			}
+		}
+	}
+	// report the worst issues of each type
+	for _, t := range []FieldIssueType{FieldLipschitz, FieldSignFlip, FieldBoundingBox} {
+		x := issues[t]
+		sort.SliceStable(x, func(i, j int) bool {
+			return x[i].Value > x[j].Value
+		})
+		if len(x) > fieldMaxIssues {
+			x = x[:fieldMaxIssues]
+		}
+		r.Issues = append(r.Issues, x...)
+	}
+	return r, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,97 @@
+//-----------------------------------------------------------------------------
+/*
+
+Automatic Filleting
+
+Concave creases in an SDF3 are formed where the surfaces of the objects in a
+union meet. Intersections and differences form convex creases, which are left
+alone. FilletEdges walks the SDF3 tree and rebuilds it with smooth minimum
+functions on the union operations, so the creases get a fillet of a given
+radius.
+
+The original tree is not modified.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+//-----------------------------------------------------------------------------
+
+// FilletEdges returns an SDF3 with fillets added to the concave creases formed by unions.
+func FilletEdges(sdf SDF3, radius float64) SDF3 {
+	if radius <= 0 {
+		return sdf
+	}
+	return filletEdges(sdf, RoundMin(radius))
+}
+
+// filletEdges rebuilds an SDF3 tree using a blending minimum function for unions.
+func filletEdges(sdf SDF3, min MinFunc) SDF3 {
+	switch s := sdf.(type) {
+	case *UnionSDF3:
+		u := *s
+		u.sdf = make([]SDF3, len(s.sdf))
+		for i, x := range s.sdf {
+			u.sdf[i] = filletEdges(x, min)
+		}
+		u.min = min
+		return &u
+	case *ArraySDF3:
+		a := *s
+		a.sdf = filletEdges(s.sdf, min)
+		a.min = min
+		return &a
+	case *RotateUnionSDF3:
+		r := *s
+		r.sdf = filletEdges(s.sdf, min)
+		r.min = min
+		return &r
+	case *DifferenceSDF3:
+		d := *s
+		d.s0 = filletEdges(s.s0, min)
+		d.s1 = filletEdges(s.s1, min)
+		return &d
+	case *IntersectionSDF3:
+		i := *s
+		i.s0 = filletEdges(s.s0, min)
+		i.s1 = filletEdges(s.s1, min)
+		return &i
+	case *TransformSDF3:
+		t := *s
+		t.sdf = filletEdges(s.sdf, min)
+		return &t
+	case *ScaleUniformSDF3:
+		// the fillet radius scales with the object
+		t := *s
+		t.sdf = filletEdges(s.sdf, scaledMin(min, s.k, s.invK))
+		return &t
+	case *OffsetSDF3:
+		o := *s
+		o.sdf = filletEdges(s.sdf, min)
+		return &o
+	case *CutSDF3:
+		c := *s
+		c.sdf = filletEdges(s.sdf, min)
+		return &c
+	case *ElongateSDF3:
+		e := *s
+		e.sdf = filletEdges(s.sdf, min)
+		return &e
+	case *RotateCopySDF3:
+		r := *s
+		r.sdf = filletEdges(s.sdf, min)
+		return &r
+	}
+	// leaf nodes and unknown types are left as is
+	return sdf
+}
+
+// scaledMin returns a minimum function that works in a scaled distance space.
+func scaledMin(min MinFunc, k, invK float64) MinFunc {
+	return func(a, b float64) float64 {
+		return min(a*k, b*k) * invK
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,143 @@
+//-----------------------------------------------------------------------------
+/*
+
+Flexures
+
+2D building blocks for compliant mechanisms. Extrude them to the part
+thickness. The stiffness of a flexure is set by the blade thickness and
+length, so those are the parameters.
+
+Parallel blade flexure: two parallel blades joining a fixed and a moving
+block. The moving block translates without rotation.
+
+Notch hinge: a link thinned by two circular notches. It rotates about the
+center of the notch.
+
+Spiral flexure: spiral blades joining a hub and an outer ring. The hub
+rotates and translates in the plane.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+// ParallelFlexureParms defines the parameters for a parallel blade flexure.
+type ParallelFlexureParms struct {
+	BladeThickness float64 // thickness of the blades
+	BladeLength    float64 // free length of the blades
+	BladeSpacing   float64 // distance between the blade centerlines
+	Block          V2      // size of the end blocks
+}
+
+// ParallelFlexure2D returns a parallel blade flexure centered on the origin
+// with the blades along the x-axis.
+func ParallelFlexure2D(k *ParallelFlexureParms) (SDF2, error) {
+	if k.BladeThickness <= 0 {
+		return nil, errors.New("BladeThickness <= 0")
+	}
+	if k.BladeLength <= 0 {
+		return nil, errors.New("BladeLength <= 0")
+	}
+	if k.BladeSpacing <= k.BladeThickness {
+		return nil, errors.New("BladeSpacing <= BladeThickness")
+	}
+	if k.Block.X <= 0 || k.Block.Y < k.BladeSpacing+k.BladeThickness {
+		return nil, errors.New("the blocks must span the blades")
+	}
+	// the blades run into the blocks
+	blade := Box2D(V2{k.BladeLength + k.Block.X, k.BladeThickness}, 0)
+	block := Box2D(k.Block, 0)
+	x := 0.5 * (k.BladeLength + k.Block.X)
+	y := 0.5 * k.BladeSpacing
+	return Union2D(
+		Transform2D(blade, Translate2d(V2{0, y})),
+		Transform2D(blade, Translate2d(V2{0, -y})),
+		Transform2D(block, Translate2d(V2{-x, 0})),
+		Transform2D(block, Translate2d(V2{x, 0})),
+	), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// NotchHingeParms defines the parameters for a circular notch hinge.
+type NotchHingeParms struct {
+	Thickness float64 // thickness of the web at the notch
+	Radius    float64 // notch radius
+	Width     float64 // width of the link
+	Length    float64 // length of the link
+}
+
+// NotchHinge2D returns a link along the x-axis with a circular notch hinge at the origin.
+func NotchHinge2D(k *NotchHingeParms) (SDF2, error) {
+	if k.Thickness <= 0 {
+		return nil, errors.New("Thickness <= 0")
+	}
+	if k.Radius <= 0 {
+		return nil, errors.New("Radius <= 0")
+	}
+	if k.Width <= k.Thickness {
+		return nil, errors.New("Width <= Thickness")
+	}
+	if k.Length < 2*k.Radius {
+		return nil, errors.New("Length < 2 * Radius")
+	}
+	notch := Circle2D(k.Radius)
+	y := 0.5*k.Thickness + k.Radius
+	notches := Union2D(
+		Transform2D(notch, Translate2d(V2{0, y})),
+		Transform2D(notch, Translate2d(V2{0, -y})),
+	)
+	return Difference2D(Box2D(V2{k.Length, k.Width}, 0), notches), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// SpiralFlexureParms defines the parameters for a spiral flexure.
+type SpiralFlexureParms struct {
+	BladeThickness float64 // thickness of the spiral blades
+	HubRadius      float64 // radius of the central hub
+	RingRadius     float64 // inner radius of the outer ring
+	RingWidth      float64 // width of the outer ring
+	Turns          float64 // turns of each blade from the hub to the ring
+	Blades         int     // number of blades
+}
+
+// SpiralFlexure2D returns a spiral flexure centered on the origin.
+func SpiralFlexure2D(k *SpiralFlexureParms) (SDF2, error) {
+	if k.BladeThickness <= 0 {
+		return nil, errors.New("BladeThickness <= 0")
+	}
+	if k.HubRadius <= 0 {
+		return nil, errors.New("HubRadius <= 0")
+	}
+	if k.RingRadius <= k.HubRadius {
+		return nil, errors.New("RingRadius <= HubRadius")
+	}
+	if k.RingWidth <= 0 {
+		return nil, errors.New("RingWidth <= 0")
+	}
+	if k.Turns <= 0 {
+		return nil, errors.New("Turns <= 0")
+	}
+	if k.Blades <= 0 {
+		return nil, errors.New("Blades <= 0")
+	}
+	// each blade runs from the hub to the ring
+	end := Tau * k.Turns
+	a := (k.RingRadius - k.HubRadius) / end
+	blade := ArcSpiral2D(a, k.HubRadius, 0, end, 0.5*k.BladeThickness)
+	s := []SDF2{
+		Circle2D(k.HubRadius),
+		Difference2D(Circle2D(k.RingRadius+k.RingWidth), Circle2D(k.RingRadius)),
+	}
+	for i := 0; i < k.Blades; i++ {
+		s = append(s, Transform2D(blade, Rotate2d(Tau*float64(i)/float64(k.Blades))))
+	}
+	return Union2D(s...), nil
+}
+
+//-----------------------------------------------------------------------------
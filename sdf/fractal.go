@@ -0,0 +1,168 @@
+//-----------------------------------------------------------------------------
+/*
+
+Fractals
+
+Menger sponge: a cube with a cross shaped hole through each face repeated
+at each level. The distance is a bound of the true distance (it is exact
+outside the cube).
+
+Mandelbulb: the 3D analogue of the Mandelbrot set using spherical
+coordinates. The distance is an estimate from the running derivative of the
+iteration. It may over estimate the distance by up to the Lipschitz factor,
+divide by it for a safe distance (e.g. for ray marching).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// MengerSpongeSDF3 is a Menger sponge.
+type MengerSpongeSDF3 struct {
+	levels int
+	scale  float64 // half size of the cube
+	bb     Box3
+}
+
+// MengerSponge3D returns a Menger sponge centered on the origin.
+func MengerSponge3D(size float64, levels int) (SDF3, error) {
+	if size <= 0 {
+		return nil, errors.New("size <= 0")
+	}
+	if levels < 0 {
+		return nil, errors.New("levels < 0")
+	}
+	s := MengerSpongeSDF3{
+		levels: levels,
+		scale:  0.5 * size,
+	}
+	h := V3{s.scale, s.scale, s.scale}
+	s.bb = Box3{h.Neg(), h}
+	return &s, nil
+}
+
+// fmod returns x mod y with the sign of y.
+func fmod(x, y float64) float64 {
+	return x - y*math.Floor(x/y)
+}
+
+// Evaluate returns the minimum distance to a Menger sponge.
+func (s *MengerSpongeSDF3) Evaluate(p V3) float64 {
+	p = p.DivScalar(s.scale)
+	// the unit cube
+	q := p.Abs().SubScalar(1)
+	d := q.Max(V3{}).Length() + math.Min(q.MaxComponent(), 0)
+	k := 1.0
+	for i := 0; i < s.levels; i++ {
+		// the cross at this level
+		a := V3{fmod(p.X*k, 2) - 1, fmod(p.Y*k, 2) - 1, fmod(p.Z*k, 2) - 1}
+		k *= 3
+		r := V3{Abs(1 - 3*Abs(a.X)), Abs(1 - 3*Abs(a.Y)), Abs(1 - 3*Abs(a.Z))}
+		da := math.Max(r.X, r.Y)
+		db := math.Max(r.Y, r.Z)
+		dc := math.Max(r.Z, r.X)
+		c := (math.Min(da, math.Min(db, dc)) - 1) / k
+		d = math.Max(d, c)
+	}
+	return d * s.scale
+}
+
+// BoundingBox returns the bounding box of a Menger sponge.
+func (s *MengerSpongeSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Lipschitz returns the Lipschitz bound of the Menger sponge distance.
+func (s *MengerSpongeSDF3) Lipschitz() float64 {
+	return 1
+}
+
+//-----------------------------------------------------------------------------
+
+// MandelbulbParms defines the parameters for a Mandelbulb.
+type MandelbulbParms struct {
+	Power      float64 // power of the iteration (8 for the classic bulb)
+	Iterations int     // maximum number of iterations
+	Size       float64 // scale (the power 8 bulb is about 2.3 x size across)
+}
+
+// MandelbulbSDF3 is a Mandelbulb.
+type MandelbulbSDF3 struct {
+	power      float64
+	iterations int
+	scale      float64
+	bb         Box3
+}
+
+// mandelbulbBailout is the escape radius of the iteration.
+const mandelbulbBailout = 2.0
+
+// Mandelbulb3D returns a Mandelbulb centered on the origin.
+func Mandelbulb3D(k *MandelbulbParms) (SDF3, error) {
+	if k.Power < 2 {
+		return nil, errors.New("Power < 2")
+	}
+	if k.Iterations <= 0 {
+		return nil, errors.New("Iterations <= 0")
+	}
+	if k.Size <= 0 {
+		return nil, errors.New("Size <= 0")
+	}
+	s := MandelbulbSDF3{
+		power:      k.Power,
+		iterations: k.Iterations,
+		scale:      k.Size,
+	}
+	// the set is within the bailout radius, it is smaller for higher powers
+	r := 1.25 * s.scale
+	if k.Power < 8 {
+		r = 1.5 * s.scale
+	}
+	s.bb = Box3{V3{-r, -r, -r}, V3{r, r, r}}
+	return &s, nil
+}
+
+// Evaluate returns the estimated minimum distance to a Mandelbulb.
+func (s *MandelbulbSDF3) Evaluate(p V3) float64 {
+	c := p.DivScalar(s.scale)
+	z := c
+	dr := 1.0
+	r := z.Length()
+	for i := 0; i < s.iterations && r <= mandelbulbBailout; i++ {
+		if r == 0 {
+			// z stays at the origin
+			return 0
+		}
+		theta := math.Acos(Clamp(z.Z/r, -1, 1)) * s.power
+		phi := math.Atan2(z.Y, z.X) * s.power
+		rp := math.Pow(r, s.power-1)
+		dr = rp*s.power*dr + 1
+		rp *= r
+		z = V3{
+			rp * math.Sin(theta) * math.Cos(phi),
+			rp * math.Sin(theta) * math.Sin(phi),
+			rp * math.Cos(theta),
+		}.Add(c)
+		r = z.Length()
+	}
+	return 0.5 * math.Log(r) * r / dr * s.scale
+}
+
+// BoundingBox returns the bounding box of a Mandelbulb.
+func (s *MandelbulbSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Lipschitz returns the Lipschitz bound of the Mandelbulb distance estimate.
+func (s *MandelbulbSDF3) Lipschitz() float64 {
+	return 2
+}
+
+//-----------------------------------------------------------------------------
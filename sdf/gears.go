@@ -8,7 +8,10 @@ Involute Gears
 
 package sdf
 
-import "math"
+import (
+	"errors"
+	"math"
+)
 
 //-----------------------------------------------------------------------------
 
@@ -199,3 +202,411 @@ func (s *GearRackSDF2) BoundingBox() Box2 {
 }
 
 //-----------------------------------------------------------------------------
+// 3D Gear Rack
+
+// RackParms defines the parameters for a 3d gear rack and its matching pinion.
+type RackParms struct {
+	NumberTeeth   int     // number of rack teeth
+	Module        float64 // pitch circle diameter / number of gear teeth
+	PressureAngle float64 // gear pressure angle (radians)
+	Backlash      float64 // backlash expressed as per-tooth distance at pitch circumference
+	BaseHeight    float64 // height of rack base
+	Width         float64 // face width of the rack (z-axis)
+	HelixAngle    float64 // helix angle of the teeth (radians, 0 = straight)
+	HoleRadius    float64 // radius of the mounting holes (0 = none)
+	NumHoles      int     // number of mounting holes, evenly spaced along the rack
+}
+
+// PitchLine returns the height of the rack pitch line above the rack base.
+func (k *RackParms) PitchLine() float64 {
+	return k.BaseHeight + 1.25*k.Module
+}
+
+// validate checks the rack parameters.
+func (k *RackParms) validate() error {
+	if k.NumberTeeth <= 0 {
+		return errors.New("NumberTeeth <= 0")
+	}
+	if k.Module <= 0 {
+		return errors.New("Module <= 0")
+	}
+	if k.BaseHeight < 0 {
+		return errors.New("BaseHeight < 0")
+	}
+	if k.Width <= 0 {
+		return errors.New("Width <= 0")
+	}
+	if Abs(k.HelixAngle) >= 0.5*Pi {
+		return errors.New("HelixAngle out of range")
+	}
+	if k.HoleRadius < 0 {
+		return errors.New("HoleRadius < 0")
+	}
+	if k.HoleRadius > 0 {
+		if k.NumHoles <= 0 {
+			return errors.New("NumHoles <= 0")
+		}
+		spacing := k.Module * Pi * float64(k.NumberTeeth) / float64(k.NumHoles)
+		if 2.0*k.HoleRadius >= spacing {
+			return errors.New("HoleRadius too large for hole spacing")
+		}
+	}
+	return nil
+}
+
+// Rack3D returns a straight or helical gear rack with mounting holes.
+// The rack runs along the x-axis with the teeth facing +y and the base at y = 0.
+// Mounting holes run along the y-axis through the rack.
+func Rack3D(k *RackParms) (SDF3, error) {
+	err := k.validate()
+	if err != nil {
+		return nil, err
+	}
+	pitch := k.Module * Pi
+	length := pitch * float64(k.NumberTeeth)
+	height := k.PitchLine() + k.Module
+	// extend the 2d rack so the sheared teeth cover the ends
+	shear := math.Tan(k.HelixAngle)
+	extra := 2.0 * math.Ceil(0.5*k.Width*Abs(shear)/pitch)
+	rack2d := GearRack2D(float64(k.NumberTeeth)+extra, k.Module, k.PressureAngle, k.Backlash, k.BaseHeight)
+	rack := Extrude3D(rack2d, k.Width)
+	if shear != 0 {
+		rack.(*ExtrudeSDF3).SetExtrude(func(p V3) V2 {
+			return V2{p.X - p.Z*shear, p.Y}
+		})
+	}
+	// trim to length
+	box := Box3D(V3{length, height, k.Width}, 0)
+	box = Transform3D(box, Translate3d(V3{0, 0.5 * height, 0}))
+	rack = Intersect3D(box, rack)
+	// mounting holes
+	if k.HoleRadius > 0 {
+		positions := make(V2Set, k.NumHoles)
+		dx := length / float64(k.NumHoles)
+		for i := range positions {
+			positions[i] = V2{(float64(i)+0.5)*dx - 0.5*length, 0}
+		}
+		holes := MultiCylinder3D(2.0*height, k.HoleRadius, positions)
+		holes = Transform3D(holes, RotateX(0.5*Pi))
+		rack = Difference3D(rack, holes)
+	}
+	return rack, nil
+}
+
+// Pinion3D returns a spur or helical pinion that meshes with the rack.
+// The pinion axis is on the z-axis. It meshes with the rack when placed with its
+// axis at (x, PitchLine() + pitch radius) where x aligns a tooth with a rack tooth gap.
+func (k *RackParms) Pinion3D(
+	numberTeeth int, // number of pinion teeth
+	clearance float64, // additional root clearance
+	shaftRadius float64, // radius of shaft hole
+	facets int, // number of facets for involute flank
+) (SDF3, error) {
+	if numberTeeth < 4 {
+		return nil, errors.New("numberTeeth < 4")
+	}
+	pitchRadius := 0.5 * k.Module * float64(numberTeeth)
+	rootRadius := pitchRadius - k.Module - clearance
+	if shaftRadius >= rootRadius {
+		return nil, errors.New("shaftRadius >= pinion root radius")
+	}
+	gear := InvoluteGear(numberTeeth, k.Module, k.PressureAngle, k.Backlash, clearance, rootRadius-shaftRadius, facets)
+	// the teeth at the bottom of the pinion follow the rack teeth
+	twist := -k.Width * math.Tan(k.HelixAngle) / pitchRadius
+	return TwistExtrude3D(gear, k.Width, twist), nil
+}
+
+//-----------------------------------------------------------------------------
+// Spur Gear Trains
+
+// GearTrainParms defines the parameters for a compound spur gear train.
+type GearTrainParms struct {
+	Ratio         float64 // desired overall reduction ratio (input speed / output speed)
+	Module        float64 // pitch circle diameter / number of gear teeth
+	PressureAngle float64 // gear pressure angle (radians)
+	MinTeeth      int     // minimum number of teeth on any gear
+	MaxTeeth      int     // maximum number of teeth on any gear
+	MaxStages     int     // maximum number of reduction stages
+	Backlash      float64 // backlash expressed as per-tooth distance at pitch circumference
+	Clearance     float64 // additional root clearance
+	Thickness     float64 // gear face width
+	Spacing       float64 // z-distance between the faces of adjacent stages
+	ShaftRadius   float64 // radius of shaft holes
+	Facets        int     // number of facets for involute flank
+}
+
+// GearStage is a single pinion/gear mesh within a gear train.
+type GearStage struct {
+	PinionTeeth int     // number of teeth on the driving pinion
+	GearTeeth   int     // number of teeth on the driven gear
+	Distance    float64 // center to center distance
+}
+
+// GearTrain is a laid out compound spur gear train.
+// Stage n drives from shaft n to shaft n+1. The shafts are placed along the x-axis
+// with the input shaft at the origin, and each stage is offset in z.
+type GearTrain struct {
+	Stages []GearStage // gear stages, input to output
+	Ratio  float64     // achieved reduction ratio
+	Shafts V2Set       // shaft positions, input to output
+	Gears  []SDF3      // positioned gears (pinion, gear) for each stage
+}
+
+// bestGearPair returns the pinion/gear tooth counts that best match a ratio.
+func bestGearPair(ratio float64, minTeeth, maxTeeth int) (int, int) {
+	bestP, bestG := minTeeth, minTeeth
+	bestErr := math.MaxFloat64
+	for p := minTeeth; p <= maxTeeth; p++ {
+		g := int(math.Floor(float64(p)*ratio + 0.5))
+		if g < minTeeth || g > maxTeeth {
+			continue
+		}
+		e := Abs(float64(g)/float64(p) - ratio)
+		if e < bestErr-tolerance {
+			bestP, bestG, bestErr = p, g, e
+		}
+	}
+	return bestP, bestG
+}
+
+// gearTrainStages chooses the tooth counts for each stage of a gear train.
+func gearTrainStages(k *GearTrainParms) ([]GearStage, error) {
+	maxRatio := float64(k.MaxTeeth) / float64(k.MinTeeth)
+	n := 1
+	for ; n <= k.MaxStages; n++ {
+		if math.Pow(k.Ratio, 1/float64(n)) <= maxRatio {
+			break
+		}
+	}
+	if n > k.MaxStages {
+		return nil, errors.New("ratio can't be achieved with MaxStages")
+	}
+	stages := make([]GearStage, n)
+	remaining := k.Ratio
+	for i := range stages {
+		// split the remaining ratio evenly across the remaining stages
+		target := math.Pow(remaining, 1/float64(n-i))
+		p, g := bestGearPair(target, k.MinTeeth, k.MaxTeeth)
+		stages[i] = GearStage{
+			PinionTeeth: p,
+			GearTeeth:   g,
+			Distance:    0.5 * k.Module * float64(p+g),
+		}
+		remaining /= float64(g) / float64(p)
+	}
+	return stages, nil
+}
+
+// spurGear3D returns an extruded involute gear with a shaft hole.
+func spurGear3D(numberTeeth int, k *GearTrainParms) SDF3 {
+	rootRadius := 0.5*float64(numberTeeth)*k.Module - k.Module - k.Clearance
+	ringWidth := rootRadius - k.ShaftRadius
+	gear := InvoluteGear(numberTeeth, k.Module, k.PressureAngle, k.Backlash, k.Clearance, ringWidth, k.Facets)
+	return Extrude3D(gear, k.Thickness)
+}
+
+// NewGearTrain chooses tooth counts for a compound spur gear train and lays out the gears.
+func NewGearTrain(k *GearTrainParms) (*GearTrain, error) {
+	// validate parameters
+	if k.Ratio < 1 {
+		return nil, errors.New("Ratio < 1")
+	}
+	if k.Module <= 0 {
+		return nil, errors.New("Module <= 0")
+	}
+	if k.MinTeeth < 4 {
+		return nil, errors.New("MinTeeth < 4")
+	}
+	if k.MaxTeeth < k.MinTeeth {
+		return nil, errors.New("MaxTeeth < MinTeeth")
+	}
+	if k.MaxStages <= 0 {
+		return nil, errors.New("MaxStages <= 0")
+	}
+	if k.Thickness <= 0 {
+		return nil, errors.New("Thickness <= 0")
+	}
+	if k.ShaftRadius < 0 {
+		return nil, errors.New("ShaftRadius < 0")
+	}
+	// the smallest gear must have room for the shaft
+	minRoot := 0.5*float64(k.MinTeeth)*k.Module - k.Module - k.Clearance
+	if k.ShaftRadius >= minRoot {
+		return nil, errors.New("ShaftRadius >= gear root radius")
+	}
+
+	stages, err := gearTrainStages(k)
+	if err != nil {
+		return nil, err
+	}
+
+	t := GearTrain{
+		Stages: stages,
+		Ratio:  1,
+		Shafts: V2Set{{0, 0}},
+	}
+	x := 0.0
+	for i, s := range stages {
+		t.Ratio *= float64(s.GearTeeth) / float64(s.PinionTeeth)
+		z := float64(i) * (k.Thickness + k.Spacing)
+		// the pinion has a tooth on the +x axis
+		pinion := spurGear3D(s.PinionTeeth, k)
+		pinion = Transform3D(pinion, Translate3d(V3{x, 0, z}))
+		// rotate the gear so a tooth gap faces the pinion tooth
+		x += s.Distance
+		gear := spurGear3D(s.GearTeeth, k)
+		m := Translate3d(V3{x, 0, z}).Mul(RotateZ(Pi + Pi/float64(s.GearTeeth)))
+		gear = Transform3D(gear, m)
+		t.Gears = append(t.Gears, pinion, gear)
+		t.Shafts = append(t.Shafts, V2{x, 0})
+	}
+	return &t, nil
+}
+
+//-----------------------------------------------------------------------------
+// Worm Gears
+
+// WormGearParms defines the parameters for a worm and worm-wheel pair.
+type WormGearParms struct {
+	Module        float64 // axial module of the worm (transverse module of the wheel)
+	Starts        int     // number of worm thread starts (< 0 for left hand)
+	WheelTeeth    int     // number of teeth on the worm-wheel
+	LeadAngle     float64 // worm lead angle at the pitch radius (radians)
+	PressureAngle float64 // thread/tooth pressure angle (radians)
+	Backlash      float64 // backlash expressed as per-tooth distance at pitch circumference
+	Clearance     float64 // additional root clearance
+	WormLength    float64 // length of the worm
+	WheelWidth    float64 // face width of the worm-wheel
+	ThroatRadius  float64 // radius of the concave throat cut in the wheel (0 = worm root radius + clearance)
+	ShaftRadius   float64 // radius of the worm-wheel shaft hole (0 = none)
+	Facets        int     // number of facets for involute flank
+}
+
+// WormGear is a meshed worm and worm-wheel.
+// The wheel is centered on the origin with its axis on the z-axis.
+// The worm axis is parallel to the y-axis and passes through (Distance, 0, 0).
+type WormGear struct {
+	Worm         SDF3    // positioned worm
+	Wheel        SDF3    // positioned worm-wheel
+	Ratio        float64 // reduction ratio (wheel teeth / worm starts)
+	Distance     float64 // center to center distance
+	WormRadius   float64 // pitch radius of the worm
+	WheelRadius  float64 // pitch radius of the worm-wheel
+	ThroatRadius float64 // radius of the throat cut in the wheel
+}
+
+// wormThread returns the 2d thread profile for a worm.
+func wormThread(
+	radius float64, // pitch radius of worm
+	k *WormGearParms,
+) SDF2 {
+	pitch := k.Module * Pi
+	addendum := k.Module
+	dedendum := k.Module + k.Clearance
+	t := math.Tan(k.PressureAngle)
+	// 1/2 thread thickness at the pitch line, tip and root
+	w := 0.25 * (pitch - k.Backlash)
+	wt := w - addendum*t
+	wr := Min(w+dedendum*t, 0.5*pitch)
+	rOuter := radius + addendum
+	rRoot := radius - dedendum
+
+	worm := NewPolygon()
+	worm.Add(pitch, 0)
+	worm.Add(pitch, rRoot)
+	worm.Add(wr, rRoot)
+	worm.Add(wt, rOuter)
+	worm.Add(-wt, rOuter)
+	worm.Add(-wr, rRoot)
+	worm.Add(-pitch, rRoot)
+	worm.Add(-pitch, 0)
+
+	//worm.Render("worm.dxf")
+	return Polygon2D(worm.Vertices())
+}
+
+// NewWormGear returns a matched worm and worm-wheel.
+// The wheel is a helical involute gear with a concave throat that wraps the worm.
+func NewWormGear(k *WormGearParms) (*WormGear, error) {
+	// validate parameters
+	if k.Module <= 0 {
+		return nil, errors.New("Module <= 0")
+	}
+	if k.Starts == 0 {
+		return nil, errors.New("Starts == 0")
+	}
+	if k.WheelTeeth < 4 {
+		return nil, errors.New("WheelTeeth < 4")
+	}
+	if k.LeadAngle <= 0 || k.LeadAngle >= 0.5*Pi {
+		return nil, errors.New("LeadAngle out of range")
+	}
+	if k.PressureAngle <= 0 || k.PressureAngle >= 0.5*Pi {
+		return nil, errors.New("PressureAngle out of range")
+	}
+	if k.WormLength <= 0 {
+		return nil, errors.New("WormLength <= 0")
+	}
+	if k.WheelWidth <= 0 {
+		return nil, errors.New("WheelWidth <= 0")
+	}
+	if k.ThroatRadius < 0 {
+		return nil, errors.New("ThroatRadius < 0")
+	}
+	if k.ShaftRadius < 0 {
+		return nil, errors.New("ShaftRadius < 0")
+	}
+	starts := float64(k.Starts)
+	if k.Starts < 0 {
+		starts = -starts
+	}
+	// the lead angle sets the worm pitch radius: tan(lead angle) = lead / (2 * pi * radius)
+	wormRadius := 0.5 * k.Module * starts / math.Tan(k.LeadAngle)
+	if wormRadius-k.Module-k.Clearance <= 0 {
+		return nil, errors.New("worm root radius <= 0, increase LeadAngle")
+	}
+	wheelRadius := 0.5 * k.Module * float64(k.WheelTeeth)
+	wheelRoot := wheelRadius - k.Module - k.Clearance
+	if k.ShaftRadius >= wheelRoot {
+		return nil, errors.New("ShaftRadius >= wheel root radius")
+	}
+	throat := k.ThroatRadius
+	if throat == 0 {
+		throat = wormRadius - k.Module
+	}
+	if throat >= wormRadius+k.Module {
+		return nil, errors.New("ThroatRadius >= worm outer radius")
+	}
+
+	g := WormGear{
+		Ratio:        float64(k.WheelTeeth) / starts,
+		Distance:     wormRadius + wheelRadius,
+		WormRadius:   wormRadius,
+		WheelRadius:  wheelRadius,
+		ThroatRadius: throat,
+	}
+
+	// worm: rotate a thread gap onto the -x side, then lay the axis along y
+	worm := Screw3D(wormThread(wormRadius, k), k.WormLength, k.Module*Pi, k.Starts)
+	phi := Pi - Pi/float64(k.Starts)
+	m := Translate3d(V3{g.Distance, 0, 0}).Mul(RotateX(-0.5 * Pi)).Mul(RotateZ(phi))
+	g.Worm = Transform3D(worm, m)
+
+	// wheel: helical gear with a helix angle and hand matching the worm
+	ringWidth := wheelRoot - k.ShaftRadius
+	profile := InvoluteGear(k.WheelTeeth, k.Module, k.PressureAngle, k.Backlash, k.Clearance, ringWidth, k.Facets)
+	twist := -k.WheelWidth * math.Tan(k.LeadAngle) / wheelRadius
+	if k.Starts < 0 {
+		twist = -twist
+	}
+	wheel := TwistExtrude3D(profile, k.WheelWidth, twist)
+	// cut the throat that wraps around the worm
+	l := 2.0 * (wheelRadius + k.Module)
+	cut := Cylinder3D(l, throat, 0)
+	cut = Transform3D(cut, Translate3d(V3{g.Distance, 0, 0}).Mul(RotateX(0.5*Pi)))
+	g.Wheel = Difference3D(wheel, cut)
+
+	return &g, nil
+}
+
+//-----------------------------------------------------------------------------
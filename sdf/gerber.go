@@ -0,0 +1,154 @@
+//-----------------------------------------------------------------------------
+/*
+
+Gerber Output
+
+Write SDF2 layers as Gerber (RS-274X) files for PCB fabrication, e.g. solder
+paste stencils, silkscreen artwork and front panels made as PCBs.
+
+The inside of the SDF2 is written as filled regions. Holes are cleared with
+clear polarity regions, so contours are written outermost first. The profile
+(board outline) is written as lines drawn with a round aperture.
+
+Units are mm.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// gerberUnits is the number of Gerber units per mm (format 4.6).
+const gerberUnits = 1e6
+
+// gerberXY returns Gerber coordinates.
+func gerberXY(p V2) string {
+	return fmt.Sprintf("X%dY%d", int64(math.Round(p.X*gerberUnits)), int64(math.Round(p.Y*gerberUnits)))
+}
+
+// gerberHeader writes the Gerber header.
+func gerberHeader(buf *bufio.Writer, function string) {
+	fmt.Fprintf(buf, "G04 sdfx*\n")
+	if function != "" {
+		fmt.Fprintf(buf, "%%TF.FileFunction,%s*%%\n", function)
+	}
+	fmt.Fprintf(buf, "%%FSLAX46Y46*%%\n%%MOMM*%%\n")
+}
+
+// gerberClose returns a contour with the first vertex repeated at the end.
+func gerberClose(c []V2) []V2 {
+	if c[0] == c[len(c)-1] {
+		return c
+	}
+	return append(append([]V2(nil), c...), c[0])
+}
+
+//-----------------------------------------------------------------------------
+
+// WriteGerber writes the inside of an SDF2 as Gerber regions.
+// function is the file function attribute (e.g. "Paste,Top"), empty for none.
+func WriteGerber(w io.Writer, s SDF2, resolution float64, function string) error {
+	if resolution <= 0 {
+		return errors.New("resolution <= 0")
+	}
+	contours := Contours2(s, resolution)
+	depth := nestingDepth(contours)
+	order := make([]int, len(contours))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return depth[order[a]] < depth[order[b]] })
+
+	buf := bufio.NewWriter(w)
+	gerberHeader(buf, function)
+	dark := true
+	fmt.Fprintf(buf, "%%LPD*%%\n")
+	for _, i := range order {
+		c := contours[i]
+		if len(c) < 3 {
+			continue
+		}
+		// even depths are solid, odd depths are holes
+		if d := depth[i]%2 == 0; d != dark {
+			dark = d
+			if dark {
+				fmt.Fprintf(buf, "%%LPD*%%\n")
+			} else {
+				fmt.Fprintf(buf, "%%LPC*%%\n")
+			}
+		}
+		c = gerberClose(c)
+		fmt.Fprintf(buf, "G36*\n%sD02*\nG01*\n", gerberXY(c[0]))
+		for _, v := range c[1:] {
+			fmt.Fprintf(buf, "%sD01*\n", gerberXY(v))
+		}
+		fmt.Fprintf(buf, "G37*\n")
+	}
+	fmt.Fprintf(buf, "M02*\n")
+	return buf.Flush()
+}
+
+// WriteGerberProfile writes the contours of an SDF2 as lines drawn with a round
+// aperture of the given width, e.g. for the board outline (profile) layer.
+func WriteGerberProfile(w io.Writer, s SDF2, resolution, width float64) error {
+	if resolution <= 0 {
+		return errors.New("resolution <= 0")
+	}
+	if width <= 0 {
+		return errors.New("width <= 0")
+	}
+	buf := bufio.NewWriter(w)
+	gerberHeader(buf, "Profile,NP")
+	fmt.Fprintf(buf, "%%ADD10C,%g*%%\n%%LPD*%%\nD10*\nG01*\n", width)
+	for _, c := range Contours2(s, resolution) {
+		if len(c) < 2 {
+			continue
+		}
+		c = gerberClose(c)
+		fmt.Fprintf(buf, "%sD02*\n", gerberXY(c[0]))
+		for _, v := range c[1:] {
+			fmt.Fprintf(buf, "%sD01*\n", gerberXY(v))
+		}
+	}
+	fmt.Fprintf(buf, "M02*\n")
+	return buf.Flush()
+}
+
+// SaveGerber writes the inside of an SDF2 to a Gerber file.
+func SaveGerber(path string, s SDF2, resolution float64, function string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteGerber(f, s, resolution, function); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SaveGerberProfile writes the contours of an SDF2 to a Gerber profile file.
+func SaveGerberProfile(path string, s SDF2, resolution, width float64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteGerberProfile(f, s, resolution, width); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,159 @@
+//-----------------------------------------------------------------------------
+/*
+
+Hinges
+
+Barrel hinges are made as two halves with a pin hole through the knuckles,
+the pin is a separate part (E.g. a length of rod or filament).
+
+Print-in-place hinges are a single part. The knuckles of one half carry an
+integral pin that runs through the bored knuckles of the other half, the
+knuckle gaps and bore clearance are built in.
+
+Hinge frame:
+	The hinge axis is the x-axis, centered on the origin.
+	Leaf A extends along -y, leaf B along +y.
+	The leaves are flat on the z = -Radius plane (the mounting face).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+// HingeParms defines the parameters for a hinge.
+type HingeParms struct {
+	Length        float64 // length of the hinge along its axis
+	Knuckles      int     // number of knuckles, alternating between the halves (>= 2)
+	Radius        float64 // outer radius of the knuckles
+	PinRadius     float64 // radius of the hinge pin
+	LeafWidth     float64 // width of each leaf from the hinge axis
+	LeafThickness float64 // thickness of the leaves
+	Clearance     float64 // gap between knuckles, and between the pin and its bore
+}
+
+func (k *HingeParms) validate() error {
+	if k.Knuckles < 2 {
+		return errors.New("Knuckles < 2")
+	}
+	if k.Radius <= 0 {
+		return errors.New("Radius <= 0")
+	}
+	if k.PinRadius <= 0 || k.PinRadius+k.Clearance >= k.Radius {
+		return errors.New("PinRadius out of range")
+	}
+	if k.LeafWidth <= k.Radius+k.Clearance {
+		return errors.New("LeafWidth <= Radius + Clearance")
+	}
+	if k.LeafThickness <= 0 || k.LeafThickness > 2*k.Radius {
+		return errors.New("LeafThickness out of range")
+	}
+	if k.Clearance < 0 {
+		return errors.New("Clearance < 0")
+	}
+	if k.knuckleLength() <= 0 {
+		return errors.New("Length is too short for the knuckles")
+	}
+	return nil
+}
+
+// knuckleLength returns the length of a single knuckle.
+func (k *HingeParms) knuckleLength() float64 {
+	n := float64(k.Knuckles)
+	return (k.Length - (n-1)*k.Clearance) / n
+}
+
+// knuckleCenter returns the x position of the center of the i-th knuckle.
+func (k *HingeParms) knuckleCenter(i int) float64 {
+	l := k.knuckleLength()
+	return -0.5*k.Length + float64(i)*(l+k.Clearance) + 0.5*l
+}
+
+// hingeKnuckles returns cylinders along the x-axis for the knuckles of a hinge half.
+func (k *HingeParms) hingeKnuckles(half int, length, radius float64) SDF3 {
+	var s []SDF3
+	for i := half; i < k.Knuckles; i += 2 {
+		c := Cylinder3D(length, radius, 0)
+		c = Transform3D(c, Translate3d(V3{k.knuckleCenter(i), 0, 0}).Mul(RotateY(0.5*Pi)))
+		s = append(s, c)
+	}
+	return Union3D(s...)
+}
+
+// hingeHalf returns a leaf with its knuckles (half = 0 for leaf A, 1 for leaf B).
+func (k *HingeParms) hingeHalf(half int) SDF3 {
+	l := k.knuckleLength()
+	// leaf, from the hinge axis out to the leaf width
+	leaf := Box3D(V3{k.Length, k.LeafWidth, k.LeafThickness}, 0)
+	y := 0.5 * k.LeafWidth
+	if half == 0 {
+		y = -y
+	}
+	leaf = Transform3D(leaf, Translate3d(V3{0, y, -k.Radius + 0.5*k.LeafThickness}))
+	// clear the knuckles of the other half
+	other := k.hingeKnuckles(1-half, l+2*k.Clearance, k.Radius+k.Clearance)
+	leaf = Difference3D(leaf, other)
+	return Union3D(leaf, k.hingeKnuckles(half, l, k.Radius))
+}
+
+// BarrelHinge3D returns the two halves (leaf A, leaf B) of a barrel hinge
+// with a pin hole through the knuckles.
+func BarrelHinge3D(k *HingeParms) (SDF3, SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, nil, err
+	}
+	hole := Cylinder3D(2*k.Length, k.PinRadius+k.Clearance, 0)
+	hole = Transform3D(hole, RotateY(0.5*Pi))
+	a := Difference3D(k.hingeHalf(0), hole)
+	b := Difference3D(k.hingeHalf(1), hole)
+	return a, b, nil
+}
+
+// PrintInPlaceHinge3D returns a single part hinge. The knuckles of leaf A
+// carry the pin, the knuckles of leaf B are bored to turn on it.
+func PrintInPlaceHinge3D(k *HingeParms) (SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	// the pin runs the length of the hinge through the leaf B knuckles
+	l := k.knuckleLength()
+	pin := Cylinder3D(k.Length, k.PinRadius, 0)
+	pin = Transform3D(pin, RotateY(0.5*Pi))
+	a := Union3D(k.hingeHalf(0), pin)
+	bore := k.hingeKnuckles(1, l+2*k.Clearance, k.PinRadius+k.Clearance)
+	b := Difference3D(k.hingeHalf(1), bore)
+	return Union3D(a, b), nil
+}
+
+// HingeOnEdge sets the hinge length to the length of a part edge and returns
+// the transform that places the hinge along the edge. The edge runs from anchor
+// a0 to anchor a1, and the axis of a0 is the normal of the mounting face. The
+// leaves sit on the mounting face with the hinge axis above the edge.
+func HingeOnEdge(k *HingeParms, a0, a1 Anchor) (M44, error) {
+	v := a1.Position.Sub(a0.Position)
+	l := v.Length()
+	if l < tolerance {
+		return M44{}, errors.New("the edge anchors are coincident")
+	}
+	u := v.DivScalar(l)
+	// the face normal, perpendicular to the edge
+	n := a0.Axis.Sub(u.MulScalar(a0.Axis.Dot(u)))
+	if n.Length() < tolerance {
+		return M44{}, errors.New("the mounting face normal is parallel to the edge")
+	}
+	n = n.Normalize()
+	k.Length = l
+	w := n.Cross(u)
+	p := a0.Position.Add(a1.Position).MulScalar(0.5).Add(n.MulScalar(k.Radius))
+	return M44{
+		u.X, w.X, n.X, p.X,
+		u.Y, w.Y, n.Y, p.Y,
+		u.Z, w.Z, n.Z, p.Z,
+		0, 0, 0, 1,
+	}, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,140 @@
+//-----------------------------------------------------------------------------
+/*
+
+HPGL Output
+
+Write 2D contours as HPGL for pen plotters and vinyl cutters.
+
+Contours are grouped into layers, each drawn with its own pen. Within a layer
+the paths are ordered nearest neighbour first to reduce pen up travel.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// hpglUnits is the number of plotter units per mm.
+const hpglUnits = 40.0
+
+// HPGLLayer is a set of paths drawn with one pen.
+type HPGLLayer struct {
+	Pen    int    // pen number (1..n)
+	Paths  [][]V2 // paths to draw
+	Closed bool   // the paths are closed contours
+}
+
+// Contours2 returns the closed contours of an SDF2 with the inside on the left.
+func Contours2(s SDF2, resolution float64) [][]V2 {
+	return isoContours(s, 0, resolution)
+}
+
+// OrderPaths orders paths nearest neighbour first, starting from a point.
+// Closed paths start at their closest vertex and have the first vertex repeated at the end.
+// Open paths may be reversed.
+func OrderPaths(paths [][]V2, start V2, closed bool) [][]V2 {
+	if closed {
+		return nearestNeighbour(paths, start)
+	}
+	used := make([]bool, len(paths))
+	out := make([][]V2, 0, len(paths))
+	p := start
+	for range paths {
+		k := -1
+		reverse := false
+		dmin := 0.0
+		for i, c := range paths {
+			if used[i] || len(c) == 0 {
+				continue
+			}
+			if d := c[0].Sub(p).Length2(); k < 0 || d < dmin {
+				k, reverse, dmin = i, false, d
+			}
+			if d := c[len(c)-1].Sub(p).Length2(); d < dmin {
+				k, reverse, dmin = i, true, d
+			}
+		}
+		if k < 0 {
+			break
+		}
+		used[k] = true
+		c := append([]V2(nil), paths[k]...)
+		if reverse {
+			for i, j := 0, len(c)-1; i < j; i, j = i+1, j-1 {
+				c[i], c[j] = c[j], c[i]
+			}
+		}
+		out = append(out, c)
+		p = c[len(c)-1]
+	}
+	return out
+}
+
+// PenUpDistance returns the pen up travel distance to draw paths from a starting point.
+func PenUpDistance(paths [][]V2, start V2) float64 {
+	d := 0.0
+	p := start
+	for _, c := range paths {
+		if len(c) == 0 {
+			continue
+		}
+		d += c[0].Sub(p).Length()
+		p = c[len(c)-1]
+	}
+	return d
+}
+
+//-----------------------------------------------------------------------------
+
+// WriteHPGL writes the layers as HPGL. The layers are drawn in pen order and
+// the paths of each layer are ordered to reduce the pen up travel.
+func WriteHPGL(w io.Writer, layers []HPGLLayer) error {
+	layers = append([]HPGLLayer(nil), layers...)
+	sort.SliceStable(layers, func(i, j int) bool { return layers[i].Pen < layers[j].Pen })
+	xy := func(p V2) string {
+		return fmt.Sprintf("%d,%d", int(math.Round(p.X*hpglUnits)), int(math.Round(p.Y*hpglUnits)))
+	}
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "IN;\n")
+	p := V2{}
+	for _, l := range layers {
+		fmt.Fprintf(buf, "SP%d;\n", l.Pen)
+		for _, c := range OrderPaths(l.Paths, p, l.Closed) {
+			if len(c) == 0 {
+				continue
+			}
+			fmt.Fprintf(buf, "PU%s;\n", xy(c[0]))
+			for _, v := range c[1:] {
+				fmt.Fprintf(buf, "PD%s;\n", xy(v))
+			}
+			p = c[len(c)-1]
+		}
+	}
+	fmt.Fprintf(buf, "PU;\nSP0;\n")
+	return buf.Flush()
+}
+
+// SaveHPGL writes the layers to an HPGL file.
+func SaveHPGL(path string, layers []HPGLLayer) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteHPGL(f, layers); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+//-----------------------------------------------------------------------------
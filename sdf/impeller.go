@@ -0,0 +1,186 @@
+//-----------------------------------------------------------------------------
+/*
+
+Impellers
+
+Axial impellers and fans made from blades lofted between a hub section and
+a tip section.
+
+Blade sections are 2D profiles in the (s, z) plane where s is the arc length
+around the axis at the section radius. The sections are blended (as for
+Loft3D) and twisted as the radius increases.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// CamberedBladeSDF2 is a cambered plate blade section.
+type CamberedBladeSDF2 struct {
+	a, b   V2      // leading/trailing edge points
+	center V2      // arc center
+	radius float64 // arc radius (0 for a flat plate)
+	phi    float64 // half angle of the arc
+	t      float64 // half thickness
+	bb     Box2
+}
+
+// CamberedBlade2D returns a blade section: a circular arc with round ends.
+// The chord is along the y-axis centered on the origin. The camber is the
+// height of the arc above the chord as a fraction of the chord (bulging to +x).
+func CamberedBlade2D(chord, camber, thickness float64) SDF2 {
+	s := CamberedBladeSDF2{
+		a: V2{0, -0.5 * chord},
+		b: V2{0, 0.5 * chord},
+		t: 0.5 * thickness,
+	}
+	h := camber * chord
+	if h != 0 {
+		s.radius = (0.25*chord*chord + h*h) / (2 * h)
+		s.center = V2{h - s.radius, 0}
+		s.phi = math.Asin(0.5 * chord / Abs(s.radius))
+	}
+	x0, x1 := Min(0, h), Max(0, h)
+	s.bb = Box2{V2{x0 - s.t, -0.5*chord - s.t}, V2{x1 + s.t, 0.5*chord + s.t}}
+	return &s
+}
+
+// Evaluate returns the minimum distance to a cambered blade section.
+func (s *CamberedBladeSDF2) Evaluate(p V2) float64 {
+	if s.radius == 0 {
+		return pointSegmentDistance(p, s.a, s.b) - s.t
+	}
+	v := p.Sub(s.center)
+	if s.radius < 0 {
+		v.X = -v.X
+	}
+	// within the angular range of the arc
+	if Abs(math.Atan2(v.Y, v.X)) <= s.phi {
+		return Abs(v.Length()-Abs(s.radius)) - s.t
+	}
+	return math.Min(p.Sub(s.a).Length(), p.Sub(s.b).Length()) - s.t
+}
+
+// BoundingBox returns the bounding box of a cambered blade section.
+func (s *CamberedBladeSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
+// BladeLoftSDF3 is a blade lofted radially between two sections.
+type BladeLoftSDF3 struct {
+	sdf0, sdf1 SDF2
+	r0, r1     float64
+	twist      float64
+	bb         Box3
+}
+
+// BladeLoft3D returns a blade along the +x axis from radius r0 to r1. The
+// blade sections are in the (s, z) plane, with s the arc length around the
+// z-axis. The section blends from sdf0 to sdf1 and rotates by twist radians
+// from r0 to r1.
+func BladeLoft3D(sdf0, sdf1 SDF2, r0, r1, twist float64) (SDF3, error) {
+	if r0 < 0 || r1 <= r0 {
+		return nil, errors.New("radius must be >= 0 with r1 > r0")
+	}
+	s := BladeLoftSDF3{
+		sdf0:  sdf0,
+		sdf1:  sdf1,
+		r0:    r0,
+		r1:    r1,
+		twist: twist,
+	}
+	// the sections may rotate, so use their extent from the origin
+	l := 0.0
+	for _, v := range append(sdf0.BoundingBox().Vertices(), sdf1.BoundingBox().Vertices()...) {
+		l = math.Max(l, v.Length())
+	}
+	s.bb = Box3{V3{-r1, -r1, -l}, V3{r1, r1, l}}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a lofted blade.
+func (s *BladeLoftSDF3) Evaluate(p V3) float64 {
+	r := math.Sqrt(p.X*p.X + p.Y*p.Y)
+	q := V2{r * math.Atan2(p.Y, p.X), p.Z}
+	k := Clamp((r-s.r0)/(s.r1-s.r0), 0, 1)
+	q = Rotate(-k * s.twist).MulPosition(q)
+	a := Mix(s.sdf0.Evaluate(q), s.sdf1.Evaluate(q), k)
+	b := math.Max(s.r0-r, r-s.r1)
+	return math.Max(a, b)
+}
+
+// BoundingBox returns the bounding box of a lofted blade.
+func (s *BladeLoftSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
+// ImpellerParms defines the parameters for an axial impeller.
+type ImpellerParms struct {
+	Blades       int     // number of blades
+	HubRadius    float64 // hub radius
+	HubHeight    float64 // hub height
+	TipRadius    float64 // blade tip radius
+	BoreDiameter float64 // shaft bore diameter (0 for no bore)
+	Thickness    float64 // blade thickness
+	HubChord     float64 // blade chord at the hub
+	TipChord     float64 // blade chord at the tip
+	HubCamber    float64 // blade camber at the hub (fraction of the chord)
+	TipCamber    float64 // blade camber at the tip (fraction of the chord)
+	HubStagger   float64 // angle of the chord from the axis at the hub (radians)
+	TipStagger   float64 // angle of the chord from the axis at the tip (radians)
+}
+
+// Impeller3D returns an axial impeller about the z-axis centered on the origin.
+func Impeller3D(k *ImpellerParms) (SDF3, error) {
+	if k.Blades <= 0 {
+		return nil, errors.New("Blades <= 0")
+	}
+	if k.HubRadius <= 0 || k.TipRadius <= k.HubRadius {
+		return nil, errors.New("radius must be > 0 with TipRadius > HubRadius")
+	}
+	if k.HubHeight <= 0 {
+		return nil, errors.New("HubHeight <= 0")
+	}
+	if k.Thickness <= 0 || k.HubChord <= 0 || k.TipChord <= 0 {
+		return nil, errors.New("blade dimensions must be > 0")
+	}
+	if k.BoreDiameter < 0 || k.BoreDiameter >= 2*k.HubRadius {
+		return nil, errors.New("invalid BoreDiameter")
+	}
+	// each blade must stay within its sector (see RotateCopy3D)
+	sector := Pi / float64(k.Blades)
+	for _, x := range []struct{ r, chord, camber, stagger float64 }{
+		{k.HubRadius, k.HubChord, k.HubCamber, k.HubStagger},
+		{k.TipRadius, k.TipChord, k.TipCamber, k.TipStagger},
+	} {
+		s := 0.5*x.chord*Abs(math.Sin(x.stagger)) + Abs(x.camber*x.chord) + k.Thickness
+		if s/x.r > sector {
+			return nil, errors.New("the blades overlap, reduce the chord or stagger")
+		}
+	}
+	hub := Transform2D(CamberedBlade2D(k.HubChord, k.HubCamber, k.Thickness), Rotate2d(k.HubStagger))
+	tip := Transform2D(CamberedBlade2D(k.TipChord, k.TipCamber, k.Thickness), Rotate2d(k.HubStagger))
+	// start the blades inside the hub so they join it
+	blade, err := BladeLoft3D(hub, tip, 0.5*k.HubRadius, k.TipRadius, k.TipStagger-k.HubStagger)
+	if err != nil {
+		return nil, err
+	}
+	s := Union3D(Cylinder3D(k.HubHeight, k.HubRadius, 0), RotateCopy3D(blade, k.Blades))
+	if k.BoreDiameter > 0 {
+		s = Difference3D(s, Cylinder3D(2*k.HubHeight, 0.5*k.BoreDiameter, 0))
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
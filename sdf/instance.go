@@ -0,0 +1,85 @@
+//-----------------------------------------------------------------------------
+/*
+
+Instancing
+
+Find sub-trees of an SDF3 that are placed many times (arrays, rotated copies,
+transformed copies in a union) so exporters can mesh them once and emit
+instanced objects rather than duplicating triangles.
+
+Only hard unions are decomposed. A blended union (SetMin) changes the geometry
+where the copies meet, so it is treated as a single object.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "reflect"
+
+//-----------------------------------------------------------------------------
+
+// Instance3 is an SDF3 placed with a transformation matrix.
+type Instance3 struct {
+	SDF      SDF3
+	Matrix   M44
+	Material *Material // see Material3D, nil for no material
+}
+
+// isMin returns true if the minimum function is the hard (non-blended) minimum.
+func isMin(min MinFunc) bool {
+	return reflect.ValueOf(min).Pointer() == reflect.ValueOf(MinFunc(Min)).Pointer()
+}
+
+// findInstances appends the instances of an SDF3 placed with matrix m.
+func findInstances(s SDF3, m M44, material *Material, out []Instance3) []Instance3 {
+	switch x := s.(type) {
+	case *MaterialSDF3:
+		return findInstances(x.sdf, m, x.material, out)
+	case *UnionSDF3:
+		if isMin(x.min) {
+			for _, c := range x.sdf {
+				out = findInstances(c, m, material, out)
+			}
+			return out
+		}
+	case *ArraySDF3:
+		if isMin(x.min) {
+			for j := 0; j < x.num[0]; j++ {
+				for k := 0; k < x.num[1]; k++ {
+					for l := 0; l < x.num[2]; l++ {
+						ofs, mirror := x.offset(j, k, l)
+						out = findInstances(x.sdf, m.Mul(Translate3d(ofs)).Mul(Scale3d(mirror)), material, out)
+					}
+				}
+			}
+			return out
+		}
+	case *RotateUnionSDF3:
+		if isMin(x.min) {
+			step := x.step.Inverse()
+			rot := Identity3d()
+			for i := 0; i < x.num; i++ {
+				out = findInstances(x.sdf, m.Mul(rot), material, out)
+				rot = rot.Mul(step)
+			}
+			return out
+		}
+	case *RotateCopySDF3:
+		for i := 0; i < x.num; i++ {
+			out = findInstances(x.sdf, m.Mul(RotateZ(float64(i)*x.theta)), material, out)
+		}
+		return out
+	case *TransformSDF3:
+		return findInstances(x.sdf, m.Mul(x.matrix), material, out)
+	}
+	return append(out, Instance3{s, m, material})
+}
+
+// FindInstances3 decomposes an SDF3 into sub-trees and the transforms that place them.
+// Repeated sub-trees share the same SDF3 value.
+func FindInstances3(s SDF3) []Instance3 {
+	return findInstances(s, Identity3d(), nil, nil)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,187 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mechanical Iris
+
+An iris diaphragm made from a pivot ring, a set of curved blades and an
+actuator ring.
+
+Each blade is an arc of a ring concentric with the aperture when the iris is
+open. A blade turns about a pin on the pivot ring and is driven by a second
+pin that rides in a radial slot of the actuator ring. Turning the actuator
+ring swings the blades inwards and closes the aperture.
+
+Turning a blade by angle b about a pivot at radius r moves the center of its
+inner edge by 2r*sin(b/2), so the aperture (the radius of the inscribed
+circle) shrinks by that amount.
+
+Z layout (blades are shown open):
+	pivot ring: z = 0 to RingThickness, with pivot pins through the blades
+	blades: z = RingThickness to RingThickness + BladeThickness
+	actuator ring: above the blades, with the blade drive pins in its slots
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// IrisParms defines the parameters for a mechanical iris.
+type IrisParms struct {
+	Blades         int     // number of blades
+	MinAperture    float64 // aperture radius when closed (0 = fully closed)
+	MaxAperture    float64 // aperture radius when open
+	PivotRadius    float64 // radius of the blade pivot circle
+	PinRadius      float64 // radius of the pivot and drive pins
+	Wall           float64 // material around pins and outside the blades
+	BladeThickness float64 // blade thickness
+	RingThickness  float64 // pivot and actuator ring thickness
+	Clearance      float64 // clearance for pins, slots and between parts
+	Facets         int     // number of facets for each blade arc
+}
+
+// Iris is the set of parts for a mechanical iris.
+type Iris struct {
+	Blade        SDF2    // 2D profile of a single blade (pivot on the +x axis)
+	Blades       []SDF3  // positioned blades (open)
+	PivotRing    SDF3    // pivot ring with the blade pivot pins
+	ActuatorRing SDF3    // actuator ring with the drive pin slots
+	BladeAngle   float64 // blade rotation about its pivot to close the iris
+	RingAngle    float64 // actuator ring rotation to close the iris
+	OuterRadius  float64 // outer radius of the rings
+}
+
+func (k *IrisParms) validate() error {
+	if k.Blades < 3 {
+		return errors.New("Blades < 3")
+	}
+	if k.MinAperture < 0 || k.MaxAperture <= k.MinAperture {
+		return errors.New("bad aperture range")
+	}
+	if k.PinRadius <= 0 || k.Wall <= 0 {
+		return errors.New("PinRadius and Wall must be > 0")
+	}
+	if k.PivotRadius-k.PinRadius-k.Wall <= k.MaxAperture {
+		return errors.New("PivotRadius is too small for MaxAperture")
+	}
+	if k.MaxAperture-k.MinAperture >= 2*k.PivotRadius {
+		return errors.New("aperture range is too large for PivotRadius")
+	}
+	if k.BladeThickness <= 0 || k.RingThickness <= 0 {
+		return errors.New("BladeThickness and RingThickness must be > 0")
+	}
+	if k.Clearance < 0 {
+		return errors.New("Clearance < 0")
+	}
+	if k.Facets < 0 {
+		return errors.New("Facets < 0")
+	}
+	return nil
+}
+
+// irisBladeRotate returns the rotation of a blade about its pivot.
+func irisBladeRotate(pivot V2, angle float64) M33 {
+	return Translate2d(pivot).Mul(Rotate2d(angle)).Mul(Translate2d(pivot.Neg()))
+}
+
+// NewIris returns the parts for a mechanical iris.
+func NewIris(k *IrisParms) (*Iris, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	n := k.Blades
+	facets := k.Facets
+	if facets == 0 {
+		facets = 32
+	}
+	iris := Iris{}
+	iris.BladeAngle = 2 * math.Asin((k.MaxAperture-k.MinAperture)/(2*k.PivotRadius))
+
+	// The blade runs from the pivot end (-e) through the direction of its
+	// innermost point when closed (Pi/2 + BladeAngle/2) and overlaps the next blade.
+	pinWall := k.PinRadius + k.Wall
+	e := pinWall / k.PivotRadius
+	span := 0.5*Pi + 0.5*iris.BladeAngle + Tau/float64(n)
+	r0 := k.MaxAperture
+	r1 := k.PivotRadius + pinWall
+	v := make([]V2, 0, 2*(facets+1))
+	for i := 0; i <= facets; i++ {
+		a := -e + (span+e)*float64(i)/float64(facets)
+		v = append(v, V2{r1 * math.Cos(a), r1 * math.Sin(a)})
+	}
+	for i := facets; i >= 0; i-- {
+		a := -e + (span+e)*float64(i)/float64(facets)
+		v = append(v, V2{r0 * math.Cos(a), r0 * math.Sin(a)})
+	}
+	pivot := V2{k.PivotRadius, 0}
+	// the drive pin is radially outside the pivot so it moves (mostly) tangentially
+	d := 2*pinWall + k.Clearance
+	drive := V2{k.PivotRadius + d, 0}
+	tab := Transform2D(Line2D(d, pinWall), Translate2d(V2{k.PivotRadius + 0.5*d, 0}))
+	hole := Transform2D(Circle2D(k.PinRadius+k.Clearance), Translate2d(pivot))
+	iris.Blade = Difference2D(Union2D(Polygon2D(v), tab), hole)
+
+	// work out the drive pin travel and the extent of the closed blades
+	rMin, rMax := drive.Length(), drive.Length()
+	extent := drive.Length() + pinWall
+	const steps = 32
+	for i := 1; i <= steps; i++ {
+		m := irisBladeRotate(pivot, iris.BladeAngle*float64(i)/float64(steps))
+		r := m.MulPosition(drive).Length()
+		rMin = Min(rMin, r)
+		rMax = Max(rMax, r)
+		extent = Max(extent, r+pinWall)
+		for _, p := range v {
+			extent = Max(extent, m.MulPosition(p).Length())
+		}
+	}
+	closed := irisBladeRotate(pivot, iris.BladeAngle).MulPosition(drive)
+	iris.RingAngle = math.Atan2(closed.Y, closed.X)
+	iris.OuterRadius = extent + k.Clearance + k.Wall
+	if rMin-k.PinRadius-k.Clearance <= k.MaxAperture {
+		return nil, errors.New("drive pin slot breaks into the aperture")
+	}
+	if k.PinRadius+k.Clearance >= rMin*math.Sin(Pi/float64(n)) {
+		return nil, errors.New("drive pin slots overlap, reduce Blades or PinRadius")
+	}
+
+	// blades with drive pins
+	z0 := k.RingThickness
+	z1 := z0 + k.BladeThickness
+	pinLength := k.Clearance + k.RingThickness
+	blade := Transform3D(Extrude3D(iris.Blade, k.BladeThickness), Translate3d(V3{0, 0, 0.5 * (z0 + z1)}))
+	pin := Cylinder3D(pinLength, k.PinRadius, 0)
+	pin = Transform3D(pin, Translate3d(drive.ToV3(z1+0.5*pinLength)))
+	blade = Union3D(blade, pin)
+	for i := 0; i < n; i++ {
+		iris.Blades = append(iris.Blades, Transform3D(blade, RotateZ(Tau*float64(i)/float64(n))))
+	}
+
+	// pivot ring with pivot pins
+	ring := Difference2D(Circle2D(iris.OuterRadius), Circle2D(k.MaxAperture))
+	pivotRing := Transform3D(Extrude3D(ring, k.RingThickness), Translate3d(V3{0, 0, 0.5 * z0}))
+	pivots := make(V2Set, n)
+	for i := range pivots {
+		pivots[i] = Rotate(Tau * float64(i) / float64(n)).MulPosition(pivot)
+	}
+	pins := MultiCylinder3D(k.BladeThickness, k.PinRadius, pivots)
+	pins = Transform3D(pins, Translate3d(V3{0, 0, 0.5 * (z0 + z1)}))
+	iris.PivotRing = Union3D(pivotRing, pins)
+
+	// actuator ring with radial drive pin slots
+	slot := Line2D(rMax-rMin, k.PinRadius+k.Clearance)
+	slot = Transform2D(slot, Translate2d(V2{0.5 * (rMin + rMax), 0}))
+	ring = Difference2D(ring, RotateCopy2D(slot, n))
+	z2 := z1 + k.Clearance
+	iris.ActuatorRing = Transform3D(Extrude3D(ring, k.RingThickness), Translate3d(V3{0, 0, z2 + 0.5*k.RingThickness}))
+
+	return &iris, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,164 @@
+//-----------------------------------------------------------------------------
+/*
+
+Threaded Jars
+
+A jar with a threaded neck and a matching screw on lid.
+
+The jar and lid use a plastic buttress thread. The lid thread is the jar
+thread with a radial clearance, and both threads have the same phase in the
+assembled position, so the lid screws down until it seats on the shoulder of
+the jar.
+
+The body of the jar is wider than the neck so the outside of the lid is
+flush with the body. The inside of the body steps in to the neck at 45
+degrees so the jar prints without supports.
+
+An optional O-ring groove in the underside of the lid seals against the rim
+of the neck.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// JarParms defines the parameters for a threaded jar and lid.
+type JarParms struct {
+	Diameter    float64 // inner diameter of the neck
+	Height      float64 // height of the jar (without the lid)
+	Wall        float64 // wall thickness
+	Floor       float64 // floor and lid top thickness
+	NeckHeight  float64 // height of the threaded neck
+	ThreadPitch float64 // thread pitch
+	Starts      int     // number of thread starts
+	Tolerance   float64 // clearance between the jar and lid threads
+	KnurlPitch  float64 // lid knurl pitch (0 for a plain lid)
+	ORing       float64 // O-ring cross section diameter (0 for no groove)
+}
+
+func (k *JarParms) validate() error {
+	if k.Diameter <= 0 {
+		return errors.New("Diameter <= 0")
+	}
+	if k.Wall <= 0 {
+		return errors.New("Wall <= 0")
+	}
+	if k.Floor <= 0 {
+		return errors.New("Floor <= 0")
+	}
+	if k.NeckHeight <= 0 {
+		return errors.New("NeckHeight <= 0")
+	}
+	if k.ThreadPitch <= 0 {
+		return errors.New("ThreadPitch <= 0")
+	}
+	if k.Starts <= 0 {
+		return errors.New("Starts <= 0")
+	}
+	if k.Tolerance <= 0 {
+		return errors.New("Tolerance <= 0")
+	}
+	if k.KnurlPitch < 0 {
+		return errors.New("KnurlPitch < 0")
+	}
+	if k.ORing < 0 {
+		return errors.New("ORing < 0")
+	}
+	return nil
+}
+
+// plasticButtressDepth returns the depth of a PlasticButtressThread.
+func plasticButtressDepth(pitch float64) float64 {
+	h0 := pitch / (1 + math.Tan(DtoR(7.0)))
+	return 0.3*pitch + 0.5*h0
+}
+
+// jarRadii returns the thread root, thread major and outer radius of a jar.
+func (k *JarParms) jarRadii() (root, major, outer float64) {
+	root = 0.5*k.Diameter + k.Wall
+	major = root + plasticButtressDepth(k.ThreadPitch)
+	outer = major + k.Tolerance + k.Wall
+	return
+}
+
+// LidDiameter returns the outer diameter of the lid (and the jar body).
+func (k *JarParms) LidDiameter() float64 {
+	_, _, outer := k.jarRadii()
+	return 2 * outer
+}
+
+// Jar3D returns a threaded jar and lid. The jar sits on z = 0 and the lid is
+// returned in its assembled position (flip it for printing).
+func Jar3D(k *JarParms) (jar, lid SDF3, err error) {
+	if err := k.validate(); err != nil {
+		return nil, nil, err
+	}
+	ri := 0.5 * k.Diameter
+	root, major, outer := k.jarRadii()
+	rb := outer - k.Wall          // inner radius of the body
+	zs := k.Height - k.NeckHeight // shoulder height
+	// the inside of the body steps in to the neck at 45 degrees, a wall
+	// thickness below the shoulder
+	zc := zs - k.Wall - (rb - ri)
+	if zc <= k.Floor {
+		return nil, nil, errors.New("the jar is too short for the neck")
+	}
+
+	// jar: a solid of revolution with a threaded neck
+	// (the profiles cross the axis so it is inside them)
+	body := NewPolygon()
+	body.Add(-outer, 0)
+	body.Add(outer, 0)
+	body.Add(outer, zs)
+	body.Add(root, zs)
+	body.Add(root, k.Height)
+	body.Add(-root, k.Height)
+	cavity := NewPolygon()
+	cavity.Add(-rb, k.Floor)
+	cavity.Add(rb, k.Floor)
+	cavity.Add(rb, zc)
+	cavity.Add(ri, zs-k.Wall)
+	cavity.Add(ri, k.Height+k.Wall)
+	cavity.Add(-ri, k.Height+k.Wall)
+	neck := Transform3D(Screw3D(PlasticButtressThread(major, k.ThreadPitch), k.NeckHeight, k.ThreadPitch, k.Starts), Translate3d(V3{0, 0, zs + 0.5*k.NeckHeight}))
+	jar = Union3D(Revolve3D(Polygon2D(body.Vertices())), neck)
+	jar = Difference3D(jar, Revolve3D(Polygon2D(cavity.Vertices())))
+
+	// lid: the internal thread has the same center (and phase) as the jar thread
+	h := k.NeckHeight + k.Tolerance + k.Floor
+	var shell SDF3
+	if k.KnurlPitch > 0 {
+		shell = KnurledHead3D(outer, h, k.KnurlPitch)
+	} else {
+		shell = Cylinder3D(h, outer, 0)
+	}
+	lid = Transform3D(shell, Translate3d(V3{0, 0, zs + 0.5*h}))
+	thread := Screw3D(PlasticButtressThread(major+k.Tolerance, k.ThreadPitch), k.NeckHeight+2*k.Tolerance, k.ThreadPitch, k.Starts)
+	lid = Difference3D(lid, Transform3D(thread, Translate3d(V3{0, 0, zs + 0.5*k.NeckHeight})))
+
+	if k.ORing > 0 {
+		// The groove is centered over the rim of the neck. The gland depth
+		// (groove plus the gap to the rim) squeezes the O-ring by 25%.
+		width := 1.4 * k.ORing
+		depth := 0.75*k.ORing - k.Tolerance
+		if width > k.Wall {
+			return nil, nil, errors.New("the O-ring groove is wider than the wall")
+		}
+		if depth <= 0 || depth >= k.Floor {
+			return nil, nil, errors.New("the O-ring groove depth must be > 0 and < Floor")
+		}
+		groove := Box2D(V2{width, 2 * depth}, 0)
+		groove = Transform2D(groove, Translate2d(V2{0.5 * (ri + root), k.Height + k.Tolerance}))
+		lid = Difference3D(lid, Revolve3D(groove))
+	}
+	return jar, lid, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,277 @@
+//-----------------------------------------------------------------------------
+/*
+
+Keyed Cuts
+
+Cut a part in two on a plane, adding matching registration keys on the cut
+faces so the halves can be aligned. The male keys are on the half on the -ve
+side of the plane, the female sockets (with clearance) are on the +ve side.
+
+Key types:
+
+Pin: a cylindrical pin, half in each side.
+Dovetail: a tenon that slides into a slot. The slot runs out of the part in
+the direction of z projected onto the plane (y for a z normal).
+Sphere: a sphere on the plane, e.g. hemispherical keys for molds.
+
+The keys are placed at the given points, or automatically where the part is
+solid enough around them.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// Plane is a plane through a point.
+type Plane struct {
+	Point  V3 // point on the plane
+	Normal V3 // normal to the plane
+}
+
+// KeyType is the type of registration key.
+type KeyType int
+
+// Key types.
+const (
+	KeyNone     KeyType = iota // no keys
+	KeyPin                     // pins and sockets
+	KeyDovetail                // dovetail tenons and slots
+	KeySphere                  // spheres and spherical sockets
+)
+
+// KeySpec defines the registration keys on a cut face.
+type KeySpec struct {
+	Type      KeyType // key type
+	Size      float64 // pin/sphere diameter or dovetail neck width
+	Length    float64 // pin length or dovetail length
+	Clearance float64 // clearance between the key and its socket
+	Number    int     // maximum number of automatically placed keys
+	Points    []V3    // key positions on the plane (nil for automatic placement)
+}
+
+func (k *KeySpec) validate() error {
+	if k.Type == KeyNone {
+		return nil
+	}
+	if k.Type != KeyPin && k.Type != KeyDovetail && k.Type != KeySphere {
+		return errors.New("bad key type")
+	}
+	if k.Size <= 0 {
+		return errors.New("Size <= 0")
+	}
+	if k.Type != KeySphere && k.Length <= 0 {
+		return errors.New("Length <= 0")
+	}
+	if k.Clearance < 0 {
+		return errors.New("Clearance < 0")
+	}
+	if k.Points == nil && k.Number <= 0 {
+		return errors.New("Number <= 0")
+	}
+	return nil
+}
+
+// dovetailAngle is the flare angle of the dovetail sides.
+const dovetailAngle = 15.0 * Pi / 180.0
+
+// protrusion returns how far a key sticks out of the cut face.
+func (k *KeySpec) protrusion() float64 {
+	switch k.Type {
+	case KeyPin:
+		return 0.5 * k.Length
+	case KeyDovetail, KeySphere:
+		return 0.5 * k.Size
+	}
+	return 0
+}
+
+// footprint returns the radius of the part needed around a key.
+func (k *KeySpec) footprint() float64 {
+	switch k.Type {
+	case KeyPin, KeySphere:
+		return 0.5*k.Size + k.Clearance
+	case KeyDovetail:
+		w := 0.5*k.Size + k.protrusion()*math.Tan(dovetailAngle)
+		return math.Max(w, 0.5*k.Length) + k.Clearance
+	}
+	return 0
+}
+
+// key returns the male and female key geometry at the origin.
+// The cut face is z = 0 and the male key sticks out along +z.
+// The dovetail runs along y, with the slot running out to y = far.
+func (k *KeySpec) key(far float64) (male, female SDF3) {
+	c := k.Clearance
+	r := 0.5 * k.Size
+	switch k.Type {
+	case KeyPin:
+		male = Cylinder3D(k.Length, r, 0)
+		female = Cylinder3D(k.Length+2*c, r+c, 0)
+	case KeySphere:
+		male = Sphere3D(r)
+		female = Sphere3D(r + c)
+	case KeyDovetail:
+		h := k.protrusion()
+		tenon := func(w, h float64) SDF2 {
+			x := h * math.Tan(dovetailAngle)
+			return Polygon2D([]V2{{-w, -h}, {w, -h}, {w + x, h}, {-w - x, h}})
+		}
+		// the profile is on the x/z plane, from z = -h to z = h
+		m := RotateX(0.5 * Pi)
+		male = Transform3D(Extrude3D(tenon(r, h), k.Length), m)
+		l := far + 0.5*k.Length + c
+		female = Extrude3D(tenon(r+c, h+c), l)
+		female = Transform3D(female, Translate3d(V3{0, 0.5*l - 0.5*k.Length - c, 0}).Mul(m))
+	}
+	return male, female
+}
+
+// keyFrame is the local frame of a cut face.
+type keyFrame struct {
+	u, v, n V3 // face axes and normal (u x v = n)
+}
+
+// newKeyFrame returns the frame for a cut face normal.
+// v is z projected onto the face (or y for a z normal).
+func newKeyFrame(n V3) keyFrame {
+	n = n.Normalize()
+	v := V3{0, 0, 1}
+	v = v.Sub(n.MulScalar(v.Dot(n)))
+	if v.Length() < 1e-6 {
+		v = V3{0, 1, 0}
+		v = v.Sub(n.MulScalar(v.Dot(n)))
+	}
+	v = v.Normalize()
+	return keyFrame{v.Cross(n), v, n}
+}
+
+// at returns the transform to a key at point p.
+func (f *keyFrame) at(p V3) M44 {
+	u, v, n := f.u, f.v, f.n
+	return M44{
+		u.X, v.X, n.X, p.X,
+		u.Y, v.Y, n.Y, p.Y,
+		u.Z, v.Z, n.Z, p.Z,
+		0, 0, 0, 1,
+	}
+}
+
+// keyPoints returns the key positions on a rectangular face region.
+// The region starts at o and has size su x sv along the sampling axes du, dv.
+func (k *KeySpec) keyPoints(part SDF3, o, du, dv, n V3, su, sv float64) []V3 {
+	r := k.footprint()
+	depth := k.protrusion() + k.Clearance
+	// sample the face for positions with room for a key
+	const samples = 16
+	type candidate struct {
+		p V3
+		d float64
+	}
+	var candidates []candidate
+	for i := 0; i < samples; i++ {
+		for j := 0; j < samples; j++ {
+			pu := su * (float64(i) + 0.5) / samples
+			pv := sv * (float64(j) + 0.5) / samples
+			// keep the key inside the face
+			if pu < r || pv < r || pu > su-r || pv > sv-r {
+				continue
+			}
+			p := o.Add(du.MulScalar(pu)).Add(dv.MulScalar(pv))
+			d := part.Evaluate(p)
+			d = math.Max(d, part.Evaluate(p.Add(n.MulScalar(depth))))
+			d = math.Max(d, part.Evaluate(p.Sub(n.MulScalar(depth))))
+			if d < -r {
+				candidates = append(candidates, candidate{p, d})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	// start with the most solid position
+	best := 0
+	for i, c := range candidates {
+		if c.d < candidates[best].d {
+			best = i
+		}
+	}
+	points := []V3{candidates[best].p}
+	// then spread them out
+	for len(points) < k.Number {
+		best, dmax := -1, 2*r
+		for i, c := range candidates {
+			dmin := math.Inf(1)
+			for _, p := range points {
+				dmin = math.Min(dmin, c.p.Sub(p).Length())
+			}
+			if dmin > dmax {
+				best, dmax = i, dmin
+			}
+		}
+		if best < 0 {
+			break
+		}
+		points = append(points, candidates[best].p)
+	}
+	return points
+}
+
+//-----------------------------------------------------------------------------
+
+// CutWithKeys cuts an SDF3 on a plane and returns both halves with matching
+// registration keys. The top half is on the normal side of the plane and has
+// the female sockets, the bottom half has the male keys.
+func CutWithKeys(sdf SDF3, plane Plane, keys KeySpec) (top, bottom SDF3, err error) {
+	if plane.Normal.Length() == 0 {
+		return nil, nil, errors.New("Normal is a zero vector")
+	}
+	if err := keys.validate(); err != nil {
+		return nil, nil, err
+	}
+	f := newKeyFrame(plane.Normal)
+	top = Cut3D(sdf, plane.Point, f.n)
+	bottom = Cut3D(sdf, plane.Point, f.n.Neg())
+	if keys.Type == KeyNone {
+		return top, bottom, nil
+	}
+	// the extent of the part on the plane
+	umin, vmin := math.Inf(1), math.Inf(1)
+	umax, vmax := math.Inf(-1), math.Inf(-1)
+	for _, p := range sdf.BoundingBox().Vertices() {
+		d := p.Sub(plane.Point)
+		umin = math.Min(umin, d.Dot(f.u))
+		umax = math.Max(umax, d.Dot(f.u))
+		vmin = math.Min(vmin, d.Dot(f.v))
+		vmax = math.Max(vmax, d.Dot(f.v))
+	}
+	points := keys.Points
+	if points == nil {
+		o := plane.Point.Add(f.u.MulScalar(umin)).Add(f.v.MulScalar(vmin))
+		points = keys.keyPoints(sdf, o, f.u, f.v, f.n, umax-umin, vmax-vmin)
+	}
+	if len(points) == 0 {
+		return top, bottom, nil
+	}
+	males := make([]SDF3, len(points))
+	females := make([]SDF3, len(points))
+	for i, p := range points {
+		// put the key on the plane
+		p = p.Sub(f.n.MulScalar(p.Sub(plane.Point).Dot(f.n)))
+		far := vmax - p.Sub(plane.Point).Dot(f.v) + keys.Clearance
+		male, female := keys.key(far)
+		males[i] = Transform3D(male, f.at(p))
+		females[i] = Transform3D(female, f.at(p))
+	}
+	top = Difference3D(top, Union3D(females...))
+	bottom = Union3D(bottom, Union3D(males...))
+	return top, bottom, nil
+}
+
+//-----------------------------------------------------------------------------
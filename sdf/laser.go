@@ -0,0 +1,179 @@
+//-----------------------------------------------------------------------------
+/*
+
+Laser Cutting
+
+Generate laser cutter paths from an SDF2.
+
+The beam removes a strip of material (the kerf), so the beam center follows
+the contour offset by half the kerf away from the part. With a distance field
+this is just the iso-contour at kerf/2.
+
+Inner contours are cut before the contours that contain them, so parts don't
+drop out or shift before their holes are cut.
+
+Output is SVG, DXF or GRBL G-code (laser mode).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// LaserMaterial is a laser cutting profile for a material.
+type LaserMaterial struct {
+	Name   string  // name of the material
+	Kerf   float64 // width of the cut
+	Feed   float64 // cutting speed
+	Power  float64 // laser power (GRBL S value)
+	Passes int     // number of passes per contour
+}
+
+// LaserParms defines the parameters for laser cutting.
+type LaserParms struct {
+	Material   LaserMaterial
+	Resolution float64 // contour sampling resolution
+}
+
+//-----------------------------------------------------------------------------
+
+// contourInside returns true if contour a is inside contour b.
+func contourInside(a, b []V2) bool {
+	return windingNumber(polygonEdges([][]V2{b}), a[0]) != 0
+}
+
+// nestingDepth returns the number of contours that contain each contour.
+func nestingDepth(contours [][]V2) []int {
+	depth := make([]int, len(contours))
+	for i, a := range contours {
+		for j, b := range contours {
+			if i != j && contourInside(a, b) {
+				depth[i]++
+			}
+		}
+	}
+	return depth
+}
+
+// nearestNeighbour orders closed contours by starting each contour at the
+// closest vertex to the end of the previous one. Each contour is closed with
+// the first vertex repeated at the end.
+func nearestNeighbour(contours [][]V2, start V2) [][]V2 {
+	used := make([]bool, len(contours))
+	out := make([][]V2, 0, len(contours))
+	p := start
+	for range contours {
+		k := -1
+		dmin := 0.0
+		for i, c := range contours {
+			if used[i] {
+				continue
+			}
+			for _, v := range c {
+				if d := v.Sub(p).Length2(); k < 0 || d < dmin {
+					k = i
+					dmin = d
+				}
+			}
+		}
+		used[k] = true
+		c := closeContour(contours[k], p)
+		out = append(out, c)
+		p = c[len(c)-1]
+	}
+	return out
+}
+
+//-----------------------------------------------------------------------------
+
+// LaserContours returns the closed beam paths for cutting an SDF2.
+// The paths are offset by half the kerf and ordered with inner contours first.
+// Each path has the first vertex repeated at the end.
+func LaserContours(s SDF2, k *LaserParms) ([][]V2, error) {
+	if k.Material.Kerf < 0 {
+		return nil, errors.New("Kerf < 0")
+	}
+	if k.Resolution <= 0 {
+		return nil, errors.New("Resolution <= 0")
+	}
+	contours := isoContours(s, 0.5*k.Material.Kerf, k.Resolution)
+	depth := nestingDepth(contours)
+	// group the contours by depth, deepest first
+	groups := make(map[int][][]V2)
+	var levels []int
+	for i, c := range contours {
+		if groups[depth[i]] == nil {
+			levels = append(levels, depth[i])
+		}
+		groups[depth[i]] = append(groups[depth[i]], c)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(levels)))
+	var paths [][]V2
+	end := V2{}
+	for _, l := range levels {
+		ordered := nearestNeighbour(groups[l], end)
+		paths = append(paths, ordered...)
+		last := ordered[len(ordered)-1]
+		end = last[len(last)-1]
+	}
+	return paths, nil
+}
+
+// contourLines returns the line segments of closed paths.
+func contourLines(paths [][]V2) []*Line {
+	var lines []*Line
+	for _, p := range paths {
+		for i := 0; i < len(p)-1; i++ {
+			lines = append(lines, &Line{p[i], p[i+1]})
+		}
+	}
+	return lines
+}
+
+// SaveLaserSVG writes laser paths to an SVG file.
+func SaveLaserSVG(path string, paths [][]V2) error {
+	return SaveSVG(path, "fill:none;stroke:red;stroke-width:0.1", contourLines(paths))
+}
+
+// SaveLaserDXF writes laser paths to a DXF file.
+func SaveLaserDXF(path string, paths [][]V2) error {
+	return SaveDXF(path, contourLines(paths))
+}
+
+// LaserGCode writes GRBL G-code (laser mode) to cut the laser paths.
+func LaserGCode(paths [][]V2, m *LaserMaterial, w io.Writer) error {
+	if m.Feed <= 0 {
+		return errors.New("Feed <= 0")
+	}
+	if m.Power <= 0 {
+		return errors.New("Power <= 0")
+	}
+	passes := m.Passes
+	if passes <= 0 {
+		passes = 1
+	}
+	g := newGcodeWriter(w)
+	fmt.Fprintf(g.w, "G21\nG90\nM4 S0\n")
+	for _, p := range paths {
+		for n := 0; n < passes; n++ {
+			g.rapidXY(p[0])
+			fmt.Fprintf(g.w, "G1 F%.0f S%.0f\n", m.Feed, m.Power)
+			for _, v := range p[1:] {
+				fmt.Fprintf(g.w, "G1 X%.4f Y%.4f\n", v.X, v.Y)
+			}
+			fmt.Fprintf(g.w, "S0\n")
+		}
+	}
+	fmt.Fprintf(g.w, "M5\nM2\n")
+	return g.w.Flush()
+}
+
+//-----------------------------------------------------------------------------
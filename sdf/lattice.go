@@ -0,0 +1,467 @@
+//-----------------------------------------------------------------------------
+/*
+
+Lattices
+
+Lattices are used as a lightweight infill for parts. The thickness of the
+lattice can be varied over space with a density field, so regions that need to
+be stronger get a denser lattice. The density field can be any function, or it
+can be sampled from a grid of values (E.g. the stress results from a finite
+element analysis).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+// Density Grids
+
+// DensityGrid is a scalar field sampled on a regular 3d grid.
+type DensityGrid struct {
+	bb     Box3      // the grid covers this box
+	n      V3i       // number of samples on each axis
+	values []float64 // sample values, x varies fastest
+}
+
+// NewDensityGrid returns a density grid for a set of samples.
+// The samples are ordered with x varying fastest, then y, then z.
+func NewDensityGrid(bb Box3, n V3i, values []float64) (*DensityGrid, error) {
+	if n[0] < 2 || n[1] < 2 || n[2] < 2 {
+		return nil, errors.New("grid needs at least 2 samples on each axis")
+	}
+	if len(values) != n[0]*n[1]*n[2] {
+		return nil, errors.New("number of values doesn't match the grid size")
+	}
+	return &DensityGrid{
+		bb:     bb,
+		n:      n,
+		values: values,
+	}, nil
+}
+
+// value returns the grid sample at i, j, k.
+func (g *DensityGrid) value(i, j, k int) float64 {
+	return g.values[(k*g.n[1]+j)*g.n[0]+i]
+}
+
+// gridIndex returns the lower grid index and the fractional offset for an axis.
+func gridIndex(x float64, n int) (int, float64) {
+	x = Clamp(x, 0, float64(n-1))
+	i := int(math.Floor(x))
+	if i >= n-1 {
+		i = n - 2
+	}
+	return i, x - float64(i)
+}
+
+// Evaluate returns the trilinear interpolation of the grid values at p.
+// Points outside the grid are clamped to the grid boundary.
+func (g *DensityGrid) Evaluate(p V3) float64 {
+	size := g.bb.Size()
+	q := p.Sub(g.bb.Min).Div(size).Mul(g.n.SubScalar(1).ToV3())
+	i, u := gridIndex(q.X, g.n[0])
+	j, v := gridIndex(q.Y, g.n[1])
+	k, w := gridIndex(q.Z, g.n[2])
+	x00 := Mix(g.value(i, j, k), g.value(i+1, j, k), u)
+	x10 := Mix(g.value(i, j+1, k), g.value(i+1, j+1, k), u)
+	x01 := Mix(g.value(i, j, k+1), g.value(i+1, j, k+1), u)
+	x11 := Mix(g.value(i, j+1, k+1), g.value(i+1, j+1, k+1), u)
+	return Mix(Mix(x00, x10, v), Mix(x01, x11, v), w)
+}
+
+// Field returns the density grid as a scalar field.
+func (g *DensityGrid) Field() Field3 {
+	return g.Evaluate
+}
+
+//-----------------------------------------------------------------------------
+// Gyroid Lattice
+
+// GyroidSDF3 is a gyroid sheet lattice with a spatially varying thickness.
+type GyroidSDF3 struct {
+	k       float64 // 2 * pi / cell size
+	minT    float64 // thickness at density 0
+	maxT    float64 // thickness at density 1
+	density Field3  // density field, [0,1]
+	bb      Box3    // bounding box
+}
+
+// GyroidLattice3D returns a gyroid sheet lattice of constant thickness filling a box.
+func GyroidLattice3D(bb Box3, cell, thickness float64) (SDF3, error) {
+	return GradedGyroidLattice3D(bb, cell, thickness, thickness, ConstantField3(0))
+}
+
+// GradedGyroidLattice3D returns a gyroid sheet lattice filling a box.
+// The sheet thickness varies from minT to maxT as the density field varies from 0 to 1.
+func GradedGyroidLattice3D(bb Box3, cell, minT, maxT float64, density Field3) (SDF3, error) {
+	if cell <= 0 {
+		return nil, errors.New("cell <= 0")
+	}
+	if minT < 0 {
+		return nil, errors.New("minT < 0")
+	}
+	if minT > maxT {
+		return nil, errors.New("minT > maxT")
+	}
+	return &GyroidSDF3{
+		k:       Tau / cell,
+		minT:    minT,
+		maxT:    maxT,
+		density: density,
+		bb:      bb,
+	}, nil
+}
+
+// Evaluate returns the minimum distance to a gyroid lattice.
+func (s *GyroidSDF3) Evaluate(p V3) float64 {
+	x, y, z := s.k*p.X, s.k*p.Y, s.k*p.Z
+	sx, cx := math.Sincos(x)
+	sy, cy := math.Sincos(y)
+	sz, cz := math.Sincos(z)
+	g := sx*cy + sy*cz + sz*cx
+	// first order distance estimate using the gradient magnitude
+	grad := V3{cx*cy - sz*sx, cy*cz - sx*sy, cz*cx - sy*sz}.MulScalar(s.k)
+	d := Abs(g) / Max(grad.Length(), 0.5*s.k)
+	t := Mix(s.minT, s.maxT, Clamp(s.density(p), 0, 1))
+	d -= 0.5 * t
+	// clip to the bounding box
+	bb := s.bb
+	return Max(d, sdfBox3d(p.Sub(bb.Center()), bb.Size().MulScalar(0.5)))
+}
+
+// BoundingBox returns the bounding box of a gyroid lattice.
+func (s *GyroidSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
+// Infill3D returns a part hollowed out to a wall thickness and filled with a lattice.
+func Infill3D(part SDF3, wall float64, lattice SDF3) SDF3 {
+	shell := Difference3D(part, Offset3D(part, -wall))
+	return Union3D(shell, Intersect3D(part, lattice))
+}
+
+//-----------------------------------------------------------------------------
+// Strut Lattices
+
+// strut is a line segment used to build strut lattices.
+type strut struct {
+	a V3      // start point
+	u V3      // unit vector from start to end
+	l float64 // length
+}
+
+// newStrut returns a strut between two points.
+func newStrut(a, b V3) strut {
+	v := b.Sub(a)
+	return strut{a, v.Normalize(), v.Length()}
+}
+
+// distance returns the minimum distance from a point to the strut.
+func (s *strut) distance(p V3) float64 {
+	v := p.Sub(s.a)
+	t := Clamp(v.Dot(s.u), 0, s.l)
+	return v.Sub(s.u.MulScalar(t)).Length()
+}
+
+// blendOvershoot returns how far blending n equal distances with a minimum
+// function pushes the surface outwards (0 for a hard minimum).
+func blendOvershoot(min MinFunc, n int) float64 {
+	d := 0.0
+	for i := 1; i < n; i++ {
+		d = min(d, 0)
+	}
+	return Max(-d, 0)
+}
+
+// strutsDistance returns the blended minimum distance from a point to a set of struts.
+func strutsDistance(struts []strut, p V3, min MinFunc) float64 {
+	d := math.MaxFloat64
+	for i := range struts {
+		d = min(d, struts[i].distance(p))
+	}
+	return d
+}
+
+//-----------------------------------------------------------------------------
+
+// StrutLatticeSDF3 is a lattice of cylindrical struts between nodes.
+type StrutLatticeSDF3 struct {
+	struts []strut
+	radius float64
+	min    MinFunc
+	nodes  Box3 // bounding box of the nodes
+	degree int  // maximum number of struts at a node
+	bb     Box3
+}
+
+// StrutLattice3D returns a lattice of struts defined by a set of nodes and the beams between them.
+// The struts are blended together at the nodes.
+func StrutLattice3D(nodes []V3, beams [][2]int, radius float64) (SDF3, error) {
+	if len(beams) == 0 {
+		return nil, errors.New("no beams")
+	}
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	s := StrutLatticeSDF3{}
+	s.struts = make([]strut, len(beams))
+	bMin := V3{math.MaxFloat64, math.MaxFloat64, math.MaxFloat64}
+	bMax := bMin.Neg()
+	degree := make([]int, len(nodes))
+	for i, b := range beams {
+		if b[0] < 0 || b[0] >= len(nodes) || b[1] < 0 || b[1] >= len(nodes) {
+			return nil, fmt.Errorf("beam %d: node index out of range", i)
+		}
+		n0, n1 := nodes[b[0]], nodes[b[1]]
+		if n0.Equals(n1, tolerance) {
+			return nil, fmt.Errorf("beam %d: zero length", i)
+		}
+		s.struts[i] = newStrut(n0, n1)
+		bMin = bMin.Min(n0).Min(n1)
+		bMax = bMax.Max(n0).Max(n1)
+		degree[b[0]]++
+		degree[b[1]]++
+	}
+	for _, n := range degree {
+		if n > s.degree {
+			s.degree = n
+		}
+	}
+	s.radius = radius
+	s.nodes = Box3{bMin, bMax}
+	s.SetMin(PolyMin(0.5 * radius))
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a strut lattice.
+func (s *StrutLatticeSDF3) Evaluate(p V3) float64 {
+	return strutsDistance(s.struts, p, s.min) - s.radius
+}
+
+// SetMin sets the minimum function to control node blending.
+// The bounding box grows by the blend overshoot at the busiest node.
+func (s *StrutLatticeSDF3) SetMin(min MinFunc) {
+	s.min = min
+	pad := s.radius + blendOvershoot(min, s.degree)
+	s.bb = Box3{s.nodes.Min.SubScalar(pad), s.nodes.Max.AddScalar(pad)}
+}
+
+// BoundingBox returns the bounding box of a strut lattice.
+func (s *StrutLatticeSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+// Unit Cells
+
+// UnitCell defines the struts of a lattice unit cell.
+// The node positions are within a unit cube centered on the origin.
+type UnitCell struct {
+	Nodes []V3
+	Beams [][2]int
+}
+
+// unitCellFromEdges returns a unit cell built from a set of line segments (duplicates are removed).
+func unitCellFromEdges(edges [][2]V3) *UnitCell {
+	c := UnitCell{}
+	index := func(v V3) int {
+		for i, n := range c.Nodes {
+			if n.Equals(v, tolerance) {
+				return i
+			}
+		}
+		c.Nodes = append(c.Nodes, v)
+		return len(c.Nodes) - 1
+	}
+	for _, e := range edges {
+		i0 := index(e[0])
+		i1 := index(e[1])
+		duplicate := false
+		for _, b := range c.Beams {
+			if (b[0] == i0 && b[1] == i1) || (b[0] == i1 && b[1] == i0) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			c.Beams = append(c.Beams, [2]int{i0, i1})
+		}
+	}
+	return &c
+}
+
+// OctetCell returns an octet truss unit cell (face centered cubic).
+func OctetCell() *UnitCell {
+	var corners, faces []V3
+	for _, x := range []float64{-0.5, 0.5} {
+		for _, y := range []float64{-0.5, 0.5} {
+			for _, z := range []float64{-0.5, 0.5} {
+				corners = append(corners, V3{x, y, z})
+			}
+		}
+		faces = append(faces, V3{x, 0, 0}, V3{0, x, 0}, V3{0, 0, x})
+	}
+	var edges [][2]V3
+	l := sqrtHalf
+	for _, f := range faces {
+		// face center to face corners
+		for _, c := range corners {
+			if Abs(f.Sub(c).Length()-l) < tolerance {
+				edges = append(edges, [2]V3{f, c})
+			}
+		}
+		// face center to adjacent face centers (the inner octahedron)
+		for _, g := range faces {
+			if Abs(f.Sub(g).Length()-l) < tolerance {
+				edges = append(edges, [2]V3{f, g})
+			}
+		}
+	}
+	return unitCellFromEdges(edges)
+}
+
+// KelvinCell returns a Kelvin (truncated octahedron) unit cell.
+// The unit cube contains a truncated octahedron at the center and parts of
+// the truncated octahedra centered on the cube corners.
+func KelvinCell() *UnitCell {
+	// truncated octahedron vertices are the permutations of (0, +/-1, +/-2)/4
+	var vertices []V3
+	for _, a := range []float64{-0.25, 0.25} {
+		for _, b := range []float64{-0.5, 0.5} {
+			vertices = append(vertices,
+				V3{0, a, b}, V3{0, b, a},
+				V3{a, 0, b}, V3{b, 0, a},
+				V3{a, b, 0}, V3{b, a, 0})
+		}
+	}
+	centers := []V3{{0, 0, 0}}
+	for _, x := range []float64{-0.5, 0.5} {
+		for _, y := range []float64{-0.5, 0.5} {
+			for _, z := range []float64{-0.5, 0.5} {
+				centers = append(centers, V3{x, y, z})
+			}
+		}
+	}
+	l := 0.25 * math.Sqrt(2)
+	inside := func(v V3) bool {
+		return v.Abs().MaxComponent() <= 0.5+tolerance
+	}
+	var edges [][2]V3
+	for _, c := range centers {
+		for i := range vertices {
+			for j := i + 1; j < len(vertices); j++ {
+				if Abs(vertices[i].Sub(vertices[j]).Length()-l) > tolerance {
+					continue
+				}
+				v0 := vertices[i].Add(c)
+				v1 := vertices[j].Add(c)
+				// keep the edges that are within the unit cube
+				if inside(v0) && inside(v1) {
+					edges = append(edges, [2]V3{v0, v1})
+				}
+			}
+		}
+	}
+	return unitCellFromEdges(edges)
+}
+
+//-----------------------------------------------------------------------------
+// Tiled Lattices
+
+// LatticeParms defines the parameters for a tiled strut lattice.
+type LatticeParms struct {
+	Cell      *UnitCell // unit cell
+	CellSize  float64   // size of the unit cell
+	MinRadius float64   // strut radius at density 0
+	MaxRadius float64   // strut radius at density 1
+	Density   Field3    // density field, [0,1] (nil for MinRadius everywhere)
+}
+
+// TiledLatticeSDF3 is a unit cell lattice repeated over a box.
+type TiledLatticeSDF3 struct {
+	struts  []strut
+	size    float64
+	minR    float64
+	maxR    float64
+	density Field3
+	center  V3
+	min     MinFunc
+	bb      Box3
+}
+
+// TiledLattice3D returns a strut lattice made by repeating a unit cell over a box.
+// The radius of the struts varies with the density field.
+func TiledLattice3D(k *LatticeParms, bb Box3) (SDF3, error) {
+	// validate parameters
+	if k.Cell == nil || len(k.Cell.Beams) == 0 {
+		return nil, errors.New("no unit cell beams")
+	}
+	if k.CellSize <= 0 {
+		return nil, errors.New("CellSize <= 0")
+	}
+	if k.MinRadius <= 0 || k.MaxRadius < k.MinRadius {
+		return nil, errors.New("invalid strut radius")
+	}
+	s := TiledLatticeSDF3{}
+	s.struts = make([]strut, len(k.Cell.Beams))
+	for i, b := range k.Cell.Beams {
+		if b[0] < 0 || b[0] >= len(k.Cell.Nodes) || b[1] < 0 || b[1] >= len(k.Cell.Nodes) {
+			return nil, errors.New("beam node index out of range")
+		}
+		n0 := k.Cell.Nodes[b[0]].MulScalar(k.CellSize)
+		n1 := k.Cell.Nodes[b[1]].MulScalar(k.CellSize)
+		s.struts[i] = newStrut(n0, n1)
+	}
+	s.size = k.CellSize
+	s.minR = k.MinRadius
+	s.maxR = k.MaxRadius
+	s.density = k.Density
+	if s.density == nil {
+		s.density = ConstantField3(0)
+	}
+	s.center = bb.Center()
+	s.min = PolyMin(0.5 * k.MinRadius)
+	s.bb = bb
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a tiled strut lattice.
+func (s *TiledLatticeSDF3) Evaluate(p V3) float64 {
+	// map the point into the unit cell
+	q := p.Sub(s.center)
+	q = V3{SawTooth(q.X, s.size), SawTooth(q.Y, s.size), SawTooth(q.Z, s.size)}
+	r := Mix(s.minR, s.maxR, Clamp(s.density(p), 0, 1))
+	d := strutsDistance(s.struts, q, s.min) - r
+	// clip to the bounding box
+	return Max(d, sdfBox3d(p.Sub(s.center), s.bb.Size().MulScalar(0.5)))
+}
+
+// SetMin sets the minimum function to control node blending.
+func (s *TiledLatticeSDF3) SetMin(min MinFunc) {
+	s.min = min
+}
+
+// BoundingBox returns the bounding box of a tiled strut lattice.
+func (s *TiledLatticeSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Lattice3D returns a tiled strut lattice clipped to a solid.
+func Lattice3D(solid SDF3, k *LatticeParms) (SDF3, error) {
+	s, err := TiledLattice3D(k, solid.BoundingBox())
+	if err != nil {
+		return nil, err
+	}
+	return Intersect3D(solid, s), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -106,3 +106,17 @@ func (l Line2) Distance(p V2) float64 {
 }
 
 //-----------------------------------------------------------------------------
+
+// pointSegmentDistance returns the minimum distance from p to the line segment ab.
+func pointSegmentDistance(p, a, b V2) float64 {
+	pa := p.Sub(a)
+	ba := b.Sub(a)
+	l2 := ba.Length2()
+	if l2 == 0 {
+		return pa.Length()
+	}
+	t := Clamp(pa.Dot(ba)/l2, 0, 1)
+	return pa.Sub(ba.MulScalar(t)).Length()
+}
+
+//-----------------------------------------------------------------------------
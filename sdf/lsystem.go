@@ -0,0 +1,233 @@
+//-----------------------------------------------------------------------------
+/*
+
+L-Systems
+
+Lindenmayer systems for trees, corals and other branching structures.
+
+The L-system string is interpreted by a 3D turtle (as in "The Algorithmic
+Beauty of Plants") that lays down a skeleton of capsule shaped bones. The
+skeleton is blended with a smooth minimum so the joints are filleted.
+
+Turtle commands:
+
+F   move forward and draw a bone
+f   move forward without drawing
++ - turn left/right (about the up vector)
+& ^ pitch down/up (about the left vector)
+\ / roll left/right (about the heading vector)
+|   turn around
+[ ] push/pop the turtle state (start/end a branch)
+!   scale the radius by RadiusScale
+"   scale the step length by LengthScale
+
+Other symbols are ignored by the turtle.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// LSystem is a Lindenmayer system.
+type LSystem struct {
+	Axiom string          // initial string
+	Rules map[rune]string // production rules
+}
+
+// Expand applies the production rules n times to the axiom.
+func (l *LSystem) Expand(n int) string {
+	s := l.Axiom
+	for i := 0; i < n; i++ {
+		var sb strings.Builder
+		for _, c := range s {
+			if r, ok := l.Rules[c]; ok {
+				sb.WriteString(r)
+			} else {
+				sb.WriteRune(c)
+			}
+		}
+		s = sb.String()
+	}
+	return s
+}
+
+//-----------------------------------------------------------------------------
+
+// Bone is a capsule in a skeleton.
+type Bone struct {
+	A, B   V3      // end points
+	Radius float64 // radius
+}
+
+// TurtleParms defines the parameters for the turtle interpretation of an L-system.
+type TurtleParms struct {
+	Angle       float64 // turn angle (radians)
+	Length      float64 // initial step length
+	Radius      float64 // initial bone radius
+	LengthScale float64 // step length scale for "
+	RadiusScale float64 // radius scale for !
+}
+
+// turtle is the state of a 3D turtle.
+type turtle struct {
+	pos     V3      // position
+	h, l, u V3      // heading, left and up vectors
+	length  float64 // step length
+	radius  float64 // bone radius
+}
+
+// rotate rotates the turtle about an axis.
+func (t *turtle) rotate(axis V3, a float64) {
+	m := Rotate3d(axis, a)
+	t.h = m.MulPosition(t.h)
+	t.l = m.MulPosition(t.l)
+	t.u = m.MulPosition(t.u)
+}
+
+// Turtle returns the skeleton drawn by a 3D turtle following an L-system
+// string. The turtle starts at the origin heading along +z.
+func Turtle(s string, k *TurtleParms) ([]Bone, error) {
+	if k.Length <= 0 {
+		return nil, errors.New("Length <= 0")
+	}
+	if k.Radius <= 0 {
+		return nil, errors.New("Radius <= 0")
+	}
+	if k.LengthScale <= 0 || k.RadiusScale <= 0 {
+		return nil, errors.New("LengthScale and RadiusScale must be > 0")
+	}
+	t := turtle{
+		h:      V3{0, 0, 1},
+		l:      V3{-1, 0, 0},
+		u:      V3{0, -1, 0},
+		length: k.Length,
+		radius: k.Radius,
+	}
+	var stack []turtle
+	var bones []Bone
+	for _, c := range s {
+		switch c {
+		case 'F':
+			p := t.pos.Add(t.h.MulScalar(t.length))
+			bones = append(bones, Bone{t.pos, p, t.radius})
+			t.pos = p
+		case 'f':
+			t.pos = t.pos.Add(t.h.MulScalar(t.length))
+		case '+':
+			t.rotate(t.u, k.Angle)
+		case '-':
+			t.rotate(t.u, -k.Angle)
+		case '&':
+			t.rotate(t.l, k.Angle)
+		case '^':
+			t.rotate(t.l, -k.Angle)
+		case '\\':
+			t.rotate(t.h, k.Angle)
+		case '/':
+			t.rotate(t.h, -k.Angle)
+		case '|':
+			t.rotate(t.u, Pi)
+		case '[':
+			stack = append(stack, t)
+		case ']':
+			if len(stack) == 0 {
+				return nil, errors.New("unbalanced ]")
+			}
+			t = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		case '!':
+			t.radius *= k.RadiusScale
+		case '"':
+			t.length *= k.LengthScale
+		}
+	}
+	if len(stack) != 0 {
+		return nil, errors.New("unbalanced [")
+	}
+	return bones, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// SkeletonSDF3 is a smooth union of capsules.
+type SkeletonSDF3 struct {
+	bones []Bone
+	min   MinFunc
+	bb    Box3
+}
+
+// Skeleton3D returns a skeleton of capsules. The capsules are blended with a
+// fillet of size smooth (0 for a plain union).
+func Skeleton3D(bones []Bone, smooth float64) (SDF3, error) {
+	if len(bones) == 0 {
+		return nil, errors.New("no bones")
+	}
+	if smooth < 0 {
+		return nil, errors.New("smooth < 0")
+	}
+	s := SkeletonSDF3{
+		bones: bones,
+		min:   math.Min,
+	}
+	if smooth > 0 {
+		s.min = PolyMin(smooth)
+	}
+	s.bb = Box3{bones[0].A, bones[0].A}
+	for _, b := range bones {
+		if b.Radius <= 0 {
+			return nil, errors.New("bone radius <= 0")
+		}
+		// the blend grows the shape (by up to smooth/4)
+		r := V3{1, 1, 1}.MulScalar(b.Radius + smooth)
+		bb := Box3{b.A.Min(b.B).Sub(r), b.A.Max(b.B).Add(r)}
+		s.bb = s.bb.Extend(bb)
+	}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a skeleton.
+func (s *SkeletonSDF3) Evaluate(p V3) float64 {
+	var d float64
+	for i := range s.bones {
+		b := &s.bones[i]
+		pa := p.Sub(b.A)
+		ba := b.B.Sub(b.A)
+		h := 0.0
+		if l2 := ba.Length2(); l2 > 0 {
+			h = Clamp(pa.Dot(ba)/l2, 0, 1)
+		}
+		di := pa.Sub(ba.MulScalar(h)).Length() - b.Radius
+		if i == 0 {
+			d = di
+		} else {
+			d = s.min(d, di)
+		}
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of a skeleton.
+func (s *SkeletonSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
+// LSystem3D returns the skeleton of an L-system expanded n times.
+func LSystem3D(l *LSystem, n int, k *TurtleParms, smooth float64) (SDF3, error) {
+	bones, err := Turtle(l.Expand(n), k)
+	if err != nil {
+		return nil, err
+	}
+	return Skeleton3D(bones, smooth)
+}
+
+//-----------------------------------------------------------------------------
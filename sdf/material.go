@@ -0,0 +1,145 @@
+//-----------------------------------------------------------------------------
+/*
+
+Materials
+
+Attach a named material (with a display color) to an SDF3 sub-tree.
+The material is ignored by the distance evaluation but it is found by the
+exporters that support materials (3MF, OBJ/MTL).
+
+Materials are found within hard unions and transforms. The innermost material
+applies, and geometry without a material has the default (nil) material.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"image/color"
+)
+
+//-----------------------------------------------------------------------------
+
+// Material is a named material with a display color.
+type Material struct {
+	Name  string
+	Color color.RGBA
+}
+
+// colorString returns a color as an sRGB hex string.
+func colorString(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X%02X", c.R, c.G, c.B, c.A)
+}
+
+//-----------------------------------------------------------------------------
+
+// MaterialSDF3 is an SDF3 with a material.
+type MaterialSDF3 struct {
+	sdf      SDF3
+	material *Material
+}
+
+// Material3D returns an SDF3 with a material attached.
+func Material3D(sdf SDF3, material *Material) SDF3 {
+	return &MaterialSDF3{sdf, material}
+}
+
+// Evaluate returns the minimum distance to the SDF3.
+func (s *MaterialSDF3) Evaluate(p V3) float64 {
+	return s.sdf.Evaluate(p)
+}
+
+// BoundingBox returns the bounding box of the SDF3.
+func (s *MaterialSDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+// Material returns the material of the SDF3.
+func (s *MaterialSDF3) Material() *Material {
+	return s.material
+}
+
+//-----------------------------------------------------------------------------
+
+// MaterialBody is the geometry of an SDF3 with a single material.
+type MaterialBody struct {
+	SDF      SDF3
+	Material *Material
+}
+
+// findMaterials appends the sub-trees of an SDF3 with their materials.
+func findMaterials(s SDF3, material *Material, out []MaterialBody) []MaterialBody {
+	switch x := s.(type) {
+	case *MaterialSDF3:
+		return findMaterials(x.sdf, x.material, out)
+	case *UnionSDF3:
+		if isMin(x.min) {
+			for _, c := range x.sdf {
+				out = findMaterials(c, material, out)
+			}
+			return out
+		}
+	case *TransformSDF3:
+		bodies := findMaterials(x.sdf, material, nil)
+		if len(bodies) == 1 && bodies[0].SDF == x.sdf {
+			// keep the existing transform
+			return append(out, MaterialBody{s, bodies[0].Material})
+		}
+		for _, b := range bodies {
+			out = append(out, MaterialBody{Transform3D(b.SDF, x.matrix), b.Material})
+		}
+		return out
+	}
+	return append(out, MaterialBody{s, material})
+}
+
+// FindMaterials3 partitions an SDF3 into one body per material.
+// The bodies are in the order the materials are first found.
+func FindMaterials3(s SDF3) []MaterialBody {
+	var materials []*Material
+	parts := map[*Material][]SDF3{}
+	for _, b := range findMaterials(s, nil, nil) {
+		if _, ok := parts[b.Material]; !ok {
+			materials = append(materials, b.Material)
+		}
+		parts[b.Material] = append(parts[b.Material], b.SDF)
+	}
+	bodies := make([]MaterialBody, len(materials))
+	for i, m := range materials {
+		bodies[i] = MaterialBody{parts[m][0], m}
+		if len(parts[m]) > 1 {
+			bodies[i].SDF = Union3D(parts[m]...)
+		}
+	}
+	return bodies
+}
+
+//-----------------------------------------------------------------------------
+// Region Partitioning
+
+// SplitByRegion partitions an SDF3 into per-material bodies for multi-extruder printing.
+// Body i is the part of the SDF3 within region i (and not within an earlier region).
+// The final body is the remainder of the SDF3 that is not within any region.
+// All bodies share the coordinate frame of the SDF3, so they stay aligned when exported.
+func SplitByRegion(sdf SDF3, regions []SDF3) []SDF3 {
+	bodies := make([]SDF3, 0, len(regions)+1)
+	var done SDF3 // union of the earlier regions
+	for _, r := range regions {
+		region := r
+		if done != nil {
+			region = Difference3D(r, done)
+			done = Union3D(done, r)
+		} else {
+			done = r
+		}
+		bodies = append(bodies, Intersect3D(sdf, region))
+	}
+	if done == nil {
+		return append(bodies, sdf)
+	}
+	return append(bodies, Difference3D(sdf, done))
+}
+
+//-----------------------------------------------------------------------------
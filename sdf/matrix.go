@@ -172,6 +172,32 @@ func RotateZ(a float64) M44 {
 	return Rotate3d(V3{0, 0, 1}, a)
 }
 
+// RotateToVector returns the rotation matrix that transforms vector a onto vector b.
+func RotateToVector(a, b V3) M44 {
+	a = a.Normalize()
+	b = b.Normalize()
+	c := a.Dot(b)
+	if c > 1-tolerance {
+		// the vectors are parallel
+		return Identity3d()
+	}
+	if c < -1+tolerance {
+		// the vectors are anti-parallel, rotate about any perpendicular axis
+		axis := a.Cross(V3{1, 0, 0})
+		if axis.Length() < tolerance {
+			axis = a.Cross(V3{0, 1, 0})
+		}
+		return Rotate3d(axis, Pi)
+	}
+	return Rotate3d(a.Cross(b), math.Acos(c))
+}
+
+// AxisTransform returns the matrix that transforms the z-axis onto the axis
+// through point p with direction v.
+func AxisTransform(p, v V3) M44 {
+	return Translate3d(p).Mul(RotateToVector(V3{0, 0, 1}, v))
+}
+
 // MirrorXY returns a 4x4 matrix with mirroring across the XY plane.
 func MirrorXY() M44 {
 	return M44{
@@ -215,6 +241,16 @@ func MirrorY() M33 {
 		0, 0, 1}
 }
 
+// Mirror2d returns a 3x3 matrix with mirroring across the line through a with normal n.
+func Mirror2d(a, n V2) M33 {
+	n = n.Normalize()
+	k := 2 * n.Dot(a)
+	return M33{
+		1 - 2*n.X*n.X, -2 * n.X * n.Y, k * n.X,
+		-2 * n.X * n.Y, 1 - 2*n.Y*n.Y, k * n.Y,
+		0, 0, 1}
+}
+
 // Rotate2d returns an orthographic 3x3 rotation matrix (right hand rule).
 func Rotate2d(a float64) M33 {
 	s := math.Sin(a)
@@ -0,0 +1,224 @@
+//-----------------------------------------------------------------------------
+/*
+
+Measurement
+
+Measure distances between SDF3s, closest points, wall thickness, feature
+size and angles between anchors. These allow design scripts and tests to
+check constraints (E.g. hole spacing, clearance between parts, minimum wall
+thickness).
+
+The results assume the SDF3s return (near) exact distances. SDF3s with
+distorted distance fields (E.g. non-uniform scaling) give approximate results.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "math"
+
+//-----------------------------------------------------------------------------
+
+// measureSamples is the number of grid samples per axis for the initial search.
+const measureSamples = 16
+
+// measureIterations is the maximum number of refinement iterations.
+const measureIterations = 64
+
+// Normal3 returns the normalized gradient of an SDF3 at a point (central differences with step eps).
+func Normal3(s SDF3, p V3, eps float64) V3 {
+	dx := s.Evaluate(p.Add(V3{eps, 0, 0})) - s.Evaluate(p.Sub(V3{eps, 0, 0}))
+	dy := s.Evaluate(p.Add(V3{0, eps, 0})) - s.Evaluate(p.Sub(V3{0, eps, 0}))
+	dz := s.Evaluate(p.Add(V3{0, 0, eps})) - s.Evaluate(p.Sub(V3{0, 0, eps}))
+	return V3{dx, dy, dz}.Normalize()
+}
+
+// measureEpsilon returns the gradient step for an SDF3.
+func measureEpsilon(s SDF3) float64 {
+	return 1e-6 * Max(1, s.BoundingBox().Size().MaxComponent())
+}
+
+// ClosestPoint3 returns the point on the surface of an SDF3 closest to p.
+func ClosestPoint3(s SDF3, p V3) V3 {
+	eps := measureEpsilon(s)
+	for i := 0; i < measureIterations; i++ {
+		d := s.Evaluate(p)
+		if Abs(d) < eps {
+			break
+		}
+		p = p.Sub(Normal3(s, p, eps).MulScalar(d))
+	}
+	return p
+}
+
+// Distance3 returns the minimum distance between the surfaces of two SDF3s
+// and the closest points on each surface. If the SDF3s overlap the distance
+// is <= 0 and estimates the penetration depth, the points are both at the
+// deepest point found.
+func Distance3(a, b SDF3) (float64, V3, V3) {
+	bb := a.BoundingBox().Extend(b.BoundingBox())
+	size := bb.Size()
+	// grid search for a starting point
+	dOverlap := math.Inf(1) // max(a, b) is < 0 in the overlap
+	dSum := math.Inf(1)     // a + b is minimal between the closest points
+	var pOverlap, pSum V3
+	for i := 0; i <= measureSamples; i++ {
+		for j := 0; j <= measureSamples; j++ {
+			for k := 0; k <= measureSamples; k++ {
+				p := bb.Min.Add(size.Mul(V3{float64(i), float64(j), float64(k)}).DivScalar(measureSamples))
+				da := a.Evaluate(p)
+				db := b.Evaluate(p)
+				if d := Max(da, db); d < dOverlap {
+					dOverlap = d
+					pOverlap = p
+				}
+				if d := da + db; d < dSum {
+					dSum = d
+					pSum = p
+				}
+			}
+		}
+	}
+	if dOverlap <= 0 {
+		return dOverlap, pOverlap, pOverlap
+	}
+	// refine with alternating projections onto the surfaces
+	eps := Min(measureEpsilon(a), measureEpsilon(b))
+	pa := ClosestPoint3(a, pSum)
+	pb := ClosestPoint3(b, pa)
+	for i := 0; i < measureIterations; i++ {
+		x := ClosestPoint3(a, pb)
+		y := ClosestPoint3(b, x)
+		done := x.Equals(pa, eps) && y.Equals(pb, eps)
+		pa, pb = x, y
+		if done {
+			break
+		}
+	}
+	return pb.Sub(pa).Length(), pa, pb
+}
+
+//-----------------------------------------------------------------------------
+
+// WallThickness3 returns the thickness of an SDF3 at the surface point closest
+// to p. It is measured along the inward normal to where the ray leaves the
+// solid (bounded by the bounding box diagonal).
+func WallThickness3(s SDF3, p V3) float64 {
+	eps := measureEpsilon(s)
+	// the ray march stops this close to the far surface
+	size := s.BoundingBox().Size().Length()
+	stop := 1e-4 * size
+	q := ClosestPoint3(s, p)
+	n := Normal3(s, q, eps)
+	t := 2 * stop
+	for i := 0; i < 4*measureIterations && t < size; i++ {
+		d := s.Evaluate(q.Sub(n.MulScalar(t)))
+		if d > -stop {
+			// at (or just past) the far surface
+			return t - d
+		}
+		t -= d
+	}
+	return math.Min(t, size)
+}
+
+// featureRay returns the distance from the surface point q along direction
+// v (the outward normal for a gap, the inward normal for a wall) to the
+// opposing surface. It returns +Inf if there is no opposing surface, E.g. a
+// ray grazing a face at an edge.
+func featureRay(s SDF3, q, v V3) float64 {
+	eps := measureEpsilon(s)
+	size := s.BoundingBox().Size().Length()
+	stop := 1e-4 * size
+	// the sign of the field on the ray
+	sign := 1.0
+	if s.Evaluate(q.Add(v.MulScalar(2*stop))) < 0 {
+		sign = -1
+	}
+	t := 2 * stop
+	for i := 0; i < 4*measureIterations && t < size; i++ {
+		d := sign * s.Evaluate(q.Add(v.MulScalar(t)))
+		if d < stop {
+			t += d
+			// the surface normal faces back along the ray
+			if sign*Normal3(s, q.Add(v.MulScalar(t)), eps).Dot(v) < -0.5 {
+				return t
+			}
+			return math.Inf(1)
+		}
+		t += d
+	}
+	return math.Inf(1)
+}
+
+// featureSamples is the number of cells on the longest axis for the
+// initial feature size search.
+const featureSamples = 8
+
+// EstimateMinFeatureSize returns an estimate of the smallest feature of an
+// SDF3, the minimum of the wall thicknesses and the gaps between surfaces.
+// The field is sampled in an octree of cells containing the surface, cells
+// with features close to the cell size are subdivided to look for smaller
+// ones. Features smaller than 1/1000 of the bounding box are not resolved.
+func EstimateMinFeatureSize(s SDF3) float64 {
+	bb := s.BoundingBox()
+	size := bb.Size().MaxComponent()
+	floor := 1e-3 * size
+	est := bb.Size().Length()
+	type cell struct {
+		c    V3      // center
+		size float64 // side length
+	}
+	var cells []cell
+	c0 := size / featureSamples
+	n := bb.Size().DivScalar(c0).Ceil().ToV3i()
+	for i := 0; i < n[0]; i++ {
+		for j := 0; j < n[1]; j++ {
+			for k := 0; k < n[2]; k++ {
+				p := V3{float64(i) + 0.5, float64(j) + 0.5, float64(k) + 0.5}
+				cells = append(cells, cell{bb.Min.Add(p.MulScalar(c0)), c0})
+			}
+		}
+	}
+	for len(cells) != 0 {
+		var next []cell
+		for _, c := range cells {
+			// does the cell contain the surface?
+			hd := 0.5 * math.Sqrt(3) * c.size
+			if Abs(s.Evaluate(c.c)) > hd {
+				continue
+			}
+			q := ClosestPoint3(s, c.c)
+			n := Normal3(s, q, measureEpsilon(s))
+			t := math.Min(featureRay(s, q, n), featureRay(s, q, n.Neg()))
+			est = math.Min(est, t)
+			// look for smaller features
+			h := 0.5 * c.size
+			if t < 2*c.size && h >= floor {
+				for i := 0; i < 8; i++ {
+					d := V3{float64(i & 1), float64(i >> 1 & 1), float64(i >> 2 & 1)}
+					d = d.SubScalar(0.5).MulScalar(h)
+					next = append(next, cell{c.c.Add(d), h})
+				}
+			}
+		}
+		cells = next
+	}
+	return est
+}
+
+//-----------------------------------------------------------------------------
+
+// AnchorAngle returns the angle (radians) between the axes of two anchors.
+func AnchorAngle(a, b Anchor) float64 {
+	c := a.Axis.Normalize().Dot(b.Axis.Normalize())
+	return math.Acos(Clamp(c, -1, 1))
+}
+
+// AnchorDistance returns the distance between the points of two anchors.
+func AnchorDistance(a, b Anchor) float64 {
+	return b.Position.Sub(a.Position).Length()
+}
+
+//-----------------------------------------------------------------------------
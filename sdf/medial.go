@@ -0,0 +1,280 @@
+//-----------------------------------------------------------------------------
+/*
+
+Medial Axis
+
+Extract the medial axis (skeleton) of an SDF2. The medial axis is the ridge
+of the distance field: the set of interior points that have more than one
+closest point on the boundary.
+
+The field is sampled on a grid. Neighbouring samples whose closest boundary
+points are far apart (and whose normals diverge) straddle the ridge. The
+marked samples are thinned to single pixel lines and joined into a graph.
+
+Each node records the distance to the boundary (the radius of the inscribed
+circle), so the skeleton can be used for toolpaths and thin feature checks.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "math"
+
+//-----------------------------------------------------------------------------
+
+// SkeletonNode is a point on the medial axis.
+type SkeletonNode struct {
+	Position V2      // position of the node
+	Radius   float64 // distance to the boundary
+}
+
+// Skeleton2 is a graph of medial axis segments.
+type Skeleton2 struct {
+	Nodes []SkeletonNode
+	Edges [][2]int // node indices
+}
+
+//-----------------------------------------------------------------------------
+
+// gradient2 returns the normalized gradient of an SDF2.
+func gradient2(s SDF2, p V2, eps float64) V2 {
+	dx := s.Evaluate(p.Add(V2{eps, 0})) - s.Evaluate(p.Sub(V2{eps, 0}))
+	dy := s.Evaluate(p.Add(V2{0, eps})) - s.Evaluate(p.Sub(V2{0, eps}))
+	return V2{dx, dy}.Normalize()
+}
+
+// thinGrid thins a grid of marked pixels to single pixel lines.
+// This is Zhang-Suen thinning with sequential rather than parallel removal.
+func thinGrid(grid [][]bool) {
+	nx := len(grid)
+	ny := len(grid[0])
+	get := func(i, j int) int {
+		if i < 0 || j < 0 || i >= nx || j >= ny || !grid[i][j] {
+			return 0
+		}
+		return 1
+	}
+	for {
+		changed := false
+		for step := 0; step < 2; step++ {
+			for i := 0; i < nx; i++ {
+				for j := 0; j < ny; j++ {
+					if !grid[i][j] {
+						continue
+					}
+					// neighbours, clockwise from north
+					p := [8]int{
+						get(i, j+1), get(i+1, j+1), get(i+1, j), get(i+1, j-1),
+						get(i, j-1), get(i-1, j-1), get(i-1, j), get(i-1, j+1),
+					}
+					b := 0
+					a := 0
+					for k := 0; k < 8; k++ {
+						b += p[k]
+						if p[k] == 0 && p[(k+1)%8] == 1 {
+							a++
+						}
+					}
+					// b < 3 keeps the end points of lines and staircases
+					if b < 3 || b > 6 || a != 1 {
+						continue
+					}
+					if step == 0 {
+						if p[0]*p[2]*p[4] != 0 || p[2]*p[4]*p[6] != 0 {
+							continue
+						}
+					} else {
+						if p[0]*p[2]*p[6] != 0 || p[0]*p[4]*p[6] != 0 {
+							continue
+						}
+					}
+					// removing pixels as we go keeps 2 pixel diagonals connected
+					grid[i][j] = false
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			return
+		}
+	}
+}
+
+// MedialAxis2 returns the medial axis of an SDF2.
+// cells is the number of grid cells on the longest axis of the bounding box.
+func MedialAxis2(s SDF2, cells int) *Skeleton2 {
+	bb := s.BoundingBox()
+	size := bb.Size()
+	h := math.Max(size.X, size.Y) / float64(cells)
+	nx := int(math.Ceil(size.X/h)) + 1
+	ny := int(math.Ceil(size.Y/h)) + 1
+	eps := 1e-3 * h
+
+	// sample the distance, normal and closest boundary point
+	pos := make([][]V2, nx)
+	dist := make([][]float64, nx)
+	foot := make([][]V2, nx)
+	normal := make([][]V2, nx)
+	for i := 0; i < nx; i++ {
+		pos[i] = make([]V2, ny)
+		dist[i] = make([]float64, ny)
+		foot[i] = make([]V2, ny)
+		normal[i] = make([]V2, ny)
+		for j := 0; j < ny; j++ {
+			p := bb.Min.Add(V2{float64(i), float64(j)}.MulScalar(h))
+			d := s.Evaluate(p)
+			n := gradient2(s, p, eps)
+			pos[i][j] = p
+			dist[i][j] = d
+			normal[i][j] = n
+			foot[i][j] = p.Sub(n.MulScalar(d))
+		}
+	}
+
+	// mark the samples straddling the ridge
+	cosMax := math.Cos(DtoR(20))
+	grid := make([][]bool, nx)
+	for i := range grid {
+		grid[i] = make([]bool, ny)
+	}
+	mark := func(i0, j0, i1, j1 int) {
+		if i1 >= nx || j1 >= ny {
+			return
+		}
+		if dist[i0][j0] >= 0 || dist[i1][j1] >= 0 {
+			return
+		}
+		if normal[i0][j0].Dot(normal[i1][j1]) > cosMax {
+			return
+		}
+		if foot[i0][j0].Sub(foot[i1][j1]).Length() < 2*h {
+			return
+		}
+		// mark the deeper sample
+		if dist[i0][j0] <= dist[i1][j1] {
+			grid[i0][j0] = true
+		} else {
+			grid[i1][j1] = true
+		}
+	}
+	for i := 0; i < nx; i++ {
+		for j := 0; j < ny; j++ {
+			mark(i, j, i+1, j)
+			mark(i, j, i, j+1)
+		}
+	}
+	thinGrid(grid)
+
+	// build the graph
+	k := &Skeleton2{}
+	index := make([][]int, nx)
+	for i := 0; i < nx; i++ {
+		index[i] = make([]int, ny)
+		for j := 0; j < ny; j++ {
+			index[i][j] = -1
+			if grid[i][j] {
+				index[i][j] = len(k.Nodes)
+				k.Nodes = append(k.Nodes, SkeletonNode{pos[i][j], -dist[i][j]})
+			}
+		}
+	}
+	marked := func(i, j int) bool {
+		return i >= 0 && j >= 0 && i < nx && j < ny && grid[i][j]
+	}
+	for i := 0; i < nx; i++ {
+		for j := 0; j < ny; j++ {
+			if !grid[i][j] {
+				continue
+			}
+			if marked(i+1, j) {
+				k.Edges = append(k.Edges, [2]int{index[i][j], index[i+1][j]})
+			}
+			if marked(i, j+1) {
+				k.Edges = append(k.Edges, [2]int{index[i][j], index[i][j+1]})
+			}
+			// diagonals, unless there is a path through an orthogonal neighbour
+			if marked(i+1, j+1) && !marked(i+1, j) && !marked(i, j+1) {
+				k.Edges = append(k.Edges, [2]int{index[i][j], index[i+1][j+1]})
+			}
+			if marked(i+1, j-1) && !marked(i+1, j) && !marked(i, j-1) {
+				k.Edges = append(k.Edges, [2]int{index[i][j], index[i+1][j-1]})
+			}
+		}
+	}
+	return k
+}
+
+//-----------------------------------------------------------------------------
+
+// adjacency returns the neighbours of each node.
+func (k *Skeleton2) adjacency() [][]int {
+	adj := make([][]int, len(k.Nodes))
+	for _, e := range k.Edges {
+		adj[e[0]] = append(adj[e[0]], e[1])
+		adj[e[1]] = append(adj[e[1]], e[0])
+	}
+	return adj
+}
+
+// Branches returns the chains of nodes between junctions and end points.
+// Closed loops are returned with the first node repeated at the end.
+func (k *Skeleton2) Branches() [][]SkeletonNode {
+	adj := k.adjacency()
+	used := make(map[[2]int]bool)
+	key := func(a, b int) [2]int {
+		if a > b {
+			a, b = b, a
+		}
+		return [2]int{a, b}
+	}
+	walk := func(start, next int) []SkeletonNode {
+		chain := []SkeletonNode{k.Nodes[start]}
+		prev, cur := start, next
+		for {
+			used[key(prev, cur)] = true
+			chain = append(chain, k.Nodes[cur])
+			if len(adj[cur]) != 2 || cur == start {
+				return chain
+			}
+			n := adj[cur][0]
+			if n == prev {
+				n = adj[cur][1]
+			}
+			if used[key(cur, n)] {
+				return chain
+			}
+			prev, cur = cur, n
+		}
+	}
+	var branches [][]SkeletonNode
+	// start at the end points and junctions
+	for i := range k.Nodes {
+		if len(adj[i]) == 2 {
+			continue
+		}
+		for _, n := range adj[i] {
+			if !used[key(i, n)] {
+				branches = append(branches, walk(i, n))
+			}
+		}
+	}
+	// the remaining edges are closed loops
+	for _, e := range k.Edges {
+		if !used[key(e[0], e[1])] {
+			branches = append(branches, walk(e[0], e[1]))
+		}
+	}
+	return branches
+}
+
+// Segments returns the edges of the skeleton as line segments.
+func (k *Skeleton2) Segments() []Line {
+	lines := make([]Line, len(k.Edges))
+	for i, e := range k.Edges {
+		lines[i] = Line{k.Nodes[e[0]].Position, k.Nodes[e[1]].Position}
+	}
+	return lines
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,246 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mesh Hashing and Comparison
+
+MeshHash gives a hash of a triangle mesh that is independent of the triangle
+order. MeshDiff compares two meshes geometrically (Hausdorff distance, volume
+change) and reports where they differ. These support regression testing when
+evaluators or meshers are refactored.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// MeshHash returns a hash of a triangle mesh. The hash doesn't depend on the order
+// of the triangles (or their vertex rotation). The vertices are hashed at float32
+// precision, matching STL files.
+func MeshHash(mesh []*Triangle3) string {
+	m := make([]*Triangle3, len(mesh))
+	for i, t := range mesh {
+		x := *t
+		for j := range x.V {
+			v := x.V[j]
+			x.V[j] = V3{float64(float32(v.X)), float64(float32(v.Y)), float64(float32(v.Z))}
+		}
+		m[i] = &x
+	}
+	SortMesh(m)
+	h := sha256.New()
+	var buf [4]byte
+	for _, t := range m {
+		for _, v := range t.V {
+			for _, x := range []float64{v.X, v.Y, v.Z} {
+				binary.LittleEndian.PutUint32(buf[:], math.Float32bits(float32(x)))
+				h.Write(buf[:])
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+//-----------------------------------------------------------------------------
+
+// closestPointTriangle returns the point on a triangle closest to p.
+// See: Real-Time Collision Detection, Christer Ericson, 5.1.5
+func closestPointTriangle(p V3, t *Triangle3) V3 {
+	a, b, c := t.V[0], t.V[1], t.V[2]
+	ab := b.Sub(a)
+	ac := c.Sub(a)
+	ap := p.Sub(a)
+	d1 := ab.Dot(ap)
+	d2 := ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a
+	}
+	bp := p.Sub(b)
+	d3 := ab.Dot(bp)
+	d4 := ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b
+	}
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return a.Add(ab.MulScalar(d1 / (d1 - d3)))
+	}
+	cp := p.Sub(c)
+	d5 := ab.Dot(cp)
+	d6 := ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c
+	}
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return a.Add(ac.MulScalar(d2 / (d2 - d6)))
+	}
+	va := d3*d6 - d5*d4
+	if va <= 0 && (d4-d3) >= 0 && (d5-d6) >= 0 {
+		return b.Add(c.Sub(b).MulScalar((d4 - d3) / ((d4 - d3) + (d5 - d6))))
+	}
+	denom := 1 / (va + vb + vc)
+	v := vb * denom
+	w := vc * denom
+	return a.Add(ab.MulScalar(v)).Add(ac.MulScalar(w))
+}
+
+//-----------------------------------------------------------------------------
+
+// meshGrid is a uniform grid of triangle buckets for nearest point queries.
+type meshGrid struct {
+	origin V3
+	size   float64 // cell size
+	n      V3i     // number of cells per axis
+	cells  map[V3i][]*Triangle3
+}
+
+// newMeshGrid returns a uniform grid for a triangle mesh.
+func newMeshGrid(mesh []*Triangle3) *meshGrid {
+	bb := MeshBoundingBox(mesh)
+	// size the cells to hold a few triangles
+	edge := 0.0
+	for _, t := range mesh {
+		edge += t.V[1].Sub(t.V[0]).Length()
+	}
+	size := Max(2*edge/float64(len(mesh)), 1e-3*Max(bb.Size().MaxComponent(), 1))
+	g := &meshGrid{
+		origin: bb.Min,
+		size:   size,
+		cells:  make(map[V3i][]*Triangle3),
+	}
+	g.n = g.index(bb.Max).AddScalar(1)
+	for _, t := range mesh {
+		vs := V3Set(t.V[:])
+		i0 := g.index(vs.Min())
+		i1 := g.index(vs.Max())
+		for x := i0[0]; x <= i1[0]; x++ {
+			for y := i0[1]; y <= i1[1]; y++ {
+				for z := i0[2]; z <= i1[2]; z++ {
+					k := V3i{x, y, z}
+					g.cells[k] = append(g.cells[k], t)
+				}
+			}
+		}
+	}
+	return g
+}
+
+// index returns the cell index for a point.
+func (g *meshGrid) index(p V3) V3i {
+	return p.Sub(g.origin).DivScalar(g.size).Floor().ToV3i()
+}
+
+// distance returns the distance from a point to the closest triangle in the grid.
+// Rings of cells are searched outwards from the point until the closest triangle is found.
+func (g *meshGrid) distance(p V3) (float64, V3) {
+	c := g.index(p)
+	best := math.Inf(1)
+	var closest V3
+	maxRing := maxInt(g.n[0], maxInt(g.n[1], g.n[2])) + absInt(c[0]) + absInt(c[1]) + absInt(c[2])
+	for r := 0; r <= maxRing; r++ {
+		for x := c[0] - r; x <= c[0]+r; x++ {
+			for y := c[1] - r; y <= c[1]+r; y++ {
+				for z := c[2] - r; z <= c[2]+r; z++ {
+					if maxInt(absInt(x-c[0]), maxInt(absInt(y-c[1]), absInt(z-c[2]))) != r {
+						// not on the ring
+						continue
+					}
+					for _, t := range g.cells[V3i{x, y, z}] {
+						q := closestPointTriangle(p, t)
+						if d := q.Sub(p).Length(); d < best {
+							best = d
+							closest = q
+						}
+					}
+				}
+			}
+		}
+		// cells on later rings are at least r cells away
+		if best <= float64(r)*g.size {
+			break
+		}
+	}
+	return best, closest
+}
+
+//-----------------------------------------------------------------------------
+
+// MeshDiffReport describes the geometric differences between two meshes.
+type MeshDiffReport struct {
+	Hausdorff   float64 // maximum distance from either mesh to the other
+	Mean        float64 // mean distance from either mesh to the other
+	VolumeDelta float64 // volume of b - volume of a
+	Points      []V3    // sample points further than the tolerance from the other mesh
+	Region      Box3    // bounding box of the points (if any)
+}
+
+// Equal returns true if the meshes are the same to within the tolerance.
+func (r *MeshDiffReport) Equal() bool {
+	return len(r.Points) == 0
+}
+
+func (r *MeshDiffReport) String() string {
+	s := fmt.Sprintf("hausdorff %g, mean %g, volume delta %g", r.Hausdorff, r.Mean, r.VolumeDelta)
+	if len(r.Points) != 0 {
+		s += fmt.Sprintf(", %d points differ within %v", len(r.Points), r.Region)
+	}
+	return s
+}
+
+// meshSamples returns sample points on a mesh (the vertices and centroids).
+func meshSamples(mesh []*Triangle3) []V3 {
+	var v []V3
+	for _, t := range mesh {
+		v = append(v, t.V[0], t.V[1], t.V[2])
+		v = append(v, t.V[0].Add(t.V[1]).Add(t.V[2]).DivScalar(3))
+	}
+	return v
+}
+
+// MeshDiff compares two triangle meshes. The distances are measured from the vertices
+// and triangle centroids of each mesh to the surface of the other mesh.
+// Sample points further than tol from the other mesh are reported.
+func MeshDiff(a, b []*Triangle3, tol float64) *MeshDiffReport {
+	r := &MeshDiffReport{
+		VolumeDelta: MeshVolume(b) - MeshVolume(a),
+	}
+	if len(a) == 0 || len(b) == 0 {
+		if len(a) != len(b) {
+			r.Hausdorff = math.Inf(1)
+			r.Points = append(meshSamples(a), meshSamples(b)...)
+			r.Region = Box3{V3Set(r.Points).Min(), V3Set(r.Points).Max()}
+		}
+		return r
+	}
+	total := 0.0
+	n := 0
+	for _, x := range [][2][]*Triangle3{{a, b}, {b, a}} {
+		g := newMeshGrid(x[1])
+		for _, p := range meshSamples(x[0]) {
+			d, _ := g.distance(p)
+			total += d
+			n++
+			r.Hausdorff = Max(r.Hausdorff, d)
+			if d > tol {
+				r.Points = append(r.Points, p)
+			}
+		}
+	}
+	r.Mean = total / float64(n)
+	if len(r.Points) != 0 {
+		r.Region = Box3{V3Set(r.Points).Min(), V3Set(r.Points).Max()}
+	}
+	return r
+}
+
+//-----------------------------------------------------------------------------
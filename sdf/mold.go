@@ -0,0 +1,177 @@
+//-----------------------------------------------------------------------------
+/*
+
+Two Part Molds
+
+Make the two halves of a mold for casting a part. The mold is a block around
+the part, split on a parting plane. The halves have registration keys so they
+can be aligned, a sprue for pouring and vents to let the air out.
+
+The sprue and vents are placed on the part surface above the parting plane,
+so the part needs to be oriented such that it is a reasonable shape for
+casting in the chosen direction.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// MoldParms defines the parameters for a two part mold.
+type MoldParms struct {
+	Point        V3      // point on the parting plane
+	Normal       V3      // normal to the parting plane (the pour direction)
+	Margin       float64 // thickness of the mold block around the part
+	KeyRadius    float64 // radius of the hemispherical registration keys (0 for no keys)
+	KeyClearance float64 // radial clearance for the registration keys
+	SprueRadius  float64 // radius of the pour sprue (0 for no sprue)
+	VentRadius   float64 // radius of the vents (0 for no vents)
+	NumberVents  int     // number of vents
+}
+
+// moldColumn is the height of the part surface at an x/y position.
+type moldColumn struct {
+	p V2      // x/y position
+	z float64 // z-height of the part top
+}
+
+// moldSurface returns the heights of the part top surface on an x/y sampling grid.
+// Only positions where the part is above z = 0 are returned.
+func moldSurface(part SDF3, n int) []moldColumn {
+	bb := part.BoundingBox()
+	size := bb.Size()
+	eps := 1e-4 * size.MaxComponent()
+	var cols []moldColumn
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			x := bb.Min.X + size.X*(float64(i)+0.5)/float64(n)
+			y := bb.Min.Y + size.Y*(float64(j)+0.5)/float64(n)
+			// march down from the top of the bounding box
+			z := bb.Max.Z
+			for z > 0 {
+				d := part.Evaluate(V3{x, y, z})
+				if d < eps {
+					cols = append(cols, moldColumn{V2{x, y}, z})
+					break
+				}
+				z -= d
+			}
+		}
+	}
+	return cols
+}
+
+// MakeMold returns the two halves of a mold for a part.
+// The first half is on the normal side of the parting plane and has the sprue and vents.
+func MakeMold(part SDF3, k *MoldParms) ([]SDF3, error) {
+	// validate parameters
+	if part == nil {
+		return nil, errors.New("no part")
+	}
+	if k.Normal.Length() == 0 {
+		return nil, errors.New("Normal is a zero vector")
+	}
+	if k.Margin <= 0 {
+		return nil, errors.New("Margin <= 0")
+	}
+	if k.KeyRadius < 0 || k.KeyClearance < 0 || k.SprueRadius < 0 || k.VentRadius < 0 {
+		return nil, errors.New("key/sprue/vent dimensions < 0")
+	}
+	if k.KeyRadius >= 0.5*k.Margin {
+		return nil, errors.New("KeyRadius >= Margin/2")
+	}
+	if k.NumberVents < 0 {
+		return nil, errors.New("NumberVents < 0")
+	}
+
+	// work in a frame with the parting plane on z = 0
+	m := RotateToVector(k.Normal, V3{0, 0, 1}).Mul(Translate3d(k.Point.Neg()))
+	p := Transform3D(part, m)
+	bb := p.BoundingBox()
+	if bb.Min.Z >= 0 || bb.Max.Z <= 0 {
+		return nil, errors.New("parting plane does not intersect the part")
+	}
+
+	// mold block
+	bMin := bb.Min.SubScalar(k.Margin)
+	bMax := bb.Max.AddScalar(k.Margin)
+	block := Box3D(bMax.Sub(bMin), 0)
+	block = Transform3D(block, Translate3d(bMin.Add(bMax).MulScalar(0.5)))
+	keys := KeySpec{}
+	if k.KeyRadius > 0 {
+		// registration keys in the block corners
+		x0 := bMin.X + 0.5*k.Margin
+		x1 := bMax.X - 0.5*k.Margin
+		y0 := bMin.Y + 0.5*k.Margin
+		y1 := bMax.Y - 0.5*k.Margin
+		keys = KeySpec{
+			Type:      KeySphere,
+			Size:      2 * k.KeyRadius,
+			Clearance: k.KeyClearance,
+			Points:    []V3{{x0, y0, 0}, {x1, y0, 0}, {x1, y1, 0}, {x0, y1, 0}},
+		}
+	}
+	top, bottom, err := CutWithKeys(Difference3D(block, p), Plane{V3{0, 0, 0}, V3{0, 0, 1}}, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	// sprue and vents
+	if k.SprueRadius > 0 || (k.VentRadius > 0 && k.NumberVents > 0) {
+		cols := moldSurface(p, 16)
+		if len(cols) == 0 {
+			return nil, errors.New("no part surface above the parting plane")
+		}
+		var channels []SDF3
+		channel := func(c moldColumn, r float64) SDF3 {
+			l := bMax.Z - c.z
+			s := Cylinder3D(l+r, r, 0)
+			return Transform3D(s, Translate3d(V3{c.p.X, c.p.Y, c.z + 0.5*(l-r)}))
+		}
+		// the sprue is placed at the part surface closest to the center of the part
+		center := V2{bb.Center().X, bb.Center().Y}
+		sort.Slice(cols, func(i, j int) bool {
+			return cols[i].p.Sub(center).Length2() < cols[j].p.Sub(center).Length2()
+		})
+		sprue := cols[0]
+		if k.SprueRadius > 0 {
+			channels = append(channels, channel(sprue, k.SprueRadius))
+		}
+		// the vents are placed at the highest points of the part away from the sprue
+		if k.VentRadius > 0 && k.NumberVents > 0 {
+			sort.SliceStable(cols, func(i, j int) bool {
+				return cols[i].z > cols[j].z
+			})
+			minDist := 2 * (k.SprueRadius + k.VentRadius)
+			var vents []moldColumn
+			for _, c := range cols {
+				ok := c.p.Sub(sprue.p).Length() >= minDist
+				for _, v := range vents {
+					if c.p.Sub(v.p).Length() < minDist {
+						ok = false
+					}
+				}
+				if ok {
+					vents = append(vents, c)
+					channels = append(channels, channel(c, k.VentRadius))
+				}
+				if len(vents) == k.NumberVents {
+					break
+				}
+			}
+		}
+		top = Difference3D(top, Union3D(channels...))
+	}
+
+	// back to the original frame
+	mInv := m.Inverse()
+	return []SDF3{Transform3D(top, mInv), Transform3D(bottom, mInv)}, nil
+}
+
+//-----------------------------------------------------------------------------
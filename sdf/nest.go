@@ -0,0 +1,301 @@
+//-----------------------------------------------------------------------------
+/*
+
+Nesting
+
+Arrange parts on a build plate or stock sheet.
+
+The footprint of each part is rasterized on a grid and dilated by half the
+spacing, so non-overlapping footprints are at least the spacing apart. Parts
+are placed largest first at the bottom-left most position where they fit.
+
+3D parts are nested on a build plate using their XY projection. 2D shapes are
+nested on stock sheets with rotation steps, and the layout of each sheet can
+be written as SVG or DXF for laser and CNC cutting.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// nestFootprint is a rasterized part footprint.
+type nestFootprint struct {
+	cells []V2i // occupied cells
+	min   V2    // position of cell 0, 0 (lower left corner) in part coordinates
+	size  V2i   // size of the footprint in cells
+}
+
+// newFootprint rasterizes a footprint given its distance function and bounding box.
+// Cells closer than the margin are occupied.
+func newFootprint(dist func(p V2) float64, bb Box2, h, margin float64) *nestFootprint {
+	// allow for the distance from the cell center to its corners
+	margin += h * math.Sqrt2 / 2
+	min := bb.Min.SubScalar(margin)
+	size := bb.Size().AddScalar(2 * margin)
+	nx := int(math.Ceil(size.X / h))
+	ny := int(math.Ceil(size.Y / h))
+	f := &nestFootprint{min: min, size: V2i{nx, ny}}
+	for i := 0; i < nx; i++ {
+		for j := 0; j < ny; j++ {
+			p := min.Add(V2{float64(i) + 0.5, float64(j) + 0.5}.MulScalar(h))
+			if dist(p) < margin {
+				f.cells = append(f.cells, V2i{i, j})
+			}
+		}
+	}
+	return f
+}
+
+// nestSheet is the occupancy grid of a plate or sheet.
+type nestSheet struct {
+	occupied [][]bool
+	nx, ny   int
+}
+
+func newNestSheet(size V2, h float64) *nestSheet {
+	nx := int(math.Floor(size.X / h))
+	ny := int(math.Floor(size.Y / h))
+	occupied := make([][]bool, nx)
+	for i := range occupied {
+		occupied[i] = make([]bool, ny)
+	}
+	return &nestSheet{occupied, nx, ny}
+}
+
+// fits returns true if the footprint fits with its cell 0, 0 at i, j.
+func (s *nestSheet) fits(f *nestFootprint, i, j int) bool {
+	if i+f.size[0] > s.nx || j+f.size[1] > s.ny {
+		return false
+	}
+	for _, c := range f.cells {
+		if s.occupied[i+c[0]][j+c[1]] {
+			return false
+		}
+	}
+	return true
+}
+
+// find returns the bottom-left most position where the footprint fits.
+func (s *nestSheet) find(f *nestFootprint) (int, int, bool) {
+	for j := 0; j+f.size[1] <= s.ny; j++ {
+		for i := 0; i+f.size[0] <= s.nx; i++ {
+			if s.fits(f, i, j) {
+				return i, j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// place marks the footprint cells as occupied.
+func (s *nestSheet) place(f *nestFootprint, i, j int) {
+	for _, c := range f.cells {
+		s.occupied[i+c[0]][j+c[1]] = true
+	}
+}
+
+// bestFit returns the rotation and bottom-left most position for a part.
+func (s *nestSheet) bestFit(footprints []*nestFootprint) (int, int, int, bool) {
+	best := -1
+	var bi, bj int
+	for r, fp := range footprints {
+		x, y, ok := s.find(fp)
+		if ok && (best < 0 || y < bj || (y == bj && x < bi)) {
+			best, bi, bj = r, x, y
+		}
+	}
+	return best, bi, bj, best >= 0
+}
+
+// nestOrder returns the part indices ordered by decreasing footprint area.
+func nestOrder(footprints []*nestFootprint) []int {
+	order := make([]int, len(footprints))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return len(footprints[order[a]].cells) > len(footprints[order[b]].cells)
+	})
+	return order
+}
+
+//-----------------------------------------------------------------------------
+
+// footprint3 returns the distance function of the XY projection of an SDF3.
+// The distance is the minimum of the 3D distance over z samples, so it never
+// exceeds the distance to the projection (given dense enough samples).
+func footprint3(s SDF3, h float64) func(p V2) float64 {
+	bb := s.BoundingBox()
+	nz := int(math.Ceil(bb.Size().Z/h)) + 1
+	dz := bb.Size().Z / float64(nz)
+	return func(p V2) float64 {
+		d := math.Inf(1)
+		for k := 0; k < nz; k++ {
+			d = math.Min(d, s.Evaluate(V3{p.X, p.Y, bb.Min.Z + (float64(k)+0.5)*dz}))
+		}
+		return d
+	}
+}
+
+// PackParts arranges parts on a build plate with at least the spacing between them.
+// Parts may be rotated by 90 degrees about z. The returned transforms place each
+// part on the plate with its base at z = 0. Use Render3MFObjects to export the plate.
+func PackParts(parts []SDF3, plate Box2, spacing float64) ([]M44, error) {
+	if spacing <= 0 {
+		return nil, errors.New("spacing <= 0")
+	}
+	size := plate.Size()
+	h := math.Max(spacing/2, size.MaxComponent()/400)
+	sheet := newNestSheet(size, h)
+
+	// footprints for 0 and 90 degree rotations
+	rotations := []M44{Identity3d(), RotateZ(Pi / 2)}
+	footprints := make([][]*nestFootprint, len(parts))
+	area := make([]*nestFootprint, len(parts))
+	for i, p := range parts {
+		for _, m := range rotations {
+			s := Transform3D(p, m)
+			bb := s.BoundingBox()
+			fp := newFootprint(footprint3(s, h), Box2{V2{bb.Min.X, bb.Min.Y}, V2{bb.Max.X, bb.Max.Y}}, h, spacing/2)
+			footprints[i] = append(footprints[i], fp)
+		}
+		area[i] = footprints[i][0]
+	}
+
+	transforms := make([]M44, len(parts))
+	for _, i := range nestOrder(area) {
+		best, bi, bj, ok := sheet.bestFit(footprints[i])
+		if !ok {
+			return nil, errors.New("the parts don't fit on the plate")
+		}
+		fp := footprints[i][best]
+		sheet.place(fp, bi, bj)
+		// move the footprint origin to the sheet position
+		m := rotations[best]
+		bb := Transform3D(parts[i], m).BoundingBox()
+		t := plate.Min.Add(V2{float64(bi), float64(bj)}.MulScalar(h)).Sub(fp.min)
+		transforms[i] = Translate3d(V3{t.X, t.Y, -bb.Min.Z}).Mul(m)
+	}
+	return transforms, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// NestParms defines the parameters for nesting 2D shapes on stock sheets.
+type NestParms struct {
+	Sheet      V2      // sheet size, the sheet is at (0, 0) to Sheet
+	Spacing    float64 // minimum distance between shapes
+	Rotations  int     // number of rotation steps (1 = no rotation, 4 = 90 degree steps)
+	Resolution float64 // grid size (0 = Spacing/2)
+}
+
+// NestPlacement is the placement of a shape on a sheet.
+type NestPlacement struct {
+	Sheet     int // sheet number
+	Transform M33 // shape to sheet transform
+}
+
+// NestShapes nests 2D shapes onto as many stock sheets as needed.
+func NestShapes(shapes []SDF2, k *NestParms) ([]NestPlacement, error) {
+	if k.Spacing <= 0 {
+		return nil, errors.New("Spacing <= 0")
+	}
+	if k.Rotations <= 0 {
+		return nil, errors.New("Rotations <= 0")
+	}
+	if k.Resolution < 0 {
+		return nil, errors.New("Resolution < 0")
+	}
+	h := k.Resolution
+	if h == 0 {
+		h = k.Spacing / 2
+	}
+
+	// footprints for each rotation
+	footprints := make([][]*nestFootprint, len(shapes))
+	rotations := make([][]M33, len(shapes))
+	area := make([]*nestFootprint, len(shapes))
+	for i, shape := range shapes {
+		for r := 0; r < k.Rotations; r++ {
+			m := Rotate2d(Tau * float64(r) / float64(k.Rotations))
+			s := Transform2D(shape, m)
+			footprints[i] = append(footprints[i], newFootprint(s.Evaluate, s.BoundingBox(), h, k.Spacing/2))
+			rotations[i] = append(rotations[i], m)
+		}
+		area[i] = footprints[i][0]
+	}
+
+	var sheets []*nestSheet
+	placements := make([]NestPlacement, len(shapes))
+	for _, i := range nestOrder(area) {
+		placed := false
+		for n := 0; !placed; n++ {
+			empty := n == len(sheets)
+			if empty {
+				sheets = append(sheets, newNestSheet(k.Sheet, h))
+			}
+			r, bi, bj, ok := sheets[n].bestFit(footprints[i])
+			if !ok {
+				if empty {
+					return nil, fmt.Errorf("shape %d doesn't fit on a sheet", i)
+				}
+				continue
+			}
+			fp := footprints[i][r]
+			sheets[n].place(fp, bi, bj)
+			t := V2{float64(bi), float64(bj)}.MulScalar(h).Sub(fp.min)
+			placements[i] = NestPlacement{n, Translate2d(t).Mul(rotations[i][r])}
+			placed = true
+		}
+	}
+	return placements, nil
+}
+
+// NestLayout returns the nested shapes on a sheet as a single SDF2 (nil if the sheet is empty).
+func NestLayout(shapes []SDF2, placements []NestPlacement, sheet int) SDF2 {
+	var s []SDF2
+	for i, p := range placements {
+		if p.Sheet == sheet {
+			s = append(s, Transform2D(shapes[i], p.Transform))
+		}
+	}
+	if len(s) == 0 {
+		return nil
+	}
+	return Union2D(s...)
+}
+
+// nestLines returns the line segments for the layout of a sheet, including the sheet outline.
+func nestLines(shapes []SDF2, placements []NestPlacement, sheet int, size V2, resolution float64) []*Line {
+	lines := []*Line{
+		{V2{0, 0}, V2{size.X, 0}},
+		{V2{size.X, 0}, size},
+		{size, V2{0, size.Y}},
+		{V2{0, size.Y}, V2{0, 0}},
+	}
+	if s := NestLayout(shapes, placements, sheet); s != nil {
+		lines = append(lines, contourLines(OrderPaths(Contours2(s, resolution), V2{}, true))...)
+	}
+	return lines
+}
+
+// SaveNestSVG writes the layout of a sheet to an SVG file.
+func SaveNestSVG(path string, shapes []SDF2, placements []NestPlacement, sheet int, k *NestParms) error {
+	return SaveSVG(path, "fill:none;stroke:black;stroke-width:0.1", nestLines(shapes, placements, sheet, k.Sheet, k.Spacing/4))
+}
+
+// SaveNestDXF writes the layout of a sheet to a DXF file.
+func SaveNestDXF(path string, shapes []SDF2, placements []NestPlacement, sheet int, k *NestParms) error {
+	return SaveDXF(path, nestLines(shapes, placements, sheet, k.Sheet, k.Spacing/4))
+}
+
+//-----------------------------------------------------------------------------
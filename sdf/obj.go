@@ -0,0 +1,134 @@
+//-----------------------------------------------------------------------------
+/*
+
+Wavefront OBJ/MTL Save
+
+The mesh is written to an OBJ file with one group per material. The material
+colors are written to an MTL file with the same base name.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// OBJGroup is a triangle mesh with a material.
+type OBJGroup struct {
+	Mesh     []*Triangle3
+	Material *Material // nil for no material
+}
+
+// objMaterialName returns a material name usable in an OBJ/MTL file.
+func objMaterialName(m *Material, i int) string {
+	name := strings.Join(strings.Fields(m.Name), "_")
+	if name == "" {
+		name = fmt.Sprintf("material%d", i)
+	}
+	return name
+}
+
+// saveMTL writes the materials to an MTL file.
+func saveMTL(path string, materials []*Material, names []string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	buf := bufio.NewWriter(file)
+	for i, m := range materials {
+		fmt.Fprintf(buf, "newmtl %s\n", names[i])
+		c := m.Color
+		fmt.Fprintf(buf, "Kd %g %g %g\n", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+		fmt.Fprintf(buf, "d %g\n\n", float64(c.A)/255)
+	}
+	return buf.Flush()
+}
+
+// SaveOBJ writes triangle meshes to an OBJ file. If any group has a material
+// an MTL file is written alongside the OBJ file.
+func SaveOBJ(path string, groups []OBJGroup) error {
+	// material names
+	var materials []*Material
+	var names []string
+	mtlName := map[*Material]string{}
+	for _, g := range groups {
+		if g.Material == nil {
+			continue
+		}
+		if _, ok := mtlName[g.Material]; !ok {
+			mtlName[g.Material] = objMaterialName(g.Material, len(materials))
+			materials = append(materials, g.Material)
+			names = append(names, mtlName[g.Material])
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	buf := bufio.NewWriter(file)
+	if len(materials) != 0 {
+		mtlPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".mtl"
+		if err := saveMTL(mtlPath, materials, names); err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "mtllib %s\n", filepath.Base(mtlPath))
+	}
+
+	// vertex indices are shared across the groups (1 based)
+	vertices := map[V3]int{}
+	for _, g := range groups {
+		for _, t := range g.Mesh {
+			for _, v := range t.V {
+				if _, ok := vertices[v]; !ok {
+					vertices[v] = len(vertices) + 1
+					fmt.Fprintf(buf, "v %g %g %g\n", v.X, v.Y, v.Z)
+				}
+			}
+		}
+	}
+	for i, g := range groups {
+		fmt.Fprintf(buf, "g group%d\n", i)
+		if g.Material != nil {
+			fmt.Fprintf(buf, "usemtl %s\n", mtlName[g.Material])
+		}
+		for _, t := range g.Mesh {
+			fmt.Fprintf(buf, "f %d %d %d\n", vertices[t.V[0]], vertices[t.V[1]], vertices[t.V[2]])
+		}
+	}
+	return buf.Flush()
+}
+
+// RenderOBJ renders an SDF3 as an OBJ file with one group per material (see Material3D).
+func RenderOBJ(
+	s SDF3, //sdf3 to render
+	meshCells int, //number of cells on the longest axis. e.g 200 (<= 0 for AutoMeshCells)
+	path string, //path to filename
+) error {
+	if meshCells <= 0 {
+		meshCells = AutoMeshCells(s)
+	}
+	resolution := s.BoundingBox().Size().MaxComponent() / float64(meshCells)
+	var groups []OBJGroup
+	for _, b := range FindMaterials3(s) {
+		// mesh each body at the same resolution as the whole object
+		cells := int(math.Ceil(b.SDF.BoundingBox().Size().MaxComponent() / resolution))
+		groups = append(groups, OBJGroup{RenderMesh(b.SDF, cells), b.Material})
+	}
+	return SaveOBJ(path, groups)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,265 @@
+//-----------------------------------------------------------------------------
+/*
+
+Shape Optimization
+
+Finite difference sensitivities of metrics (see SweepMetric) with respect to
+named model parameters, and a simple optimizer to tune the parameters.
+
+The optimizer minimizes an objective metric subject to constraints of the
+form metric >= minimum. Constraints are handled with a quadratic penalty that
+is increased over several rounds of gradient descent (with a backtracking
+line search). Each evaluation meshes the model, so keep MeshCells modest.
+
+E.g. minimize the volume of a bracket subject to a clearance constraint.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// OptimizeConstraint requires a metric to be >= Min.
+type OptimizeConstraint struct {
+	Metric SweepMetric
+	Min    float64
+}
+
+// OptimizeParms defines the parameters for a shape optimization.
+type OptimizeParms struct {
+	Build       func(params map[string]float64) (SDF3, error) // model builder
+	Params      map[string]float64                            // initial parameter values
+	Bounds      map[string][2]float64                         // parameter [min, max] (optional)
+	Step        map[string]float64                            // finite difference step (optional, default 1% of the value)
+	Objective   SweepMetric                                   // metric to minimize
+	Constraints []OptimizeConstraint                          // metric constraints
+	MeshCells   int                                           // number of cells on the longest axis. e.g 50
+	Iterations  int                                           // maximum iterations per penalty round (default 50)
+	Penalty     float64                                       // initial constraint penalty weight (default 10)
+}
+
+//-----------------------------------------------------------------------------
+
+// paramNames returns the sorted parameter names.
+func paramNames(params map[string]float64) []string {
+	var names []string
+	for k := range params {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// copyParams returns a copy of a parameter map.
+func copyParams(params map[string]float64) map[string]float64 {
+	p := make(map[string]float64, len(params))
+	for k, v := range params {
+		p[k] = v
+	}
+	return p
+}
+
+// evalMetrics builds a model and returns the metric values.
+func evalMetrics(build func(map[string]float64) (SDF3, error), params map[string]float64, metrics []SweepMetric, meshCells int) ([]float64, error) {
+	k := &SweepParms{
+		Build:     build,
+		Metrics:   metrics,
+		MeshCells: meshCells,
+	}
+	r := sweepVariant(k, 0, params)
+	return r.Metrics, r.Err
+}
+
+// fdStep returns the finite difference step for a parameter.
+func fdStep(steps map[string]float64, name string, x float64) float64 {
+	if h, ok := steps[name]; ok && h > 0 {
+		return h
+	}
+	return Max(0.01*Abs(x), 1e-3)
+}
+
+// Sensitivities returns the central finite difference derivatives of the metrics
+// with respect to each parameter. The result maps a parameter name to the
+// derivatives of the metrics (in metric order).
+func Sensitivities(
+	build func(params map[string]float64) (SDF3, error), // model builder
+	params map[string]float64, // parameter values
+	metrics []SweepMetric, // metrics to differentiate
+	steps map[string]float64, // finite difference steps (optional, default 1% of the value)
+	meshCells int, // number of cells on the longest axis. e.g 50
+) (map[string][]float64, error) {
+	d := make(map[string][]float64)
+	for _, name := range paramNames(params) {
+		h := fdStep(steps, name, params[name])
+		p := copyParams(params)
+		p[name] = params[name] + h
+		m1, err := evalMetrics(build, p, metrics, meshCells)
+		if err != nil {
+			return nil, err
+		}
+		p[name] = params[name] - h
+		m0, err := evalMetrics(build, p, metrics, meshCells)
+		if err != nil {
+			return nil, err
+		}
+		d[name] = make([]float64, len(metrics))
+		for i := range metrics {
+			d[name][i] = (m1[i] - m0[i]) / (2 * h)
+		}
+	}
+	return d, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// optimizer holds the state of an optimization.
+type optimizer struct {
+	k       *OptimizeParms
+	names   []string
+	metrics []SweepMetric
+	penalty float64
+}
+
+// clamp limits the parameters to their bounds.
+func (o *optimizer) clamp(p map[string]float64) {
+	for name, b := range o.k.Bounds {
+		if x, ok := p[name]; ok {
+			p[name] = Clamp(x, b[0], b[1])
+		}
+	}
+}
+
+// cost returns the penalized objective for a set of parameters.
+func (o *optimizer) cost(p map[string]float64) (float64, error) {
+	m, err := evalMetrics(o.k.Build, p, o.metrics, o.k.MeshCells)
+	if err != nil {
+		return 0, err
+	}
+	c := m[0]
+	for i, x := range o.k.Constraints {
+		if v := x.Min - m[i+1]; v > 0 {
+			c += o.penalty * v * v
+		}
+	}
+	return c, nil
+}
+
+// gradient returns the finite difference gradient of the cost.
+func (o *optimizer) gradient(p map[string]float64) (map[string]float64, error) {
+	g := make(map[string]float64)
+	for _, name := range o.names {
+		h := fdStep(o.k.Step, name, o.k.Params[name])
+		q := copyParams(p)
+		q[name] = p[name] + h
+		c1, err := o.cost(q)
+		if err != nil {
+			return nil, err
+		}
+		q[name] = p[name] - h
+		c0, err := o.cost(q)
+		if err != nil {
+			return nil, err
+		}
+		g[name] = (c1 - c0) / (2 * h)
+	}
+	return g, nil
+}
+
+// descend runs gradient descent with a backtracking line search.
+func (o *optimizer) descend(p map[string]float64, c float64) (map[string]float64, float64, error) {
+	alpha := 10.0 // step size in units of the finite difference step
+	for i := 0; i < o.k.Iterations && alpha > 0.1; i++ {
+		g, err := o.gradient(p)
+		if err != nil {
+			return nil, 0, err
+		}
+		// scale the gradient so the largest step is alpha finite difference steps
+		gmax := 0.0
+		for _, name := range o.names {
+			gmax = Max(gmax, Abs(g[name]*fdStep(o.k.Step, name, o.k.Params[name])))
+		}
+		if gmax == 0 {
+			break
+		}
+		for alpha > 0.1 {
+			q := copyParams(p)
+			for _, name := range o.names {
+				h := fdStep(o.k.Step, name, o.k.Params[name])
+				q[name] -= alpha * h * g[name] * h / gmax
+			}
+			o.clamp(q)
+			cq, err := o.cost(q)
+			if err != nil {
+				return nil, 0, err
+			}
+			if cq < c {
+				p, c = q, cq
+				alpha *= 1.5
+				break
+			}
+			alpha *= 0.5
+		}
+	}
+	return p, c, nil
+}
+
+// Optimize tunes the parameters to minimize the objective subject to the constraints.
+// It returns the optimized parameters and the objective value.
+func Optimize(k *OptimizeParms) (map[string]float64, float64, error) {
+	if k.Build == nil || k.Objective.Measure == nil {
+		return nil, 0, errors.New("no model builder or objective")
+	}
+	if k.MeshCells <= 0 {
+		return nil, 0, errors.New("MeshCells <= 0")
+	}
+	o := &optimizer{
+		k:       k,
+		names:   paramNames(k.Params),
+		metrics: []SweepMetric{k.Objective},
+		penalty: k.Penalty,
+	}
+	if o.penalty <= 0 {
+		o.penalty = 10
+	}
+	if k.Iterations <= 0 {
+		k2 := *k
+		k2.Iterations = 50
+		o.k = &k2
+	}
+	for _, x := range k.Constraints {
+		o.metrics = append(o.metrics, x.Metric)
+	}
+	p := copyParams(k.Params)
+	o.clamp(p)
+	// increase the penalty to push the solution onto the constraints
+	for round := 0; round < 4; round++ {
+		c, err := o.cost(p)
+		if err != nil {
+			return nil, 0, err
+		}
+		p, _, err = o.descend(p, c)
+		if err != nil {
+			return nil, 0, err
+		}
+		o.penalty *= 10
+	}
+	m, err := evalMetrics(k.Build, p, o.metrics, k.MeshCells)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i, x := range k.Constraints {
+		if m[i+1] < x.Min-1e-3*Max(1, Abs(x.Min)) {
+			return p, m[0], fmt.Errorf("constraint \"%s\" not met (%g < %g)", x.Metric.Name, m[i+1], x.Min)
+		}
+	}
+	return p, m[0], nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,481 @@
+//-----------------------------------------------------------------------------
+/*
+
+Operator Options and Tree Rebuilding
+
+Functional options configure operators at construction time. E.g.
+
+	s := NewUnionSDF3(a, b, WithSmooth(2))
+
+The With methods return a copy of an operator with modified options, and
+Rebuild3 returns a copy of an SDF3 tree with some nodes replaced. Parametric
+sweeps and optimizers can then vary the parameters of an existing tree.
+
+Naming: constructors that take functional options are New<Node>SDF2/3
+(E.g. NewUnionSDF3, NewExtrudeSDF3). Constructors without options follow the
+naming of the rest of the package (E.g. Union3D, Draft3D).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+// Blending Options
+
+// blendOptions are the blending functions of an operator.
+type blendOptions struct {
+	min MinFunc
+	max MaxFunc
+}
+
+// BlendOption sets the blending of a union, difference or intersection.
+type BlendOption func(b *blendOptions)
+
+// WithMin sets the minimum function used by unions.
+func WithMin(min MinFunc) BlendOption {
+	return func(b *blendOptions) {
+		b.min = min
+	}
+}
+
+// WithMax sets the maximum function used by differences and intersections.
+func WithMax(max MaxFunc) BlendOption {
+	return func(b *blendOptions) {
+		b.max = max
+	}
+}
+
+// WithSmooth sets polynomial smoothing of size k for unions, differences and intersections.
+func WithSmooth(k float64) BlendOption {
+	return func(b *blendOptions) {
+		b.min = PolyMin(k)
+		b.max = PolyMax(k)
+	}
+}
+
+// applyBlend returns the blending functions with the options applied.
+func applyBlend(min MinFunc, max MaxFunc, opts []BlendOption) blendOptions {
+	b := blendOptions{min, max}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	return b
+}
+
+// NewUnionSDF3 returns the union of two SDF3s with blending options.
+func NewUnionSDF3(s0, s1 SDF3, opts ...BlendOption) SDF3 {
+	s := Union3D(s0, s1)
+	if u, ok := s.(*UnionSDF3); ok {
+		return u.With(opts...)
+	}
+	return s
+}
+
+// NewDifferenceSDF3 returns the difference of two SDF3s (s0 - s1) with blending options.
+func NewDifferenceSDF3(s0, s1 SDF3, opts ...BlendOption) SDF3 {
+	s := Difference3D(s0, s1)
+	if d, ok := s.(*DifferenceSDF3); ok {
+		return d.With(opts...)
+	}
+	return s
+}
+
+// NewIntersectionSDF3 returns the intersection of two SDF3s with blending options.
+func NewIntersectionSDF3(s0, s1 SDF3, opts ...BlendOption) SDF3 {
+	s := Intersect3D(s0, s1)
+	if i, ok := s.(*IntersectionSDF3); ok {
+		return i.With(opts...)
+	}
+	return s
+}
+
+// NewUnionSDF2 returns the union of two SDF2s with blending options.
+func NewUnionSDF2(s0, s1 SDF2, opts ...BlendOption) SDF2 {
+	s := Union2D(s0, s1)
+	if u, ok := s.(*UnionSDF2); ok {
+		return u.With(opts...)
+	}
+	return s
+}
+
+// NewDifferenceSDF2 returns the difference of two SDF2s (s0 - s1) with blending options.
+func NewDifferenceSDF2(s0, s1 SDF2, opts ...BlendOption) SDF2 {
+	s := Difference2D(s0, s1)
+	if d, ok := s.(*DifferenceSDF2); ok {
+		return d.With(opts...)
+	}
+	return s
+}
+
+// NewIntersectionSDF2 returns the intersection of two SDF2s with blending options.
+func NewIntersectionSDF2(s0, s1 SDF2, opts ...BlendOption) SDF2 {
+	s := Intersect2D(s0, s1)
+	if i, ok := s.(*IntersectionSDF2); ok {
+		return i.With(opts...)
+	}
+	return s
+}
+
+//-----------------------------------------------------------------------------
+// Extrusion Options
+
+// extrudeOptions are the placement options of an extrusion.
+type extrudeOptions struct {
+	centered    bool    // symmetric about z = 0
+	base        float64 // z of the extrusion base (not centered)
+	bottom, top float64 // extension of the bottom and top
+}
+
+// ExtrudeOption sets the placement of an extrusion along the z-axis.
+type ExtrudeOption func(e *extrudeOptions)
+
+// WithCentered extrudes symmetrically about z = 0 (the default).
+func WithCentered() ExtrudeOption {
+	return func(e *extrudeOptions) {
+		e.centered = true
+	}
+}
+
+// WithBase extrudes from z = base to z = base + height.
+// A negative height extrudes down from the base.
+func WithBase(base float64) ExtrudeOption {
+	return func(e *extrudeOptions) {
+		e.centered = false
+		e.base = base
+	}
+}
+
+// WithEndOffsets extends the bottom of an extrusion down and the top up.
+// E.g. so a cutting tool passes through the faces it cuts.
+// Negative offsets trim the extrusion.
+func WithEndOffsets(bottom, top float64) ExtrudeOption {
+	return func(e *extrudeOptions) {
+		e.bottom = bottom
+		e.top = top
+	}
+}
+
+// NewExtrudeSDF3 returns a linear extrusion of an SDF2 placed with extrusion options.
+func NewExtrudeSDF3(sdf SDF2, height float64, opts ...ExtrudeOption) SDF3 {
+	e := extrudeOptions{centered: true}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	z0, z1 := e.base, e.base+height
+	if e.centered {
+		z0, z1 = -0.5*height, 0.5*height
+	}
+	if z0 > z1 {
+		z0, z1 = z1, z0
+	}
+	z0 -= e.bottom
+	z1 += e.top
+	s := Extrude3D(sdf, z1-z0)
+	if z := 0.5 * (z0 + z1); z != 0 {
+		s = Transform3D(s, Translate3d(V3{0, 0, z}))
+	}
+	return s
+}
+
+//-----------------------------------------------------------------------------
+// Revolve Options
+
+// revolveOptions are the options of a partial revolution.
+type revolveOptions struct {
+	start float64 // start angle
+	round bool    // rounded ends
+}
+
+// RevolveOption sets the start angle and ends of a partial revolution.
+type RevolveOption func(r *revolveOptions)
+
+// WithStartAngle starts a partial revolution at an angle (radians) from the x-axis.
+func WithStartAngle(a float64) RevolveOption {
+	return func(r *revolveOptions) {
+		r.start = a
+	}
+}
+
+// WithRoundEnds rounds the ends of a partial revolution. The ends are the
+// profile revolved about the vertical axis through the profile center, so
+// they suit profiles that are symmetric about their center (E.g. circles).
+func WithRoundEnds() RevolveOption {
+	return func(r *revolveOptions) {
+		r.round = true
+	}
+}
+
+// NewRevolveSDF3 returns a solid of revolution through theta radians
+// (0 for a full revolution) with revolve options.
+func NewRevolveSDF3(sdf SDF2, theta float64, opts ...RevolveOption) SDF3 {
+	r := revolveOptions{}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return newSorSDF3(sdf, theta, r.start, r.round)
+}
+
+//-----------------------------------------------------------------------------
+// Polar Array Options
+
+// PolarFacing is the orientation of the copies in a polar array.
+type PolarFacing int
+
+// Polar array facing.
+const (
+	FaceOutward PolarFacing = iota // the +x axis of each copy points away from the center
+	FaceInward                     // the +x axis of each copy points to the center
+	FaceFixed                      // the copies are not rotated
+)
+
+// polarOptions are the options of a polar array.
+type polarOptions struct {
+	start  float64     // angle of the first copy
+	arc    float64     // angle from the first to the last copy (0 for a full circle)
+	facing PolarFacing // orientation of the copies
+}
+
+// PolarOption sets the placement of the copies in a polar array.
+type PolarOption func(p *polarOptions)
+
+// WithPolarStart places the first copy at an angle (radians) from the x-axis.
+func WithPolarStart(a float64) PolarOption {
+	return func(p *polarOptions) {
+		p.start = a
+	}
+}
+
+// WithPolarArc spreads the copies over an arc (radians) from the first to the last copy.
+func WithPolarArc(arc float64) PolarOption {
+	return func(p *polarOptions) {
+		p.arc = arc
+	}
+}
+
+// WithPolarFacing sets the orientation of the copies.
+func WithPolarFacing(f PolarFacing) PolarOption {
+	return func(p *polarOptions) {
+		p.facing = f
+	}
+}
+
+// NewPolarArraySDF3 returns num copies of an SDF3 placed around a circle of a
+// given radius about the z-axis. The SDF3 is modelled at the origin and by
+// default its +x axis faces away from the center.
+func NewPolarArraySDF3(sdf SDF3, num int, radius float64, opts ...PolarOption) SDF3 {
+	if num <= 0 {
+		return nil
+	}
+	p := polarOptions{}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	step, _ := rotateCopyParms(num, p.arc)
+	return InstanceArray3D(sdf, num, func(i int) M44 {
+		a := p.start + float64(i)*step
+		switch p.facing {
+		case FaceInward:
+			return RotateZ(a).Mul(Translate3d(V3{radius, 0, 0})).Mul(RotateZ(Pi))
+		case FaceFixed:
+			return Translate3d(V3{radius * math.Cos(a), radius * math.Sin(a), 0})
+		}
+		return RotateZ(a).Mul(Translate3d(V3{radius, 0, 0}))
+	})
+}
+
+//-----------------------------------------------------------------------------
+// Array Options
+
+// arrayOptions are the options of a grid array.
+type arrayOptions struct {
+	mirror    [3]bool // mirror alternate copies along x, y, z
+	rowOffset float64 // x offset of alternate rows
+}
+
+// ArrayOption sets the layout of the copies in a grid array.
+type ArrayOption func(a *arrayOptions)
+
+// WithMirrorAlternate mirrors the odd numbered copies along the x, y and/or z
+// axes about their own origin. E.g. herringbone layouts.
+// z is ignored by 2D arrays.
+func WithMirrorAlternate(x, y, z bool) ArrayOption {
+	return func(a *arrayOptions) {
+		a.mirror = [3]bool{x, y, z}
+	}
+}
+
+// WithRowOffset offsets the odd numbered rows (along y) by a distance along x.
+// E.g. brick layouts and staggered perforations.
+func WithRowOffset(ofs float64) ArrayOption {
+	return func(a *arrayOptions) {
+		a.rowOffset = ofs
+	}
+}
+
+//-----------------------------------------------------------------------------
+// Copy With Options
+
+// With returns a copy of a union with modified blending.
+func (s *UnionSDF3) With(opts ...BlendOption) SDF3 {
+	x := *s
+	x.min = applyBlend(s.min, nil, opts).min
+	return &x
+}
+
+// With returns a copy of a difference with modified blending.
+func (s *DifferenceSDF3) With(opts ...BlendOption) SDF3 {
+	x := *s
+	x.max = applyBlend(nil, s.max, opts).max
+	return &x
+}
+
+// With returns a copy of an intersection with modified blending.
+func (s *IntersectionSDF3) With(opts ...BlendOption) SDF3 {
+	x := *s
+	x.max = applyBlend(nil, s.max, opts).max
+	return &x
+}
+
+// With returns a copy of an array with modified blending.
+func (s *ArraySDF3) With(opts ...BlendOption) SDF3 {
+	x := *s
+	x.min = applyBlend(s.min, nil, opts).min
+	return &x
+}
+
+// With returns a copy of a rotate/union with modified blending.
+func (s *RotateUnionSDF3) With(opts ...BlendOption) SDF3 {
+	x := *s
+	x.min = applyBlend(s.min, nil, opts).min
+	return &x
+}
+
+// With returns a copy of a union with modified blending.
+func (s *UnionSDF2) With(opts ...BlendOption) SDF2 {
+	x := *s
+	x.min = applyBlend(s.min, nil, opts).min
+	return &x
+}
+
+// With returns a copy of a difference with modified blending.
+func (s *DifferenceSDF2) With(opts ...BlendOption) SDF2 {
+	x := *s
+	x.max = applyBlend(nil, s.max, opts).max
+	return &x
+}
+
+// With returns a copy of an intersection with modified blending.
+func (s *IntersectionSDF2) With(opts ...BlendOption) SDF2 {
+	x := *s
+	x.max = applyBlend(nil, s.max, opts).max
+	return &x
+}
+
+// With returns a copy of an array with modified blending.
+func (s *ArraySDF2) With(opts ...BlendOption) SDF2 {
+	x := *s
+	x.min = applyBlend(s.min, nil, opts).min
+	return &x
+}
+
+// With returns a copy of a rotate/union with modified blending.
+func (s *RotateUnionSDF2) With(opts ...BlendOption) SDF2 {
+	x := *s
+	x.min = applyBlend(s.min, nil, opts).min
+	return &x
+}
+
+//-----------------------------------------------------------------------------
+// Rebuilding Trees
+
+// rebuild3 returns a copy of an SDF3 node with new SDF3 children.
+// The node is rebuilt with its constructor so derived values (E.g. the bounding box) are updated.
+func rebuild3(s SDF3, c []SDF3) (SDF3, error) {
+	switch x := s.(type) {
+	case *UnionSDF3:
+		u := Union3D(c...)
+		if uu, ok := u.(*UnionSDF3); ok {
+			// a one child union is the child
+			uu.min = x.min
+		}
+		return u, nil
+	case *DifferenceSDF3:
+		return NewDifferenceSDF3(c[0], c[1], WithMax(x.max)), nil
+	case *IntersectionSDF3:
+		return NewIntersectionSDF3(c[0], c[1], WithMax(x.max)), nil
+	case *XorSDF3:
+		return Xor3D(c[0], c[1]), nil
+	case *BlendRegionSDF3:
+		return BlendRegion3D(c[0], c[1], c[2], x.k)
+	case *TransformSDF3:
+		return Transform3D(c[0], x.matrix), nil
+	case *ScaleUniformSDF3:
+		return ScaleUniform3D(c[0], x.k), nil
+	case *OffsetSDF3:
+		return Offset3D(c[0], x.offset), nil
+	case *ElongateSDF3:
+		return Elongate3D(c[0], x.hp.Sub(x.hn)), nil
+	case *CutSDF3:
+		return Cut3D(c[0], x.a, x.n), nil
+	case *MaterialSDF3:
+		return Material3D(c[0], x.material), nil
+	case *DraftSDF3:
+		return Draft3D(c[0], x.pull, math.Atan(x.k))
+	case *VariableOffsetSDF3:
+		return VariableOffset3D(c[0], x.offset, x.maxOffset), nil
+	case *ProfileSDF3:
+		return &ProfileSDF3{c[0], x.counter}, nil
+	case *ArraySDF3:
+		a := NewArraySDF3(c[0], x.num, x.step, WithMirrorAlternate(x.mirror[0], x.mirror[1], x.mirror[2]), WithRowOffset(x.rowOffset))
+		if aa, ok := a.(*ArraySDF3); ok {
+			aa.min = x.min
+		}
+		return a, nil
+	case *RotateUnionSDF3:
+		r := RotateUnion3D(c[0], x.num, x.step.Inverse())
+		if rr, ok := r.(*RotateUnionSDF3); ok {
+			rr.min = x.min
+		}
+		return r, nil
+	case *RotateCopySDF3:
+		return RotateCopyArc3D(c[0], x.num, x.arc), nil
+	}
+	return nil, fmt.Errorf("can't rebuild %s", NodeName(s))
+}
+
+// Rebuild3 returns a copy of an SDF3 tree with nodes replaced by fn.
+// fn is called for each SDF3 node (children before parents) and returns the
+// node to use, either the node itself or a replacement. The ancestors of a
+// replaced node are rebuilt. The original tree is unchanged.
+// SDF2 sub-trees (E.g. the profile of an extrusion) are not visited.
+func Rebuild3(s SDF3, fn func(s SDF3) SDF3) (SDF3, error) {
+	if p, ok := s.(Parent3); ok {
+		c0 := p.Children()
+		c1 := make([]SDF3, len(c0))
+		changed := false
+		for i := range c0 {
+			x, err := Rebuild3(c0[i], fn)
+			if err != nil {
+				return nil, err
+			}
+			c1[i] = x
+			changed = changed || x != c0[i]
+		}
+		if changed {
+			x, err := rebuild3(s, c1)
+			if err != nil {
+				return nil, err
+			}
+			s = x
+		}
+	}
+	return fn(s), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,124 @@
+//-----------------------------------------------------------------------------
+/*
+
+Print Orientation
+
+Overhang analysis and orientation optimization for 3D printing.
+
+A face overhangs if it faces downwards at more than the critical angle from
+vertical. Supports are estimated as the volume below the overhanging faces.
+
+The orientation optimizer rotates the part over a set of candidate
+orientations and scores each one for support volume, bed contact area and
+build height. The part is meshed once and the mesh is rotated.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// Overhang is the overhang analysis of a mesh on a build plate at the minimum z of the mesh.
+type Overhang struct {
+	Area          float64 // area of the overhanging faces
+	SupportVolume float64 // volume between the overhanging faces and the bed
+	ContactArea   float64 // area of the faces on the bed
+	Height        float64 // build height
+}
+
+// triangleArea returns the area of a triangle.
+func triangleArea(t *Triangle3) float64 {
+	return 0.5 * t.V[1].Sub(t.V[0]).Cross(t.V[2].Sub(t.V[0])).Length()
+}
+
+// AnalyzeOverhang returns the overhang analysis of a mesh printed along +z.
+// maxAngle is the largest printable overhang measured from vertical (radians).
+func AnalyzeOverhang(mesh []*Triangle3, maxAngle float64) Overhang {
+	bb := MeshBoundingBox(mesh)
+	bed := bb.Min.Z
+	eps := 1e-3 * math.Max(bb.Size().MaxComponent(), 1)
+	limit := -math.Sin(maxAngle)
+	o := Overhang{Height: bb.Size().Z}
+	for _, t := range mesh {
+		n := t.Normal()
+		if n.Z >= limit {
+			continue
+		}
+		area := triangleArea(t)
+		z := (t.V[0].Z + t.V[1].Z + t.V[2].Z) / 3
+		if z-bed < eps {
+			o.ContactArea += area
+			continue
+		}
+		o.Area += area
+		o.SupportVolume += area * -n.Z * (z - bed)
+	}
+	return o
+}
+
+//-----------------------------------------------------------------------------
+
+// OrientParms defines the parameters for the orientation optimizer.
+type OrientParms struct {
+	MeshCells     int     // number of cells on the longest axis for meshing
+	MaxOverhang   float64 // largest printable overhang from vertical (radians)
+	Steps         int     // rotation steps per axis (e.g. 8 = 45 degree increments)
+	SupportWeight float64 // score per unit of support volume
+	ContactWeight float64 // score per unit of bed contact area (subtracted)
+	HeightWeight  float64 // score per unit of build height
+}
+
+// Orientation is a candidate print orientation.
+type Orientation struct {
+	Transform M44 // rotation that places the part on the bed at z = 0
+	Overhang
+	Score float64 // lower is better
+}
+
+// OrientForPrint returns the best print orientation for an SDF3.
+func OrientForPrint(s SDF3, k *OrientParms) (*Orientation, error) {
+	if k.MeshCells <= 0 {
+		return nil, errors.New("MeshCells <= 0")
+	}
+	if k.Steps <= 0 {
+		return nil, errors.New("Steps <= 0")
+	}
+	if k.MaxOverhang <= 0 || k.MaxOverhang >= Pi/2 {
+		return nil, errors.New("MaxOverhang must be > 0 and < Pi/2")
+	}
+	mesh := RenderMesh(s, k.MeshCells)
+	if len(mesh) == 0 {
+		return nil, errors.New("empty mesh")
+	}
+	var best *Orientation
+	rotated := make([]*Triangle3, len(mesh))
+	for i := range rotated {
+		rotated[i] = &Triangle3{}
+	}
+	for i := 0; i < k.Steps; i++ {
+		for j := 0; j < k.Steps; j++ {
+			m := RotateY(Tau * float64(j) / float64(k.Steps)).Mul(RotateX(Tau * float64(i) / float64(k.Steps)))
+			for n, t := range mesh {
+				for v := 0; v < 3; v++ {
+					rotated[n].V[v] = m.MulPosition(t.V[v])
+				}
+			}
+			o := AnalyzeOverhang(rotated, k.MaxOverhang)
+			score := k.SupportWeight*o.SupportVolume - k.ContactWeight*o.ContactArea + k.HeightWeight*o.Height
+			if best == nil || score < best.Score-tolerance {
+				// sit the part on the bed
+				bed := MeshBoundingBox(rotated).Min.Z
+				best = &Orientation{Translate3d(V3{0, 0, -bed}).Mul(m), o, score}
+			}
+		}
+	}
+	return best, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,268 @@
+//-----------------------------------------------------------------------------
+/*
+
+O-Ring Grooves
+
+Groove (gland) dimensions for standard O-rings, and groove shapes to cut
+from a part.
+
+Face glands are cut into a flat face and the O-ring is squeezed axially.
+Piston glands are cut into the outside of a shaft and seal against a bore.
+Rod glands are cut into a bore and seal against a shaft.
+
+The gland depth (the groove depth plus the gap between the parts) sets the
+squeeze of the O-ring cross section. Stretching an O-ring onto a groove
+reduces its cross section, this is allowed for using conservation of
+volume. The groove width is set so the O-ring fills a fraction of the gland.
+
+AS568 sizes are also the ISO 3601-1 inch series. Metric O-rings are given
+by their inner diameter and cross section.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+// O-Ring Database - lookup standard O-rings by name
+
+// ORing stores the dimensions of an O-ring (mm).
+type ORing struct {
+	Name string  // name of O-ring
+	ID   float64 // inner diameter
+	CS   float64 // cross section diameter
+}
+
+type oringDatabase map[string]*ORing
+
+var oringDB = initORingLookup()
+
+func (m oringDatabase) add(dash int, id, cs float64) {
+	name := fmt.Sprintf("AS568-%03d", dash)
+	m[name] = &ORing{name, id, cs}
+}
+
+func initORingLookup() oringDatabase {
+	m := make(oringDatabase)
+	// 1.78 mm cross section
+	m.add(4, 1.78, 1.78)
+	m.add(5, 2.57, 1.78)
+	m.add(6, 2.90, 1.78)
+	m.add(7, 3.68, 1.78)
+	m.add(8, 4.47, 1.78)
+	m.add(9, 5.28, 1.78)
+	m.add(10, 6.07, 1.78)
+	m.add(11, 7.65, 1.78)
+	m.add(12, 9.25, 1.78)
+	m.add(13, 10.82, 1.78)
+	m.add(14, 12.42, 1.78)
+	m.add(15, 14.00, 1.78)
+	m.add(16, 15.60, 1.78)
+	m.add(17, 17.17, 1.78)
+	m.add(18, 18.77, 1.78)
+	m.add(19, 20.35, 1.78)
+	m.add(20, 21.95, 1.78)
+	// 2.62 mm cross section
+	m.add(110, 9.19, 2.62)
+	m.add(111, 10.77, 2.62)
+	m.add(112, 12.37, 2.62)
+	m.add(113, 13.94, 2.62)
+	m.add(114, 15.54, 2.62)
+	m.add(115, 17.12, 2.62)
+	m.add(116, 18.72, 2.62)
+	m.add(117, 20.29, 2.62)
+	m.add(118, 21.89, 2.62)
+	m.add(119, 23.47, 2.62)
+	m.add(120, 25.07, 2.62)
+	// 3.53 mm cross section
+	m.add(210, 18.64, 3.53)
+	m.add(211, 20.22, 3.53)
+	m.add(212, 21.82, 3.53)
+	m.add(213, 23.39, 3.53)
+	m.add(214, 24.99, 3.53)
+	m.add(215, 26.57, 3.53)
+	m.add(216, 28.17, 3.53)
+	m.add(217, 29.74, 3.53)
+	m.add(218, 31.34, 3.53)
+	m.add(219, 32.92, 3.53)
+	m.add(220, 34.52, 3.53)
+	// 5.33 mm cross section
+	m.add(325, 37.47, 5.33)
+	m.add(326, 40.64, 5.33)
+	m.add(327, 43.82, 5.33)
+	m.add(328, 46.99, 5.33)
+	m.add(329, 50.17, 5.33)
+	m.add(330, 53.34, 5.33)
+	return m
+}
+
+// ORingLookup lookups the dimensions of an AS568 O-ring by name (e.g. "AS568-210").
+func ORingLookup(name string) (*ORing, error) {
+	if o, ok := oringDB[name]; ok {
+		return o, nil
+	}
+	return nil, fmt.Errorf("O-ring \"%s\" not found", name)
+}
+
+// MetricORing returns a metric O-ring with the given inner diameter and cross section.
+func MetricORing(id, cs float64) *ORing {
+	return &ORing{fmt.Sprintf("%gx%g", id, cs), id, cs}
+}
+
+// Stretch returns the fractional stretch of the O-ring inner diameter when
+// it is fitted on a diameter (negative for compression).
+func (o *ORing) Stretch(diameter float64) float64 {
+	return (diameter - o.ID) / o.ID
+}
+
+// StretchedCS returns the cross section of the O-ring with a given stretch.
+func (o *ORing) StretchedCS(stretch float64) float64 {
+	return o.CS / math.Sqrt(1+stretch)
+}
+
+// Squeeze returns the fractional compression of the O-ring cross section in
+// a gland of the given depth.
+func (o *ORing) Squeeze(gland, stretch float64) float64 {
+	cs := o.StretchedCS(stretch)
+	return (cs - gland) / cs
+}
+
+//-----------------------------------------------------------------------------
+
+// GlandType is the type of an O-ring gland.
+type GlandType int
+
+// O-ring gland types.
+const (
+	GlandFace   GlandType = iota // groove in a flat face, axial squeeze
+	GlandPiston                  // groove on the outside of a shaft, sealing against a bore
+	GlandRod                     // groove in a bore, sealing against a shaft
+)
+
+// ORingGrooveParms defines the parameters for an O-ring groove.
+type ORingGrooveParms struct {
+	ORing    *ORing
+	Gland    GlandType
+	Diameter float64 // mating diameter: the bore (piston), the shaft (rod) or the groove inner diameter (face)
+	Gap      float64 // clearance between the parts (radial for piston/rod glands)
+	Squeeze  float64 // cross section compression (0 = 0.25 for face glands, 0.2 for piston/rod glands)
+	Fill     float64 // fraction of the gland filled by the O-ring (0 = 0.8)
+}
+
+// ORingGroove is the calculated size of an O-ring groove.
+type ORingGroove struct {
+	InnerDiameter float64 // inner diameter of the groove
+	OuterDiameter float64 // outer diameter of the groove
+	Depth         float64 // depth of the groove below the part surface
+	Width         float64 // width of the groove
+	Stretch       float64 // stretch of the O-ring inner diameter
+	CS            float64 // cross section of the stretched O-ring
+}
+
+func (k *ORingGrooveParms) validate() error {
+	if k.ORing == nil {
+		return errors.New("ORing == nil")
+	}
+	if k.Diameter <= 0 {
+		return errors.New("Diameter <= 0")
+	}
+	if k.Gap < 0 {
+		return errors.New("Gap < 0")
+	}
+	if k.Squeeze < 0 || k.Squeeze >= 1 {
+		return errors.New("Squeeze must be >= 0 and < 1")
+	}
+	if k.Fill < 0 || k.Fill > 1 {
+		return errors.New("Fill must be >= 0 and <= 1")
+	}
+	return nil
+}
+
+// Groove returns the dimensions of an O-ring groove.
+func (k *ORingGrooveParms) Groove() (*ORingGroove, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	o := k.ORing
+	squeeze := k.Squeeze
+	if squeeze == 0 {
+		squeeze = 0.2
+		if k.Gland == GlandFace {
+			squeeze = 0.25
+		}
+	}
+	fill := k.Fill
+	if fill == 0 {
+		fill = 0.8
+	}
+	g := ORingGroove{}
+	var gland float64
+	switch k.Gland {
+	case GlandFace:
+		// the O-ring inner diameter is against the groove inner wall
+		g.CS = o.CS
+		gland = g.CS * (1 - squeeze)
+		g.InnerDiameter = k.Diameter
+		g.Stretch = o.Stretch(k.Diameter)
+	case GlandPiston:
+		// The O-ring is stretched onto the groove bottom, which depends
+		// on the stretched cross section. This converges quickly.
+		g.CS = o.CS
+		for i := 0; i < 10; i++ {
+			gland = g.CS * (1 - squeeze)
+			g.InnerDiameter = k.Diameter - 2*gland
+			g.Stretch = o.Stretch(g.InnerDiameter)
+			g.CS = o.StretchedCS(g.Stretch)
+		}
+		g.OuterDiameter = k.Diameter - 2*k.Gap
+	case GlandRod:
+		g.Stretch = o.Stretch(k.Diameter)
+		g.CS = o.StretchedCS(g.Stretch)
+		gland = g.CS * (1 - squeeze)
+		g.InnerDiameter = k.Diameter + 2*k.Gap
+		g.OuterDiameter = k.Diameter + 2*gland
+	default:
+		return nil, errors.New("bad gland type")
+	}
+	g.Depth = gland - k.Gap
+	if g.Depth <= 0 {
+		return nil, errors.New("the gap is too large for the O-ring")
+	}
+	g.Width = 0.25 * Pi * g.CS * g.CS / (fill * gland)
+	if k.Gland == GlandFace {
+		g.OuterDiameter = g.InnerDiameter + 2*g.Width
+	}
+	return &g, nil
+}
+
+// ORingGroove3D returns the shape to cut from a part for an O-ring groove.
+// The groove is about the z-axis. A face groove is cut into a face on z = 0
+// with its normal along +z. A piston or rod groove is centered on z = 0.
+func ORingGroove3D(k *ORingGrooveParms) (SDF3, error) {
+	g, err := k.Groove()
+	if err != nil {
+		return nil, err
+	}
+	r0 := 0.5 * g.InnerDiameter
+	r1 := 0.5 * g.OuterDiameter
+	// extend the groove past the part surface so the cut is clean
+	h := g.Width
+	switch k.Gland {
+	case GlandFace:
+		h = 2 * g.Depth
+	case GlandPiston:
+		r1 += g.Depth
+	case GlandRod:
+		r0 -= g.Depth
+	}
+	profile := Transform2D(Box2D(V2{r1 - r0, h}, 0), Translate2d(V2{0.5 * (r0 + r1), 0}))
+	return Revolve3D(profile), nil
+}
+
+//-----------------------------------------------------------------------------
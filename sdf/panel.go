@@ -0,0 +1,226 @@
+//-----------------------------------------------------------------------------
+/*
+
+Front Panels
+
+Parametric front panels for racks and enclosures: an outline with mounting
+holes, cutouts for common panel mounted components and engraved labels.
+
+The panel can be written as DXF for laser/CNC cutting or extruded to an SDF3
+for 3D printing. The front of the 3D panel faces +z.
+
+Standard sizes:
+19" rack: EIA-310, 482.6 mm wide, 44.45 mm per rack unit (U).
+Eurorack: Doepfer A-100, 128.5 mm high, 5.08 mm per horizontal pitch (HP).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+// Component cutouts
+
+// dsubCutout returns a D-subminiature cutout with mounting holes.
+// w is the width of the D at the top, pitch is the mounting hole spacing.
+func dsubCutout(w, pitch float64) SDF2 {
+	const h = 10.5 // height of the D
+	dx := h * math.Tan(DtoR(10))
+	p := NewPolygon()
+	p.Add(-0.5*w, 0.5*h).Smooth(1, 4)
+	p.Add(0.5*w, 0.5*h).Smooth(1, 4)
+	p.Add(0.5*w-dx, -0.5*h).Smooth(1, 4)
+	p.Add(-0.5*w+dx, -0.5*h).Smooth(1, 4)
+	d := Polygon2D(p.Vertices())
+	hole := Circle2D(1.55)
+	return Union2D(d,
+		Transform2D(hole, Translate2d(V2{-0.5 * pitch, 0})),
+		Transform2D(hole, Translate2d(V2{0.5 * pitch, 0})))
+}
+
+// roundCutout returns a function for a round hole.
+func roundCutout(d float64) func() SDF2 {
+	return func() SDF2 { return Circle2D(0.5 * d) }
+}
+
+// rectCutout returns a function for a rectangular cutout with rounded corners.
+func rectCutout(w, h, round float64) func() SDF2 {
+	return func() SDF2 { return Box2D(V2{w, h}, round) }
+}
+
+// PanelComponents is the library of component cutouts, centered on the origin.
+// Add entries to extend the library.
+var PanelComponents = map[string]func() SDF2{
+	"DB9":    func() SDF2 { return dsubCutout(19.4, 25.0) },
+	"DB15":   func() SDF2 { return dsubCutout(27.7, 33.3) },
+	"DB25":   func() SDF2 { return dsubCutout(41.4, 47.0) },
+	"USB-A":  rectCutout(13.5, 6.0, 0.5),
+	"USB-B":  rectCutout(12.5, 11.5, 0.5),
+	"USB-C":  rectCutout(9.4, 3.6, 1.6),
+	"toggle": roundCutout(6.5),            // 1/4" bushing toggle switch
+	"pot":    roundCutout(7.5),            // 7 mm bushing potentiometer
+	"jack":   roundCutout(6.2),            // 3.5 mm jack (Thonkiconn)
+	"led3":   roundCutout(3.2),            // 3 mm LED
+	"led5":   roundCutout(5.2),            // 5 mm LED
+	"OLED":   rectCutout(23.0, 12.0, 0.5), // 0.96" 128x64 OLED viewing window
+}
+
+//-----------------------------------------------------------------------------
+
+// PanelCutout is a component cutout on a panel.
+type PanelCutout struct {
+	Component string  // component name (see PanelComponents)
+	Position  V2      // position of the component center
+	Angle     float64 // rotation (radians)
+}
+
+// PanelLabel is an engraved label on a panel.
+type PanelLabel struct {
+	Text     SDF2 // label outline centered on the origin, e.g. from TextSDF2
+	Position V2   // position of the label center
+}
+
+// FrontPanelParms defines the parameters for a front panel.
+type FrontPanelParms struct {
+	Size         V2      // panel size
+	CornerRadius float64 // radius of the panel corners
+	HoleDiameter float64 // mounting hole diameter
+	Holes        []V2    // mounting hole positions
+	Cutouts      []PanelCutout
+	Labels       []PanelLabel
+	Thickness    float64 // panel thickness (3D)
+	EngraveDepth float64 // label engraving depth (3D)
+}
+
+// RackPanel returns the parameters for a 19" rack panel with the given number
+// of rack units. The mounting holes are at the top and bottom of each unit.
+func RackPanel(units int) *FrontPanelParms {
+	const u = 44.45
+	h := float64(units)*u - 0.79
+	k := &FrontPanelParms{
+		Size:         V2{482.6, h},
+		HoleDiameter: 6.4,
+		Thickness:    3,
+		EngraveDepth: 0.5,
+	}
+	for i := 0; i < units; i++ {
+		for _, y := range []float64{6.35, 38.1} {
+			// holes relative to the rack unit boundaries
+			y += float64(i)*u - 0.5*float64(units)*u
+			k.Holes = append(k.Holes, V2{-232.55, y}, V2{232.55, y})
+		}
+	}
+	return k
+}
+
+// EurorackPanel returns the parameters for a Eurorack (3U) panel with the given
+// width in HP. Panels wider than 6 HP have a second pair of mounting holes.
+func EurorackPanel(hp int) *FrontPanelParms {
+	const pitch = 5.08
+	w := float64(hp)*pitch - 0.3
+	k := &FrontPanelParms{
+		Size:         V2{w, 128.5},
+		HoleDiameter: 3.2,
+		Thickness:    2,
+		EngraveDepth: 0.4,
+	}
+	y := 0.5*128.5 - 3
+	x := []float64{-0.5*w + 7.5}
+	if hp > 6 {
+		x = append(x, x[0]+float64(hp-3)*pitch)
+	}
+	for _, x := range x {
+		k.Holes = append(k.Holes, V2{x, y}, V2{x, -y})
+	}
+	return k
+}
+
+//-----------------------------------------------------------------------------
+
+// FrontPanel2D returns the 2D outline of a front panel with its mounting holes and cutouts.
+func FrontPanel2D(k *FrontPanelParms) (SDF2, error) {
+	if k.Size.X <= 0 || k.Size.Y <= 0 {
+		return nil, errors.New("Size <= 0")
+	}
+	var holes []SDF2
+	if k.HoleDiameter > 0 {
+		hole := Circle2D(0.5 * k.HoleDiameter)
+		for _, p := range k.Holes {
+			holes = append(holes, Transform2D(hole, Translate2d(p)))
+		}
+	}
+	for _, c := range k.Cutouts {
+		f, ok := PanelComponents[c.Component]
+		if !ok {
+			return nil, fmt.Errorf("unknown panel component \"%s\"", c.Component)
+		}
+		holes = append(holes, Transform2D(f(), Translate2d(c.Position).Mul(Rotate2d(c.Angle))))
+	}
+	s := Box2D(k.Size, k.CornerRadius)
+	if len(holes) == 0 {
+		return s, nil
+	}
+	return Difference2D(s, Union2D(holes...)), nil
+}
+
+// FrontPanel3D returns a front panel of the given thickness with engraved labels.
+// The panel is centered on z = 0 with its front facing +z.
+func FrontPanel3D(k *FrontPanelParms) (SDF3, error) {
+	if k.Thickness <= 0 {
+		return nil, errors.New("Thickness <= 0")
+	}
+	if len(k.Labels) != 0 && (k.EngraveDepth <= 0 || k.EngraveDepth >= k.Thickness) {
+		return nil, errors.New("EngraveDepth must be > 0 and < Thickness")
+	}
+	s2, err := FrontPanel2D(k)
+	if err != nil {
+		return nil, err
+	}
+	s := Extrude3D(s2, k.Thickness)
+	if len(k.Labels) == 0 {
+		return s, nil
+	}
+	labels := make([]SDF2, len(k.Labels))
+	for i, l := range k.Labels {
+		labels[i] = Transform2D(l.Text, Translate2d(l.Position))
+	}
+	// engrave the labels into the front face
+	engrave := Transform3D(Extrude3D(Union2D(labels...), 2*k.EngraveDepth), Translate3d(V3{0, 0, 0.5 * k.Thickness}))
+	return Difference3D(s, engrave), nil
+}
+
+// SaveFrontPanelDXF writes the cutting contours of a front panel to a DXF file.
+// The label outlines are included for engraving/scoring.
+func SaveFrontPanelDXF(path string, k *FrontPanelParms, resolution float64) error {
+	if resolution <= 0 {
+		return errors.New("resolution <= 0")
+	}
+	s, err := FrontPanel2D(k)
+	if err != nil {
+		return err
+	}
+	contours := Contours2(s, resolution)
+	for _, l := range k.Labels {
+		contours = append(contours, Contours2(Transform2D(l.Text, Translate2d(l.Position)), resolution)...)
+	}
+	return SaveDXF(path, contourLines(OrderPaths(contours, V2{}, true)))
+}
+
+// PanelComponentNames returns the sorted names of the components in the library.
+func PanelComponentNames() []string {
+	var names []string
+	for name := range PanelComponents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,234 @@
+//-----------------------------------------------------------------------------
+/*
+
+PDF Output
+
+Write drawings and 2D contours as single page vector PDF files.
+One drawing unit is 1 mm on the page, so the output can be sent directly to
+laser cutting services and printed at real size.
+
+Text uses the standard Helvetica font. The PDF writer doesn't measure the
+text, so centered and end anchored text is placed using an average character
+width.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// pdfPointsPerMM is the number of PDF points (1/72 inch) per mm.
+const pdfPointsPerMM = 72.0 / 25.4
+
+// pdfCharWidth is the average Helvetica character width (em).
+const pdfCharWidth = 0.556
+
+// pdfText escapes a PDF string.
+func pdfText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+	return r.Replace(s)
+}
+
+// pdfColor returns the PDF color operands for a color.
+func pdfColor(c color.RGBA) string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// pdfImage returns the compressed RGB and alpha channels of an image.
+func pdfImage(img image.Image) ([]byte, []byte, error) {
+	b := img.Bounds()
+	var rgb, alpha bytes.Buffer
+	zrgb := zlib.NewWriter(&rgb)
+	zalpha := zlib.NewWriter(&alpha)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			zrgb.Write([]byte{c.R, c.G, c.B})
+			zalpha.Write([]byte{c.A})
+		}
+	}
+	if err := zrgb.Close(); err != nil {
+		return nil, nil, err
+	}
+	if err := zalpha.Close(); err != nil {
+		return nil, nil, err
+	}
+	return rgb.Bytes(), alpha.Bytes(), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// pdfWriter writes the objects of a PDF file.
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int // object offsets, object n is at offsets[n-1]
+}
+
+// object writes an object and returns its number.
+func (w *pdfWriter) object(dict string, stream []byte) int {
+	w.offsets = append(w.offsets, w.buf.Len())
+	n := len(w.offsets)
+	fmt.Fprintf(&w.buf, "%d 0 obj\n", n)
+	if stream == nil {
+		fmt.Fprintf(&w.buf, "%s\nendobj\n", dict)
+		return n
+	}
+	fmt.Fprintf(&w.buf, "<< %s /Length %d >>\nstream\n", dict, len(stream))
+	w.buf.Write(stream)
+	fmt.Fprintf(&w.buf, "\nendstream\nendobj\n")
+	return n
+}
+
+// reserve reserves an object number for an object written later with set.
+func (w *pdfWriter) reserve() int {
+	w.offsets = append(w.offsets, -1)
+	return len(w.offsets)
+}
+
+// set writes a reserved object.
+func (w *pdfWriter) set(n int, dict string) {
+	w.offsets[n-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", n, dict)
+}
+
+// finish writes the cross reference table and trailer.
+func (w *pdfWriter) finish(root int) {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, ofs := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", ofs)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, root, xref)
+}
+
+//-----------------------------------------------------------------------------
+
+// WritePDF writes the drawing as a single page PDF with a margin around the extents.
+// One drawing unit is 1 mm on the page.
+func (d *Drawing) WritePDF(w io.Writer, margin float64) error {
+	bb := d.bb
+	size := bb.Size().AddScalar(2 * margin)
+	pw := &pdfWriter{}
+	pw.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	catalog := pw.reserve()
+	pages := pw.reserve()
+	font := pw.object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>", nil)
+
+	// content stream in mm with the origin at the lower left of the extents
+	var c strings.Builder
+	fmt.Fprintf(&c, "%.6f 0 0 %.6f 0 0 cm\n", pdfPointsPerMM, pdfPointsPerMM)
+	fmt.Fprintf(&c, "1 0 0 1 %.3f %.3f cm\n1 J 1 j\n", margin-bb.Min.X, margin-bb.Min.Y)
+	var images []string
+	for _, item := range d.items {
+		if item.text != "" {
+			p := item.paths[0][0]
+			width := pdfCharWidth * item.size * float64(len(item.text))
+			p.X -= [...]float64{0, 0.5, 1}[item.anchor] * width
+			fmt.Fprintf(&c, "0 g BT /F1 %g Tf %.3f %.3f Td (%s) Tj ET\n", item.size, p.X, p.Y, pdfText(item.text))
+			continue
+		}
+		if item.image != nil {
+			rgb, alpha, err := pdfImage(item.image)
+			if err != nil {
+				return err
+			}
+			ib := item.image.Bounds()
+			dims := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /BitsPerComponent 8 /Filter /FlateDecode", ib.Dx(), ib.Dy())
+			mask := pw.object(dims+" /ColorSpace /DeviceGray", alpha)
+			img := pw.object(fmt.Sprintf("%s /ColorSpace /DeviceRGB /SMask %d 0 R", dims, mask), rgb)
+			name := fmt.Sprintf("Im%d", len(images))
+			images = append(images, fmt.Sprintf("/%s %d 0 R", name, img))
+			a, b := item.paths[0][0], item.paths[0][1]
+			fmt.Fprintf(&c, "q %.3f 0 0 %.3f %.3f %.3f cm /%s Do Q\n", b.X-a.X, b.Y-a.Y, a.X, a.Y, name)
+			continue
+		}
+		stroke := item.style.Stroke.A != 0
+		fill := item.closed && item.style.Fill.A != 0
+		if !stroke && !fill {
+			continue
+		}
+		fmt.Fprintf(&c, "%s RG %s rg %.3f w\n", pdfColor(item.style.Stroke), pdfColor(item.style.Fill), item.style.Width)
+		for _, p := range item.paths {
+			for i, v := range p {
+				op := "l"
+				if i == 0 {
+					op = "m"
+				}
+				fmt.Fprintf(&c, "%.3f %.3f %s\n", v.X, v.Y, op)
+			}
+			if item.closed {
+				c.WriteString("h\n")
+			}
+		}
+		switch {
+		case stroke && fill:
+			c.WriteString("B*\n")
+		case fill:
+			c.WriteString("f*\n")
+		default:
+			c.WriteString("S\n")
+		}
+	}
+	content := pw.object("", []byte(c.String()))
+
+	page := pw.object(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.3f %.3f] /Contents %d 0 R /Resources << /Font << /F1 %d 0 R >> /XObject << %s >> >> >>",
+		pages, size.X*pdfPointsPerMM, size.Y*pdfPointsPerMM, content, font, strings.Join(images, " ")), nil)
+	pw.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", page))
+	pw.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	pw.finish(catalog)
+	_, err := w.Write(pw.buf.Bytes())
+	return err
+}
+
+// SavePDF writes the drawing to a PDF file with a margin around the extents.
+func (d *Drawing) SavePDF(path string, margin float64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := d.WritePDF(f, margin); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+//-----------------------------------------------------------------------------
+
+// pdfHairline is the line style for cutting paths.
+var pdfHairline = DrawingStyle{Stroke: color.RGBA{0, 0, 0, 255}, Width: 0.1}
+
+// SavePDF writes line segments to a PDF file. One unit is 1 mm on the page.
+func SavePDF(path string, lines []*Line) error {
+	d := NewDrawing()
+	for _, l := range lines {
+		d.Line(l[0], l[1], pdfHairline)
+	}
+	return d.SavePDF(path, 0)
+}
+
+// RenderPDF renders the contours of an SDF2 as a PDF file. One unit is 1 mm on the page.
+func RenderPDF(
+	s SDF2, // sdf2 to render
+	meshCells int, // number of cells on the longest axis. e.g 200
+	path string, // path to filename
+) error {
+	resolution := s.BoundingBox().Size().MaxComponent() / float64(meshCells)
+	d := NewDrawing()
+	d.Polygons(Contours2(s, resolution), pdfHairline)
+	return d.SavePDF(path, 0)
+}
+
+//-----------------------------------------------------------------------------
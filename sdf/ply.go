@@ -0,0 +1,88 @@
+//-----------------------------------------------------------------------------
+/*
+
+PLY Save
+
+Write point clouds and triangle meshes (with vertex colors) as ASCII PLY files.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"os"
+)
+
+//-----------------------------------------------------------------------------
+
+// SavePLY writes a point cloud to an ASCII PLY file. The normals are optional (nil).
+func SavePLY(path string, points, normals []V3) error {
+	if normals != nil && len(normals) != len(points) {
+		return fmt.Errorf("%d normals for %d points", len(normals), len(points))
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	buf := bufio.NewWriter(file)
+	fmt.Fprintf(buf, "ply\nformat ascii 1.0\nelement vertex %d\n", len(points))
+	fmt.Fprintf(buf, "property float x\nproperty float y\nproperty float z\n")
+	if normals != nil {
+		fmt.Fprintf(buf, "property float nx\nproperty float ny\nproperty float nz\n")
+	}
+	fmt.Fprintf(buf, "end_header\n")
+	for i, p := range points {
+		if normals != nil {
+			n := normals[i]
+			fmt.Fprintf(buf, "%g %g %g %g %g %g\n", p.X, p.Y, p.Z, n.X, n.Y, n.Z)
+		} else {
+			fmt.Fprintf(buf, "%g %g %g\n", p.X, p.Y, p.Z)
+		}
+	}
+	return buf.Flush()
+}
+
+// SaveMeshPLY writes a triangle mesh to an ASCII PLY file.
+// If vertexColor is not nil it gives the color of each vertex.
+func SaveMeshPLY(path string, mesh []*Triangle3, vertexColor func(v V3) color.RGBA) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// dedupe the vertices
+	vertices := meshVertices(mesh)
+	index := make(map[V3]int, len(vertices))
+	for i, v := range vertices {
+		index[v] = i
+	}
+
+	buf := bufio.NewWriter(file)
+	fmt.Fprintf(buf, "ply\nformat ascii 1.0\nelement vertex %d\n", len(vertices))
+	fmt.Fprintf(buf, "property float x\nproperty float y\nproperty float z\n")
+	if vertexColor != nil {
+		fmt.Fprintf(buf, "property uchar red\nproperty uchar green\nproperty uchar blue\n")
+	}
+	fmt.Fprintf(buf, "element face %d\nproperty list uchar int vertex_indices\nend_header\n", len(mesh))
+	for _, v := range vertices {
+		if vertexColor != nil {
+			c := vertexColor(v)
+			fmt.Fprintf(buf, "%g %g %g %d %d %d\n", v.X, v.Y, v.Z, c.R, c.G, c.B)
+		} else {
+			fmt.Fprintf(buf, "%g %g %g\n", v.X, v.Y, v.Z)
+		}
+	}
+	for _, t := range mesh {
+		fmt.Fprintf(buf, "3 %d %d %d\n", index[t.V[0]], index[t.V[1]], index[t.V[2]])
+	}
+	return buf.Flush()
+}
+
+//-----------------------------------------------------------------------------
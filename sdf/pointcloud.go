@@ -0,0 +1,62 @@
+//-----------------------------------------------------------------------------
+/*
+
+Point Cloud Sampling
+
+Sample points on the surface of an SDF3 for comparison with scans and for
+point based exports (PLY point clouds).
+
+Random seeds are taken from a thin band about the surface, so the samples
+are close to uniformly distributed over the surface area. Each seed is then
+projected onto the surface along the gradient.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+//-----------------------------------------------------------------------------
+
+// sampleTries is the number of random seeds tried per requested sample.
+const sampleTries = 1000
+
+// SampleSurface returns n points on the surface of an SDF3.
+// See SampleSurfaceNormals for when fewer than n points are returned.
+func SampleSurface(s SDF3, n int) []V3 {
+	p, _ := SampleSurfaceNormals(s, n)
+	return p
+}
+
+// SampleSurfaceNormals returns n points on the surface of an SDF3 and the surface normals at the points.
+// Fewer than n points (possibly none) are returned if the surface can't be
+// found, E.g. the SDF3 has no zero crossing within its bounding box.
+func SampleSurfaceNormals(s SDF3, n int) ([]V3, []V3) {
+	bb := s.BoundingBox()
+	eps := measureEpsilon(s)
+	band := 0.01 * bb.Size().MaxComponent()
+	points := make([]V3, 0, n)
+	normals := make([]V3, 0, n)
+	tries := 0
+	for i := 0; i < sampleTries*n && len(points) < n; i++ {
+		x := bb.Random()
+		tries++
+		if tries > 1000 && len(points) < tries/1000 {
+			// too few seeds in the band, widen it
+			band *= 2
+			tries = 0
+		}
+		if Abs(s.Evaluate(x)) > band {
+			continue
+		}
+		p := ClosestPoint3(s, x)
+		if Abs(s.Evaluate(p)) > 10*eps {
+			// the projection didn't converge
+			continue
+		}
+		points = append(points, p)
+		normals = append(normals, Normal3(s, p, eps))
+	}
+	return points, normals
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,228 @@
+//-----------------------------------------------------------------------------
+/*
+
+Poisson Disk (Blue Noise) Sampling
+
+Random points with no two points closer than a minimum distance r. These
+look natural and evenly spread (E.g. Voronoi seeds, speaker grille holes,
+texture displacement seeds).
+
+Regions are sampled with Bridson's algorithm:
+"Fast Poisson Disk Sampling in Arbitrary Dimensions", Robert Bridson, 2007
+
+Surfaces are sampled by dart throwing with candidates from SampleSurface.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"math"
+	"math/rand"
+)
+
+//-----------------------------------------------------------------------------
+
+// poissonTries is the number of candidates tried about each active sample.
+const poissonTries = 30
+
+// poissonGrid2 is a background grid of samples for neighbour queries.
+type poissonGrid2 struct {
+	origin V2
+	size   float64
+	cells  map[V2i]V2
+}
+
+func (g *poissonGrid2) index(p V2) V2i {
+	q := p.Sub(g.origin).DivScalar(g.size)
+	return V2i{int(math.Floor(q.X)), int(math.Floor(q.Y))}
+}
+
+// near returns true if there is a sample within r of p.
+func (g *poissonGrid2) near(p V2, r float64) bool {
+	c := g.index(p)
+	for x := c[0] - 2; x <= c[0]+2; x++ {
+		for y := c[1] - 2; y <= c[1]+2; y++ {
+			if q, ok := g.cells[V2i{x, y}]; ok && q.Sub(p).Length() < r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// poissonDisk2 samples a region, accept limits the samples to a sub-region.
+func poissonDisk2(bb Box2, r float64, accept func(p V2) bool) V2Set {
+	if r <= 0 {
+		return nil
+	}
+	// each grid cell holds at most one sample
+	g := &poissonGrid2{bb.Min, r / math.Sqrt2, make(map[V2i]V2)}
+	var samples V2Set
+	var active []V2
+	add := func(p V2) {
+		g.cells[g.index(p)] = p
+		samples = append(samples, p)
+		active = append(active, p)
+	}
+	// initial sample
+	for i := 0; i < 1000; i++ {
+		if p := bb.Random(); accept(p) {
+			add(p)
+			break
+		}
+	}
+	for len(active) > 0 {
+		i := rand.Intn(len(active))
+		found := false
+		for k := 0; k < poissonTries; k++ {
+			// random point in the annulus [r, 2r]
+			p := active[i].Add(PolarToXY(randomRange(r, 2*r), randomRange(0, Tau)))
+			if !bb.Contains(p) || !accept(p) || g.near(p, r) {
+				continue
+			}
+			add(p)
+			found = true
+			break
+		}
+		if !found {
+			active[i] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+	return samples
+}
+
+// PoissonDisk returns blue noise samples within a bounding box, with no two samples closer than r.
+func (b *Box2) PoissonDisk(r float64) V2Set {
+	return poissonDisk2(*b, r, func(p V2) bool { return true })
+}
+
+// PoissonDiskSDF2 returns blue noise samples inside an SDF2, with no two samples closer than r.
+func PoissonDiskSDF2(s SDF2, r float64) V2Set {
+	return poissonDisk2(s.BoundingBox(), r, func(p V2) bool { return s.Evaluate(p) < 0 })
+}
+
+//-----------------------------------------------------------------------------
+
+// poissonGrid3 is a background grid of samples for neighbour queries.
+type poissonGrid3 struct {
+	origin V3
+	size   float64
+	cells  map[V3i][]V3
+}
+
+func (g *poissonGrid3) index(p V3) V3i {
+	return p.Sub(g.origin).DivScalar(g.size).Floor().ToV3i()
+}
+
+func (g *poissonGrid3) add(p V3) {
+	k := g.index(p)
+	g.cells[k] = append(g.cells[k], p)
+}
+
+// near returns true if there is a sample within r of p (r <= cell size * 2).
+func (g *poissonGrid3) near(p V3, r float64) bool {
+	c := g.index(p)
+	n := int(math.Ceil(r / g.size))
+	for x := c[0] - n; x <= c[0]+n; x++ {
+		for y := c[1] - n; y <= c[1]+n; y++ {
+			for z := c[2] - n; z <= c[2]+n; z++ {
+				for _, q := range g.cells[V3i{x, y, z}] {
+					if q.Sub(p).Length() < r {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// randomDirection3 returns a random unit vector.
+func randomDirection3() V3 {
+	z := randomRange(-1, 1)
+	theta := randomRange(0, Tau)
+	r := math.Sqrt(1 - z*z)
+	return V3{r * math.Cos(theta), r * math.Sin(theta), z}
+}
+
+// poissonDisk3 samples a region, accept limits the samples to a sub-region.
+func poissonDisk3(bb Box3, r float64, accept func(p V3) bool) V3Set {
+	if r <= 0 {
+		return nil
+	}
+	g := &poissonGrid3{bb.Min, r / math.Sqrt(3), make(map[V3i][]V3)}
+	var samples V3Set
+	var active []V3
+	add := func(p V3) {
+		g.add(p)
+		samples = append(samples, p)
+		active = append(active, p)
+	}
+	for i := 0; i < 1000; i++ {
+		if p := bb.Random(); accept(p) {
+			add(p)
+			break
+		}
+	}
+	for len(active) > 0 {
+		i := rand.Intn(len(active))
+		found := false
+		for k := 0; k < poissonTries; k++ {
+			// random point in the shell [r, 2r]
+			p := active[i].Add(randomDirection3().MulScalar(randomRange(r, 2*r)))
+			if !bb.Contains(p) || !accept(p) || g.near(p, r) {
+				continue
+			}
+			add(p)
+			found = true
+			break
+		}
+		if !found {
+			active[i] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+	return samples
+}
+
+// PoissonDisk returns blue noise samples within a bounding box, with no two samples closer than r.
+func (b *Box3) PoissonDisk(r float64) V3Set {
+	return poissonDisk3(*b, r, func(p V3) bool { return true })
+}
+
+// PoissonDiskSDF3 returns blue noise samples inside an SDF3, with no two samples closer than r.
+func PoissonDiskSDF3(s SDF3, r float64) V3Set {
+	return poissonDisk3(s.BoundingBox(), r, func(p V3) bool { return s.Evaluate(p) < 0 })
+}
+
+// PoissonDiskSurface returns blue noise samples on the surface of an SDF3,
+// with no two samples closer than r (measured in 3D).
+func PoissonDiskSurface(s SDF3, r float64) []V3 {
+	if r <= 0 {
+		return nil
+	}
+	bb := s.BoundingBox()
+	g := &poissonGrid3{bb.Min, r, make(map[V3i][]V3)}
+	var samples []V3
+	// throw darts in batches until a batch adds few samples
+	const batch = 1000
+	for {
+		added := 0
+		for _, p := range SampleSurface(s, batch) {
+			if !g.near(p, r) {
+				g.add(p)
+				samples = append(samples, p)
+				added++
+			}
+		}
+		if added < batch/100 {
+			break
+		}
+	}
+	return samples
+}
+
+//-----------------------------------------------------------------------------
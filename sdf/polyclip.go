@@ -0,0 +1,344 @@
+//-----------------------------------------------------------------------------
+/*
+
+Polygon Boolean Operations
+
+Union, difference and intersection of polygons in contour space.
+The results are new contours, not SDFs, so they can be used to clean up
+imported outlines or to generate export outlines.
+
+A polygon is a set of closed contours with the inside determined by the
+non-zero winding rule (see MultiPolygon2D). Outer contours should be counter
+clockwise and holes clockwise. The resulting contours follow the same rule.
+
+Method:
+1) Split the edges of both polygons at every intersection.
+2) Classify each split edge as inside/outside/on the other polygon.
+3) Keep the edges required by the operation.
+4) Chain the kept edges into closed contours.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "sort"
+
+//-----------------------------------------------------------------------------
+
+// PolygonOp is a polygon boolean operation.
+type PolygonOp int
+
+const (
+	// PolygonUnion is a + b
+	PolygonUnion PolygonOp = iota
+	// PolygonDifference is a - b
+	PolygonDifference
+	// PolygonIntersection is a & b
+	PolygonIntersection
+)
+
+//-----------------------------------------------------------------------------
+
+// clipEdge is a directed polygon edge.
+type clipEdge struct {
+	a, b V2
+}
+
+// polygonEdges returns the directed edges of a set of closed contours.
+func polygonEdges(contours [][]V2) []clipEdge {
+	var edges []clipEdge
+	for _, c := range contours {
+		n := len(c)
+		if n < 3 {
+			continue
+		}
+		for i := range c {
+			a := c[i]
+			b := c[(i+1)%n]
+			if !a.Equals(b, tolerance) {
+				edges = append(edges, clipEdge{a, b})
+			}
+		}
+	}
+	return edges
+}
+
+// windingNumber returns the winding number of a set of edges about a point.
+func windingNumber(edges []clipEdge, p V2) int {
+	wn := 0
+	for _, e := range edges {
+		side := e.b.Sub(e.a).Cross(p.Sub(e.a))
+		if e.a.Y <= p.Y {
+			if e.b.Y > p.Y && side > 0 {
+				wn++
+			}
+		} else {
+			if e.b.Y <= p.Y && side < 0 {
+				wn--
+			}
+		}
+	}
+	return wn
+}
+
+// onEdges returns the edge that the point lies on (within eps).
+func onEdges(edges []clipEdge, p V2, eps float64) (clipEdge, bool) {
+	for _, e := range edges {
+		if pointSegmentDistance(p, e.a, e.b) < eps {
+			return e, true
+		}
+	}
+	return clipEdge{}, false
+}
+
+//-----------------------------------------------------------------------------
+
+// pointSnap maps points within eps of each other onto a single point.
+type pointSnap struct {
+	points []V2
+	eps    float64
+}
+
+func (s *pointSnap) snap(p V2) V2 {
+	for _, x := range s.points {
+		if x.Equals(p, s.eps) {
+			return x
+		}
+	}
+	s.points = append(s.points, p)
+	return p
+}
+
+// splitEdges splits edges at the given t parameters.
+func splitEdges(edges []clipEdge, splits [][]float64, snap *pointSnap) []clipEdge {
+	var out []clipEdge
+	for i, e := range edges {
+		t := append([]float64{0, 1}, splits[i]...)
+		sort.Float64s(t)
+		v := e.b.Sub(e.a)
+		p0 := snap.snap(e.a)
+		for _, x := range t[1:] {
+			p1 := e.b
+			if x < 1 {
+				p1 = e.a.Add(v.MulScalar(x))
+			}
+			p1 = snap.snap(p1)
+			if p1 != p0 {
+				out = append(out, clipEdge{p0, p1})
+			}
+			p0 = p1
+		}
+	}
+	return out
+}
+
+// intersectEdges splits the edges of two polygons at their mutual intersections.
+func intersectEdges(ea, eb []clipEdge, eps float64) ([]clipEdge, []clipEdge) {
+	sa := make([][]float64, len(ea))
+	sb := make([][]float64, len(eb))
+	// project returns the t parameter of p on edge e if p lies within the edge.
+	project := func(e clipEdge, p V2) (float64, bool) {
+		v := e.b.Sub(e.a)
+		t := p.Sub(e.a).Dot(v) / v.Length2()
+		if t <= 0 || t >= 1 {
+			return 0, false
+		}
+		return t, pointSegmentDistance(p, e.a, e.b) < eps
+	}
+	for i, a := range ea {
+		r := a.b.Sub(a.a)
+		for j, b := range eb {
+			s := b.b.Sub(b.a)
+			denom := r.Cross(s)
+			if Abs(denom) > tolerance*r.Length()*s.Length() {
+				// not parallel
+				q := b.a.Sub(a.a)
+				t := q.Cross(s) / denom
+				u := q.Cross(r) / denom
+				if t > 0 && t < 1 && u > -tolerance && u < 1+tolerance {
+					sa[i] = append(sa[i], t)
+				}
+				if u > 0 && u < 1 && t > -tolerance && t < 1+tolerance {
+					sb[j] = append(sb[j], u)
+				}
+				continue
+			}
+			// parallel - split any overlaps at the end points
+			if t, ok := project(a, b.a); ok {
+				sa[i] = append(sa[i], t)
+			}
+			if t, ok := project(a, b.b); ok {
+				sa[i] = append(sa[i], t)
+			}
+			if t, ok := project(b, a.a); ok {
+				sb[j] = append(sb[j], t)
+			}
+			if t, ok := project(b, a.b); ok {
+				sb[j] = append(sb[j], t)
+			}
+		}
+	}
+	snap := &pointSnap{eps: eps}
+	return splitEdges(ea, sa, snap), splitEdges(eb, sb, snap)
+}
+
+//-----------------------------------------------------------------------------
+
+// keepEdge returns true if an edge of polygon a should be kept given the other polygon b.
+func keepEdge(e clipEdge, other []clipEdge, op PolygonOp, isA bool, eps float64) (keep, reverse bool) {
+	m := e.a.Add(e.b).MulScalar(0.5)
+	if oe, on := onEdges(other, m, eps); on {
+		// Shared boundary, the edge from polygon a decides.
+		if !isA {
+			return false, false
+		}
+		same := oe.b.Sub(oe.a).Dot(e.b.Sub(e.a)) > 0
+		if op == PolygonDifference {
+			return !same, false
+		}
+		return same, false
+	}
+	inside := windingNumber(other, m) != 0
+	switch op {
+	case PolygonUnion:
+		return !inside, false
+	case PolygonIntersection:
+		return inside, false
+	case PolygonDifference:
+		if isA {
+			return !inside, false
+		}
+		return inside, true
+	}
+	return false, false
+}
+
+// chainEdges joins directed edges into closed contours.
+func chainEdges(edges []clipEdge) [][]V2 {
+	next := make(map[V2][]int)
+	for i, e := range edges {
+		next[e.a] = append(next[e.a], i)
+	}
+	used := make([]bool, len(edges))
+	var contours [][]V2
+	for i := range edges {
+		if used[i] {
+			continue
+		}
+		start := edges[i].a
+		var c []V2
+		k := i
+		for {
+			used[k] = true
+			c = append(c, edges[k].a)
+			p := edges[k].b
+			if p == start {
+				break
+			}
+			// find an unused edge from this point
+			k = -1
+			for _, j := range next[p] {
+				if !used[j] {
+					k = j
+					break
+				}
+			}
+			if k < 0 {
+				// open chain, discard it
+				c = nil
+				break
+			}
+		}
+		c = removeColinear(c)
+		if len(c) >= 3 {
+			contours = append(contours, c)
+		}
+	}
+	return contours
+}
+
+// removeColinear removes colinear vertices from a closed contour.
+func removeColinear(c []V2) []V2 {
+	n := len(c)
+	if n < 3 {
+		return c
+	}
+	var out []V2
+	for i := range c {
+		a := c[(i+n-1)%n]
+		b := c[i]
+		d := c[(i+1)%n]
+		u := b.Sub(a)
+		v := d.Sub(b)
+		if Abs(u.Cross(v)) > tolerance*u.Length()*v.Length() || u.Dot(v) < 0 {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+//-----------------------------------------------------------------------------
+
+// PolygonBoolean returns the contours for a boolean operation on two polygons.
+func PolygonBoolean(a, b [][]V2, op PolygonOp) [][]V2 {
+	ea := polygonEdges(a)
+	eb := polygonEdges(b)
+	// work out a distance tolerance relative to the polygon size
+	var vs V2Set
+	for _, e := range append(ea, eb...) {
+		vs = append(vs, e.a)
+	}
+	if len(vs) == 0 {
+		return nil
+	}
+	size := vs.Max().Sub(vs.Min())
+	eps := tolerance * Max(1, Max(size.X, size.Y))
+	// split the edges at the intersections
+	sa, sb := intersectEdges(ea, eb, eps)
+	// select the edges
+	var edges []clipEdge
+	for _, e := range sa {
+		if keep, _ := keepEdge(e, eb, op, true, eps); keep {
+			edges = append(edges, e)
+		}
+	}
+	for _, e := range sb {
+		if keep, reverse := keepEdge(e, ea, op, false, eps); keep {
+			if reverse {
+				e = clipEdge{e.b, e.a}
+			}
+			edges = append(edges, e)
+		}
+	}
+	return chainEdges(edges)
+}
+
+// PolygonUnion2 returns the contours for the union of two polygons.
+func PolygonUnion2(a, b [][]V2) [][]V2 {
+	return PolygonBoolean(a, b, PolygonUnion)
+}
+
+// PolygonDifference2 returns the contours for the difference of two polygons, a - b.
+func PolygonDifference2(a, b [][]V2) [][]V2 {
+	return PolygonBoolean(a, b, PolygonDifference)
+}
+
+// PolygonIntersection2 returns the contours for the intersection of two polygons.
+func PolygonIntersection2(a, b [][]V2) [][]V2 {
+	return PolygonBoolean(a, b, PolygonIntersection)
+}
+
+//-----------------------------------------------------------------------------
+
+// polygonArea returns the signed area of a closed contour (> 0 for counter clockwise).
+func polygonArea(c []V2) float64 {
+	area := 0.0
+	n := len(c)
+	for i := range c {
+		area += c[i].Cross(c[(i+1)%n])
+	}
+	return 0.5 * area
+}
+
+//-----------------------------------------------------------------------------
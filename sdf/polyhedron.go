@@ -0,0 +1,342 @@
+//-----------------------------------------------------------------------------
+/*
+
+Convex Polyhedra
+
+A convex polyhedron is the intersection of half-spaces n.p <= d. Inside the
+polyhedron the maximum of the plane distances is the exact distance to the
+surface. Outside, the distance is to the nearest face polygon, so the face
+polygons are worked out by intersecting the planes.
+
+Regular (Platonic) and Catalan solids are given by the directions of their
+face normals and are sized by their inradius (center to face distance).
+General convex solids are given by their bounding planes.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// PolyhedronSDF3 is an exact SDF3 for a convex polyhedron.
+type PolyhedronSDF3 struct {
+	n     []V3      // unit face normals
+	d     []float64 // plane offsets (n.p = d on the face)
+	faces [][]V3    // face polygons, counterclockwise about the face normal
+	bb    Box3      // bounding box
+}
+
+// polyhedronEpsilon is the tolerance for vertices lying on a plane.
+const polyhedronEpsilon = 1e-9
+
+// newPolyhedron returns a convex polyhedron from its face planes.
+// Redundant planes (without a face) are removed.
+func newPolyhedron(normals []V3, offsets []float64) (*PolyhedronSDF3, error) {
+	n := make([]V3, len(normals))
+	for i := range normals {
+		if normals[i].Length() < tolerance {
+			return nil, errors.New("zero length normal")
+		}
+		n[i] = normals[i].Normalize()
+	}
+	// the vertices are the intersections of plane triples within all the half-spaces
+	scale := 0.0
+	for _, d := range offsets {
+		scale = Max(scale, Abs(d))
+	}
+	eps := polyhedronEpsilon * Max(scale, 1)
+	var vertices []V3
+	for i := 0; i < len(n); i++ {
+		for j := i + 1; j < len(n); j++ {
+			for k := j + 1; k < len(n); k++ {
+				c := n[j].Cross(n[k])
+				det := n[i].Dot(c)
+				if Abs(det) < 1e-12 {
+					continue
+				}
+				v := c.MulScalar(offsets[i]).Add(n[k].Cross(n[i]).MulScalar(offsets[j])).Add(n[i].Cross(n[j]).MulScalar(offsets[k])).DivScalar(det)
+				inside := true
+				for m := range n {
+					if n[m].Dot(v)-offsets[m] > eps {
+						inside = false
+						break
+					}
+				}
+				if !inside {
+					continue
+				}
+				dup := false
+				for _, u := range vertices {
+					if u.Sub(v).Length() < eps {
+						dup = true
+						break
+					}
+				}
+				if !dup {
+					vertices = append(vertices, v)
+				}
+			}
+		}
+	}
+	if len(vertices) < 4 {
+		return nil, errors.New("the planes don't bound a solid")
+	}
+	s := PolyhedronSDF3{}
+	for i := range n {
+		var f []V3
+		c := V3{}
+		for _, v := range vertices {
+			if Abs(n[i].Dot(v)-offsets[i]) < eps {
+				f = append(f, v)
+				c = c.Add(v)
+			}
+		}
+		if len(f) < 3 {
+			// redundant plane
+			continue
+		}
+		c = c.DivScalar(float64(len(f)))
+		// sort the face vertices counterclockwise about the normal
+		u := f[0].Sub(c).Normalize()
+		w := n[i].Cross(u)
+		sort.Slice(f, func(a, b int) bool {
+			pa, pb := f[a].Sub(c), f[b].Sub(c)
+			return math.Atan2(w.Dot(pa), u.Dot(pa)) < math.Atan2(w.Dot(pb), u.Dot(pb))
+		})
+		s.n = append(s.n, n[i])
+		s.d = append(s.d, offsets[i])
+		s.faces = append(s.faces, f)
+	}
+	// check the solid is closed (Euler characteristic V - E + F = 2)
+	edges := 0
+	for _, f := range s.faces {
+		edges += len(f)
+	}
+	if len(vertices)-edges/2+len(s.faces) != 2 {
+		return nil, errors.New("the planes don't bound a solid")
+	}
+	s.bb = Box3{V3Set(vertices).Min(), V3Set(vertices).Max()}
+	return &s, nil
+}
+
+// faceDistance returns the distance from p to a face polygon.
+func (s *PolyhedronSDF3) faceDistance(i int, p V3, h float64) float64 {
+	n := s.n[i]
+	f := s.faces[i]
+	// project p onto the face plane
+	q := p.Sub(n.MulScalar(h))
+	inside := true
+	d2 := math.MaxFloat64
+	for j := range f {
+		a := f[j]
+		b := f[(j+1)%len(f)]
+		e := b.Sub(a)
+		if e.Cross(q.Sub(a)).Dot(n) < 0 {
+			inside = false
+		}
+		// distance to the edge segment
+		t := Clamp(p.Sub(a).Dot(e)/e.Dot(e), 0, 1)
+		d2 = Min(d2, p.Sub(a.Add(e.MulScalar(t))).Length2())
+	}
+	if inside {
+		return Abs(h)
+	}
+	return math.Sqrt(d2)
+}
+
+// Evaluate returns the minimum distance to a convex polyhedron.
+func (s *PolyhedronSDF3) Evaluate(p V3) float64 {
+	d := -math.MaxFloat64
+	for i := range s.n {
+		d = Max(d, s.n[i].Dot(p)-s.d[i])
+	}
+	if d <= 0 {
+		return d
+	}
+	// outside: the closest point is on a face the point is in front of
+	d = math.MaxFloat64
+	for i := range s.n {
+		h := s.n[i].Dot(p) - s.d[i]
+		if h > 0 {
+			d = Min(d, s.faceDistance(i, p, h))
+		}
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of a convex polyhedron.
+func (s *PolyhedronSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Normals returns the unit face normals of a convex polyhedron.
+func (s *PolyhedronSDF3) Normals() []V3 {
+	return append([]V3{}, s.n...)
+}
+
+// Faces returns the face polygons of a convex polyhedron.
+// The vertices are counterclockwise about the face normal.
+func (s *PolyhedronSDF3) Faces() [][]V3 {
+	faces := make([][]V3, len(s.faces))
+	for i, f := range s.faces {
+		faces[i] = append([]V3{}, f...)
+	}
+	return faces
+}
+
+// RoundPolyhedron3D returns a convex polyhedron with its edges and vertices
+// rounded to a radius. The face planes are inset by the radius and the inset
+// polyhedron is offset by the radius, so the faces stay flat and the distance
+// is exact. Faces that vanish when the planes are inset are lost.
+func RoundPolyhedron3D(s *PolyhedronSDF3, radius float64) (SDF3, error) {
+	if radius < 0 {
+		return nil, errors.New("radius < 0")
+	}
+	if radius == 0 {
+		return s, nil
+	}
+	d := make([]float64, len(s.d))
+	for i := range d {
+		d[i] = s.d[i] - radius
+	}
+	inset, err := newPolyhedron(s.n, d)
+	if err != nil {
+		return nil, errors.New("radius is too large for the polyhedron")
+	}
+	return Offset3D(inset, radius), nil
+}
+
+// Convex3D returns the convex solid bounded by a set of planes, with its
+// edges and vertices rounded to a radius (0 for sharp edges). The plane normals
+// point out of the solid. E.g. prisms, frustums and antiprisms.
+func Convex3D(planes []Plane, round float64) (SDF3, error) {
+	if len(planes) < 4 {
+		return nil, errors.New("a convex solid needs at least 4 planes")
+	}
+	n := make([]V3, len(planes))
+	d := make([]float64, len(planes))
+	for i, p := range planes {
+		if p.Normal.Length() < tolerance {
+			return nil, errors.New("zero length plane normal")
+		}
+		n[i] = p.Normal.Normalize()
+		d[i] = n[i].Dot(p.Point)
+	}
+	s, err := newPolyhedron(n, d)
+	if err != nil {
+		return nil, err
+	}
+	return RoundPolyhedron3D(s, round)
+}
+
+//-----------------------------------------------------------------------------
+// Regular and Catalan Solids
+
+// Polyhedron is a regular (Platonic) or Catalan solid.
+type Polyhedron int
+
+// Polyhedron types.
+const (
+	Tetrahedron               Polyhedron = iota // 4 triangles
+	Cube                                        // 6 squares
+	Octahedron                                  // 8 triangles
+	Dodecahedron                                // 12 pentagons
+	Icosahedron                                 // 20 triangles
+	RhombicDodecahedron                         // 12 rhombi (Catalan)
+	DeltoidalIcositetrahedron                   // 24 kites (Catalan)
+	RhombicTriacontahedron                      // 30 rhombi (Catalan)
+)
+
+// goldenRatio is (1 + sqrt(5)) / 2
+var goldenRatio = 0.5 * (1 + math.Sqrt(5))
+
+// signedPermutations returns the distinct vectors made by changing the signs of
+// the components of v and permuting them (cyclically or fully).
+func signedPermutations(v V3, cyclic bool) []V3 {
+	perms := [][3]int{{0, 1, 2}, {1, 2, 0}, {2, 0, 1}}
+	if !cyclic {
+		perms = append(perms, [3]int{0, 2, 1}, [3]int{2, 1, 0}, [3]int{1, 0, 2})
+	}
+	var out []V3
+	a := [3]float64{v.X, v.Y, v.Z}
+	for _, p := range perms {
+		for signs := 0; signs < 8; signs++ {
+			var b [3]float64
+			for i := 0; i < 3; i++ {
+				b[i] = a[p[i]]
+				if signs&(1<<i) != 0 {
+					b[i] = -b[i]
+				}
+			}
+			x := V3{b[0], b[1], b[2]}
+			dup := false
+			for _, y := range out {
+				if x.Equals(y, tolerance) {
+					dup = true
+					break
+				}
+			}
+			if !dup {
+				out = append(out, x)
+			}
+		}
+	}
+	return out
+}
+
+// polyhedronNormals returns the face normal directions of a polyhedron.
+func polyhedronNormals(kind Polyhedron) ([]V3, error) {
+	g := goldenRatio
+	switch kind {
+	case Tetrahedron:
+		return []V3{{1, 1, 1}, {1, -1, -1}, {-1, 1, -1}, {-1, -1, 1}}, nil
+	case Cube:
+		return signedPermutations(V3{1, 0, 0}, true), nil
+	case Octahedron:
+		return signedPermutations(V3{1, 1, 1}, true), nil
+	case Dodecahedron:
+		// icosahedron vertices
+		return signedPermutations(V3{0, 1, g}, true), nil
+	case Icosahedron:
+		// dodecahedron vertices
+		n := signedPermutations(V3{1, 1, 1}, true)
+		return append(n, signedPermutations(V3{0, 1 / g, g}, true)...), nil
+	case RhombicDodecahedron:
+		// cuboctahedron vertices
+		return signedPermutations(V3{1, 1, 0}, false), nil
+	case DeltoidalIcositetrahedron:
+		// rhombicuboctahedron vertices
+		return signedPermutations(V3{1, 1, 1 + math.Sqrt2}, false), nil
+	case RhombicTriacontahedron:
+		// icosidodecahedron vertices
+		n := signedPermutations(V3{0, 0, g}, true)
+		return append(n, signedPermutations(V3{0.5, 0.5 * g, 0.5 * g * g}, true)...), nil
+	}
+	return nil, fmt.Errorf("unknown polyhedron %d", kind)
+}
+
+// Polyhedron3D returns a regular or Catalan solid with a given inradius, centered on the origin.
+func Polyhedron3D(kind Polyhedron, inradius float64) (*PolyhedronSDF3, error) {
+	if inradius <= 0 {
+		return nil, errors.New("inradius <= 0")
+	}
+	n, err := polyhedronNormals(kind)
+	if err != nil {
+		return nil, err
+	}
+	d := make([]float64, len(n))
+	for i := range d {
+		d[i] = inradius
+	}
+	return newPolyhedron(n, d)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,252 @@
+//-----------------------------------------------------------------------------
+/*
+
+Profiling SDF Trees
+
+A profiler wraps the nodes of an SDF tree to count the Evaluate calls and
+time them. After meshing the hot-node report shows which parts of a deep tree
+dominate the cost (and where a voxel cache might help).
+
+	p := NewProfiler()
+	s = p.Tree3(s)
+	RenderSTL(s, 200, "part.stl")
+	p.Print(os.Stdout)
+
+Tree3 copies the nodes of the tree, so the original tree is unchanged.
+Self time is the node time less the time of its profiled children. Sub-trees
+shared by several parents are profiled once, so their self time is approximate.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+//-----------------------------------------------------------------------------
+
+// profileCounter accumulates the evaluations of a profiled node.
+type profileCounter struct {
+	name     string
+	calls    uint64
+	nsec     int64
+	children []*profileCounter
+}
+
+// add records an evaluation that started at t.
+func (c *profileCounter) add(t time.Time) {
+	atomic.AddUint64(&c.calls, 1)
+	atomic.AddInt64(&c.nsec, int64(time.Since(t)))
+}
+
+// ProfileSDF3 is an SDF3 with profiled evaluation.
+type ProfileSDF3 struct {
+	sdf     SDF3
+	counter *profileCounter
+}
+
+// Evaluate returns the minimum distance to a profiled SDF3.
+func (s *ProfileSDF3) Evaluate(p V3) float64 {
+	t := time.Now()
+	d := s.sdf.Evaluate(p)
+	s.counter.add(t)
+	return d
+}
+
+// BoundingBox returns the bounding box of a profiled SDF3.
+func (s *ProfileSDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+// ProfileSDF2 is an SDF2 with profiled evaluation.
+type ProfileSDF2 struct {
+	sdf     SDF2
+	counter *profileCounter
+}
+
+// Evaluate returns the minimum distance to a profiled SDF2.
+func (s *ProfileSDF2) Evaluate(p V2) float64 {
+	t := time.Now()
+	d := s.sdf.Evaluate(p)
+	s.counter.add(t)
+	return d
+}
+
+// BoundingBox returns the bounding box of a profiled SDF2.
+func (s *ProfileSDF2) BoundingBox() Box2 {
+	return s.sdf.BoundingBox()
+}
+
+//-----------------------------------------------------------------------------
+
+// Profiler collects evaluation counts and times for the nodes of SDF trees.
+type Profiler struct {
+	counters []*profileCounter
+	wrapped  map[interface{}]interface{} // original node -> profiled node
+	owner    map[interface{}]*profileCounter
+}
+
+// NewProfiler returns a new profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{
+		wrapped: make(map[interface{}]interface{}),
+		owner:   make(map[interface{}]*profileCounter),
+	}
+}
+
+func (p *Profiler) newCounter(name string) *profileCounter {
+	c := &profileCounter{name: name}
+	p.counters = append(p.counters, c)
+	return c
+}
+
+// SDF3 wraps a single SDF3 for profiling with the given name.
+func (p *Profiler) SDF3(s SDF3, name string) SDF3 {
+	return &ProfileSDF3{s, p.newCounter(name)}
+}
+
+// SDF2 wraps a single SDF2 for profiling with the given name.
+func (p *Profiler) SDF2(s SDF2, name string) SDF2 {
+	return &ProfileSDF2{s, p.newCounter(name)}
+}
+
+// Tree3 returns a copy of an SDF3 tree with every node profiled.
+func (p *Profiler) Tree3(s SDF3) SDF3 {
+	return p.tree(reflect.ValueOf(s), "", nil).Interface().(SDF3)
+}
+
+// Tree2 returns a copy of an SDF2 tree with every node profiled.
+func (p *Profiler) Tree2(s SDF2) SDF2 {
+	return p.tree(reflect.ValueOf(s), "", nil).Interface().(SDF2)
+}
+
+var sdf3Type = reflect.TypeOf((*SDF3)(nil)).Elem()
+var sdf2Type = reflect.TypeOf((*SDF2)(nil)).Elem()
+
+// nodeName returns the short name of a node type.
+func nodeName(v reflect.Value) string {
+	t := v.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// tree returns a profiled copy of an SDF node (v is an SDF2 or SDF3).
+func (p *Profiler) tree(v reflect.Value, path string, parent *profileCounter) reflect.Value {
+	key := v.Interface()
+	comparable := v.Type().Comparable()
+	if comparable {
+		if x, ok := p.wrapped[key]; ok {
+			// shared sub-tree
+			if parent != nil {
+				parent.children = append(parent.children, p.owner[key])
+			}
+			return reflect.ValueOf(x)
+		}
+	}
+	name := nodeName(v)
+	if path != "" {
+		name = path + "/" + name
+	}
+	c := p.newCounter(name)
+	if parent != nil {
+		parent.children = append(parent.children, c)
+	}
+	// copy the node and profile its children
+	node := v
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+		node = reflect.New(v.Elem().Type())
+		node.Elem().Set(v.Elem())
+		for i := 0; i < node.Elem().NumField(); i++ {
+			f := node.Elem().Field(i)
+			// make unexported fields settable
+			f = reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+			switch {
+			case (f.Type() == sdf3Type || f.Type() == sdf2Type) && !f.IsNil():
+				f.Set(p.tree(f.Elem(), name, c))
+			case f.Kind() == reflect.Slice && (f.Type().Elem() == sdf3Type || f.Type().Elem() == sdf2Type):
+				x := reflect.MakeSlice(f.Type(), f.Len(), f.Len())
+				for j := 0; j < f.Len(); j++ {
+					if !f.Index(j).IsNil() {
+						x.Index(j).Set(p.tree(f.Index(j).Elem(), fmt.Sprintf("%s[%d]", name, j), c))
+					}
+				}
+				f.Set(x)
+			}
+		}
+	}
+	var w interface{}
+	if s, ok := node.Interface().(SDF3); ok {
+		w = &ProfileSDF3{s, c}
+	} else {
+		w = &ProfileSDF2{node.Interface().(SDF2), c}
+	}
+	if comparable {
+		p.wrapped[key] = w
+		p.owner[key] = c
+	}
+	return reflect.ValueOf(w)
+}
+
+//-----------------------------------------------------------------------------
+
+// ProfileNode is the profile of a node.
+type ProfileNode struct {
+	Name  string        // node path within the tree
+	Calls uint64        // number of Evaluate calls
+	Time  time.Duration // total time within Evaluate
+	Self  time.Duration // time not spent in profiled children
+}
+
+// Report returns the node profiles sorted by decreasing self time.
+func (p *Profiler) Report() []ProfileNode {
+	nodes := make([]ProfileNode, len(p.counters))
+	for i, c := range p.counters {
+		t := time.Duration(atomic.LoadInt64(&c.nsec))
+		self := t
+		for _, x := range c.children {
+			self -= time.Duration(atomic.LoadInt64(&x.nsec))
+		}
+		if self < 0 {
+			self = 0
+		}
+		nodes[i] = ProfileNode{c.name, atomic.LoadUint64(&c.calls), t, self}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Self > nodes[j].Self
+	})
+	return nodes
+}
+
+// Print writes the hot-node report.
+func (p *Profiler) Print(w io.Writer) {
+	nodes := p.Report()
+	total := time.Duration(0)
+	for _, n := range nodes {
+		total += n.Self
+	}
+	fmt.Fprintf(w, "%6s %12s %12s %12s %8s  %s\n", "self%", "self", "total", "calls", "ns/call", "node")
+	for _, n := range nodes {
+		pct := 0.0
+		if total > 0 {
+			pct = 100 * float64(n.Self) / float64(total)
+		}
+		nsPerCall := 0.0
+		if n.Calls > 0 {
+			nsPerCall = float64(n.Time) / float64(n.Calls)
+		}
+		fmt.Fprintf(w, "%5.1f%% %12s %12s %12d %8.0f  %s\n", pct, n.Self.Round(time.Microsecond), n.Time.Round(time.Microsecond), n.Calls, nsPerCall, strings.TrimPrefix(n.Name, "/"))
+	}
+}
+
+//-----------------------------------------------------------------------------
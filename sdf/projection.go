@@ -0,0 +1,134 @@
+//-----------------------------------------------------------------------------
+/*
+
+Orthographic Projections
+
+Silhouettes of an SDF3 viewed along the principal axes, for shop drawings.
+Views are third angle: the front view looks along +y, the top view looks
+down -z and the right view looks along -x.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "math"
+
+//-----------------------------------------------------------------------------
+
+// View is an orthographic view direction.
+type View int
+
+// Orthographic views.
+const (
+	ViewFront View = iota // x right, z up
+	ViewTop               // x right, y up
+	ViewRight             // y right, z up
+)
+
+// axes returns the drawing x, y axes and the view depth axis (towards the viewer).
+func (v View) axes() (V3, V3, V3) {
+	switch v {
+	case ViewTop:
+		return V3{1, 0, 0}, V3{0, 1, 0}, V3{0, 0, 1}
+	case ViewRight:
+		return V3{0, 1, 0}, V3{0, 0, 1}, V3{1, 0, 0}
+	}
+	return V3{1, 0, 0}, V3{0, 0, 1}, V3{0, -1, 0}
+}
+
+// Project returns the drawing position of a 3D point.
+func (v View) Project(p V3) V2 {
+	x, y, _ := v.axes()
+	return V2{p.Dot(x), p.Dot(y)}
+}
+
+//-----------------------------------------------------------------------------
+
+// ProjectionSDF2 is the silhouette of an SDF3 in a view.
+type ProjectionSDF2 struct {
+	sdf        SDF3
+	x, y, z    V3      // drawing axes and depth axis
+	zmin, zmax float64 // depth range
+	bb         Box2
+}
+
+// Project2D returns the silhouette of an SDF3 in a view.
+// The distance is accurate for the inside/outside test, but outside it is the
+// smallest distance sampled along the view ray, so it can overestimate the
+// distance to the silhouette. It is fine for rendering contours, but it is not
+// a safe bound for ray marching or for offsetting the silhouette.
+func Project2D(s SDF3, view View) SDF2 {
+	p := ProjectionSDF2{sdf: s}
+	p.x, p.y, p.z = view.axes()
+	bb := s.BoundingBox()
+	p.zmin = math.Inf(1)
+	p.zmax = math.Inf(-1)
+	var v2 V2Set
+	for _, v := range bb.Vertices() {
+		v2 = append(v2, view.Project(v))
+		p.zmin = math.Min(p.zmin, v.Dot(p.z))
+		p.zmax = math.Max(p.zmax, v.Dot(p.z))
+	}
+	p.bb = Box2{v2.Min(), v2.Max()}
+	return &p
+}
+
+// Evaluate returns the distance to the silhouette. The ray along the depth axis
+// is sphere traced with a minimum step of 1/1000 of the depth, so features
+// thinner than that along the ray may be missed.
+func (s *ProjectionSDF2) Evaluate(p V2) float64 {
+	base := s.x.MulScalar(p.X).Add(s.y.MulScalar(p.Y))
+	eps := 1e-3 * (s.zmax - s.zmin)
+	d := math.Inf(1)
+	for z := s.zmin; z <= s.zmax; {
+		x := s.sdf.Evaluate(base.Add(s.z.MulScalar(z)))
+		if x < 0 {
+			return x
+		}
+		d = math.Min(d, x)
+		z += math.Max(x, eps)
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of the silhouette.
+func (s *ProjectionSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
+// ViewDrawing returns a drawing of the silhouette of an SDF3 in a view.
+func ViewDrawing(s SDF3, view View, resolution float64) *Drawing {
+	d := NewDrawing()
+	d.Polygons(Contours2(Project2D(s, view), resolution), OutlineStyle)
+	return d
+}
+
+// AnchorDimension is a dimension between two named anchors of a part.
+type AnchorDimension struct {
+	A, B   string  // anchor names
+	Offset float64 // offset of the dimension line (to the left of A->B)
+	Text   string  // dimension text (empty for the distance)
+}
+
+// PartDrawing returns a drawing of a part in a view with dimensions between
+// its named anchors. The part is drawn in its own coordinate frame.
+func PartDrawing(p *Part, view View, dims []AnchorDimension, resolution float64) (*Drawing, error) {
+	d := ViewDrawing(p.SDF, view, resolution)
+	for _, dim := range dims {
+		a, err := p.LocalAnchor(dim.A)
+		if err != nil {
+			return nil, err
+		}
+		b, err := p.LocalAnchor(dim.B)
+		if err != nil {
+			return nil, err
+		}
+		d.Dimension(view.Project(a.Position), view.Project(b.Position), dim.Offset, dim.Text)
+	}
+	return d, nil
+}
+
+//-----------------------------------------------------------------------------
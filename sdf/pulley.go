@@ -0,0 +1,201 @@
+//-----------------------------------------------------------------------------
+/*
+
+Timing Belt Pulleys
+
+The pulley tooth profile is made by cutting rounded grooves into a circle at
+the pulley outside diameter. The outside diameter is the pitch diameter less
+twice the pitch line differential (the distance from the pitch line of the belt
+to the bottom of the belt teeth).
+
+The groove shapes are an approximation of the standard profiles, but they are
+close enough for printed pulleys to mesh properly with commercial belts.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+//-----------------------------------------------------------------------------
+// Belt Database - lookup standard timing belts by name
+
+// BeltParameters stores the values that define a timing belt tooth profile.
+type BeltParameters struct {
+	Name        string  // name of belt profile
+	Pitch       float64 // tooth to tooth distance
+	PLD         float64 // pitch line differential
+	ToothHeight float64 // height of the belt tooth (groove depth)
+	ToothRadius float64 // radius of the belt tooth tip
+}
+
+type beltDatabase map[string]*BeltParameters
+
+var beltDB = initBeltLookup()
+
+// add adds a belt profile to the belt database.
+func (m beltDatabase) add(
+	name string, // belt name
+	pitch float64, // tooth to tooth distance
+	pld float64, // pitch line differential
+	height float64, // belt tooth height
+	radius float64, // belt tooth tip radius
+) {
+	m[name] = &BeltParameters{
+		Name:        name,
+		Pitch:       pitch,
+		PLD:         pld,
+		ToothHeight: height,
+		ToothRadius: radius,
+	}
+}
+
+// initBeltLookup adds a collection of standard belts to the belt database.
+func initBeltLookup() beltDatabase {
+	m := make(beltDatabase)
+	// Gates GT2/GT3
+	m.add("GT2_2mm", 2, 0.254, 0.75, 0.555)
+	m.add("GT2_3mm", 3, 0.381, 1.14, 0.85)
+	m.add("GT2_5mm", 5, 0.5715, 1.93, 1.44)
+	m.add("GT3_3mm", 3, 0.381, 1.14, 0.85)
+	m.add("GT3_5mm", 5, 0.5715, 1.93, 1.44)
+	// HTD
+	m.add("HTD_3M", 3, 0.381, 1.17, 0.86)
+	m.add("HTD_5M", 5, 0.5715, 2.06, 1.49)
+	m.add("HTD_8M", 8, 0.686, 3.38, 2.46)
+	return m
+}
+
+// BeltLookup lookups the parameters for a timing belt by name.
+func BeltLookup(name string) (*BeltParameters, error) {
+	if b, ok := beltDB[name]; ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("belt \"%s\" not found", name)
+}
+
+// PitchRadius returns the pitch radius of a pulley with n teeth.
+func (b *BeltParameters) PitchRadius(n int) float64 {
+	return float64(n) * b.Pitch / Tau
+}
+
+// OuterRadius returns the outside radius of a pulley with n teeth.
+func (b *BeltParameters) OuterRadius(n int) float64 {
+	return b.PitchRadius(n) - b.PLD
+}
+
+//-----------------------------------------------------------------------------
+
+// Pulley2D returns the 2D tooth profile for a timing belt pulley.
+func Pulley2D(
+	belt *BeltParameters, // timing belt parameters
+	numberTeeth int, // number of pulley teeth
+) SDF2 {
+	r := belt.OuterRadius(numberTeeth)
+	// a rounded groove extending from the groove bottom to beyond the outside radius
+	gr := belt.ToothRadius
+	x0 := r - belt.ToothHeight + gr
+	x1 := r + gr
+	groove := Line2D(x1-x0, gr)
+	groove = Transform2D(groove, Translate2d(V2{0.5 * (x0 + x1), 0}))
+	grooves := RotateCopy2D(groove, numberTeeth)
+	return Difference2D(Circle2D(r), grooves)
+}
+
+//-----------------------------------------------------------------------------
+
+// PulleyParms defines the parameters for a timing belt pulley.
+type PulleyParms struct {
+	Belt            string  // name of timing belt
+	NumberTeeth     int     // number of pulley teeth
+	BeltWidth       float64 // width of the toothed section
+	FlangeHeight    float64 // radial height of the flanges above the outside radius (0 for no flanges)
+	FlangeThickness float64 // thickness of the flanges
+	BoreRadius      float64 // radius of the shaft bore (0 for no bore)
+	HubRadius       float64 // radius of the hub (0 for no hub)
+	HubHeight       float64 // height of the hub
+	SetScrewRadius  float64 // radius of the hub set screw hole (0 for no set screw)
+}
+
+// Pulley3D returns a timing belt pulley.
+// The pulley axis is the z-axis, with the hub (if any) at the bottom and z = 0 at the base.
+func Pulley3D(k *PulleyParms) (SDF3, error) {
+	// validate parameters
+	belt, err := BeltLookup(k.Belt)
+	if err != nil {
+		return nil, err
+	}
+	if k.NumberTeeth < 6 {
+		return nil, errors.New("NumberTeeth < 6")
+	}
+	if k.BeltWidth <= 0 {
+		return nil, errors.New("BeltWidth <= 0")
+	}
+	if k.FlangeHeight < 0 || k.FlangeThickness < 0 {
+		return nil, errors.New("flange dimensions < 0")
+	}
+	r := belt.OuterRadius(k.NumberTeeth)
+	if k.BoreRadius < 0 || k.BoreRadius >= r-belt.ToothHeight {
+		return nil, errors.New("invalid BoreRadius")
+	}
+	if k.HubRadius != 0 {
+		if k.HubRadius <= k.BoreRadius {
+			return nil, errors.New("HubRadius <= BoreRadius")
+		}
+		if k.HubHeight <= 0 {
+			return nil, errors.New("HubHeight <= 0")
+		}
+	}
+	if k.SetScrewRadius < 0 || (k.SetScrewRadius > 0 && k.HubRadius == 0) {
+		return nil, errors.New("invalid SetScrewRadius")
+	}
+
+	var parts []SDF3
+	z := 0.0
+
+	// hub
+	hubHeight := 0.0
+	if k.HubRadius != 0 {
+		hubHeight = k.HubHeight
+		hub := Cylinder3D(hubHeight, k.HubRadius, 0)
+		parts = append(parts, Transform3D(hub, Translate3d(V3{0, 0, 0.5 * hubHeight})))
+		z += hubHeight
+	}
+
+	// flanges and teeth
+	flanges := k.FlangeHeight > 0 && k.FlangeThickness > 0
+	flange := Cylinder3D(k.FlangeThickness, r+k.FlangeHeight, 0)
+	if flanges {
+		parts = append(parts, Transform3D(flange, Translate3d(V3{0, 0, z + 0.5*k.FlangeThickness})))
+		z += k.FlangeThickness
+	}
+	teeth := Extrude3D(Pulley2D(belt, k.NumberTeeth), k.BeltWidth)
+	parts = append(parts, Transform3D(teeth, Translate3d(V3{0, 0, z + 0.5*k.BeltWidth})))
+	z += k.BeltWidth
+	if flanges {
+		parts = append(parts, Transform3D(flange, Translate3d(V3{0, 0, z + 0.5*k.FlangeThickness})))
+		z += k.FlangeThickness
+	}
+	s := Union3D(parts...)
+
+	// bore
+	if k.BoreRadius > 0 {
+		bore := Cylinder3D(z, k.BoreRadius, 0)
+		s = Difference3D(s, Transform3D(bore, Translate3d(V3{0, 0, 0.5 * z})))
+	}
+
+	// radial set screw hole through the hub
+	if k.SetScrewRadius > 0 {
+		screw := Cylinder3D(k.HubRadius, k.SetScrewRadius, 0)
+		m := Translate3d(V3{0.5 * k.HubRadius, 0, 0.5 * hubHeight}).Mul(RotateY(DtoR(90)))
+		s = Difference3D(s, Transform3D(screw, m))
+	}
+
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,91 @@
+//-----------------------------------------------------------------------------
+/*
+
+Quadratic Bezier Curves
+
+An exact SDF2 for a stroked quadratic bezier curve.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "math"
+
+//-----------------------------------------------------------------------------
+
+// quadraticBezierDistance returns the exact minimum distance from p to the
+// quadratic bezier curve with end points p0, p2 and control point p1.
+// The closest point is found by solving the cubic for the curve parameter in closed form.
+// See: https://iquilezles.org/articles/distfunctions2d/
+func quadraticBezierDistance(p, p0, p1, p2 V2) float64 {
+	a := p1.Sub(p0)
+	b := p0.Sub(p1.MulScalar(2)).Add(p2)
+	if b.Length2() < tolerance*tolerance {
+		// the curve is a straight line
+		return pointSegmentDistance(p, p0, p2)
+	}
+	cc := a.MulScalar(2)
+	d := p0.Sub(p)
+	// dist2 returns the squared distance to the curve at t
+	dist2 := func(t float64) float64 {
+		return d.Add(cc.Add(b.MulScalar(t)).MulScalar(t)).Length2()
+	}
+	// the cubic in t (depressed form)
+	kk := 1 / b.Dot(b)
+	kx := kk * a.Dot(b)
+	ky := kk * (2*a.Dot(a) + d.Dot(b)) / 3
+	kz := kk * d.Dot(a)
+	pp := ky - kx*kx
+	q := kx*(2*kx*kx-3*ky) + kz
+	h := q*q + 4*pp*pp*pp
+	if h >= 0 {
+		// one real root
+		h = math.Sqrt(h)
+		t := math.Cbrt(0.5*(h-q)) + math.Cbrt(0.5*(-h-q)) - kx
+		return math.Sqrt(dist2(Clamp(t, 0, 1)))
+	}
+	// three real roots (the middle root is never the minimum)
+	z := math.Sqrt(-pp)
+	v := math.Acos(q/(pp*z*2)) / 3
+	m := math.Cos(v)
+	n := math.Sin(v) * math.Sqrt(3)
+	t0 := Clamp((m+m)*z-kx, 0, 1)
+	t1 := Clamp((-n-m)*z-kx, 0, 1)
+	return math.Sqrt(Min(dist2(t0), dist2(t1)))
+}
+
+//-----------------------------------------------------------------------------
+// Quadratic Bezier SDF
+
+// QuadraticBezierSDF2 is an SDF2 made by stroking a quadratic bezier curve.
+type QuadraticBezierSDF2 struct {
+	p0, p1, p2 V2 // end, control, end points
+	radius     float64
+	bb         Box2
+}
+
+// QuadraticBezier2D returns an SDF2 for a quadratic bezier curve stroked with
+// the given width. The distance is exact, so there are no flattening artifacts.
+func QuadraticBezier2D(p0, p1, p2 V2, width float64) SDF2 {
+	s := QuadraticBezierSDF2{}
+	s.p0, s.p1, s.p2 = p0, p1, p2
+	s.radius = 0.5 * width
+	// the curve lies within the hull of the control points
+	vmin := p0.Min(p1).Min(p2)
+	vmax := p0.Max(p1).Max(p2)
+	s.bb = Box2{vmin.SubScalar(s.radius), vmax.AddScalar(s.radius)}
+	return &s
+}
+
+// Evaluate returns the minimum distance to a stroked quadratic bezier curve.
+func (s *QuadraticBezierSDF2) Evaluate(p V2) float64 {
+	return quadraticBezierDistance(p, s.p0, s.p1, s.p2) - s.radius
+}
+
+// BoundingBox returns the bounding box of a stroked quadratic bezier curve.
+func (s *QuadraticBezierSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,545 @@
+//-----------------------------------------------------------------------------
+/*
+
+QR Codes
+
+Generate QR code symbols (ISO/IEC 18004) for embossing or engraving onto parts.
+
+The text is encoded in byte mode with versions 1 to 10 (up to 57x57 modules).
+
+See: https://www.thonky.com/qr-code-tutorial/
+See: https://www.nayuki.io/page/qr-code-generator-library
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+//-----------------------------------------------------------------------------
+
+// QRLevel is the error correction level of a QR code.
+type QRLevel int
+
+// QR code error correction levels.
+const (
+	QRLow      QRLevel = iota // recovers ~7% of the data
+	QRMedium                  // recovers ~15% of the data
+	QRQuartile                // recovers ~25% of the data
+	QRHigh                    // recovers ~30% of the data
+)
+
+// formatBits returns the error correction level bits for the format information.
+func (l QRLevel) formatBits() int {
+	return [4]int{1, 0, 3, 2}[l]
+}
+
+// qrBlocks is the error correction block structure for a version/level.
+type qrBlocks struct {
+	ec     int // error correction codewords per block
+	n1, d1 int // number of blocks/data codewords per block (group 1)
+	n2, d2 int // number of blocks/data codewords per block (group 2)
+}
+
+// qrBlockTable is indexed by [version-1][level].
+var qrBlockTable = [][4]qrBlocks{
+	{{7, 1, 19, 0, 0}, {10, 1, 16, 0, 0}, {13, 1, 13, 0, 0}, {17, 1, 9, 0, 0}},
+	{{10, 1, 34, 0, 0}, {16, 1, 28, 0, 0}, {22, 1, 22, 0, 0}, {28, 1, 16, 0, 0}},
+	{{15, 1, 55, 0, 0}, {26, 1, 44, 0, 0}, {18, 2, 17, 0, 0}, {22, 2, 13, 0, 0}},
+	{{20, 1, 80, 0, 0}, {18, 2, 32, 0, 0}, {26, 2, 24, 0, 0}, {16, 4, 9, 0, 0}},
+	{{26, 1, 108, 0, 0}, {24, 2, 43, 0, 0}, {18, 2, 15, 2, 16}, {22, 2, 11, 2, 12}},
+	{{18, 2, 68, 0, 0}, {16, 4, 27, 0, 0}, {24, 4, 19, 0, 0}, {28, 4, 15, 0, 0}},
+	{{20, 2, 78, 0, 0}, {18, 4, 31, 0, 0}, {18, 2, 14, 4, 15}, {26, 4, 13, 1, 14}},
+	{{24, 2, 97, 0, 0}, {22, 2, 38, 2, 39}, {22, 4, 18, 2, 19}, {26, 4, 14, 2, 15}},
+	{{30, 2, 116, 0, 0}, {22, 3, 36, 2, 37}, {20, 4, 16, 4, 17}, {24, 4, 12, 4, 13}},
+	{{18, 2, 68, 2, 69}, {26, 4, 43, 1, 44}, {24, 6, 19, 2, 20}, {28, 6, 15, 2, 16}},
+}
+
+// qrAlignment is the alignment pattern centers for each version.
+var qrAlignment = [][]int{
+	{},
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+	{6, 28, 50},
+}
+
+// dataCodewords returns the total number of data codewords.
+func (b *qrBlocks) dataCodewords() int {
+	return b.n1*b.d1 + b.n2*b.d2
+}
+
+//-----------------------------------------------------------------------------
+// Reed-Solomon error correction over GF(256)
+
+// gfMul multiplies two elements of GF(256) (modulo x^8 + x^4 + x^3 + x^2 + 1).
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11d)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsDivisor returns the generator polynomial for a given degree (leading term omitted).
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return result
+}
+
+// rsRemainder returns the error correction codewords for the data.
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMul(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+//-----------------------------------------------------------------------------
+
+// qrBits is a bit buffer.
+type qrBits []bool
+
+func (b *qrBits) add(val, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (val>>uint(i))&1 != 0)
+	}
+}
+
+// qrCodewords encodes the text into data and error correction codewords.
+// It returns the interleaved codewords and the version.
+func qrCodewords(text string, level QRLevel) ([]byte, int, error) {
+	data := []byte(text)
+	// find the smallest version that fits the data
+	version := 0
+	for v := 1; v <= len(qrBlockTable); v++ {
+		ccBits := 8
+		if v >= 10 {
+			ccBits = 16
+		}
+		b := qrBlockTable[v-1][level]
+		if 4+ccBits+8*len(data) <= 8*b.dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, 0, fmt.Errorf("text is too long (%d bytes) for a QR code", len(data))
+	}
+	blocks := qrBlockTable[version-1][level]
+	capacity := 8 * blocks.dataCodewords()
+
+	// byte mode encoding
+	var bits qrBits
+	bits.add(4, 4)
+	if version >= 10 {
+		bits.add(len(data), 16)
+	} else {
+		bits.add(len(data), 8)
+	}
+	for _, x := range data {
+		bits.add(int(x), 8)
+	}
+	// terminator and pad to a byte boundary
+	bits.add(0, minInt(4, capacity-len(bits)))
+	bits.add(0, (8-len(bits)%8)%8)
+	// pad bytes
+	for pad := 0xec; len(bits) < capacity; pad ^= 0xec ^ 0x11 {
+		bits.add(pad, 8)
+	}
+	codewords := make([]byte, len(bits)/8)
+	for i, b := range bits {
+		if b {
+			codewords[i>>3] |= 1 << uint(7-i&7)
+		}
+	}
+
+	// split into blocks and add error correction
+	divisor := rsDivisor(blocks.ec)
+	var dataBlocks, ecBlocks [][]byte
+	k := 0
+	for i := 0; i < blocks.n1+blocks.n2; i++ {
+		n := blocks.d1
+		if i >= blocks.n1 {
+			n = blocks.d2
+		}
+		dataBlocks = append(dataBlocks, codewords[k:k+n])
+		ecBlocks = append(ecBlocks, rsRemainder(codewords[k:k+n], divisor))
+		k += n
+	}
+
+	// interleave the blocks
+	var result []byte
+	for i := 0; i < maxInt(blocks.d1, blocks.d2); i++ {
+		for _, b := range dataBlocks {
+			if i < len(b) {
+				result = append(result, b[i])
+			}
+		}
+	}
+	for i := 0; i < blocks.ec; i++ {
+		for _, b := range ecBlocks {
+			result = append(result, b[i])
+		}
+	}
+	return result, version, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// qrSymbol is the module matrix for a QR code.
+type qrSymbol struct {
+	size     int
+	modules  [][]bool // [y][x] true == dark
+	function [][]bool // [y][x] true == function pattern
+}
+
+func newQRSymbol(version int) *qrSymbol {
+	q := qrSymbol{size: 17 + 4*version}
+	q.modules = make([][]bool, q.size)
+	q.function = make([][]bool, q.size)
+	for i := range q.modules {
+		q.modules[i] = make([]bool, q.size)
+		q.function[i] = make([]bool, q.size)
+	}
+	return &q
+}
+
+// set sets a function module.
+func (q *qrSymbol) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+// finder draws a finder pattern (and separator) centered on x, y.
+func (q *qrSymbol) finder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx >= 0 && xx < q.size && yy >= 0 && yy < q.size {
+				d := maxInt(absInt(dx), absInt(dy))
+				q.set(xx, yy, d != 2 && d != 4)
+			}
+		}
+	}
+}
+
+// alignment draws an alignment pattern centered on x, y.
+func (q *qrSymbol) alignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.set(x+dx, y+dy, maxInt(absInt(dx), absInt(dy)) != 1)
+		}
+	}
+}
+
+// functionPatterns draws the function patterns for a version.
+func (q *qrSymbol) functionPatterns(version int) {
+	// timing patterns
+	for i := 0; i < q.size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	// finder patterns
+	q.finder(3, 3)
+	q.finder(q.size-4, 3)
+	q.finder(3, q.size-4)
+	// alignment patterns
+	pos := qrAlignment[version-1]
+	n := len(pos)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if (i == 0 && j == 0) || (i == 0 && j == n-1) || (i == n-1 && j == 0) {
+				// overlaps a finder pattern
+				continue
+			}
+			q.alignment(pos[i], pos[j])
+		}
+	}
+	// reserve the format information
+	q.format(0, 0)
+	// version information
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>uint(i))&1 != 0
+			a := q.size - 11 + i%3
+			b := i / 3
+			q.set(a, b, dark)
+			q.set(b, a, dark)
+		}
+	}
+}
+
+// qrFormatBits returns the 15 bit format information for a level and mask.
+func qrFormatBits(level, mask int) int {
+	data := level<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// format draws the format information.
+func (q *qrSymbol) format(level, mask int) {
+	bits := qrFormatBits(level, mask)
+	bit := func(i int) bool {
+		return (bits>>uint(i))&1 != 0
+	}
+	// first copy
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+	// second copy
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	// dark module
+	q.set(8, q.size-8, true)
+}
+
+// codewords places the data codewords in the zig-zag pattern.
+func (q *qrSymbol) codewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			// skip the vertical timing pattern
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					// upwards
+					y = q.size - 1 - vert
+				}
+				if !q.function[y][x] && i < len(data)*8 {
+					q.modules[y][x] = (data[i>>3]>>uint(7-i&7))&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// qrMask returns true if the mask pattern inverts the module at x, y.
+func qrMask(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// applyMask xors a mask pattern onto the data modules.
+func (q *qrSymbol) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if !q.function[y][x] && qrMask(mask, x, y) {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty returns the mask penalty score for the symbol.
+func (q *qrSymbol) penalty() int {
+	n := q.size
+	p := 0
+	at := func(x, y int, horizontal bool) bool {
+		if horizontal {
+			return q.modules[y][x]
+		}
+		return q.modules[x][y]
+	}
+	finderLike := []bool{true, false, true, true, true, false, true}
+	for _, horizontal := range []bool{true, false} {
+		for y := 0; y < n; y++ {
+			// runs of the same color
+			run := 1
+			for x := 1; x < n; x++ {
+				if at(x, y, horizontal) == at(x-1, y, horizontal) {
+					run++
+					if run == 5 {
+						p += 3
+					} else if run > 5 {
+						p++
+					}
+				} else {
+					run = 1
+				}
+			}
+			// finder like patterns with 4 light modules on either side
+			for x := 0; x+7 <= n; x++ {
+				match := true
+				for k, v := range finderLike {
+					if at(x+k, y, horizontal) != v {
+						match = false
+						break
+					}
+				}
+				if !match {
+					continue
+				}
+				light := func(x0, x1 int) bool {
+					for k := x0; k < x1; k++ {
+						if k >= 0 && k < n && at(k, y, horizontal) {
+							return false
+						}
+					}
+					return true
+				}
+				if light(x-4, x) || light(x+7, x+11) {
+					p += 40
+				}
+			}
+		}
+	}
+	// 2x2 blocks of the same color
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x < n-1 && y < n-1 {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					p += 3
+				}
+			}
+		}
+	}
+	// balance of dark and light modules
+	total := n * n
+	k := absInt(dark*20-total*10) / total
+	p += 10 * k
+	return p
+}
+
+//-----------------------------------------------------------------------------
+
+// QRCode returns the module matrix of a QR code for the text.
+// The matrix is indexed [y][x] with y = 0 at the top and true for a dark module.
+// The quiet zone is not included.
+func QRCode(text string, level QRLevel) ([][]bool, error) {
+	if level < QRLow || level > QRHigh {
+		return nil, errors.New("bad QR code error correction level")
+	}
+	data, version, err := qrCodewords(text, level)
+	if err != nil {
+		return nil, err
+	}
+	q := newQRSymbol(version)
+	q.functionPatterns(version)
+	q.codewords(data)
+	// pick the mask with the lowest penalty
+	best := -1
+	bestPenalty := 0
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.format(level.formatBits(), mask)
+		p := q.penalty()
+		if best < 0 || p < bestPenalty {
+			best = mask
+			bestPenalty = p
+		}
+		// undo the mask
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.format(level.formatBits(), best)
+	return q.modules, nil
+}
+
+// QRCode2D returns an SDF2 for the dark modules of a QR code.
+// The code is centered on the origin. Leave a quiet zone of 4 modules around the code.
+func QRCode2D(text string, moduleSize float64, level QRLevel) (SDF2, error) {
+	if moduleSize <= 0 {
+		return nil, errors.New("moduleSize <= 0")
+	}
+	m, err := QRCode(text, level)
+	if err != nil {
+		return nil, err
+	}
+	return moduleGrid2D(m, moduleSize), nil
+}
+
+// moduleGrid2D returns an SDF2 for the dark modules of a [y][x] (y down) grid, centered on the origin.
+func moduleGrid2D(m [][]bool, moduleSize float64) SDF2 {
+	h := len(m)
+	w := len(m[0])
+	// convert to a [x][y] grid with y up
+	grid := make([][]bool, w)
+	for x := range grid {
+		grid[x] = make([]bool, h)
+		for y := range grid[x] {
+			grid[x][y] = m[h-1-y][x]
+		}
+	}
+	contours := chainEdges(traceEdges(grid))
+	ofs := V2{float64(w), float64(h)}.MulScalar(0.5)
+	for _, c := range contours {
+		for i := range c {
+			c[i] = c[i].Sub(ofs).MulScalar(moduleSize)
+		}
+	}
+	return MultiPolygon2D(contours, NonZero)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,159 @@
+//-----------------------------------------------------------------------------
+/*
+
+Ratchets
+
+A ratchet wheel with sawtooth teeth and a matching pawl.
+
+The wheel turns freely clockwise, the pawl slides over the sloping backs of
+the teeth. Counterclockwise rotation is stopped by the pawl tip bearing on the
+locking face of a tooth. The engagement angle tilts the locking face so it
+undercuts the tooth tip, a positive angle pulls the pawl into the tooth under
+load.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// RatchetParms defines the parameters for a ratchet wheel and pawl.
+type RatchetParms struct {
+	NumberTeeth     int     // number of ratchet teeth
+	OuterRadius     float64 // radius at the tooth tips
+	ToothDepth      float64 // radial depth of the teeth
+	EngagementAngle float64 // undercut of the locking face from the radial line (radians)
+	BoreRadius      float64 // radius of the wheel shaft bore (0 = none)
+	PawlLength      float64 // distance from the pawl pivot to the pawl tip
+	PawlWidth       float64 // width of the pawl arm
+	PawlBoreRadius  float64 // radius of the pawl pivot bore (0 = none)
+	Clearance       float64 // clearance between the pawl and the wheel
+	Thickness       float64 // thickness of the wheel and pawl (3D)
+}
+
+func (k *RatchetParms) validate() error {
+	if k.NumberTeeth < 3 {
+		return errors.New("NumberTeeth < 3")
+	}
+	if k.OuterRadius <= 0 {
+		return errors.New("OuterRadius <= 0")
+	}
+	if k.ToothDepth <= 0 || k.ToothDepth >= k.OuterRadius {
+		return errors.New("ToothDepth out of range")
+	}
+	rootRadius := k.OuterRadius - k.ToothDepth
+	if k.EngagementAngle < 0 || math.Sin(k.EngagementAngle) >= rootRadius/k.OuterRadius {
+		return errors.New("EngagementAngle out of range")
+	}
+	if k.BoreRadius < 0 || k.BoreRadius >= rootRadius {
+		return errors.New("BoreRadius out of range")
+	}
+	if k.PawlLength <= 0 {
+		return errors.New("PawlLength <= 0")
+	}
+	if k.PawlWidth <= 0 {
+		return errors.New("PawlWidth <= 0")
+	}
+	if k.PawlBoreRadius < 0 || k.PawlBoreRadius >= 0.5*k.PawlWidth {
+		return errors.New("PawlBoreRadius out of range")
+	}
+	if k.Clearance < 0 {
+		return errors.New("Clearance < 0")
+	}
+	return nil
+}
+
+// ratchetRoot returns the root point at the bottom of the locking face for the tooth tip on the +x axis.
+func (k *RatchetParms) ratchetRoot() V2 {
+	r := k.OuterRadius
+	rr := r - k.ToothDepth
+	c := math.Cos(k.EngagementAngle)
+	s := math.Sin(k.EngagementAngle)
+	// distance along the locking face from the tip to the root circle
+	l := r*c - math.Sqrt(r*r*c*c-r*r+rr*rr)
+	// the face runs inwards, tilted clockwise to undercut the tip
+	return V2{r - l*c, -l * s}
+}
+
+// ratchetContact returns the point on the locking face (tooth tip on the +x axis) where the pawl bears.
+func (k *RatchetParms) ratchetContact() V2 {
+	return V2{k.OuterRadius, 0}.Add(k.ratchetRoot()).MulScalar(0.5)
+}
+
+// PawlPivot returns the position of the pawl pivot relative to the wheel center.
+// The pawl lies on the tangent to the contact point on the locking face of the
+// tooth with its tip on the +x axis.
+func (k *RatchetParms) PawlPivot() V2 {
+	c := k.ratchetContact()
+	t := V2{-c.Y, c.X}.Normalize()
+	return c.Add(t.MulScalar(k.PawlLength))
+}
+
+// Ratchet2D returns the 2D profile of a ratchet wheel centered on the origin.
+// A tooth tip is on the +x axis.
+func Ratchet2D(k *RatchetParms) (SDF2, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	root := k.ratchetRoot()
+	tip := V2{k.OuterRadius, 0}
+	n := k.NumberTeeth
+	v := make([]V2, 2*n)
+	for i := 0; i < n; i++ {
+		m := Rotate(Tau * float64(i) / float64(n))
+		v[2*i] = m.MulPosition(tip)
+		v[2*i+1] = m.MulPosition(root)
+	}
+	s := Polygon2D(v)
+	if k.BoreRadius > 0 {
+		s = Difference2D(s, Circle2D(k.BoreRadius))
+	}
+	return s, nil
+}
+
+// Pawl2D returns the 2D profile of a pawl engaged with the ratchet wheel.
+// The pawl is in the wheel coordinate frame, pivoting about PawlPivot().
+func Pawl2D(k *RatchetParms) (SDF2, error) {
+	wheel, err := Ratchet2D(k)
+	if err != nil {
+		return nil, err
+	}
+	c := k.ratchetContact()
+	pivot := k.PawlPivot()
+	// arm from the contact point to the pivot
+	v := pivot.Sub(c)
+	arm := Line2D(k.PawlLength, 0.5*k.PawlWidth)
+	m := Translate2d(c.Add(pivot).MulScalar(0.5)).Mul(Rotate2d(math.Atan2(v.Y, v.X)))
+	s := Transform2D(arm, m)
+	// trim the tip to the shape of the teeth
+	s = Difference2D(s, Offset2D(wheel, k.Clearance))
+	if k.PawlBoreRadius > 0 {
+		bore := Transform2D(Circle2D(k.PawlBoreRadius), Translate2d(pivot))
+		s = Difference2D(s, bore)
+	}
+	return s, nil
+}
+
+// Ratchet3D returns an extruded ratchet wheel and pawl.
+func Ratchet3D(k *RatchetParms) (SDF3, SDF3, error) {
+	if k.Thickness <= 0 {
+		return nil, nil, errors.New("Thickness <= 0")
+	}
+	wheel, err := Ratchet2D(k)
+	if err != nil {
+		return nil, nil, err
+	}
+	pawl, err := Pawl2D(k)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Extrude3D(wheel, k.Thickness), Extrude3D(pawl, k.Thickness), nil
+}
+
+//-----------------------------------------------------------------------------
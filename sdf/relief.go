@@ -0,0 +1,275 @@
+//-----------------------------------------------------------------------------
+/*
+
+2.5D Relief Carving
+
+Generate raster toolpaths for carving a relief with a CNC router.
+The SDF3 is sampled as a heightfield: the height at (x, y) is the top of the
+solid found by marching down from the top of the bounding box.
+
+The heightfield is compensated for the tool shape (flat or ball end), so the
+tool tip positions never gouge the surface.
+
+Roughing: raster passes at decreasing levels, leaving some stock on the surface.
+Finishing: raster passes that follow the compensated surface.
+
+The work is cut in the model coordinates, with the top of the stock at the top
+of the bounding box.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// ReliefParms defines the parameters for relief carving toolpaths.
+type ReliefParms struct {
+	ToolRadius   float64 // radius of the cutter
+	BallEnd      bool    // ball end (vs flat end) cutter
+	Stepover     float64 // distance between raster lines
+	Resolution   float64 // sample spacing along the raster lines (0 = ToolRadius/5)
+	StepDown     float64 // maximum depth of cut for roughing passes
+	StockToLeave float64 // material left above the surface by roughing (vertical)
+	SafeZ        float64 // retract height above the stock
+	Feed         float64 // cutting feed rate
+	PlungeFeed   float64 // plunge feed rate
+	Spindle      float64 // spindle speed (0 = no spindle commands)
+}
+
+func (k *ReliefParms) validate() error {
+	if k.ToolRadius <= 0 {
+		return errors.New("ToolRadius <= 0")
+	}
+	if k.Stepover <= 0 {
+		return errors.New("Stepover <= 0")
+	}
+	if k.Resolution < 0 {
+		return errors.New("Resolution < 0")
+	}
+	if k.StepDown <= 0 {
+		return errors.New("StepDown <= 0")
+	}
+	if k.StockToLeave < 0 {
+		return errors.New("StockToLeave < 0")
+	}
+	if k.SafeZ <= 0 {
+		return errors.New("SafeZ <= 0")
+	}
+	if k.Feed <= 0 || k.PlungeFeed <= 0 {
+		return errors.New("Feed/PlungeFeed <= 0")
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+
+// reliefMap is a tool compensated heightfield.
+type reliefMap struct {
+	bb     Box3        // bounding box of the SDF3
+	origin V2          // position of sample 0, 0
+	step   float64     // sample spacing
+	h      [][]float64 // tool tip height [x][y]
+}
+
+// surfaceHeight returns the height of the top of an SDF3 at (x, y).
+// The bottom of the bounding box is returned if there is no solid.
+func surfaceHeight(s SDF3, bb Box3, x, y, eps float64) float64 {
+	z := bb.Max.Z
+	for z > bb.Min.Z {
+		d := s.Evaluate(V3{x, y, z})
+		if d <= eps {
+			return z
+		}
+		z -= d
+	}
+	return bb.Min.Z
+}
+
+// newReliefMap samples the heightfield and compensates it for the tool.
+func newReliefMap(s SDF3, k *ReliefParms) *reliefMap {
+	bb := s.BoundingBox()
+	step := k.Resolution
+	if step == 0 {
+		step = k.ToolRadius / 5
+	}
+	size := bb.Size()
+	nx := int(math.Ceil(size.X/step)) + 1
+	ny := int(math.Ceil(size.Y/step)) + 1
+	eps := 1e-3 * step
+	surface := make([][]float64, nx)
+	for i := range surface {
+		surface[i] = make([]float64, ny)
+		for j := range surface[i] {
+			surface[i][j] = surfaceHeight(s, bb, bb.Min.X+float64(i)*step, bb.Min.Y+float64(j)*step, eps)
+		}
+	}
+
+	// the tool profile: how far the tip is below the cutting edge at radius d
+	r := k.ToolRadius
+	n := int(math.Ceil(r / step))
+	type offset struct {
+		i, j int
+		drop float64
+	}
+	var kernel []offset
+	for i := -n; i <= n; i++ {
+		for j := -n; j <= n; j++ {
+			d := math.Hypot(float64(i), float64(j)) * step
+			if d > r {
+				continue
+			}
+			drop := 0.0
+			if k.BallEnd {
+				drop = r - math.Sqrt(r*r-d*d)
+			}
+			kernel = append(kernel, offset{i, j, drop})
+		}
+	}
+
+	// the tool tip rests on the highest point under the tool
+	h := make([][]float64, nx)
+	for i := range h {
+		h[i] = make([]float64, ny)
+		for j := range h[i] {
+			z := bb.Min.Z
+			for _, o := range kernel {
+				x, y := i+o.i, j+o.j
+				if x < 0 || y < 0 || x >= nx || y >= ny {
+					continue
+				}
+				z = math.Max(z, surface[x][y]-o.drop)
+			}
+			h[i][j] = z
+		}
+	}
+	return &reliefMap{bb, V2{bb.Min.X, bb.Min.Y}, step, h}
+}
+
+// rasterRows returns the heightfield rows for the raster lines.
+func (m *reliefMap) rasterRows(stepover float64) []int {
+	ny := len(m.h[0])
+	n := int(math.Max(1, math.Round(stepover/m.step)))
+	var rows []int
+	for j := 0; j < ny; j += n {
+		rows = append(rows, j)
+	}
+	if rows[len(rows)-1] != ny-1 {
+		rows = append(rows, ny-1)
+	}
+	return rows
+}
+
+// position returns the tool tip position for a heightfield sample.
+func (m *reliefMap) position(i, j int, z float64) V3 {
+	return V3{m.origin.X + float64(i)*m.step, m.origin.Y + float64(j)*m.step, z}
+}
+
+//-----------------------------------------------------------------------------
+
+// roughing returns the roughing toolpaths.
+func (m *reliefMap) roughing(k *ReliefParms) [][]V3 {
+	nx := len(m.h)
+	rows := m.rasterRows(k.Stepover)
+	depth := m.bb.Max.Z - m.bb.Min.Z
+	levels := int(math.Ceil(depth/k.StepDown - tolerance))
+	var paths [][]V3
+	for l := 1; l <= levels; l++ {
+		z := m.bb.Max.Z - depth*float64(l)/float64(levels)
+		// the previous level
+		top := z + depth/float64(levels)
+		for n, j := range rows {
+			// runs of samples with material to remove at this level
+			var path []V3
+			for x := 0; x < nx; x++ {
+				i := x
+				if n&1 != 0 {
+					// zig-zag
+					i = nx - 1 - x
+				}
+				floor := m.h[i][j] + k.StockToLeave
+				if floor < top-tolerance {
+					path = append(path, m.position(i, j, math.Max(z, floor)))
+					continue
+				}
+				if len(path) != 0 {
+					paths = append(paths, path)
+					path = nil
+				}
+			}
+			if len(path) != 0 {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// finishing returns the finishing toolpaths.
+func (m *reliefMap) finishing(k *ReliefParms) [][]V3 {
+	nx := len(m.h)
+	var paths [][]V3
+	for n, j := range m.rasterRows(k.Stepover) {
+		path := make([]V3, nx)
+		for x := 0; x < nx; x++ {
+			i := x
+			if n&1 != 0 {
+				// zig-zag
+				i = nx - 1 - x
+			}
+			path[x] = m.position(i, j, m.h[i][j])
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+//-----------------------------------------------------------------------------
+
+// ReliefRoughing returns the roughing toolpaths for a relief.
+// Each path is cut with a plunge at the start and a retract at the end.
+func ReliefRoughing(s SDF3, k *ReliefParms) ([][]V3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	return newReliefMap(s, k).roughing(k), nil
+}
+
+// ReliefFinishing returns the finishing toolpaths for a relief.
+// Each path is cut with a plunge at the start and a retract at the end.
+func ReliefFinishing(s SDF3, k *ReliefParms) ([][]V3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	return newReliefMap(s, k).finishing(k), nil
+}
+
+// ReliefGCode writes the G-code for the roughing and finishing passes of a relief.
+func ReliefGCode(s SDF3, k *ReliefParms, w io.Writer) error {
+	if err := k.validate(); err != nil {
+		return err
+	}
+	m := newReliefMap(s, k)
+	rough := m.roughing(k)
+	finish := m.finishing(k)
+	safeZ := s.BoundingBox().Max.Z + k.SafeZ
+	g := newGcodeWriter(w)
+	g.start(safeZ, k.Spindle)
+	for _, p := range append(rough, finish...) {
+		g.rapidXY(V2{p[0].X, p[0].Y})
+		g.feedZ(p[0].Z, k.PlungeFeed)
+		for _, v := range p[1:] {
+			g.feedXYZ(v, k.Feed)
+		}
+		g.rapidZ(safeZ)
+	}
+	return g.end(safeZ, k.Spindle)
+}
+
+//-----------------------------------------------------------------------------
@@ -14,17 +14,49 @@ package sdf
 
 import (
 	"fmt"
+	"math"
 	"sync"
 )
 
 //-----------------------------------------------------------------------------
 
+// DeterministicMesh puts rendered meshes into a canonical triangle order (see SortMesh).
+// Identical inputs then give byte-identical exports (E.g. for golden file tests).
+// The whole mesh is held in memory before it is written.
+var DeterministicMesh = false
+
+//-----------------------------------------------------------------------------
+
+// autoFeatureCells is the number of cells across the smallest feature for
+// an automatically chosen mesh resolution.
+const autoFeatureCells = 4
+
+// autoMinCells and autoMaxCells limit an automatically chosen mesh resolution.
+const (
+	autoMinCells = 50
+	autoMaxCells = 500
+)
+
+// AutoMeshCells returns a mesh resolution (cells on the longest axis) for an
+// SDF3 chosen from an estimate of its smallest feature (see EstimateMinFeatureSize).
+// The exporters use this when they are given meshCells <= 0.
+func AutoMeshCells(s SDF3) int {
+	size := s.BoundingBox().Size().MaxComponent()
+	cells := math.Ceil(autoFeatureCells * size / EstimateMinFeatureSize(s))
+	return int(Clamp(cells, autoMinCells, autoMaxCells))
+}
+
+//-----------------------------------------------------------------------------
+
 // RenderSTL renders an SDF3 as an STL file (uses octree sampling).
 func RenderSTL(
 	s SDF3, //sdf3 to render
-	meshCells int, //number of cells on the longest axis. e.g 200
+	meshCells int, //number of cells on the longest axis. e.g 200 (<= 0 for AutoMeshCells)
 	path string, //path to filename
 ) {
+	if meshCells <= 0 {
+		meshCells = AutoMeshCells(s)
+	}
 
 	// work out the sampling resolution to use
 	bbSize := s.BoundingBox().Size()
@@ -33,6 +65,13 @@ func RenderSTL(
 
 	fmt.Printf("rendering %s (%dx%dx%d, resolution %.2f)\n", path, cells[0], cells[1], cells[2], resolution)
 
+	if DeterministicMesh {
+		if err := SaveSTL(path, RenderMesh(s, meshCells)); err != nil {
+			fmt.Printf("%s", err)
+		}
+		return
+	}
+
 	// write the triangles to an STL file
 	var wg sync.WaitGroup
 	output, err := WriteSTL(&wg, path)
@@ -50,10 +89,38 @@ func RenderSTL(
 	wg.Wait()
 }
 
+// RenderMesh renders an SDF3 as a triangle mesh (uses octree sampling).
+func RenderMesh(
+	s SDF3, //sdf3 to render
+	meshCells int, //number of cells on the longest axis. e.g 200 (<= 0 for AutoMeshCells)
+) []*Triangle3 {
+	if meshCells <= 0 {
+		meshCells = AutoMeshCells(s)
+	}
+	resolution := s.BoundingBox().Size().MaxComponent() / float64(meshCells)
+	// collect the triangles from the output channel
+	output := make(chan *Triangle3)
+	done := make(chan []*Triangle3)
+	go func() {
+		var mesh []*Triangle3
+		for t := range output {
+			mesh = append(mesh, t)
+		}
+		done <- mesh
+	}()
+	marchingCubesOctree(s, resolution, output)
+	close(output)
+	mesh := <-done
+	if DeterministicMesh {
+		SortMesh(mesh)
+	}
+	return mesh
+}
+
 // RenderSTLSlow renders an SDF3 as an STL file (uses uniform grid sampling).
 func RenderSTLSlow(
 	s SDF3, //sdf3 to render
-	meshCells int, //number of cells on the longest axis. e.g 200
+	meshCells int, //number of cells on the longest axis. e.g 200 (<= 0 for AutoMeshCells)
 	path string, //path to filename
 ) {
 	m := RenderSlow(s, meshCells)
@@ -66,8 +133,11 @@ func RenderSTLSlow(
 // RenderSlow renders an SDF3 as an mesh (uses uniform grid sampling).
 func RenderSlow(
 	s SDF3, //sdf3 to render
-	meshCells int, //number of cells on the longest axis. e.g 200
+	meshCells int, //number of cells on the longest axis. e.g 200 (<= 0 for AutoMeshCells)
 ) []*Triangle3 {
+	if meshCells <= 0 {
+		meshCells = AutoMeshCells(s)
+	}
 	// work out the region we will sample
 	bb0 := s.BoundingBox()
 	bb0Size := bb0.Size()
@@ -78,7 +148,11 @@ func RenderSlow(
 	bb := NewBox3(bb0.Center(), bb1Size)
 
 	// run marching cubes to generate the triangle mesh
-	return marchingCubes(s, bb, meshInc)
+	mesh := marchingCubes(s, bb, meshInc)
+	if DeterministicMesh {
+		SortMesh(mesh)
+	}
+	return mesh
 }
 
 //-----------------------------------------------------------------------------
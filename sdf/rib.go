@@ -0,0 +1,97 @@
+//-----------------------------------------------------------------------------
+/*
+
+Ribs and Gussets
+
+Reinforcing ribs and gussets positioned from anchor points, so they don't
+need to be placed by hand.
+
+A rib is a flat plate spanning two edges, e.g. an edge on each of two faces
+to be joined. A gusset is a triangular plate in the corner between a wall
+and a floor.
+
+The plates are centered on the plane of the anchor points, union them with
+the part.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// Plate3D returns a flat plate of the given thickness with its outline
+// through the points. The points must be coplanar and in order around the
+// outline. The plate is centered on the plane of the points.
+func Plate3D(points []V3, thickness float64) (SDF3, error) {
+	if len(points) < 3 {
+		return nil, errors.New("len(points) < 3")
+	}
+	if thickness <= 0 {
+		return nil, errors.New("thickness <= 0")
+	}
+	// find the plane of the points
+	o := points[0]
+	var n V3
+	size := 0.0
+	for i := 1; i < len(points); i++ {
+		size = math.Max(size, points[i].Sub(o).Length())
+	}
+	for i := 1; i+1 < len(points); i++ {
+		// the area vector (this works for concave outlines)
+		n = n.Add(points[i].Sub(o).Cross(points[i+1].Sub(o)))
+	}
+	if n.Length() <= 1e-6*size*size {
+		return nil, errors.New("the points are collinear")
+	}
+	n = n.Normalize()
+	for _, p := range points {
+		if Abs(p.Sub(o).Dot(n)) > 1e-6*size {
+			return nil, errors.New("the points are not coplanar")
+		}
+	}
+	// the local frame of the plane
+	u := points[1].Sub(o).Normalize()
+	v := n.Cross(u)
+	outline := make([]V2, len(points))
+	for i, p := range points {
+		d := p.Sub(o)
+		outline[i] = V2{d.Dot(u), d.Dot(v)}
+	}
+	plate := Extrude3D(Polygon2D(outline), thickness)
+	m := M44{
+		u.X, v.X, n.X, o.X,
+		u.Y, v.Y, n.Y, o.Y,
+		u.Z, v.Z, n.Z, o.Z,
+		0, 0, 0, 1,
+	}
+	return Transform3D(plate, m), nil
+}
+
+// Rib3D returns a rib of the given thickness spanning two anchor edges
+// (a0 to a1 and b0 to b1). The edges must be coplanar, with a0 and b0 at the
+// same end of the rib.
+func Rib3D(a0, a1, b0, b1 V3, thickness float64) (SDF3, error) {
+	return Plate3D([]V3{a0, a1, b1, b0}, thickness)
+}
+
+// Gusset3D returns a triangular gusset in the corner between a floor and a
+// wall. The legs run from the corner along the floor and wall directions.
+func Gusset3D(corner, floor, wall V3, floorLength, wallLength, thickness float64) (SDF3, error) {
+	if floorLength <= 0 || wallLength <= 0 {
+		return nil, errors.New("leg length <= 0")
+	}
+	if floor.Length() == 0 || wall.Length() == 0 {
+		return nil, errors.New("bad leg direction")
+	}
+	f := corner.Add(floor.Normalize().MulScalar(floorLength))
+	w := corner.Add(wall.Normalize().MulScalar(wallLength))
+	return Plate3D([]V3{corner, f, w}, thickness)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,221 @@
+//-----------------------------------------------------------------------------
+/*
+
+Single Board Computer Cases
+
+Parametric cases for common single board computers and microcontroller
+boards. Each board has its outline, mounting hole table and connector
+positions built in. The case generator returns a base with standoffs and
+port cutouts, and a lid with a locating lip.
+
+Board coordinates have the origin at the lower left corner of the board with
+the component side facing +z. Ports are positioned along a board edge.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+// BoardEdge is an edge of a board.
+type BoardEdge int
+
+// Board edges.
+const (
+	EdgeBottom BoardEdge = iota // y = 0
+	EdgeRight                   // x = size.X
+	EdgeTop                     // y = size.Y
+	EdgeLeft                    // x = 0
+)
+
+// BoardPort is a connector that needs a cutout in the case wall.
+type BoardPort struct {
+	Name     string
+	Edge     BoardEdge
+	Position float64 // position of the connector center along the edge (x or y)
+	Z        float64 // height of the connector center above the board
+	Size     V2      // width and height of the connector opening
+}
+
+// Board defines a circuit board.
+type Board struct {
+	Name         string
+	Size         V2      // board outline
+	CornerRadius float64 // board corner radius
+	Thickness    float64 // board thickness
+	Height       float64 // height of the tallest component above the board
+	HoleDiameter float64 // mounting hole diameter
+	Holes        []V2    // mounting hole positions
+	Ports        []BoardPort
+}
+
+// RaspberryPi4 is a Raspberry Pi 4 Model B.
+var RaspberryPi4 = Board{
+	Name:         "Raspberry Pi 4B",
+	Size:         V2{85, 56},
+	CornerRadius: 3,
+	Thickness:    1.4,
+	Height:       16,
+	HoleDiameter: 2.7,
+	Holes:        []V2{{3.5, 3.5}, {61.5, 3.5}, {3.5, 52.5}, {61.5, 52.5}},
+	Ports: []BoardPort{
+		{"USB-C", EdgeBottom, 11.2, 1.6, V2{9, 3.2}},
+		{"micro HDMI 0", EdgeBottom, 26, 1.5, V2{7, 3}},
+		{"micro HDMI 1", EdgeBottom, 39.5, 1.5, V2{7, 3}},
+		{"audio", EdgeBottom, 53.5, 3, V2{6, 6}},
+		{"USB 1", EdgeRight, 9, 8, V2{13.1, 16}},
+		{"USB 2", EdgeRight, 27, 8, V2{13.1, 16}},
+		{"ethernet", EdgeRight, 45.75, 6.75, V2{15.9, 13.5}},
+	},
+}
+
+// RaspberryPiZero is a Raspberry Pi Zero (W).
+var RaspberryPiZero = Board{
+	Name:         "Raspberry Pi Zero",
+	Size:         V2{65, 30},
+	CornerRadius: 3,
+	Thickness:    1.4,
+	Height:       5,
+	HoleDiameter: 2.75,
+	Holes:        []V2{{3.5, 3.5}, {61.5, 3.5}, {3.5, 26.5}, {61.5, 26.5}},
+	Ports: []BoardPort{
+		{"mini HDMI", EdgeBottom, 12.4, 1.6, V2{11.2, 3.2}},
+		{"micro USB", EdgeBottom, 41.4, 1.3, V2{8, 2.6}},
+		{"micro USB power", EdgeBottom, 54, 1.3, V2{8, 2.6}},
+		{"micro SD", EdgeLeft, 16.9, -1, V2{12, 2}},
+	},
+}
+
+// ArduinoUno is an Arduino Uno R3.
+var ArduinoUno = Board{
+	Name:         "Arduino Uno",
+	Size:         V2{68.6, 53.3},
+	Thickness:    1.6,
+	Height:       13,
+	HoleDiameter: 3.2,
+	Holes:        []V2{{14.0, 2.5}, {15.3, 50.7}, {66.1, 7.6}, {66.1, 35.5}},
+	Ports: []BoardPort{
+		{"USB-B", EdgeLeft, 38.1, 5.5, V2{12.5, 11}},
+		{"power", EdgeLeft, 7.6, 5.5, V2{9.5, 11}},
+	},
+}
+
+//-----------------------------------------------------------------------------
+
+// CaseParms defines the parameters for a board case.
+type CaseParms struct {
+	Board     *Board
+	Wall      float64 // wall thickness
+	Floor     float64 // floor (and lid) thickness
+	Clearance float64 // gap between the board and the walls, and around the ports
+	Standoff  float64 // height of the board above the floor
+	LipHeight float64 // height of the lid lip
+	Fit       float64 // gap between the lid lip and the walls
+}
+
+func (k *CaseParms) validate() error {
+	if k.Board == nil {
+		return errors.New("Board == nil")
+	}
+	if k.Wall <= 0 {
+		return errors.New("Wall <= 0")
+	}
+	if k.Floor <= 0 {
+		return errors.New("Floor <= 0")
+	}
+	if k.Clearance < 0 {
+		return errors.New("Clearance < 0")
+	}
+	if k.Standoff <= 0 {
+		return errors.New("Standoff <= 0")
+	}
+	if k.Fit < 0 {
+		return errors.New("Fit < 0")
+	}
+	return nil
+}
+
+// caseCutout returns the wall cutout for a port in board coordinates.
+// z0 is the height of the top of the board.
+func caseCutout(b *Board, p *BoardPort, z0, depth, clearance float64) SDF3 {
+	size := p.Size.AddScalar(2 * clearance)
+	var s SDF3
+	var pos V3
+	z := z0 + p.Z
+	switch p.Edge {
+	case EdgeBottom:
+		s = Box3D(V3{size.X, depth, size.Y}, 0)
+		pos = V3{p.Position, 0, z}
+	case EdgeTop:
+		s = Box3D(V3{size.X, depth, size.Y}, 0)
+		pos = V3{p.Position, b.Size.Y, z}
+	case EdgeLeft:
+		s = Box3D(V3{depth, size.X, size.Y}, 0)
+		pos = V3{0, p.Position, z}
+	default:
+		s = Box3D(V3{depth, size.X, size.Y}, 0)
+		pos = V3{b.Size.X, p.Position, z}
+	}
+	return Transform3D(s, Translate3d(pos))
+}
+
+// BoardCase3D returns the base and lid of a case for a board.
+// The base sits on z = 0 and the lid is returned in its assembled position
+// (flip it for printing). Both are in board coordinates.
+func BoardCase3D(k *CaseParms) (base, lid SDF3, err error) {
+	if err := k.validate(); err != nil {
+		return nil, nil, err
+	}
+	b := k.Board
+	center := b.Size.MulScalar(0.5)
+	inner := b.Size.AddScalar(2 * k.Clearance)
+	outer := inner.AddScalar(2 * k.Wall)
+	round := b.CornerRadius + k.Clearance
+	z0 := k.Floor + k.Standoff + b.Thickness // top of the board
+	height := z0 + b.Height                  // height of the base
+
+	// base: walls and floor
+	shell := Extrude3D(Box2D(outer, round+k.Wall), height)
+	shell = Transform3D(shell, Translate3d(V3{center.X, center.Y, 0.5 * height}))
+	cavity := Extrude3D(Box2D(inner, round), height)
+	cavity = Transform3D(cavity, Translate3d(V3{center.X, center.Y, k.Floor + 0.5*height}))
+	base = Difference3D(shell, cavity)
+
+	// standoffs with pilot holes for self tapping screws
+	if len(b.Holes) != 0 {
+		sk := &StandoffParms{
+			PillarHeight:   k.Standoff,
+			PillarDiameter: 2.2 * b.HoleDiameter,
+			HoleDepth:      k.Standoff,
+			HoleDiameter:   0.85 * b.HoleDiameter,
+		}
+		var positions V3Set
+		for _, h := range b.Holes {
+			positions = append(positions, V3{h.X, h.Y, k.Floor + 0.5*k.Standoff})
+		}
+		base = Union3D(base, Standoffs3D(sk, positions))
+	}
+
+	// port cutouts
+	depth := 2 * (k.Wall + k.Clearance)
+	for i := range b.Ports {
+		base = Difference3D(base, caseCutout(b, &b.Ports[i], z0, depth, k.Clearance))
+	}
+
+	// lid: a plate with a lip that fits inside the walls
+	plate := Extrude3D(Box2D(outer, round+k.Wall), k.Floor)
+	lid = Transform3D(plate, Translate3d(V3{center.X, center.Y, height + 0.5*k.Floor}))
+	if k.LipHeight > 0 {
+		lip := inner.SubScalar(2 * k.Fit)
+		r := Max(round-k.Fit, 0)
+		ring := Difference2D(Box2D(lip, r), Box2D(lip.SubScalar(2*k.Wall), Max(r-k.Wall, 0)))
+		s := Transform3D(Extrude3D(ring, k.LipHeight), Translate3d(V3{center.X, center.Y, height - 0.5*k.LipHeight}))
+		lid = Union3D(lid, s)
+	}
+	return base, lid, nil
+}
+
+//-----------------------------------------------------------------------------
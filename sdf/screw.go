@@ -11,6 +11,10 @@ the x-axis as the screw axis. Most thread profiles are symmetric about the y-axi
 but a few aren't (E.g. buttress threads) so in general we build the profile of
 an entire pitch period.
 
+Threaded rods add finished ends to a screw: lead-in chamfers, higbee (blunt
+start) cuts that remove the feather edge of a partial thread, and runouts
+where the thread fades out into the major diameter.
+
 This code doesn't deal with thread tolerancing. If you want threads to fit properly
 the radius of the thread will need to be tweaked (+/-) to give internal/external thread
 clearance.
@@ -21,6 +25,7 @@ clearance.
 package sdf
 
 import (
+	"errors"
 	"fmt"
 	"math"
 )
@@ -35,6 +40,7 @@ type ThreadParameters struct {
 	Pitch        float64 // thread to thread distance of screw
 	HexFlat2Flat float64 // hex head flat to flat distance
 	Units        string  // "inch" or "mm"
+	Form         string  // thread form "iso", "trapezoidal" or "acme"
 }
 
 type threadDatabase map[string]*ThreadParameters
@@ -54,6 +60,7 @@ func (m threadDatabase) UTSAdd(
 	t.Pitch = 1.0 / tpi
 	t.HexFlat2Flat = ftof
 	t.Units = "inch"
+	t.Form = "iso"
 	m[name] = &t
 }
 
@@ -70,6 +77,39 @@ func (m threadDatabase) ISOAdd(
 	t.Pitch = pitch
 	t.HexFlat2Flat = ftof
 	t.Units = "mm"
+	t.Form = "iso"
+	m[name] = &t
+}
+
+// TrAdd adds an ISO metric trapezoidal thread to the thread database.
+func (m threadDatabase) TrAdd(
+	name string, // thread name
+	diameter float64, // screw major diameter
+	pitch float64, // thread pitch
+) {
+	t := ThreadParameters{}
+	t.Name = name
+	t.Radius = diameter / 2.0
+	t.Pitch = pitch
+	t.HexFlat2Flat = -1
+	t.Units = "mm"
+	t.Form = "trapezoidal"
+	m[name] = &t
+}
+
+// AcmeAdd adds a general purpose acme thread to the thread database.
+func (m threadDatabase) AcmeAdd(
+	name string, // thread name
+	diameter float64, // screw major diameter
+	tpi float64, // threads per inch
+) {
+	t := ThreadParameters{}
+	t.Name = name
+	t.Radius = diameter / 2.0
+	t.Pitch = 1.0 / tpi
+	t.HexFlat2Flat = -1
+	t.Units = "inch"
+	t.Form = "acme"
 	m[name] = &t
 }
 
@@ -142,6 +182,30 @@ func initThreadLookup() threadDatabase {
 	m.ISOAdd("M48x3", 48, 3, 75)
 	m.ISOAdd("M56x4", 56, 4, 85)
 	m.ISOAdd("M64x4", 64, 4, 95)
+	// ISO Trapezoidal
+	m.TrAdd("Tr8x1.5", 8, 1.5)
+	m.TrAdd("Tr8x2", 8, 2)
+	m.TrAdd("Tr10x2", 10, 2)
+	m.TrAdd("Tr10x3", 10, 3)
+	m.TrAdd("Tr12x3", 12, 3)
+	m.TrAdd("Tr14x3", 14, 3)
+	m.TrAdd("Tr16x4", 16, 4)
+	m.TrAdd("Tr20x4", 20, 4)
+	m.TrAdd("Tr24x5", 24, 5)
+	m.TrAdd("Tr28x5", 28, 5)
+	m.TrAdd("Tr32x6", 32, 6)
+	m.TrAdd("Tr36x6", 36, 6)
+	m.TrAdd("Tr40x7", 40, 7)
+	// Acme General Purpose
+	m.AcmeAdd("acme_1/4", 1.0/4.0, 16)
+	m.AcmeAdd("acme_5/16", 5.0/16.0, 14)
+	m.AcmeAdd("acme_3/8", 3.0/8.0, 12)
+	m.AcmeAdd("acme_7/16", 7.0/16.0, 12)
+	m.AcmeAdd("acme_1/2", 1.0/2.0, 10)
+	m.AcmeAdd("acme_5/8", 5.0/8.0, 8)
+	m.AcmeAdd("acme_3/4", 3.0/4.0, 6)
+	m.AcmeAdd("acme_7/8", 7.0/8.0, 6)
+	m.AcmeAdd("acme_1", 1.0, 5)
 	return m
 }
 
@@ -166,6 +230,26 @@ func (t *ThreadParameters) HexHeight() float64 {
 	return 2.0 * t.HexRadius() * (5.0 / 12.0)
 }
 
+// Profile returns the 2d thread profile for the thread form.
+// The radius is the thread radius with any clearance.
+func (t *ThreadParameters) Profile(
+	radius float64, // radius of thread
+	mode string, // internal/external thread
+) (SDF2, error) {
+	if mode != "internal" && mode != "external" {
+		return nil, fmt.Errorf("bad mode \"%s\"", mode)
+	}
+	switch t.Form {
+	case "iso":
+		return ISOThread(radius, t.Pitch, mode), nil
+	case "trapezoidal":
+		return TrapezoidalThread(radius, t.Pitch, mode), nil
+	case "acme":
+		return AcmeThread(radius, t.Pitch), nil
+	}
+	return nil, fmt.Errorf("unknown thread form \"%s\"", t.Form)
+}
+
 //-----------------------------------------------------------------------------
 // Thread Profiles
 
@@ -239,6 +323,60 @@ func ISOThread(
 	return Polygon2D(iso.Vertices())
 }
 
+// trapezoidalClearance returns the crest clearance (ac) for an ISO metric trapezoidal thread.
+func trapezoidalClearance(pitch float64) float64 {
+	switch {
+	case pitch <= 1.5:
+		return 0.15
+	case pitch <= 5:
+		return 0.25
+	case pitch <= 12:
+		return 0.5
+	}
+	return 1
+}
+
+// TrapezoidalThread returns the 2d profile for an ISO metric trapezoidal thread (E.g. Tr8x2).
+// The internal thread has the standard crest clearances.
+// https://en.wikipedia.org/wiki/Trapezoidal_thread_form
+// ISO 2904
+func TrapezoidalThread(
+	radius float64, // radius of thread
+	pitch float64, // thread to thread distance
+	mode string, // internal/external thread
+) SDF2 {
+	t := math.Tan(DtoR(15.0))
+	ac := trapezoidalClearance(pitch)
+	h1 := 0.5 * pitch // basic thread depth
+	// the thread width is pitch/2 at the pitch radius
+	rp := radius - 0.5*h1
+	var rMajor, rMinor float64
+	if mode == "external" {
+		rMajor = radius
+		rMinor = radius - h1 - ac
+	} else if mode == "internal" {
+		rMajor = radius + ac
+		rMinor = radius - h1
+	} else {
+		panic("bad mode")
+	}
+	xMajor := 0.25*pitch - (rMajor-rp)*t
+	xMinor := 0.25*pitch + (rp-rMinor)*t
+
+	tp := NewPolygon()
+	tp.Add(pitch, 0)
+	tp.Add(pitch, rMinor)
+	tp.Add(xMinor, rMinor)
+	tp.Add(xMajor, rMajor)
+	tp.Add(-xMajor, rMajor)
+	tp.Add(-xMinor, rMinor)
+	tp.Add(-pitch, rMinor)
+	tp.Add(-pitch, 0)
+
+	//tp.Render("trapezoidal.dxf")
+	return Polygon2D(tp.Vertices())
+}
+
 // ANSIButtressThread returns the 2d profile for an ANSI 45/7 buttress thread.
 // https://en.wikipedia.org/wiki/Buttress_thread
 // AMSE B1.9-1973
@@ -294,6 +432,35 @@ func PlasticButtressThread(
 	return Polygon2D(tp.Vertices())
 }
 
+// MetricButtressThread returns the 2d profile for a metric 3/30 buttress thread.
+// The 3 degree load flank faces +x.
+// DIN 513
+func MetricButtressThread(
+	radius float64, // radius of thread
+	pitch float64, // thread to thread distance
+) SDF2 {
+	t3 := math.Tan(DtoR(3.0))
+	t30 := math.Tan(DtoR(30.0))
+	a := 0.26384 * pitch  // crest width
+	h3 := 0.86777 * pitch // thread depth
+	// center the groove on the ends of the pitch period
+	x0 := 0.5 * h3 * (t30 - t3)
+	rMinor := radius - h3
+
+	tp := NewPolygon()
+	tp.Add(pitch, 0)
+	tp.Add(pitch, rMinor)
+	tp.Add(x0+0.5*a+h3*t3, rMinor).Smooth(0.06*pitch, 3)
+	tp.Add(x0+0.5*a, radius)
+	tp.Add(x0-0.5*a, radius)
+	tp.Add(x0-0.5*a-h3*t30, rMinor).Smooth(0.06*pitch, 3)
+	tp.Add(-pitch, rMinor)
+	tp.Add(-pitch, 0)
+
+	//tp.Render("buttress.dxf")
+	return Polygon2D(tp.Vertices())
+}
+
 //-----------------------------------------------------------------------------
 
 // ScrewSDF3 is a 3d screw form.
@@ -351,3 +518,148 @@ func (s *ScrewSDF3) BoundingBox() Box3 {
 }
 
 //-----------------------------------------------------------------------------
+// Thread Ends
+
+// ThreadEnd defines the finish of one end of a threaded rod.
+type ThreadEnd struct {
+	Chamfer float64 // 45 degree lead-in chamfer depth (0 for none)
+	Higbee  bool    // remove the partial thread at the end (blunt start)
+	Runout  float64 // length over which the thread runs out to the major radius (0 for none)
+}
+
+// ThreadedRodParms defines the parameters for a threaded rod.
+type ThreadedRodParms struct {
+	Thread   SDF2      // 2D thread profile
+	Length   float64   // length of the rod
+	Pitch    float64   // thread to thread distance
+	Starts   int       // number of thread starts (< 0 for left hand threads)
+	Internal bool      // the thread is for cutting an internal thread (E.g. a tapped hole)
+	Bottom   ThreadEnd // -z end of the rod
+	Top      ThreadEnd // +z end of the rod
+}
+
+func (k *ThreadedRodParms) validate() error {
+	if k.Thread == nil {
+		return errors.New("no thread profile")
+	}
+	if k.Length <= 0 {
+		return errors.New("Length <= 0")
+	}
+	if k.Pitch <= 0 {
+		return errors.New("Pitch <= 0")
+	}
+	if k.Starts == 0 {
+		return errors.New("Starts == 0")
+	}
+	for _, e := range []ThreadEnd{k.Bottom, k.Top} {
+		if e.Chamfer < 0 || e.Runout < 0 {
+			return errors.New("Chamfer/Runout < 0")
+		}
+		if k.Internal && (e.Higbee || e.Runout > 0) {
+			return errors.New("Higbee/Runout are not supported for internal threads")
+		}
+	}
+	if k.Bottom.Runout+k.Top.Runout > k.Length {
+		return errors.New("Runout > Length")
+	}
+	return nil
+}
+
+// threadRadii returns the root and major radii of a thread profile.
+func threadRadii(thread SDF2, pitch float64) (root, major float64) {
+	// the bounding box may be above the crest of the thread
+	ymax := thread.BoundingBox().Max.Y
+	root = ymax
+	const samples = 32
+	for i := 0; i < samples; i++ {
+		x := pitch * (float64(i)/samples - 0.5)
+		// find the top of the profile
+		lo, hi := 0.0, ymax
+		for j := 0; j < 32; j++ {
+			y := 0.5 * (lo + hi)
+			if thread.Evaluate(V2{x, y}) < 0 {
+				lo = y
+			} else {
+				hi = y
+			}
+		}
+		root = math.Min(root, lo)
+		major = math.Max(major, lo)
+	}
+	return root, major
+}
+
+// ThreadedRodSDF3 is a screw with finished ends.
+type ThreadedRodSDF3 struct {
+	screw       *ScrewSDF3
+	root, major float64 // thread radii
+	internal    bool
+	ends        [2]ThreadEnd // bottom and top
+	bb          Box3
+}
+
+// ThreadedRod3D returns a screw with lead-in chamfers, higbee (blunt start)
+// cuts and thread runouts at the ends. For internal threads the chamfer is a
+// countersink on the cutting thread.
+func ThreadedRod3D(k *ThreadedRodParms) (SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	s := ThreadedRodSDF3{
+		screw:    Screw3D(k.Thread, k.Length, k.Pitch, k.Starts).(*ScrewSDF3),
+		internal: k.Internal,
+		ends:     [2]ThreadEnd{k.Bottom, k.Top},
+	}
+	s.root, s.major = threadRadii(k.Thread, k.Pitch)
+	s.bb = s.screw.bb
+	if k.Internal {
+		r := s.major + math.Max(k.Bottom.Chamfer, k.Top.Chamfer)
+		s.bb = s.bb.Extend(Box3{V3{-r, -r, s.bb.Min.Z}, V3{r, r, s.bb.Max.Z}})
+	}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a threaded rod.
+func (s *ThreadedRodSDF3) Evaluate(p V3) float64 {
+	d := s.screw.Evaluate(p)
+	l := s.screw.length
+	rho := math.Sqrt(p.X*p.X + p.Y*p.Y)
+	dl := Abs(p.Z) - l
+	for i, e := range s.ends {
+		sign := float64(2*i - 1)
+		// distance from the end (+ve towards the middle)
+		dz := l - sign*p.Z
+		if e.Higbee {
+			// the z position of the center of the thread ridge at this angle
+			theta := math.Atan2(p.Y, p.X)
+			zc := p.Z - SawTooth(p.Z+s.screw.lead*theta/Tau, s.screw.pitch)
+			// remove the thread ridges with a center within half a pitch of the end
+			cut := math.Max(s.root-rho, l-sign*zc-0.5*s.screw.pitch)
+			d = math.Max(d, -cut)
+		}
+		if e.Runout > 0 {
+			// the thread root rises to the major radius at the end
+			t := Clamp((e.Runout-dz)/e.Runout, 0, 1)
+			r := s.root + (s.major-s.root)*t
+			d = math.Min(d, math.Max(rho-r, dl))
+		}
+		if e.Chamfer > 0 {
+			if s.internal {
+				// countersink
+				cone := (rho - (s.major + e.Chamfer) + dz) / math.Sqrt2
+				d = math.Min(d, math.Max(cone, dl))
+			} else {
+				cone := (rho - (s.major - e.Chamfer) - dz) / math.Sqrt2
+				d = math.Max(d, cone)
+			}
+		}
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of a threaded rod.
+func (s *ThreadedRodSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
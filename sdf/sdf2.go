@@ -10,6 +10,7 @@ package sdf
 
 import (
 	"errors"
+	"fmt"
 	"math"
 )
 
@@ -219,6 +220,35 @@ func (s *OffsetSDF2) BoundingBox() Box2 {
 	return s.bb
 }
 
+//-----------------------------------------------------------------------------
+
+// ShellSDF2 is an outline of another SDF2.
+type ShellSDF2 struct {
+	sdf   SDF2
+	delta float64
+	bb    Box2
+}
+
+// Shell2D returns an SDF2 for a stroke of the given thickness centered on the outline of another SDF2.
+func Shell2D(sdf SDF2, thickness float64) SDF2 {
+	s := ShellSDF2{}
+	s.sdf = sdf
+	s.delta = 0.5 * thickness
+	bb := sdf.BoundingBox()
+	s.bb = NewBox2(bb.Center(), bb.Size().AddScalar(thickness))
+	return &s
+}
+
+// Evaluate returns the minimum distance to a shelled SDF2.
+func (s *ShellSDF2) Evaluate(p V2) float64 {
+	return Abs(s.sdf.Evaluate(p)) - s.delta
+}
+
+// BoundingBox returns the bounding box of a shelled SDF2.
+func (s *ShellSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
 //-----------------------------------------------------------------------------
 // Cut an SDF2 along a line
 
@@ -363,6 +393,248 @@ func (s *PolySDF2) Vertices() []V2 {
 	return s.vertex
 }
 
+// ValidatePolygon checks the vertices of a closed polygon.
+// It returns an error for too few vertices, repeated vertices,
+// zero area or self-intersection.
+func ValidatePolygon(vertex []V2) error {
+	// drop the closing vertex
+	n := len(vertex)
+	if n > 1 && vertex[0].Equals(vertex[n-1], tolerance) {
+		vertex = vertex[:n-1]
+		n--
+	}
+	if n < 3 {
+		return errors.New("polygon has < 3 vertices")
+	}
+	for i := range vertex {
+		if vertex[i].Equals(vertex[(i+1)%n], tolerance) {
+			return fmt.Errorf("polygon has repeated vertex %d", i)
+		}
+	}
+	if Abs(polygonArea(vertex)) < tolerance {
+		return errors.New("polygon has zero area")
+	}
+	// check for intersections between non-adjacent edges
+	for i := 0; i < n; i++ {
+		a0, a1 := vertex[i], vertex[(i+1)%n]
+		for j := i + 2; j < n; j++ {
+			if i == 0 && j == n-1 {
+				// adjacent via the closing edge
+				continue
+			}
+			b0, b1 := vertex[j], vertex[(j+1)%n]
+			if segmentsIntersect(a0, a1, b0, b1) {
+				return fmt.Errorf("polygon edges %d and %d intersect", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// segmentsIntersect returns true if line segments ab and cd touch or cross.
+func segmentsIntersect(a, b, c, d V2) bool {
+	if pointSegmentDistance(a, c, d) < tolerance || pointSegmentDistance(b, c, d) < tolerance ||
+		pointSegmentDistance(c, a, b) < tolerance || pointSegmentDistance(d, a, b) < tolerance {
+		return true
+	}
+	d0 := b.Sub(a).Cross(c.Sub(a))
+	d1 := b.Sub(a).Cross(d.Sub(a))
+	d2 := d.Sub(c).Cross(a.Sub(c))
+	d3 := d.Sub(c).Cross(b.Sub(c))
+	return d0*d1 < 0 && d2*d3 < 0
+}
+
+// MakePolygon2D returns an SDF2 for a validated polygon. The vertices are
+// reordered counter clockwise if required.
+func MakePolygon2D(vertex []V2) (SDF2, error) {
+	err := ValidatePolygon(vertex)
+	if err != nil {
+		return nil, err
+	}
+	if polygonArea(vertex) < 0 {
+		// reverse the vertices
+		v := make([]V2, len(vertex))
+		for i := range vertex {
+			v[len(vertex)-1-i] = vertex[i]
+		}
+		vertex = v
+	}
+	return Polygon2D(vertex), nil
+}
+
+//-----------------------------------------------------------------------------
+// 2D Multi-Contour Polygon
+
+// FillRule determines the inside of a multi-contour polygon.
+type FillRule int
+
+const (
+	// NonZero fills points with a non-zero winding number.
+	NonZero FillRule = iota
+	// EvenOdd fills points enclosed by an odd number of contours.
+	EvenOdd
+)
+
+// MultiPolySDF2 is an SDF2 made from multiple closed contours.
+type MultiPolySDF2 struct {
+	a, b   []V2      // line segment end points
+	vector []V2      // unit line vectors
+	length []float64 // line lengths
+	rule   FillRule  // fill rule
+	bb     Box2      // bounding box
+}
+
+// MultiPolygon2D returns an SDF2 made from multiple closed contours (E.g. a letter "O"
+// or a washer) with the inside determined by the fill rule. With the NonZero rule,
+// holes must wind in the opposite direction to the outer contour.
+func MultiPolygon2D(contours [][]V2, rule FillRule) SDF2 {
+	s := MultiPolySDF2{}
+	s.rule = rule
+	first := true
+	for _, vertex := range contours {
+		n := len(vertex)
+		if n < 3 {
+			continue
+		}
+		for i := 0; i < n; i++ {
+			a := vertex[i]
+			b := vertex[(i+1)%n]
+			if a.Equals(b, tolerance) {
+				// skip repeated (or closing) vertices
+				continue
+			}
+			l := b.Sub(a)
+			s.a = append(s.a, a)
+			s.b = append(s.b, b)
+			s.vector = append(s.vector, l.Normalize())
+			s.length = append(s.length, l.Length())
+			if first {
+				s.bb = Box2{a, a}
+				first = false
+			}
+			s.bb = s.bb.Extend(Box2{a, a})
+		}
+	}
+	if len(s.a) == 0 {
+		return nil
+	}
+	return &s
+}
+
+// Evaluate returns the minimum distance for a multi-contour polygon.
+func (s *MultiPolySDF2) Evaluate(p V2) float64 {
+	dd := math.MaxFloat64 // d^2 to polygon (>0)
+	wn := 0               // winding number
+	cn := 0               // crossing number
+	for i := range s.a {
+		a := s.a[i]
+		b := s.b[i]
+		pa := p.Sub(a)
+		t := pa.Dot(s.vector[i])                        // t-parameter of projection onto line
+		dn := pa.Dot(V2{s.vector[i].Y, -s.vector[i].X}) // normal distance from p to line
+		// Distance to line segment
+		if t < 0 {
+			dd = Min(dd, pa.Length2())
+		} else if t > s.length[i] {
+			dd = Min(dd, p.Sub(b).Length2())
+		} else {
+			dd = Min(dd, dn*dn)
+		}
+		// winding/crossing numbers
+		if a.Y <= p.Y {
+			if b.Y > p.Y && dn < 0 { // upward crossing, p left of line
+				wn++
+				cn++
+			}
+		} else {
+			if b.Y <= p.Y && dn > 0 { // downward crossing, p right of line
+				wn--
+				cn++
+			}
+		}
+	}
+	d := math.Sqrt(dd)
+	inside := wn != 0
+	if s.rule == EvenOdd {
+		inside = cn%2 == 1
+	}
+	if inside {
+		return -d
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of a multi-contour polygon.
+func (s *MultiPolySDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+// 2D Polyline
+
+// PolylineSDF2 is an SDF2 made by stroking an open set of line segments.
+type PolylineSDF2 struct {
+	vertex []V2      // vertices
+	vector []V2      // unit line vectors
+	length []float64 // line lengths
+	radius float64   // half the stroke width
+	bb     Box2      // bounding box
+}
+
+// Polyline2D returns an SDF2 for an open path of line segments stroked with
+// the given width. The path has round caps and joins.
+func Polyline2D(vertex []V2, width float64) SDF2 {
+	if len(vertex) == 0 || width <= 0 {
+		return nil
+	}
+	s := PolylineSDF2{}
+	s.vertex = vertex
+	s.radius = 0.5 * width
+
+	// allocate pre-calculated line segment info
+	nsegs := len(vertex) - 1
+	s.vector = make([]V2, nsegs)
+	s.length = make([]float64, nsegs)
+
+	vmin := vertex[0]
+	vmax := vertex[0]
+	for i := 0; i < nsegs; i++ {
+		l := vertex[i+1].Sub(vertex[i])
+		s.length[i] = l.Length()
+		s.vector[i] = l.Normalize()
+		vmin = vmin.Min(vertex[i+1])
+		vmax = vmax.Max(vertex[i+1])
+	}
+
+	s.bb = Box2{vmin.SubScalar(s.radius), vmax.AddScalar(s.radius)}
+	return &s
+}
+
+// Evaluate returns the minimum distance for a 2d polyline.
+func (s *PolylineSDF2) Evaluate(p V2) float64 {
+	// single point
+	dd := p.Sub(s.vertex[0]).Length2()
+	// iterate over the line segments
+	for i := range s.vector {
+		pa := p.Sub(s.vertex[i])
+		t := pa.Dot(s.vector[i]) // t-parameter of projection onto line
+		if t < 0 {
+			dd = Min(dd, pa.Length2()) // distance to vertex[0] of line
+		} else if t > s.length[i] {
+			dd = Min(dd, p.Sub(s.vertex[i+1]).Length2()) // distance to vertex[1] of line
+		} else {
+			dn := pa.Dot(V2{s.vector[i].Y, -s.vector[i].X}) // normal distance to line
+			dd = Min(dd, dn*dn)
+		}
+	}
+	return math.Sqrt(dd) - s.radius
+}
+
+// BoundingBox returns the bounding box of a 2d polyline.
+func (s *PolylineSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
 //-----------------------------------------------------------------------------
 // Transform SDF2 (rotation and translation are distance preserving)
 
@@ -430,6 +702,48 @@ func (s *ScaleUniformSDF2) BoundingBox() Box2 {
 
 //-----------------------------------------------------------------------------
 
+// ScaleSDF2 scales another SDF2 by different amounts on each axis.
+type ScaleSDF2 struct {
+	sdf  SDF2
+	invk V2
+	kmin float64
+	bb   Box2
+}
+
+// Scale2D scales an SDF2 by k.X on the x-axis and k.Y on the y-axis.
+// The distance is scaled by the smaller factor, so it is a lower bound
+// on the true distance for non-uniform scaling.
+func Scale2D(sdf SDF2, k V2) SDF2 {
+	if k.X == k.Y {
+		return ScaleUniform2D(sdf, k.X)
+	}
+	m := Scale2d(k)
+	return &ScaleSDF2{
+		sdf:  sdf,
+		invk: V2{1 / k.X, 1 / k.Y},
+		kmin: Min(Abs(k.X), Abs(k.Y)),
+		bb:   m.MulBox(sdf.BoundingBox()),
+	}
+}
+
+// Evaluate returns the minimum distance to an SDF2 with non-uniform scaling.
+func (s *ScaleSDF2) Evaluate(p V2) float64 {
+	q := V2{p.X * s.invk.X, p.Y * s.invk.Y}
+	return s.sdf.Evaluate(q) * s.kmin
+}
+
+// BoundingBox returns the bounding box of an SDF2 with non-uniform scaling.
+func (s *ScaleSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+// Mirror2D returns an SDF2 mirrored across the line through a with normal n.
+func Mirror2D(sdf SDF2, a, n V2) SDF2 {
+	return Transform2D(sdf, Mirror2d(a, n))
+}
+
+//-----------------------------------------------------------------------------
+
 // Center2D centers the origin of an SDF2 on it's bounding box.
 func Center2D(s SDF2) SDF2 {
 	ofs := s.BoundingBox().Center().Neg()
@@ -449,31 +763,70 @@ func CenterAndScale2D(s SDF2, k float64) SDF2 {
 
 // ArraySDF2 defines an XY grid array of an existing SDF2.
 type ArraySDF2 struct {
-	sdf  SDF2
-	num  V2i // grid size
-	step V2  // grid step size
-	min  MinFunc
-	bb   Box2
+	sdf       SDF2
+	num       V2i     // grid size
+	step      V2      // grid step size
+	mirror    [2]bool // mirror alternate copies along x, y
+	rowOffset float64 // x offset of alternate rows
+	min       MinFunc
+	bb        Box2
 }
 
-// Array2D returns an XY grid array of an existing SDF2.
-func Array2D(sdf SDF2, num V2i, step V2) SDF2 {
+// NewArraySDF2 returns an XY grid array of an existing SDF2 with array options.
+func NewArraySDF2(sdf SDF2, num V2i, step V2, opts ...ArrayOption) SDF2 {
 	// check the number of steps
 	if num[0] <= 0 || num[1] <= 0 {
 		return nil
 	}
+	a := arrayOptions{}
+	for _, opt := range opts {
+		opt(&a)
+	}
 	s := ArraySDF2{}
 	s.sdf = sdf
 	s.num = num
 	s.step = step
+	s.mirror = [2]bool{a.mirror[0], a.mirror[1]}
+	s.rowOffset = a.rowOffset
 	s.min = Min
 	// work out the bounding box
 	bb0 := sdf.BoundingBox()
+	if s.mirror[0] {
+		bb0 = bb0.Extend(Box2{V2{-bb0.Max.X, bb0.Min.Y}, V2{-bb0.Min.X, bb0.Max.Y}})
+	}
+	if s.mirror[1] {
+		bb0 = bb0.Extend(Box2{V2{bb0.Min.X, -bb0.Max.Y}, V2{bb0.Max.X, -bb0.Min.Y}})
+	}
 	bb1 := bb0.Translate(step.Mul(num.SubScalar(1).ToV2()))
 	s.bb = bb0.Extend(bb1)
+	if num[1] > 1 {
+		ofs := V2{s.rowOffset, 0}
+		s.bb = s.bb.Extend(bb0.Translate(ofs)).Extend(bb1.Translate(ofs))
+	}
 	return &s
 }
 
+// Array2D returns an XY grid array of an existing SDF2.
+func Array2D(sdf SDF2, num V2i, step V2) SDF2 {
+	return NewArraySDF2(sdf, num, step)
+}
+
+// offset returns the offset and mirroring of the (j, k) copy.
+func (s *ArraySDF2) offset(j, k int) (V2, V2) {
+	ofs := V2{float64(j) * s.step.X, float64(k) * s.step.Y}
+	m := V2{1, 1}
+	if k%2 == 1 {
+		ofs.X += s.rowOffset
+		if s.mirror[1] {
+			m.Y = -1
+		}
+	}
+	if j%2 == 1 && s.mirror[0] {
+		m.X = -1
+	}
+	return ofs, m
+}
+
 // SetMin sets the minimum function to control blending.
 func (s *ArraySDF2) SetMin(min MinFunc) {
 	s.min = min
@@ -484,7 +837,8 @@ func (s *ArraySDF2) Evaluate(p V2) float64 {
 	d := math.MaxFloat64
 	for j := 0; j < s.num[0]; j++ {
 		for k := 0; k < s.num[1]; k++ {
-			x := p.Sub(V2{float64(j) * s.step.X, float64(k) * s.step.Y})
+			ofs, m := s.offset(j, k)
+			x := p.Sub(ofs).Mul(m)
 			d = s.min(d, s.sdf.Evaluate(x))
 		}
 	}
@@ -555,41 +909,78 @@ func (s *RotateUnionSDF2) BoundingBox() Box2 {
 
 //-----------------------------------------------------------------------------
 
-// RotateCopySDF2 copies an SDF2 n times in a full circle.
+// RotateCopySDF2 copies an SDF2 n times in a full circle or over an arc.
 type RotateCopySDF2 struct {
 	sdf   SDF2
-	theta float64
+	num   int     // number of copies
+	theta float64 // angle between copies
+	arc   float64 // angle from the first to the last copy (0 for a full circle)
 	bb    Box2
 }
 
-// RotateCopy2D rotates and copies an SDF2 n times in a full circle.
-func RotateCopy2D(sdf SDF2, n int) SDF2 {
+// rotateCopyAngle maps an angle to the sector of the first copy.
+// The copies are at multiples of theta, either in a full circle or over an arc.
+func rotateCopyAngle(a, theta, arc float64, num int) float64 {
+	if arc == 0 {
+		// full circle
+		return SawTooth(a, theta)
+	}
+	// angle relative to the middle of the arc
+	mid := 0.5 * arc
+	a = math.Remainder(a-mid, Tau) + mid
+	if theta == 0 {
+		return a
+	}
+	k := Clamp(math.Round(a/theta), 0, float64(num-1))
+	return a - k*theta
+}
+
+// rotateCopyParms returns the angle between copies and the normalized arc.
+func rotateCopyParms(num int, arc float64) (float64, float64) {
+	if arc == 0 || Abs(arc) >= Tau {
+		return Tau / float64(num), 0
+	}
+	if num == 1 {
+		return 0, arc
+	}
+	return arc / float64(num-1), arc
+}
+
+// RotateCopyArc2D rotates and copies an SDF2 num times about the origin.
+// The copies are spread over an arc (radians) from the first to the last copy,
+// with arc = 0 for a full circle. Each copy must stay within its sector.
+func RotateCopyArc2D(sdf SDF2, num int, arc float64) SDF2 {
 	// check the number of steps
-	if n <= 0 {
+	if num <= 0 {
 		return nil
 	}
 	s := RotateCopySDF2{}
 	s.sdf = sdf
-	s.theta = Tau / float64(n)
-	// work out the bounding box
-	bb := sdf.BoundingBox()
-	rmax := 0.0
-	// find the bounding box vertex with the greatest distance from the origin
-	for _, v := range bb.Vertices() {
-		l := v.Length()
-		if l > rmax {
-			rmax = l
+	s.num = num
+	s.theta, s.arc = rotateCopyParms(num, arc)
+	// work out the bounding box from the copies
+	v := sdf.BoundingBox().Vertices()
+	s.bb = Box2{v[0], v[0]}
+	for i := 0; i < num; i++ {
+		m := Rotate2d(float64(i) * s.theta)
+		for _, x := range v {
+			x = m.MulPosition(x)
+			s.bb = s.bb.Extend(Box2{x, x})
 		}
 	}
-	s.bb = Box2{V2{-rmax, -rmax}, V2{rmax, rmax}}
 	return &s
 }
 
+// RotateCopy2D rotates and copies an SDF2 n times in a full circle.
+func RotateCopy2D(sdf SDF2, n int) SDF2 {
+	return RotateCopyArc2D(sdf, n, 0)
+}
+
 // Evaluate returns the minimum distance to a rotate/copy SDF2.
 func (s *RotateCopySDF2) Evaluate(p V2) float64 {
 	// Map p to a point in the first copy sector.
-	pnew := PolarToXY(p.Length(), SawTooth(math.Atan2(p.Y, p.X), s.theta))
-	return s.sdf.Evaluate(pnew)
+	a := rotateCopyAngle(math.Atan2(p.Y, p.X), s.theta, s.arc, s.num)
+	return s.sdf.Evaluate(PolarToXY(p.Length(), a))
 }
 
 // BoundingBox returns the bounding box of a rotate/copy SDF2.
@@ -799,6 +1190,80 @@ func (s *DifferenceSDF2) BoundingBox() Box2 {
 
 //-----------------------------------------------------------------------------
 
+// XorSDF2 is the symmetric difference of two SDF2s.
+type XorSDF2 struct {
+	s0 SDF2
+	s1 SDF2
+	bb Box2
+}
+
+// Xor2D returns the symmetric difference of two SDF2s, (s0 + s1) - (s0 & s1).
+func Xor2D(s0, s1 SDF2) SDF2 {
+	if s1 == nil {
+		return s0
+	}
+	if s0 == nil {
+		return s1
+	}
+	s := XorSDF2{}
+	s.s0 = s0
+	s.s1 = s1
+	s.bb = s0.BoundingBox().Extend(s1.BoundingBox())
+	return &s
+}
+
+// Evaluate returns the minimum distance to the SDF2 symmetric difference.
+func (s *XorSDF2) Evaluate(p V2) float64 {
+	a := s.s0.Evaluate(p)
+	b := s.s1.Evaluate(p)
+	return Max(Min(a, b), -Max(a, b))
+}
+
+// BoundingBox returns the bounding box of the SDF2 symmetric difference.
+func (s *XorSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
+// IntersectionSDF2 is the intersection of two SDF2s.
+type IntersectionSDF2 struct {
+	s0  SDF2
+	s1  SDF2
+	max MaxFunc
+	bb  Box2
+}
+
+// Intersect2D returns the intersection of two SDF2s.
+func Intersect2D(s0, s1 SDF2) SDF2 {
+	if s0 == nil || s1 == nil {
+		return nil
+	}
+	s := IntersectionSDF2{}
+	s.s0 = s0
+	s.s1 = s1
+	s.max = Max
+	s.bb = s0.BoundingBox().Intersect(s1.BoundingBox())
+	return &s
+}
+
+// Evaluate returns the minimum distance to the SDF2 intersection.
+func (s *IntersectionSDF2) Evaluate(p V2) float64 {
+	return s.max(s.s0.Evaluate(p), s.s1.Evaluate(p))
+}
+
+// SetMax sets the maximum function to control blending.
+func (s *IntersectionSDF2) SetMax(max MaxFunc) {
+	s.max = max
+}
+
+// BoundingBox returns the bounding box of an SDF2 intersection.
+func (s *IntersectionSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
 // ElongateSDF2 is the elongation of an SDF2.
 type ElongateSDF2 struct {
 	sdf    SDF2 // the sdf being elongated
@@ -879,3 +1344,27 @@ func LineOf2D(s SDF2, p0, p1 V2, pattern string) SDF2 {
 }
 
 //-----------------------------------------------------------------------------
+
+// FuncSDF2 is an SDF2 defined by a function.
+type FuncSDF2 struct {
+	f  func(p V2) float64
+	bb Box2
+}
+
+// Func2D returns an SDF2 with the distance field f and bounding box bb.
+// The field should be (or under estimate) the distance to the boundary.
+func Func2D(f func(p V2) float64, bb Box2) SDF2 {
+	return &FuncSDF2{f, bb}
+}
+
+// Evaluate returns the minimum distance to a function SDF2.
+func (s *FuncSDF2) Evaluate(p V2) float64 {
+	return s.f(p)
+}
+
+// BoundingBox returns the bounding box of a function SDF2.
+func (s *FuncSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
@@ -9,6 +9,8 @@
 package sdf
 
 import (
+	"errors"
+	"fmt"
 	"math"
 )
 
@@ -60,14 +62,34 @@ func sdfBox3d(p, s V3) float64 {
 
 // SorSDF3 solid of revolution, SDF2 to SDF3.
 type SorSDF3 struct {
-	sdf   SDF2
-	theta float64 // angle for partial revolutions
-	norm  V2      // pre-calculated normal to theta line
-	bb    Box3
+	sdf    SDF2
+	theta  float64 // angle for partial revolutions
+	norm   V2      // pre-calculated normal to theta line
+	start  float64 // start angle for partial revolutions
+	rotate M22     // pre-calculated rotation by -start
+	round  bool    // rounded ends for partial revolutions
+	center float64 // radius of the rounded end axes
+	end    V2      // pre-calculated unit vector along the theta line
+	bb     Box3
 }
 
-// RevolveTheta3D returns an SDF3 for a solid of revolution.
-func RevolveTheta3D(sdf SDF2, theta float64) SDF3 {
+// sorBox returns the xy bounding box of a radius l sector from a0 to a0 + theta.
+func sorBox(a0, theta, l float64) Box2 {
+	if theta == 0 {
+		return Box2{V2{-l, -l}, V2{l, l}}
+	}
+	a1 := a0 + theta
+	vset := V2Set{{0, 0}, {math.Cos(a0), math.Sin(a0)}, {math.Cos(a1), math.Sin(a1)}}
+	// add the axis crossings
+	for k := math.Ceil(a0 / (0.5 * Pi)); k*0.5*Pi < a1; k++ {
+		a := k * 0.5 * Pi
+		vset = append(vset, V2{math.Cos(a), math.Sin(a)})
+	}
+	return Box2{vset.Min().MulScalar(l), vset.Max().MulScalar(l)}
+}
+
+// newSorSDF3 returns a solid of revolution from start to start + theta (theta = 0 for a full revolution).
+func newSorSDF3(sdf SDF2, theta, start float64, round bool) *SorSDF3 {
 	s := SorSDF3{}
 	s.sdf = sdf
 	// normalize theta
@@ -76,37 +98,60 @@ func RevolveTheta3D(sdf SDF2, theta float64) SDF3 {
 	cos := math.Cos(s.theta)
 	// pre-calculate the normal to the theta line
 	s.norm = V2{-sin, cos}
+	s.end = V2{cos, sin}
+	s.start = start
+	s.rotate = Rotate(-start)
+	bb := s.sdf.BoundingBox()
+	s.round = round && s.theta != 0
+	s.center = 0.5 * (bb.Min.X + bb.Max.X)
 	// work out the bounding box
-	var vset V2Set
-	if s.theta == 0 {
-		vset = []V2{{1, 1}, {-1, -1}}
-	} else {
-		vset = []V2{{0, 0}, {1, 0}, {cos, sin}}
-		if s.theta > 0.5*Pi {
-			vset = append(vset, V2{0, 1})
-		}
-		if s.theta > Pi {
-			vset = append(vset, V2{-1, 0})
-		}
-		if s.theta > 1.5*Pi {
-			vset = append(vset, V2{0, -1})
+	l := Max(Abs(bb.Min.X), Abs(bb.Max.X))
+	b := sorBox(start, s.theta, l)
+	if s.round {
+		// add the rounded ends
+		w := V2{1, 1}.MulScalar(0.5 * (bb.Max.X - bb.Min.X))
+		for _, a := range []float64{start, start + s.theta} {
+			c := V2{math.Cos(a), math.Sin(a)}.MulScalar(s.center)
+			b = b.Extend(Box2{c.Sub(w), c.Add(w)})
 		}
 	}
-	bb := s.sdf.BoundingBox()
-	l := Max(Abs(bb.Min.X), Abs(bb.Max.X))
-	vmin := vset.Min().MulScalar(l)
-	vmax := vset.Max().MulScalar(l)
-	s.bb = Box3{V3{vmin.X, vmin.Y, bb.Min.Y}, V3{vmax.X, vmax.Y, bb.Max.Y}}
+	s.bb = Box3{V3{b.Min.X, b.Min.Y, bb.Min.Y}, V3{b.Max.X, b.Max.Y, bb.Max.Y}}
 	return &s
 }
 
+// RevolveTheta3D returns an SDF3 for a solid of revolution.
+func RevolveTheta3D(sdf SDF2, theta float64) SDF3 {
+	return newSorSDF3(sdf, theta, 0, false)
+}
+
 // Revolve3D returns an SDF3 for a solid of revolution.
 func Revolve3D(sdf SDF2) SDF3 {
 	return RevolveTheta3D(sdf, 0)
 }
 
+// RevolveAxis3D returns a solid of revolution about an axis through axisPoint
+// with direction axisDir. The profile x is the radius from the axis and the
+// profile y is the distance along the axis from axisPoint.
+func RevolveAxis3D(sdf SDF2, axisPoint, axisDir V3) (SDF3, error) {
+	if axisDir.Length() < tolerance {
+		return nil, errors.New("zero length axis direction")
+	}
+	return Transform3D(Revolve3D(sdf), AxisTransform(axisPoint, axisDir)), nil
+}
+
+// endDistance returns the distance to the rounded end of a partial revolution.
+// The end is the profile revolved about the vertical axis through its center.
+func (s *SorSDF3) endDistance(p V3, u V2) float64 {
+	r := V2{p.X, p.Y}.Sub(u.MulScalar(s.center)).Length()
+	return s.sdf.Evaluate(V2{s.center + r, p.Z})
+}
+
 // Evaluate returns the minimum distance to a solid of revolution.
 func (s *SorSDF3) Evaluate(p V3) float64 {
+	if s.start != 0 {
+		q := s.rotate.MulPosition(V2{p.X, p.Y})
+		p = V3{q.X, q.Y, p.Z}
+	}
 	x := math.Sqrt(p.X*p.X + p.Y*p.Y)
 	a := s.sdf.Evaluate(V2{x, p.Z})
 	b := a
@@ -120,7 +165,11 @@ func (s *SorSDF3) Evaluate(p V3) float64 {
 		}
 	}
 	// return the intersection
-	return Max(a, b)
+	d := Max(a, b)
+	if s.round {
+		d = Min(d, Min(s.endDistance(p, V2{1, 0}), s.endDistance(p, s.end)))
+	}
+	return d
 }
 
 // BoundingBox returns the bounding box for a solid of revolution.
@@ -275,6 +324,92 @@ func (s *ExtrudeRoundedSDF3) BoundingBox() Box3 {
 	return s.bb
 }
 
+//-----------------------------------------------------------------------------
+// Linear extrude an SDF2 with rounded top and bottom rims.
+// The 2D distance and the slab distance are combined as a rounded 2D corner.
+
+// ExtrudeRoundedRimsSDF3 extrudes an SDF2 to an SDF3 with rounded top and bottom rims.
+type ExtrudeRoundedRimsSDF3 struct {
+	sdf    SDF2
+	height float64
+	round  float64
+	bb     Box3
+}
+
+// ExtrudeRoundedRims3D does a linear extrude of an SDF2 with rounded top and bottom rims.
+// Unlike ExtrudeRounded3D, which rounds the whole extrusion by growing the
+// profile outwards by round, the rims are rounded inwards: the footprint and
+// height of the extrusion are not changed and the vertical edges stay sharp.
+func ExtrudeRoundedRims3D(sdf SDF2, height, round float64) (SDF3, error) {
+	if height <= 0 {
+		return nil, errors.New("height <= 0")
+	}
+	if round < 0 || 2*round > height {
+		return nil, errors.New("round out of range")
+	}
+	s := ExtrudeRoundedRimsSDF3{}
+	s.sdf = sdf
+	s.height = height / 2
+	s.round = round
+	bb := sdf.BoundingBox()
+	s.bb = Box3{V3{bb.Min.X, bb.Min.Y, -s.height}, V3{bb.Max.X, bb.Max.Y, s.height}}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a rounded rim extrusion.
+func (s *ExtrudeRoundedRimsSDF3) Evaluate(p V3) float64 {
+	// distances to the rounding centers
+	a := s.sdf.Evaluate(V2{p.X, p.Y}) + s.round
+	b := Abs(p.Z) - s.height + s.round
+	d := Min(Max(a, b), 0) + V2{Max(a, 0), Max(b, 0)}.Length()
+	return d - s.round
+}
+
+// BoundingBox returns the bounding box for a rounded rim extrusion.
+func (s *ExtrudeRoundedRimsSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+// Stacked extrusions of profiles along the z-axis.
+// E.g. stepped bosses and multi-diameter spacers.
+
+// StackSection is a single section of a stacked extrusion.
+type StackSection struct {
+	Profile SDF2    // profile of the section
+	Height  float64 // height of the section
+	Round   float64 // fillet radius blending the section into the section below (0 = sharp)
+}
+
+// Stack3D stacks extrusions of profiles along the z-axis, starting from z = 0.
+func Stack3D(sections []StackSection) (SDF3, error) {
+	if len(sections) == 0 {
+		return nil, errors.New("no stack sections")
+	}
+	var s SDF3
+	z := 0.0
+	for i, x := range sections {
+		if x.Height <= 0 {
+			return nil, fmt.Errorf("section %d: Height <= 0", i)
+		}
+		if x.Round < 0 {
+			return nil, fmt.Errorf("section %d: Round < 0", i)
+		}
+		stage := NewExtrudeSDF3(x.Profile, x.Height, WithBase(z))
+		z += x.Height
+		if s == nil {
+			s = stage
+			continue
+		}
+		if x.Round > 0 {
+			s = NewUnionSDF3(s, stage, WithMin(RoundMin(x.Round)))
+		} else {
+			s = Union3D(s, stage)
+		}
+	}
+	return s, nil
+}
+
 //-----------------------------------------------------------------------------
 // Extrude/Loft (with rounded edges)
 // Blend between sdf0 and sdf1 as we move from bottom to top.
@@ -797,6 +932,42 @@ func (s *IntersectionSDF3) BoundingBox() Box3 {
 
 //-----------------------------------------------------------------------------
 
+// XorSDF3 is the symmetric difference of two SDF3s.
+type XorSDF3 struct {
+	s0 SDF3
+	s1 SDF3
+	bb Box3
+}
+
+// Xor3D returns the symmetric difference of two SDF3s, (s0 + s1) - (s0 & s1).
+func Xor3D(s0, s1 SDF3) SDF3 {
+	if s1 == nil {
+		return s0
+	}
+	if s0 == nil {
+		return s1
+	}
+	s := XorSDF3{}
+	s.s0 = s0
+	s.s1 = s1
+	s.bb = s0.BoundingBox().Extend(s1.BoundingBox())
+	return &s
+}
+
+// Evaluate returns the minimum distance to the SDF3 symmetric difference.
+func (s *XorSDF3) Evaluate(p V3) float64 {
+	a := s.s0.Evaluate(p)
+	b := s.s1.Evaluate(p)
+	return Max(Min(a, b), -Max(a, b))
+}
+
+// BoundingBox returns the bounding box of the SDF3 symmetric difference.
+func (s *XorSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
 // CutSDF3 makes a planar cut through an SDF3.
 type CutSDF3 struct {
 	sdf SDF3
@@ -831,31 +1002,76 @@ func (s *CutSDF3) BoundingBox() Box3 {
 
 // ArraySDF3 stores an XYZ array of a given SDF3
 type ArraySDF3 struct {
-	sdf  SDF3
-	num  V3i
-	step V3
-	min  MinFunc
-	bb   Box3
+	sdf       SDF3
+	num       V3i
+	step      V3
+	mirror    [3]bool // mirror alternate copies along x, y, z
+	rowOffset float64 // x offset of alternate rows (along y)
+	min       MinFunc
+	bb        Box3
 }
 
-// Array3D returns an XYZ array of a given SDF3
-func Array3D(sdf SDF3, num V3i, step V3) SDF3 {
+// NewArraySDF3 returns an XYZ array of a given SDF3 with array options.
+func NewArraySDF3(sdf SDF3, num V3i, step V3, opts ...ArrayOption) SDF3 {
 	// check the number of steps
 	if num[0] <= 0 || num[1] <= 0 || num[2] <= 0 {
 		return nil
 	}
+	a := arrayOptions{}
+	for _, opt := range opts {
+		opt(&a)
+	}
 	s := ArraySDF3{}
 	s.sdf = sdf
 	s.num = num
 	s.step = step
+	s.mirror = a.mirror
+	s.rowOffset = a.rowOffset
 	s.min = Min
 	// work out the bounding box
 	bb0 := sdf.BoundingBox()
+	if s.mirror[0] {
+		bb0 = bb0.Extend(Box3{V3{-bb0.Max.X, bb0.Min.Y, bb0.Min.Z}, V3{-bb0.Min.X, bb0.Max.Y, bb0.Max.Z}})
+	}
+	if s.mirror[1] {
+		bb0 = bb0.Extend(Box3{V3{bb0.Min.X, -bb0.Max.Y, bb0.Min.Z}, V3{bb0.Max.X, -bb0.Min.Y, bb0.Max.Z}})
+	}
+	if s.mirror[2] {
+		bb0 = bb0.Extend(Box3{V3{bb0.Min.X, bb0.Min.Y, -bb0.Max.Z}, V3{bb0.Max.X, bb0.Max.Y, -bb0.Min.Z}})
+	}
 	bb1 := bb0.Translate(step.Mul(num.SubScalar(1).ToV3()))
 	s.bb = bb0.Extend(bb1)
+	if num[1] > 1 {
+		ofs := V3{s.rowOffset, 0, 0}
+		s.bb = s.bb.Extend(bb0.Translate(ofs)).Extend(bb1.Translate(ofs))
+	}
 	return &s
 }
 
+// Array3D returns an XYZ array of a given SDF3
+func Array3D(sdf SDF3, num V3i, step V3) SDF3 {
+	return NewArraySDF3(sdf, num, step)
+}
+
+// offset returns the offset and mirroring of the (j, k, l) copy.
+func (s *ArraySDF3) offset(j, k, l int) (V3, V3) {
+	ofs := V3{float64(j) * s.step.X, float64(k) * s.step.Y, float64(l) * s.step.Z}
+	m := V3{1, 1, 1}
+	if j%2 == 1 && s.mirror[0] {
+		m.X = -1
+	}
+	if k%2 == 1 {
+		ofs.X += s.rowOffset
+		if s.mirror[1] {
+			m.Y = -1
+		}
+	}
+	if l%2 == 1 && s.mirror[2] {
+		m.Z = -1
+	}
+	return ofs, m
+}
+
 // SetMin sets the minimum function to control blending.
 func (s *ArraySDF3) SetMin(min MinFunc) {
 	s.min = min
@@ -867,7 +1083,8 @@ func (s *ArraySDF3) Evaluate(p V3) float64 {
 	for j := 0; j < s.num[0]; j++ {
 		for k := 0; k < s.num[1]; k++ {
 			for l := 0; l < s.num[2]; l++ {
-				x := p.Sub(V3{float64(j) * s.step.X, float64(k) * s.step.Y, float64(l) * s.step.Z})
+				ofs, m := s.offset(j, k, l)
+				x := p.Sub(ofs).Mul(m)
 				d = s.min(d, s.sdf.Evaluate(x))
 			}
 		}
@@ -942,44 +1159,51 @@ func (s *RotateUnionSDF3) BoundingBox() Box3 {
 // RotateCopySDF3 rotates and creates N copies of an SDF3 about the z-axis.
 type RotateCopySDF3 struct {
 	sdf   SDF3
-	theta float64
+	num   int     // number of copies
+	theta float64 // angle between copies
+	arc   float64 // angle from the first to the last copy (0 for a full circle)
 	bb    Box3
 }
 
-// RotateCopy3D rotates and creates N copies of an SDF3 about the z-axis.
-func RotateCopy3D(
-	sdf SDF3, // SDF3 to rotate and copy
-	num int, // number of copies
-) SDF3 {
+// RotateCopyArc3D rotates and copies an SDF3 num times about the z-axis.
+// The copies are spread over an arc (radians) from the first to the last copy,
+// with arc = 0 for a full circle. Each copy must stay within its sector.
+func RotateCopyArc3D(sdf SDF3, num int, arc float64) SDF3 {
 	// check the number of steps
 	if num <= 0 {
 		return nil
 	}
 	s := RotateCopySDF3{}
 	s.sdf = sdf
-	s.theta = Tau / float64(num)
-	// work out the bounding box
-	bb := sdf.BoundingBox()
-	zmax := bb.Max.Z
-	zmin := bb.Min.Z
-	rmax := 0.0
-	// find the bounding box vertex with the greatest distance from the z-axis
-	// TODO - revisit - should go by real vertices
-	for _, v := range bb.Vertices() {
-		l := V2{v.X, v.Y}.Length()
-		if l > rmax {
-			rmax = l
+	s.num = num
+	s.theta, s.arc = rotateCopyParms(num, arc)
+	// work out the bounding box from the copies
+	v := sdf.BoundingBox().Vertices()
+	s.bb = Box3{v[0], v[0]}
+	for i := 0; i < num; i++ {
+		m := RotateZ(float64(i) * s.theta)
+		for _, x := range v {
+			x = m.MulPosition(x)
+			s.bb = s.bb.Extend(Box3{x, x})
 		}
 	}
-	s.bb = Box3{V3{-rmax, -rmax, zmin}, V3{rmax, rmax, zmax}}
 	return &s
 }
 
+// RotateCopy3D rotates and creates N copies of an SDF3 about the z-axis.
+func RotateCopy3D(
+	sdf SDF3, // SDF3 to rotate and copy
+	num int, // number of copies
+) SDF3 {
+	return RotateCopyArc3D(sdf, num, 0)
+}
+
 // Evaluate returns the minimum distance to a rotate/copy SDF3.
 func (s *RotateCopySDF3) Evaluate(p V3) float64 {
 	// Map p to a point in the first copy sector.
 	p2 := V2{p.X, p.Y}
-	p2 = PolarToXY(p2.Length(), SawTooth(math.Atan2(p2.Y, p2.X), s.theta))
+	a := rotateCopyAngle(math.Atan2(p2.Y, p2.X), s.theta, s.arc, s.num)
+	p2 = PolarToXY(p2.Length(), a)
 	return s.sdf.Evaluate(V3{p2.X, p2.Y, p.Z})
 }
 
@@ -990,6 +1214,18 @@ func (s *RotateCopySDF3) BoundingBox() Box3 {
 
 //-----------------------------------------------------------------------------
 
+// InstanceArray3D returns the union of num copies of an SDF3, each placed
+// with the transform returned by transform(i). E.g. staggered and spiral patterns.
+func InstanceArray3D(sdf SDF3, num int, transform func(i int) M44) SDF3 {
+	s := make([]SDF3, num)
+	for i := range s {
+		s[i] = Transform3D(sdf, transform(i))
+	}
+	return Union3D(s...)
+}
+
+//-----------------------------------------------------------------------------
+
 /* WIP
 
 // Connector3 defines a 3d connection point.
@@ -1065,3 +1301,154 @@ func (s *OffsetSDF3) BoundingBox() Box3 {
 }
 
 //-----------------------------------------------------------------------------
+
+// DraftSDF3 tapers the walls of an SDF3 by a draft angle.
+type DraftSDF3 struct {
+	sdf  SDF3    // the underlying SDF
+	pull V3      // unit vector for the pull direction
+	h0   float64 // pull direction height of the parting plane
+	k    float64 // tan(draft angle)
+	l    float64 // 1 / Lipschitz constant of the drafted distance
+	bb   Box3    // bounding box
+}
+
+// draftLipschitz returns the Lipschitz constant of the drafted distance.
+// With d(p) = f(p - h.k.n) + h.k, the gradient is g + k(1 - g.n)n for a unit
+// gradient g of f. Its squared length is 1 - c^2 + (c(1-k) + k)^2 where
+// c = g.n, which we maximize over c in [-1, 1]. E.g. walls parallel to the
+// pull direction (c = 0) give sqrt(1 + k^2), but faces inclined to the
+// pull direction give more than that.
+func draftLipschitz(k float64) float64 {
+	f := func(c float64) float64 {
+		x := c*(1-k) + k
+		return 1 - c*c + x*x
+	}
+	l := Max(f(-1), f(1))
+	if k*(k-2) < 0 {
+		// concave, check the vertex
+		c := (1 - k) / (2 - k)
+		if c >= -1 && c <= 1 {
+			l = Max(l, f(c))
+		}
+	}
+	return math.Sqrt(l)
+}
+
+// Draft3D tapers the walls of an SDF3 that are parallel to the pull direction.
+// The parting plane is at the base of the bounding box (relative to the pull direction)
+// and the walls move inwards by tan(angle) per unit height above the parting plane.
+// Faces normal to the pull direction stay where they are.
+// The distance is scaled by the Lipschitz constant of the draft (see draftLipschitz)
+// so it is a bound on the true distance, not an exact distance.
+// The angle must be in [0, 90) degrees, so the drafted solid is within the
+// bounding box of the SDF3.
+func Draft3D(sdf SDF3, pull V3, angle float64) (SDF3, error) {
+	if angle < 0 || angle >= 0.5*Pi {
+		return nil, errors.New("angle out of range")
+	}
+	if pull.Length() < tolerance {
+		return nil, errors.New("zero length pull direction")
+	}
+	s := DraftSDF3{
+		sdf:  sdf,
+		pull: pull.Normalize(),
+		k:    math.Tan(angle),
+		bb:   sdf.BoundingBox(),
+	}
+	s.l = 1 / draftLipschitz(s.k)
+	// the parting plane is at the lowest bounding box vertex
+	s.h0 = math.MaxFloat64
+	for _, v := range s.bb.Vertices() {
+		s.h0 = Min(s.h0, v.Dot(s.pull))
+	}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a drafted SDF3.
+func (s *DraftSDF3) Evaluate(p V3) float64 {
+	h := p.Dot(s.pull) - s.h0
+	if h <= 0 {
+		// scaled on both sides of the parting plane to stay continuous
+		return s.sdf.Evaluate(p) * s.l
+	}
+	// Offset the SDF inwards by the draft distance. The offset also lowers the
+	// faces that are normal to the pull direction, so we move the evaluation
+	// point back along the pull direction to compensate.
+	ofs := h * s.k
+	return (s.sdf.Evaluate(p.Sub(s.pull.MulScalar(ofs))) + ofs) * s.l
+}
+
+// BoundingBox returns the bounding box of a drafted SDF3.
+func (s *DraftSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
+// BlendRegionSDF3 is a union of two SDF3s that is only blended within a zone.
+type BlendRegionSDF3 struct {
+	s0   SDF3
+	s1   SDF3
+	zone SDF3
+	k    float64
+	bb   Box3
+}
+
+// BlendRegion3D returns the union of two SDF3s with a smooth (PolyMin) blend
+// of size k inside the zone SDF3 and a hard union outside it. The blend fades
+// in over a distance of k inside the zone boundary, so fillets can be applied
+// locally without softening the whole model.
+func BlendRegion3D(s0, s1, zone SDF3, k float64) (SDF3, error) {
+	if k <= 0 {
+		return nil, errors.New("k <= 0")
+	}
+	s := BlendRegionSDF3{}
+	s.s0 = s0
+	s.s1 = s1
+	s.zone = zone
+	s.k = k
+	s.bb = s0.BoundingBox().Extend(s1.BoundingBox())
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a blend region union.
+func (s *BlendRegionSDF3) Evaluate(p V3) float64 {
+	a := s.s0.Evaluate(p)
+	b := s.s1.Evaluate(p)
+	k := s.k * Clamp(-s.zone.Evaluate(p)/s.k, 0, 1)
+	if k <= 0 {
+		return Min(a, b)
+	}
+	return poly(a, b, k)
+}
+
+// BoundingBox returns the bounding box of a blend region union.
+func (s *BlendRegionSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
+// FuncSDF3 is an SDF3 defined by a function.
+type FuncSDF3 struct {
+	f  func(p V3) float64
+	bb Box3
+}
+
+// Func3D returns an SDF3 with the distance field f and bounding box bb.
+// The field should be (or under estimate) the distance to the surface.
+func Func3D(f func(p V3) float64, bb Box3) SDF3 {
+	return &FuncSDF3{f, bb}
+}
+
+// Evaluate returns the minimum distance to a function SDF3.
+func (s *FuncSDF3) Evaluate(p V3) float64 {
+	return s.f(p)
+}
+
+// BoundingBox returns the bounding box of a function SDF3.
+func (s *FuncSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
@@ -5,8 +5,15 @@
 package sdf
 
 import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
 	"fmt"
+	"image"
+	"image/color"
 	"math"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -722,3 +729,4584 @@ func Test_Box2_Distances(t *testing.T) {
 }
 
 //-----------------------------------------------------------------------------
+
+func Test_Rack3D(t *testing.T) {
+	k := RackParms{
+		NumberTeeth:   10,
+		Module:        1,
+		PressureAngle: DtoR(20),
+		BaseHeight:    3,
+		Width:         8,
+		HoleRadius:    1,
+		NumHoles:      2,
+	}
+	for _, helix := range []float64{0, 20} {
+		k.HelixAngle = DtoR(helix)
+		rack, err := Rack3D(&k)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		length := 10 * Pi
+		// the rack is trimmed to length
+		if rack.Evaluate(V3{0.5*length + 0.1, 1, 0}) <= 0 || rack.Evaluate(V3{0.5*length - 1, 1, 0}) >= 0 {
+			t.Error("FAIL")
+		}
+		// mounting holes at 1/4 and 3/4 of the length
+		if rack.Evaluate(V3{0.25 * length, 1, 0}) <= 0 {
+			t.Error("FAIL")
+		}
+		// a tooth at x = 0 on the mid plane, a gap half a pitch away
+		y := k.PitchLine()
+		if rack.Evaluate(V3{0, y, 0}) >= 0 || rack.Evaluate(V3{0.5 * Pi, y, 0}) <= 0 {
+			t.Error("FAIL")
+		}
+		pinion, err := k.Pinion3D(12, 0, 1, 5)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		// pinion teeth and rack teeth don't collide at the mesh
+		pinion = Transform3D(pinion, Translate3d(V3{0.5 * Pi, y + 6, 0}).Mul(RotateZ(-0.5*Pi)))
+		for z := -4.0; z <= 4; z += 0.5 {
+			for x := -2.0; x <= 2; x += 0.25 {
+				p := V3{x, y, z}
+				if rack.Evaluate(p) < -0.05 && pinion.Evaluate(p) < -0.05 {
+					t.Error("FAIL")
+				}
+			}
+		}
+	}
+	k.NumHoles = 0
+	if _, err := Rack3D(&k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+func Test_GearTrain(t *testing.T) {
+	k := GearTrainParms{
+		Module:        1,
+		PressureAngle: DtoR(20),
+		MinTeeth:      12,
+		MaxTeeth:      60,
+		MaxStages:     3,
+		Thickness:     5,
+		Spacing:       1,
+		ShaftRadius:   2,
+		Facets:        5,
+	}
+	tests := []struct {
+		ratio  float64
+		stages int
+	}{
+		{2, 1},
+		{4.5, 1},
+		{9, 2},
+		{50, 3},
+	}
+	for _, v := range tests {
+		k.Ratio = v.ratio
+		gt, err := NewGearTrain(&k)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(gt.Stages) != v.stages || len(gt.Shafts) != v.stages+1 || len(gt.Gears) != 2*v.stages {
+			t.Error("FAIL")
+		}
+		if Abs(gt.Ratio-v.ratio)/v.ratio > 0.02 {
+			t.Logf("expected %f, actual %f\n", v.ratio, gt.Ratio)
+			t.Error("FAIL")
+		}
+		for i, s := range gt.Stages {
+			d := gt.Shafts[i+1].Sub(gt.Shafts[i]).Length()
+			if Abs(d-0.5*k.Module*float64(s.PinionTeeth+s.GearTeeth)) > tolerance {
+				t.Error("FAIL")
+			}
+		}
+	}
+	k.Ratio = 1000
+	if _, err := NewGearTrain(&k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_WormGear(t *testing.T) {
+	k := WormGearParms{
+		Module:        1,
+		Starts:        1,
+		WheelTeeth:    30,
+		LeadAngle:     DtoR(5),
+		PressureAngle: DtoR(20),
+		WormLength:    20,
+		WheelWidth:    6,
+		ShaftRadius:   3,
+		Facets:        5,
+	}
+	for _, starts := range []int{1, 2, -1} {
+		k.Starts = starts
+		g, err := NewWormGear(&k)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if g.Ratio != 30/Abs(float64(starts)) || Abs(g.Distance-g.WormRadius-g.WheelRadius) > tolerance {
+			t.Error("FAIL")
+		}
+		// a wheel tooth sits in a worm thread gap at the mesh point
+		p := V3{g.WheelRadius, 0, 0}
+		if g.Wheel.Evaluate(p) >= 0 || g.Worm.Evaluate(p) <= 0 {
+			t.Error("FAIL")
+		}
+		// the throat clears the worm root
+		if g.Wheel.Evaluate(V3{g.Distance - g.ThroatRadius + 0.1, 0, 0}) <= 0 {
+			t.Error("FAIL")
+		}
+	}
+	k.LeadAngle = DtoR(89)
+	if _, err := NewWormGear(&k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_BearingSeat(t *testing.T) {
+	b, err := BearingLookup("608")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := 0.5 * b.Outer
+	for _, fit := range []string{"press", "slip"} {
+		s, err := BearingSeat3D(&BearingSeatParms{
+			Bearing:      "608",
+			Fit:          fit,
+			LipWidth:     1,
+			LipThickness: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the pocket wall
+		d := s.Evaluate(V3{r, 0, -0.5 * b.Width})
+		if (fit == "press" && d <= 0) || (fit == "slip" && d >= 0) {
+			t.Error("FAIL")
+		}
+		// the lip is solid, the lip hole is not
+		if s.Evaluate(V3{r - 0.5, 0, -b.Width - 0.5}) < 0 {
+			t.Error("FAIL")
+		}
+		if s.Evaluate(V3{r - 1.5, 0, -b.Width - 0.5}) > 0 {
+			t.Error("FAIL")
+		}
+	}
+	if _, err := BearingSeat3D(&BearingSeatParms{Bearing: "608", Fit: "loose"}); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_FilletEdges(t *testing.T) {
+	b0 := Box3D(V3{2, 2, 2}, 0)
+	b1 := Transform3D(Box3D(V3{1, 1, 1}, 0), Translate3d(V3{0, 0, 1.5}))
+	s0 := Union3D(b0, b1)
+	s1 := FilletEdges(s0, 0.2)
+	// a point in the concave crease is outside the plain union, inside the fillet
+	p := V3{0.55, 0, 1.05}
+	if s0.Evaluate(p) <= 0 || s1.Evaluate(p) >= 0 {
+		t.Error("FAIL")
+	}
+	// away from the crease the objects are unchanged
+	for _, p := range []V3{{0, 0, 0}, {1, 0, 0}, {0, 0, 2}, {3, 3, 3}} {
+		if Abs(s0.Evaluate(p)-s1.Evaluate(p)) > tolerance {
+			t.Error("FAIL")
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Draft3D(t *testing.T) {
+	k := math.Tan(DtoR(5))
+	s, err := Draft3D(Box3D(V3{10, 10, 10}, 0), V3{0, 0, 1}, DtoR(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		p V3
+		d float64
+	}{
+		{V3{5, 0, -5}, 0},        // base edge
+		{V3{5 - 5*k, 0, 0}, 0},   // wall at mid height
+		{V3{5 - 10*k, 0, 5}, 0},  // wall at top
+		{V3{0, 0, 5}, 0},         // top face
+		{V3{0, 0, -5}, 0},        // bottom face
+		{V3{0, 5 - 5*k, 0}, 0},   // wall at mid height
+		{V3{-5 + 5*k, 0, 0}, 0},  // wall at mid height
+		{V3{0, -5 + 10*k, 5}, 0}, // wall at top
+	}
+	for _, v := range tests {
+		d := s.Evaluate(v.p)
+		if Abs(d-v.d) > tolerance {
+			t.Logf("%v expected %f, actual %f\n", v.p, v.d, d)
+			t.Error("FAIL")
+		}
+	}
+	// the drafted distance is a bound (Lipschitz constant <= 1)
+	for _, a := range []float64{0, 5, 30, 60} {
+		s, _ := Draft3D(Box3D(V3{10, 10, 10}, 1), V3{0, 0, 1}, DtoR(a))
+		for i := 0; i < 2000; i++ {
+			p0 := V3{randomRange(-8, 8), randomRange(-8, 8), randomRange(-8, 8)}
+			p1 := p0.Add(V3{randomRange(-0.01, 0.01), randomRange(-0.01, 0.01), randomRange(-0.01, 0.01)})
+			if Abs(s.Evaluate(p0)-s.Evaluate(p1)) > p0.Sub(p1).Length()*(1+1e-6) {
+				t.Errorf("FAIL angle %f: distance is not a bound at %v", a, p0)
+				break
+			}
+		}
+	}
+	if !EqualFloat64(draftLipschitz(0), 1, tolerance) {
+		t.Error("FAIL zero draft")
+	}
+	// the draft angle must be in [0, 90) degrees
+	for _, a := range []float64{-10, 90, 100} {
+		if _, err := Draft3D(Box3D(V3{10, 10, 10}, 0), V3{0, 0, 1}, DtoR(a)); err == nil {
+			t.Errorf("FAIL angle %f: expected an error", a)
+		}
+	}
+	if _, err := Draft3D(Box3D(V3{10, 10, 10}, 0), V3{}, DtoR(5)); err == nil {
+		t.Error("FAIL expected a pull direction error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ExtrudeRoundedRims(t *testing.T) {
+	s, err := ExtrudeRoundedRims3D(Box2D(V2{4, 4}, 0), 2, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := V2{0.5, 0.5}.Length() - 0.5
+	for _, x := range []struct {
+		p V3
+		d float64
+	}{
+		{V3{0, 0, 0}, -1},             // center
+		{V3{0, 0, 1}, 0},              // top
+		{V3{2, 0, 0}, 0},              // side
+		{V3{2, 2, 0}, 0},              // sharp vertical edge
+		{V3{2, 0, 1}, c},              // rounded rim
+		{V3{3, 0, 2}, math.Sqrt2 + c}, // outside the rim
+		{V3{0, 0, 3}, 2},              // above the top
+	} {
+		if Abs(s.Evaluate(x.p)-x.d) > tolerance {
+			t.Errorf("FAIL %v %f expected %f", x.p, s.Evaluate(x.p), x.d)
+		}
+	}
+	if _, err := ExtrudeRoundedRims3D(Circle2D(1), 2, 1.5); err == nil {
+		t.Error("FAIL expected error")
+	}
+	// ExtrudeRounded3D grows the footprint by the rounding, this doesn't
+	profile := Box2D(V2{4, 4}, 0)
+	s, _ = ExtrudeRoundedRims3D(profile, 2, 0.5)
+	if Abs(ExtrudeRounded3D(profile, 2, 0.5).Evaluate(V3{2, 0, 0})+0.5) > tolerance {
+		t.Error("FAIL extrude rounded footprint")
+	}
+	if c := s.(Parent2).Children2(); len(c) != 1 || c[0] != profile {
+		t.Error("FAIL children")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Stack(t *testing.T) {
+	sections := []StackSection{
+		{Circle2D(5), 2, 0},
+		{Circle2D(2), 3, 1},
+	}
+	s, err := Stack3D(sections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s.BoundingBox()
+	if !EqualFloat64(bb.Min.Z, 0, tolerance) || !EqualFloat64(bb.Max.Z, 5, tolerance) {
+		t.Errorf("FAIL bounding box %v", bb)
+	}
+	for _, x := range []struct {
+		p      V3
+		inside bool
+	}{
+		{V3{4.9, 0, 0.1}, true}, // bottom section
+		{V3{0, 0, 4.9}, true},   // top section
+		{V3{3, 0, 3}, false},    // outside the top section
+		{V3{2.1, 0, 2.1}, true}, // fillet
+		{V3{0, 0, 5.1}, false},  // above the stack
+		{V3{0, 0, -0.1}, false}, // below the stack
+	} {
+		if (s.Evaluate(x.p) < 0) != x.inside {
+			t.Errorf("FAIL %v", x.p)
+		}
+	}
+	sections[1].Round = 0
+	s, _ = Stack3D(sections)
+	if s.Evaluate(V3{2.1, 0, 2.1}) < 0 {
+		t.Error("FAIL unexpected fillet")
+	}
+	sections[0].Height = 0
+	if _, err := Stack3D(sections); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_DensityGrid(t *testing.T) {
+	bb := Box3{V3{0, 0, 0}, V3{2, 4, 6}}
+	n := V3i{3, 5, 7}
+	values := make([]float64, n[0]*n[1]*n[2])
+	f := func(p V3) float64 {
+		return 1 + 2*p.X - p.Y + 0.5*p.Z
+	}
+	for k := 0; k < n[2]; k++ {
+		for j := 0; j < n[1]; j++ {
+			for i := 0; i < n[0]; i++ {
+				values[(k*n[1]+j)*n[0]+i] = f(V3{float64(i), float64(j), float64(k)})
+			}
+		}
+	}
+	g, err := NewDensityGrid(bb, n, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// trilinear interpolation is exact for a linear function
+	for i := 0; i < 100; i++ {
+		p := bb.Random()
+		if Abs(g.Evaluate(p)-f(p)) > tolerance {
+			t.Error("FAIL")
+		}
+	}
+	// clamped outside the grid
+	if Abs(g.Evaluate(V3{-1, -1, -1})-f(V3{0, 0, 0})) > tolerance {
+		t.Error("FAIL")
+	}
+	if _, err := NewDensityGrid(bb, n, values[1:]); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Lattice(t *testing.T) {
+	// gyroid parameters
+	gbox := Box3{V3{-10, -10, -10}, V3{10, 10, 10}}
+	if _, err := GyroidLattice3D(gbox, 5, 1); err != nil {
+		t.Error(err)
+	}
+	for _, x := range []struct {
+		cell, minT, maxT float64
+	}{{0, 1, 1}, {-5, 1, 1}, {5, -1, 1}, {5, 2, 1}} {
+		if _, err := GradedGyroidLattice3D(gbox, x.cell, x.minT, x.maxT, ConstantField3(0.5)); err == nil {
+			t.Errorf("FAIL gyroid %v: expected an error", x)
+		}
+	}
+	// the gyroid sheet passes through the origin
+	g, _ := GyroidLattice3D(gbox, 5, 1)
+	if !EqualFloat64(g.Evaluate(V3{}), -0.5, tolerance) {
+		t.Errorf("FAIL gyroid Evaluate(0) = %f", g.Evaluate(V3{}))
+	}
+
+	// a single strut is a capsule
+	s, err := StrutLattice3D([]V3{{0, 0, 0}, {10, 0, 0}}, [][2]int{{0, 1}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		p V3
+		d float64
+	}{
+		{V3{5, 0, 0}, -1},
+		{V3{5, 3, 0}, 2},
+		{V3{-2, 0, 0}, 1},
+		{V3{12, 0, 0}, 1},
+	} {
+		if d := s.Evaluate(tc.p); !EqualFloat64(d, tc.d, tolerance) {
+			t.Errorf("strut Evaluate(%v) = %f, expected %f", tc.p, d, tc.d)
+		}
+	}
+	bb := Box3{V3{-1, -1, -1}, V3{11, 1, 1}}
+	if !s.BoundingBox().Equals(bb, tolerance) {
+		t.Errorf("strut bounding box %v, expected %v", s.BoundingBox(), bb)
+	}
+
+	// the node blend is within the bounding box
+	s, err = StrutLattice3D([]V3{{0, 0, 0}, {10, 0, 0}, {0, 10, 0}}, [][2]int{{0, 1}, {0, 2}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []float64{0.5, 2} {
+		if k != 0.5 {
+			s.(*StrutLatticeSDF3).SetMin(PolyMin(k))
+		}
+		bb := s.BoundingBox()
+		if s.Evaluate(V3{-1.05, 0, 0}) >= 0 || bb.Min.X > -1.05 {
+			t.Errorf("k %f: the node blend is outside the bounding box %v", k, bb)
+		}
+		for i := 0; i < 1000; i++ {
+			// points on the faces of the bounding box are outside
+			p := V3{randomRange(bb.Min.X, bb.Max.X), randomRange(bb.Min.Y, bb.Max.Y), randomRange(bb.Min.Z, bb.Max.Z)}
+			switch i % 3 {
+			case 0:
+				p.X = bb.Min.X
+			case 1:
+				p.Y = bb.Min.Y
+			case 2:
+				p.Z = bb.Max.Z
+			}
+			if d := s.Evaluate(p); d < -tolerance {
+				t.Errorf("k %f: %v is inside (%f), on the bounding box", k, p, d)
+				break
+			}
+		}
+	}
+
+	// bad strut lattices
+	nodes := []V3{{0, 0, 0}, {1, 0, 0}}
+	for _, tc := range []struct {
+		beams  [][2]int
+		radius float64
+	}{
+		{nil, 1},
+		{[][2]int{{0, 1}}, 0},
+		{[][2]int{{0, 2}}, 1},
+		{[][2]int{{-1, 1}}, 1},
+		{[][2]int{{1, 1}}, 1},
+	} {
+		if _, err := StrutLattice3D(nodes, tc.beams, tc.radius); err == nil {
+			t.Errorf("beams %v radius %f: expected an error", tc.beams, tc.radius)
+		}
+	}
+
+	// unit cells: the beams are all the same length and within the unit cube
+	for _, tc := range []struct {
+		name   string
+		cell   *UnitCell
+		nodes  int
+		beams  int
+		length float64
+	}{
+		{"octet", OctetCell(), 14, 36, math.Sqrt(0.5)},
+		{"kelvin", KelvinCell(), 24, 36, 0.25 * math.Sqrt(2)},
+	} {
+		c := tc.cell
+		if len(c.Nodes) != tc.nodes {
+			t.Errorf("%s: %d nodes, expected %d", tc.name, len(c.Nodes), tc.nodes)
+		}
+		if len(c.Beams) != tc.beams {
+			t.Errorf("%s: %d beams, expected %d", tc.name, len(c.Beams), tc.beams)
+		}
+		for _, b := range c.Beams {
+			n0, n1 := c.Nodes[b[0]], c.Nodes[b[1]]
+			if !EqualFloat64(n0.Sub(n1).Length(), tc.length, tolerance) {
+				t.Errorf("%s: beam %v length %f, expected %f", tc.name, b, n0.Sub(n1).Length(), tc.length)
+			}
+			if n0.Abs().MaxComponent() > 0.5+tolerance || n1.Abs().MaxComponent() > 0.5+tolerance {
+				t.Errorf("%s: beam %v is outside the unit cube", tc.name, b)
+			}
+		}
+	}
+
+	// tiled lattices repeat with the cell size
+	k := &LatticeParms{
+		Cell:      OctetCell(),
+		CellSize:  10,
+		MinRadius: 1,
+		MaxRadius: 1,
+	}
+	box := Box3{V3{-50, -50, -50}, V3{50, 50, 50}}
+	tl, err := TiledLattice3D(k, box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tl.BoundingBox().Equals(box, tolerance) {
+		t.Errorf("tiled lattice bounding box %v, expected %v", tl.BoundingBox(), box)
+	}
+	for i := 0; i < 100; i++ {
+		p := V3{randomRange(-20, 20), randomRange(-20, 20), randomRange(-20, 20)}
+		d0 := tl.Evaluate(p)
+		d1 := tl.Evaluate(p.Add(V3{10, -20, 10}))
+		if !EqualFloat64(d0, d1, tolerance) {
+			t.Errorf("tiled lattice is not periodic at %v: %f != %f", p, d0, d1)
+		}
+	}
+	for _, bad := range []LatticeParms{
+		{Cell: nil, CellSize: 10, MinRadius: 1, MaxRadius: 1},
+		{Cell: OctetCell(), CellSize: 0, MinRadius: 1, MaxRadius: 1},
+		{Cell: OctetCell(), CellSize: 10, MinRadius: 0, MaxRadius: 1},
+		{Cell: OctetCell(), CellSize: 10, MinRadius: 2, MaxRadius: 1},
+	} {
+		bad := bad
+		if _, err := TiledLattice3D(&bad, box); err == nil {
+			t.Errorf("%+v: expected an error", bad)
+		}
+	}
+
+	// a lattice clipped to a solid is empty outside the solid
+	solid := Sphere3D(30)
+	l, err := Lattice3D(solid, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		p := V3{randomRange(-50, 50), randomRange(-50, 50), randomRange(-50, 50)}
+		if solid.Evaluate(p) > 0 && l.Evaluate(p) <= 0 {
+			t.Errorf("lattice is inside at %v, outside the solid", p)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Attractors(t *testing.T) {
+	f0 := PointAttractor3(V3{1, 2, 3}, 2)
+	f1 := CurveAttractor2([]V2{{0, 0}, {4, 0}, {4, 4}}, 1)
+	f2 := RemapField2(f1, 3, 5)
+	tests := []struct {
+		x, y float64
+	}{
+		{f0(V3{1, 2, 3}), 1},
+		{f0(V3{2, 2, 3}), 0.5},
+		{f0(V3{1, 5, 3}), 0},
+		{f1(V2{2, 0}), 1},
+		{f1(V2{4, 2}), 1},
+		{f1(V2{2, 0.5}), 0.5},
+		{f1(V2{2, 2}), 0},
+		{f2(V2{2, 0.5}), 4},
+	}
+	for _, v := range tests {
+		if Abs(v.x-v.y) > tolerance {
+			t.Logf("expected %f, actual %f\n", v.y, v.x)
+			t.Error("FAIL")
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_BlendRegion3D(t *testing.T) {
+	s0 := Box3D(V3{10, 10, 10}, 0)
+	s1 := Transform3D(Box3D(V3{10, 10, 10}, 0), Translate3d(V3{10, 0, 0}))
+	// only blend the crease for z > 0
+	zone := Transform3D(Box3D(V3{20, 20, 10}, 0), Translate3d(V3{0, 0, 5}))
+	s, err := BlendRegion3D(s0, s1, zone, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// outside the zone the union is hard
+	p := V3{5.1, 0, -4}
+	if Abs(s.Evaluate(p)-Min(s0.Evaluate(p), s1.Evaluate(p))) > tolerance {
+		t.Error("FAIL")
+	}
+	// inside the zone the union is smooth
+	p = V3{5, 0, 4}
+	if s.Evaluate(p) >= Min(s0.Evaluate(p), s1.Evaluate(p)) {
+		t.Error("FAIL")
+	}
+	// the blend size must be positive
+	for _, k := range []float64{0, -1} {
+		if _, err := BlendRegion3D(s0, s1, zone, k); err == nil {
+			t.Errorf("k = %f: expected an error", k)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Xor(t *testing.T) {
+	s0 := Circle2D(1)
+	s1 := Transform2D(Circle2D(1), Translate2d(V2{1, 0}))
+	s := Xor2D(s0, s1)
+	tests := []struct {
+		p V2
+		d float64
+	}{
+		{V2{-0.5, 0}, -0.5}, // only in s0
+		{V2{0.5, 0}, 0.5},   // in both
+		{V2{1.5, 0}, -0.5},  // only in s1
+		{V2{3, 0}, 1},       // in neither
+	}
+	for _, v := range tests {
+		d := s.Evaluate(v.p)
+		if Abs(d-v.d) > tolerance {
+			t.Logf("%v expected %f, actual %f\n", v.p, v.d, d)
+			t.Error("FAIL")
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Intersect2D(t *testing.T) {
+	s0 := Circle2D(2)
+	s1 := Transform2D(Circle2D(2), Translate2d(V2{3, 1}))
+	s := Intersect2D(s0, s1)
+	bb := Box2{V2{1, -1}, V2{2, 2}}
+	if !s.BoundingBox().Equals(bb, tolerance) {
+		t.Errorf("bounding box %v, expected %v", s.BoundingBox(), bb)
+	}
+	// the intersection is within the bounding box
+	for i := 0; i < 1000; i++ {
+		p := V2{randomRange(-3, 6), randomRange(-3, 4)}
+		if s.Evaluate(p) < 0 && !bb.Contains(p) {
+			t.Errorf("%v is inside the intersection but outside the bounding box", p)
+		}
+	}
+	// disjoint circles have an empty bounding box
+	s1 = Transform2D(Circle2D(2), Translate2d(V2{10, 0}))
+	s = Intersect2D(s0, s1)
+	if size := s.BoundingBox().Size(); size.X != 0 {
+		t.Errorf("disjoint bounding box size %v, expected zero width", size)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_2D_Operators(t *testing.T) {
+	s0 := Circle2D(1)
+	s1 := Transform2D(Circle2D(1), Translate2d(V2{1, 0}))
+	s := Intersect2D(s0, s1)
+	if Abs(s.Evaluate(V2{0.5, 0})+0.5) > tolerance {
+		t.Error("FAIL")
+	}
+	s = Shell2D(s0, 0.2)
+	if Abs(s.Evaluate(V2{0, 0})-0.9) > tolerance || Abs(s.Evaluate(V2{1, 0})+0.1) > tolerance {
+		t.Error("FAIL")
+	}
+	s = Scale2D(s0, V2{2, 1})
+	if Abs(s.Evaluate(V2{2, 0})) > tolerance || Abs(s.Evaluate(V2{0, 1})) > tolerance {
+		t.Error("FAIL")
+	}
+	// mirror across the line x = y + 2
+	s = Mirror2D(s0, V2{2, 0}, V2{1, -1})
+	if Abs(s.Evaluate(V2{2, -2})+1) > tolerance {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Polyline2D(t *testing.T) {
+	s := Polyline2D([]V2{{0, 0}, {4, 0}, {4, 4}}, 1)
+	tests := []struct {
+		p V2
+		d float64
+	}{
+		{V2{2, 0}, -0.5},
+		{V2{2, 2}, 1.5},
+		{V2{-1, 0}, 0.5},   // round start cap
+		{V2{4, 5}, 0.5},    // round end cap
+		{V2{5, -1}, 0.914}, // round join
+	}
+	for _, v := range tests {
+		d := s.Evaluate(v.p)
+		if Abs(d-v.d) > 0.001 {
+			t.Logf("%v expected %f, actual %f\n", v.p, v.d, d)
+			t.Error("FAIL")
+		}
+	}
+	bb := s.BoundingBox()
+	if !bb.Equals(Box2{V2{-0.5, -0.5}, V2{4.5, 4.5}}, tolerance) {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_QuadraticBezierDistance(t *testing.T) {
+	p0, p1, p2 := V2{0, 0}, V2{1, 2}, V2{3, -1}
+	// brute force distance by dense sampling
+	v := make([]V2, 20001)
+	for i := range v {
+		x := float64(i) / 20000
+		y := 1 - x
+		v[i] = p0.MulScalar(y * y).Add(p1.MulScalar(2 * x * y)).Add(p2.MulScalar(x * x))
+	}
+	for i := 0; i < 100; i++ {
+		p := V2{randomRange(-2, 4), randomRange(-2, 4)}
+		d0 := math.MaxFloat64
+		for j := range v {
+			d0 = Min(d0, p.Sub(v[j]).Length())
+		}
+		d1 := quadraticBezierDistance(p, p0, p1, p2)
+		if Abs(d0-d1) > 1e-3 {
+			t.Logf("%v expected %f, actual %f\n", p, d0, d1)
+			t.Error("FAIL")
+		}
+	}
+	// a straight line
+	if Abs(quadraticBezierDistance(V2{1, 1}, V2{0, 0}, V2{1, 0}, V2{2, 0})-1) > tolerance {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_MultiPolygon2D(t *testing.T) {
+	outer := []V2{{-2, -2}, {2, -2}, {2, 2}, {-2, 2}} // ccw
+	inner := []V2{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} // ccw
+	// even-odd: same winding, the inner contour is a hole
+	s := MultiPolygon2D([][]V2{outer, inner}, EvenOdd)
+	if Abs(s.Evaluate(V2{0, 0})-1) > tolerance || Abs(s.Evaluate(V2{1.5, 0})+0.5) > tolerance {
+		t.Error("FAIL")
+	}
+	// non-zero: same winding fills the hole
+	s = MultiPolygon2D([][]V2{outer, inner}, NonZero)
+	if Abs(s.Evaluate(V2{0, 0})+1) > tolerance {
+		t.Error("FAIL")
+	}
+	// non-zero: reversed winding makes a hole
+	hole := []V2{{-1, -1}, {-1, 1}, {1, 1}, {1, -1}}
+	s = MultiPolygon2D([][]V2{outer, hole}, NonZero)
+	if Abs(s.Evaluate(V2{0, 0})-1) > tolerance || Abs(s.Evaluate(V2{3, 0})-1) > tolerance {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_PolygonBoolean(t *testing.T) {
+	a := [][]V2{{{0, 0}, {2, 0}, {2, 2}, {0, 2}}}
+	b := [][]V2{{{1, 1}, {3, 1}, {3, 3}, {1, 3}}}
+	c := [][]V2{{{0.5, 0.5}, {1.5, 0.5}, {1.5, 1.5}, {0.5, 1.5}}}
+	d := [][]V2{{{2, 0}, {4, 0}, {4, 2}, {2, 2}}}
+	area := func(contours [][]V2) float64 {
+		x := 0.0
+		for _, v := range contours {
+			x += polygonArea(v)
+		}
+		return x
+	}
+	tests := []struct {
+		a, b [][]V2
+		op   PolygonOp
+		n    int
+		area float64
+	}{
+		{a, b, PolygonUnion, 1, 7},
+		{a, b, PolygonIntersection, 1, 1},
+		{a, b, PolygonDifference, 1, 3},
+		{a, c, PolygonDifference, 2, 3},   // hole
+		{a, c, PolygonUnion, 1, 4},        // contained
+		{a, c, PolygonIntersection, 1, 1}, // contained
+		{a, a, PolygonUnion, 1, 4},        // coincident
+		{a, a, PolygonDifference, 0, 0},   // coincident
+		{a, d, PolygonUnion, 1, 8},        // shared edge
+		{a, d, PolygonIntersection, 0, 0}, // shared edge
+	}
+	for i, v := range tests {
+		x := PolygonBoolean(v.a, v.b, v.op)
+		if len(x) != v.n || Abs(area(x)-v.area) > tolerance {
+			t.Logf("test %d: %d contours, area %f\n", i, len(x), area(x))
+			t.Error("FAIL")
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ValidatePolygon(t *testing.T) {
+	tests := []struct {
+		v  []V2
+		ok bool
+	}{
+		{[]V2{{0, 0}, {1, 0}, {1, 1}}, true},
+		{[]V2{{0, 0}, {1, 0}, {1, 1}, {0, 0}}, true},          // closed
+		{[]V2{{0, 0}, {0, 1}, {1, 1}, {1, 0}}, true},          // clockwise
+		{[]V2{{0, 0}, {1, 0}}, false},                         // too few
+		{[]V2{{0, 0}, {1, 0}, {1, 0}, {1, 1}}, false},         // repeated
+		{[]V2{{0, 0}, {1, 0}, {2, 0}}, false},                 // zero area
+		{[]V2{{0, 0}, {1, 1}, {1, 0}, {0, 1}}, false},         // bow tie
+		{[]V2{{0, 0}, {2, 0}, {2, 2}, {1, 0}, {0, 2}}, false}, // touching
+	}
+	for i, v := range tests {
+		err := ValidatePolygon(v.v)
+		if (err == nil) != v.ok {
+			t.Logf("test %d: %v\n", i, err)
+			t.Error("FAIL")
+		}
+	}
+	// clockwise vertices are reordered
+	s, err := MakePolygon2D([]V2{{0, 0}, {0, 1}, {1, 1}, {1, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if polygonArea(s.(*PolySDF2).Vertices()) <= 0 {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Contours(t *testing.T) {
+	// a noisy line
+	var v []V2
+	for i := 0; i < 10; i++ {
+		v = append(v, V2{float64(i), 0.01 * float64(i%2)})
+	}
+	s := SimplifyContour(v, 0.1, false)
+	if len(s) != 2 {
+		t.Logf("simplified to %d vertices\n", len(s))
+		t.Error("FAIL")
+	}
+	sq := []V2{{0, 0}, {0.5, 0}, {1, 0}, {1, 1}, {0, 1}}
+	s = SimplifyContour(sq, 0.1, true)
+	if len(s) != 4 {
+		t.Error("FAIL")
+	}
+	// resample a closed square
+	s = ResampleContour([]V2{{0, 0}, {4, 0}, {4, 4}, {0, 4}}, 1, true)
+	if len(s) != 16 || !s[5].Equals(V2{4, 1}, tolerance) {
+		t.Error("FAIL")
+	}
+	// resample an open line
+	s = ResampleContour([]V2{{0, 0}, {3, 0}}, 1, false)
+	if len(s) != 4 || !s[3].Equals(V2{3, 0}, tolerance) {
+		t.Error("FAIL")
+	}
+	// smoothing an open contour keeps the end points
+	s = SmoothContour([]V2{{0, 0}, {1, 1}, {2, 0}}, 1, false)
+	if !s[0].Equals(V2{0, 0}, tolerance) || !s[1].Equals(V2{1, 0.5}, tolerance) {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_TraceImage(t *testing.T) {
+	// a black 8x8 square with a 2x2 hole on a white background
+	img := image.NewGray(image.Rect(0, 0, 12, 12))
+	for x := 0; x < 12; x++ {
+		for y := 0; y < 12; y++ {
+			c := color.Gray{255}
+			if x >= 2 && x < 10 && y >= 2 && y < 10 && !(x >= 5 && x < 7 && y >= 5 && y < 7) {
+				c = color.Gray{0}
+			}
+			img.SetGray(x, y, c)
+		}
+	}
+	k := TraceParms{
+		Threshold: 0.5,
+		PixelSize: 0.5,
+	}
+	contours, err := TraceImage(img, &k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	area := 0.0
+	for _, c := range contours {
+		area += polygonArea(c)
+	}
+	if len(contours) != 2 || Abs(area-15) > tolerance {
+		t.Logf("%d contours, area %f\n", len(contours), area)
+		t.Error("FAIL")
+	}
+	s, _ := TraceImage2D(img, &k)
+	if s.Evaluate(V2{3, 3}) <= 0 || s.Evaluate(V2{1.5, 1.5}) >= 0 {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_QRCode(t *testing.T) {
+	// Reed-Solomon: version 1-M "HELLO WORLD" (alphanumeric) example
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ec := rsRemainder(data, rsDivisor(10))
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	for i := range ec {
+		if ec[i] != expected[i] {
+			t.Logf("ec %v\n", ec)
+			t.Error("FAIL")
+			break
+		}
+	}
+	// format information
+	if qrFormatBits(QRLow.formatBits(), 0) != 0x77c4 || qrFormatBits(QRHigh.formatBits(), 7) != 0x083b {
+		t.Error("FAIL")
+	}
+	// symbol size
+	m, err := QRCode("https://github.com/deadsy/sdfx", QRMedium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 29 {
+		t.Logf("size %d\n", len(m))
+		t.Error("FAIL")
+	}
+	_, err = QRCode(string(make([]byte, 300)), QRHigh)
+	if err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Code128(t *testing.T) {
+	// every symbol is 11 modules wide (the stop symbol is 13)
+	for i, p := range code128Patterns {
+		n := 0
+		for _, c := range p {
+			n += int(c - '0')
+		}
+		if (i != code128Stop && n != 11) || (i == code128Stop && n != 13) {
+			t.Logf("pattern %d is %d modules\n", i, n)
+			t.Error("FAIL")
+		}
+	}
+	// "PJJ123C": start B, data, checksum 55, stop
+	w, err := Code128("PJJ123C")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w) != 6*9+7 {
+		t.Error("FAIL")
+	}
+	check := ""
+	for _, x := range w[6*8 : 6*9] {
+		check += fmt.Sprintf("%d", x)
+	}
+	if check != code128Patterns[55] {
+		t.Logf("checksum pattern %s\n", check)
+		t.Error("FAIL")
+	}
+	if _, err := Code128("\t"); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_MeshCache(t *testing.T) {
+	model := func(r float64) SDF3 {
+		return Union3D(Sphere3D(r), Box3D(V3{1, 1, 3}, 0))
+	}
+	h0, err := HashSDF3(model(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h1, _ := HashSDF3(model(1))
+	h2, _ := HashSDF3(model(1.1))
+	if h0 != h1 || h0 == h2 {
+		t.Error("FAIL")
+	}
+	// adjacent strings don't collide
+	type pair struct{ A, B string }
+	h3, _ := hashSDF(pair{"ab", "c"})
+	h4, _ := hashSDF(pair{"a", "bc"})
+	if h3 == h4 {
+		t.Error("FAIL adjacent strings")
+	}
+	// closures can't be hashed
+	s := model(1)
+	s.(*UnionSDF3).SetMin(RoundMin(0.1))
+	if _, err := HashSDF3(s); err == nil {
+		t.Error("FAIL")
+	}
+	// cache round trip
+	c, err := NewMeshCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m0, err := c.Mesh(model(1), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m1, err := c.Mesh(model(1), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m0) == 0 || len(m0) != len(m1) || m0[0].V != m1[0].V {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Instances(t *testing.T) {
+	ball := Sphere3D(1)
+	s := Union3D(
+		Array3D(ball, V3i{2, 2, 1}, V3{3, 3, 0}),
+		Transform3D(ball, Translate3d(V3{0, 0, 5})),
+		RotateCopy3D(Transform3D(ball, Translate3d(V3{10, 0, 0})), 4),
+	)
+	x := FindInstances3(s)
+	if len(x) != 9 {
+		t.Fatalf("%d instances\n", len(x))
+	}
+	for _, i := range x {
+		if i.SDF != ball {
+			t.Error("FAIL")
+		}
+	}
+	if !x[3].Matrix.MulPosition(V3{}).Equals(V3{3, 3, 0}, tolerance) ||
+		!x[4].Matrix.MulPosition(V3{}).Equals(V3{0, 0, 5}, tolerance) ||
+		!x[6].Matrix.MulPosition(V3{}).Equals(V3{0, 10, 0}, tolerance) {
+		t.Error("FAIL")
+	}
+	// blended arrays are not decomposed
+	a := Array3D(ball, V3i{2, 1, 1}, V3{1.5, 0, 0})
+	a.(*ArraySDF3).SetMin(PolyMin(0.5))
+	if len(FindInstances3(a)) != 1 {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Assembly(t *testing.T) {
+	a := NewAssembly()
+	a.Add("base", Box3D(V3{10, 10, 2}, 0), Identity3d())
+	p, _ := a.Add("post", Cylinder3D(10, 1, 0), Translate3d(V3{0, 0, 6}))
+	if _, err := a.Add("post", Sphere3D(1), Identity3d()); err == nil {
+		t.Error("FAIL")
+	}
+	if x, err := a.Part("post"); err != nil || x != p {
+		t.Error("FAIL")
+	}
+	if !a.BoundingBox().Equals(Box3{V3{-5, -5, -1}, V3{5, 5, 11}}, tolerance) {
+		t.Error("FAIL")
+	}
+	// exploded view
+	e := a.Exploded(1)
+	x, _ := e.Part("post")
+	if !x.Matrix.MulPosition(V3{}).Equals(V3{0, 0, 7}, tolerance) || !p.Matrix.MulPosition(V3{}).Equals(V3{0, 0, 6}, tolerance) {
+		t.Error("FAIL")
+	}
+	// the exploded parts have their own anchors
+	p.AddAnchor("top", V3{0, 0, 5}, V3{0, 0, 1})
+	x, _ = a.Exploded(1).Part("post")
+	x.AddAnchor("side", V3{1, 0, 0}, V3{1, 0, 0})
+	if _, err := x.LocalAnchor("top"); err != nil {
+		t.Error(err)
+	}
+	if _, err := p.LocalAnchor("side"); err == nil {
+		t.Error("FAIL anchor shared with the exploded part")
+	}
+	// automatic mesh resolution
+	dir := t.TempDir()
+	if err := a.RenderSTLs(0, dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"base", "post"} {
+		mesh, err := LoadSTL(dir + "/" + name + ".stl")
+		if err != nil || len(mesh) == 0 {
+			t.Errorf("FAIL %s mesh %d %v", name, len(mesh), err)
+		}
+	}
+	if err := a.Render3MF(0, dir+"/assembly.3mf"); err != nil {
+		t.Error(err)
+	}
+	if err := Render3MFObjects([]SDF3{Sphere3D(1)}, 0, dir+"/objects.3mf"); err != nil {
+		t.Error(err)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Materials(t *testing.T) {
+	red := &Material{"red", color.RGBA{255, 0, 0, 255}}
+	blue := &Material{"blue", color.RGBA{0, 0, 255, 255}}
+	s0 := Material3D(Sphere3D(1), red)
+	s1 := Transform3D(Material3D(Box3D(V3{1, 1, 1}, 0), blue), Translate3d(V3{3, 0, 0}))
+	s2 := Material3D(Sphere3D(0.5), red)
+	s3 := Cylinder3D(1, 0.2, 0)
+	s := Union3D(s0, s1, Transform3D(Union3D(s2, s3), Translate3d(V3{-3, 0, 0})))
+	// the material doesn't change the distance
+	p := V3{0.3, 0.2, 0.1}
+	if s0.Evaluate(p) != Sphere3D(1).Evaluate(p) {
+		t.Error("FAIL")
+	}
+	bodies := FindMaterials3(s)
+	if len(bodies) != 3 || bodies[0].Material != red || bodies[1].Material != blue || bodies[2].Material != nil {
+		t.Error("FAIL")
+	}
+	// the red body has both spheres
+	if bodies[0].SDF.Evaluate(V3{-3, 0, 0}) >= 0 || bodies[0].SDF.Evaluate(V3{}) >= 0 {
+		t.Error("FAIL")
+	}
+	// the default body is the translated cylinder
+	if bodies[2].SDF.Evaluate(V3{-3, 0, 0.4}) >= 0 || bodies[2].SDF.Evaluate(V3{0, 0, 0.4}) <= 0 {
+		t.Error("FAIL")
+	}
+	// instances carry their material
+	for _, x := range FindInstances3(s) {
+		if x.SDF == s3 && x.Material != nil {
+			t.Error("FAIL")
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_SplitByRegion(t *testing.T) {
+	s := Box3D(V3{10, 2, 2}, 0)
+	r0 := Transform3D(Box3D(V3{4, 4, 4}, 0), Translate3d(V3{-3, 0, 0}))
+	r1 := Transform3D(Box3D(V3{4, 4, 4}, 0), Translate3d(V3{-1, 0, 0}))
+	bodies := SplitByRegion(s, []SDF3{r0, r1})
+	if len(bodies) != 3 {
+		t.Error("FAIL")
+	}
+	// each point of the SDF3 is inside exactly one body
+	for _, x := range []float64{-4.5, -2, -0.5, 0.5, 4.5} {
+		n := 0
+		for _, b := range bodies {
+			if b.Evaluate(V3{x, 0, 0}) < 0 {
+				n++
+			}
+		}
+		if n != 1 {
+			t.Errorf("FAIL x %f in %d bodies", x, n)
+		}
+	}
+	// the overlap goes to the first region
+	if bodies[0].Evaluate(V3{-2, 0, 0}) >= 0 || bodies[1].Evaluate(V3{-0.5, 0, 0}) >= 0 || bodies[2].Evaluate(V3{4.5, 0, 0}) >= 0 {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Anchors(t *testing.T) {
+	asm := NewAssembly()
+	base, _ := asm.Add("base", Box3D(V3{10, 10, 2}, 0), RotateZ(DtoR(90)))
+	base.AddAnchor("top", V3{3, 0, 1}, V3{0, 0, 1})
+	if base.AddAnchor("top", V3{}, V3{}) == nil {
+		t.Error("FAIL")
+	}
+	// a peg with its anchor on the end face, pointing down
+	peg, _ := asm.Add("peg", Cylinder3D(4, 1, 0), Identity3d())
+	peg.AddAnchor("bottom", V3{0, 0, -2}, V3{0, 0, -1})
+	if err := asm.Mate("base", "top", "peg", "bottom"); err != nil {
+		t.Error("FAIL")
+	}
+	a, _ := base.Anchor("top")
+	b, _ := peg.Anchor("bottom")
+	if !a.Position.Equals(V3{0, 3, 1}, tolerance) || !b.Position.Equals(a.Position, tolerance) || !b.Axis.Equals(a.Axis.Neg(), tolerance) {
+		t.Error("FAIL")
+	}
+	// the peg sits on the base
+	if !peg.Placed().BoundingBox().Equals(Box3{V3{-1, 2, 1}, V3{1, 4, 5}}, tolerance) {
+		t.Error("FAIL")
+	}
+	// mating with a flipped axis
+	m := Mate(Anchor{"", V3{1, 2, 3}, V3{1, 0, 0}}, Anchor{"", V3{0, 0, 0}, V3{1, 0, 0}})
+	if !m.MulPosition(V3{1, 0, 0}).Equals(V3{0, 2, 3}, tolerance) {
+		t.Error("FAIL")
+	}
+	if _, err := peg.Anchor("top"); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Measure(t *testing.T) {
+	a := Sphere3D(1)
+	b := Transform3D(Sphere3D(1), Translate3d(V3{3, 4, 0}))
+	d, pa, pb := Distance3(a, b)
+	if Abs(d-3) > 1e-4 || !pa.Equals(V3{0.6, 0.8, 0}, 1e-4) || !pb.Equals(V3{2.4, 3.2, 0}, 1e-4) {
+		t.Errorf("FAIL %f %v %v", d, pa, pb)
+	}
+	// box to box clearance
+	c := Transform3D(Box3D(V3{2, 2, 2}, 0), Translate3d(V3{0, 0, 3.5}))
+	d, _, _ = Distance3(Box3D(V3{4, 4, 2}, 0), c)
+	if Abs(d-1.5) > 1e-4 {
+		t.Errorf("FAIL %f", d)
+	}
+	// overlapping
+	d, _, _ = Distance3(a, Transform3D(Sphere3D(1), Translate3d(V3{1, 0, 0})))
+	if d > 0 {
+		t.Error("FAIL")
+	}
+	p := ClosestPoint3(Box3D(V3{2, 2, 2}, 0), V3{3, 0.5, 0})
+	if !p.Equals(V3{1, 0.5, 0}, 1e-6) {
+		t.Error("FAIL")
+	}
+	if Abs(AnchorAngle(Anchor{Axis: V3{1, 0, 0}}, Anchor{Axis: V3{0, 2, 0}})-Pi/2) > tolerance {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_MeshVolume(t *testing.T) {
+	mesh := RenderMesh(Box3D(V3{2, 3, 4}, 0), 50)
+	if Abs(MeshVolume(mesh)-24) > 0.1 {
+		t.Error("FAIL")
+	}
+	if !MeshBoundingBox(mesh).Equals(Box3{V3{-1, -1.5, -2}, V3{1, 1.5, 2}}, 0.01) {
+		t.Error("FAIL")
+	}
+	// STL round trip
+	path := t.TempDir() + "/box.stl"
+	if err := SaveSTL(path, mesh); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadSTL(path)
+	if err != nil || len(m) != len(mesh) || !m[0].V[1].Equals(mesh[0].V[1], 1e-6) {
+		t.Error("FAIL")
+	}
+	// a huge triangle count in the header
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, 80)
+	f.Close()
+	if _, err := LoadSTL(path); err == nil {
+		t.Error("FAIL expected error for a bad triangle count")
+	}
+	// a truncated file
+	SaveSTL(path, mesh)
+	if err := os.Truncate(path, 84+50*int64(len(mesh))-1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSTL(path); err == nil {
+		t.Error("FAIL expected error for a truncated file")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_SortMesh(t *testing.T) {
+	a := NewTriangle3(V3{1, 0, 0}, V3{0, 1, 0}, V3{0, 0, 1})
+	b := NewTriangle3(V3{0, 0, -1}, V3{1, 0, 0}, V3{0, 1, 0})
+	mesh := []*Triangle3{a, b}
+	n := a.Normal()
+	SortMesh(mesh)
+	if mesh[0] != b || mesh[1] != a {
+		t.Error("FAIL")
+	}
+	// the least vertex is first and the winding is unchanged
+	if a.V[0] != (V3{0, 0, 1}) || !a.Normal().Equals(n, tolerance) {
+		t.Error("FAIL")
+	}
+	// rendering is repeatable
+	DeterministicMesh = true
+	defer func() { DeterministicMesh = false }()
+	s := Union3D(Sphere3D(1), Box3D(V3{1, 1, 3}, 0.1))
+	m0 := RenderMesh(s, 20)
+	m1 := RenderMesh(s, 20)
+	for i := range m0 {
+		if m0[i].V != m1[i].V {
+			t.Error("FAIL")
+			break
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_MeshDiff(t *testing.T) {
+	a := RenderMesh(Sphere3D(1), 20)
+	b := RenderMesh(Sphere3D(1), 20)
+	// reverse the triangle order
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	if MeshHash(a) != MeshHash(b) {
+		t.Error("FAIL")
+	}
+	r := MeshDiff(a, b, 1e-6)
+	if !r.Equal() || r.Hausdorff > 1e-9 || r.VolumeDelta > 1e-9 {
+		t.Errorf("FAIL %s", r)
+	}
+	// a bump on the surface
+	c := RenderMesh(Union3D(Sphere3D(1), Transform3D(Sphere3D(0.3), Translate3d(V3{1, 0, 0}))), 20)
+	if MeshHash(a) == MeshHash(c) {
+		t.Error("FAIL")
+	}
+	r = MeshDiff(a, c, 0.05)
+	if r.Equal() || Abs(r.Hausdorff-0.3) > 0.05 || r.VolumeDelta <= 0 || r.Region.Min.X < 0.5 {
+		t.Errorf("FAIL %s", r)
+	}
+	// point to triangle distance
+	tri := NewTriangle3(V3{0, 0, 0}, V3{1, 0, 0}, V3{0, 1, 0})
+	if !closestPointTriangle(V3{0.25, 0.25, 2}, tri).Equals(V3{0.25, 0.25, 0}, tolerance) ||
+		!closestPointTriangle(V3{2, 2, 0}, tri).Equals(V3{0.5, 0.5, 0}, tolerance) ||
+		!closestPointTriangle(V3{-1, -1, 1}, tri).Equals(V3{0, 0, 0}, tolerance) {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Profiler(t *testing.T) {
+	sphere := Sphere3D(1)
+	box := Box3D(V3{1, 1, 1}, 0)
+	s := Union3D(sphere, Transform3D(box, Translate3d(V3{2, 0, 0})), Extrude3D(Circle2D(0.5), 3))
+	p := NewProfiler()
+	ps := p.Tree3(s)
+	pt := V3{0.5, 0.2, 0.1}
+	if ps.Evaluate(pt) != s.Evaluate(pt) || ps.BoundingBox() != s.BoundingBox() {
+		t.Error("FAIL")
+	}
+	for i := 0; i < 9; i++ {
+		ps.Evaluate(pt)
+	}
+	// the original tree is unchanged
+	if s.(*UnionSDF3).sdf[0] != sphere {
+		t.Error("FAIL")
+	}
+	nodes := p.Report()
+	names := map[string]uint64{}
+	for _, n := range nodes {
+		names[n.Name] = n.Calls
+	}
+	if len(nodes) != 6 || names["UnionSDF3"] != 10 || names["UnionSDF3[0]/SphereSDF3"] != 10 ||
+		names["UnionSDF3[1]/TransformSDF3/BoxSDF3"] != 10 || names["UnionSDF3[2]/ExtrudeSDF3/CircleSDF2"] != 10 {
+		t.Errorf("FAIL %v", names)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Tree(t *testing.T) {
+	s := Union3D(Sphere3D(1), Transform3D(Extrude3D(Circle2D(0.5), 3), RotateX(1)))
+	var names []string
+	Walk(s, func(n interface{}, depth int) bool {
+		names = append(names, fmt.Sprintf("%d%s", depth, NodeName(n)))
+		return true
+	})
+	if fmt.Sprint(names) != "[0UnionSDF3 1SphereSDF3 1TransformSDF3 2ExtrudeSDF3 3CircleSDF2]" {
+		t.Errorf("FAIL %v", names)
+	}
+	// prune the walk
+	n := 0
+	Walk(s, func(x interface{}, depth int) bool {
+		n++
+		_, ok := x.(*TransformSDF3)
+		return !ok
+	})
+	if n != 3 {
+		t.Error("FAIL")
+	}
+	// the thread profile of a threaded rod is reachable
+	thread := ISOThread(5, 1, "external")
+	rod, err := ThreadedRod3D(&ThreadedRodParms{Thread: thread, Length: 10, Pitch: 1, Starts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	Walk(rod, func(x interface{}, depth int) bool {
+		found = found || x == thread
+		return true
+	})
+	if !found {
+		t.Error("FAIL threaded rod profile")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Options(t *testing.T) {
+	a := Sphere3D(1)
+	b := Transform3D(Sphere3D(1), Translate3d(V3{1.5, 0, 0}))
+	p := V3{0.75, 0.9, 0}
+	hard := Union3D(a, b)
+	smooth := NewUnionSDF3(a, b, WithSmooth(0.5))
+	if smooth.Evaluate(p) >= hard.Evaluate(p) {
+		t.Error("FAIL")
+	}
+	// the copy doesn't change the original
+	u := hard.(*UnionSDF3)
+	if u.With(WithMin(PolyMin(0.5))).Evaluate(p) != smooth.Evaluate(p) || u.Evaluate(p) != hard.Evaluate(p) {
+		t.Error("FAIL")
+	}
+	// rebuild the tree with a bigger sphere
+	s := Difference3D(Union3D(Transform3D(a, Translate3d(V3{0, 0, 2})), Box3D(V3{1, 1, 1}, 0)), Sphere3D(0.2))
+	s2, err := Rebuild3(s, func(n SDF3) SDF3 {
+		if n == a {
+			return Sphere3D(2)
+		}
+		return n
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.BoundingBox().Max.Z != 4 || s.BoundingBox().Max.Z != 3 {
+		t.Error("FAIL")
+	}
+	if s2.Evaluate(V3{1.5, 0, 2}) >= 0 || s.Evaluate(V3{1.5, 0, 2}) <= 0 {
+		t.Error("FAIL")
+	}
+	// unchanged trees are returned as is
+	s3, _ := Rebuild3(s, func(n SDF3) SDF3 { return n })
+	if s3 != s {
+		t.Error("FAIL")
+	}
+	// removing a child leaves a one child union, which is the child
+	s4, err := Rebuild3(hard, func(n SDF3) SDF3 {
+		if n == b {
+			return nil
+		}
+		return n
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s4 != a {
+		t.Error("FAIL")
+	}
+	// draft and variable offset nodes are rebuilt with the new child
+	box := Box3D(V3{2, 2, 2}, 0)
+	draft, _ := Draft3D(box, V3{0, 0, 1}, DtoR(10))
+	for _, n := range []SDF3{
+		draft,
+		VariableOffset3D(box, ConstantField3(0.5), 0.5),
+	} {
+		s5, err := Rebuild3(n, func(n SDF3) SDF3 {
+			if n == box {
+				return Box3D(V3{4, 4, 4}, 0)
+			}
+			return n
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if NodeName(s5) != NodeName(n) {
+			t.Errorf("rebuilt %s as %s", NodeName(n), NodeName(s5))
+		}
+		if s5.BoundingBox().Size().X <= n.BoundingBox().Size().X {
+			t.Errorf("%s: bounding box not updated", NodeName(n))
+		}
+		if s5.Evaluate(V3{1.5, 0, -1.5}) >= 0 || n.Evaluate(V3{1.5, 0, -1.5}) <= 0 {
+			t.Errorf("%s: child not replaced", NodeName(n))
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ExtrudeOptions(t *testing.T) {
+	c := Circle2D(1)
+	for _, x := range []struct {
+		s      SDF3
+		z0, z1 float64
+	}{
+		{NewExtrudeSDF3(c, 2), -1, 1},
+		{NewExtrudeSDF3(c, -2, WithCentered()), -1, 1},
+		{NewExtrudeSDF3(c, 2, WithBase(0)), 0, 2},
+		{NewExtrudeSDF3(c, -2, WithBase(1)), -1, 1},
+		{NewExtrudeSDF3(c, 2, WithBase(0), WithEndOffsets(0.5, 1)), -0.5, 3},
+		{NewExtrudeSDF3(c, 2, WithEndOffsets(-0.5, 0)), -0.5, 1},
+	} {
+		bb := x.s.BoundingBox()
+		if !EqualFloat64(bb.Min.Z, x.z0, tolerance) || !EqualFloat64(bb.Max.Z, x.z1, tolerance) {
+			t.Errorf("FAIL z range %f %f expected %f %f", bb.Min.Z, bb.Max.Z, x.z0, x.z1)
+		}
+		if Abs(x.s.Evaluate(V3{0, 0, x.z1 + 1})-1) > tolerance {
+			t.Errorf("FAIL top distance")
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_RevolveOptions(t *testing.T) {
+	c := Transform2D(Circle2D(1), Translate2d(V2{5, 0}))
+	s := NewRevolveSDF3(c, 0.5*Pi, WithStartAngle(0.5*Pi))
+	bb := s.BoundingBox()
+	if !bb.Min.Equals(V3{-6, 0, -1}, tolerance) || !bb.Max.Equals(V3{0, 6, 1}, tolerance) {
+		t.Errorf("FAIL bounding box %v", bb)
+	}
+	r := NewRevolveSDF3(c, 0.5*Pi, WithStartAngle(0.5*Pi), WithRoundEnds())
+	bb = r.BoundingBox()
+	if !bb.Min.Equals(V3{-6, -1, -1}, tolerance) || !bb.Max.Equals(V3{1, 6, 1}, tolerance) {
+		t.Errorf("FAIL bounding box %v", bb)
+	}
+	for _, x := range []struct {
+		p             V3
+		inside, round bool
+	}{
+		{V3{0.1, 5, 0}, false, true},   // beyond the start
+		{V3{-0.1, 5, 0}, true, true},   // after the start
+		{V3{-5, 0.1, 0}, true, true},   // before the end
+		{V3{-5, -0.5, 0}, false, true}, // beyond the end
+		{V3{0.9, 5, 0}, false, true},   // inside the rounded start
+		{V3{1.1, 5, 0}, false, false},  // outside the rounded start
+		{V3{5, 0, 0}, false, false},    // outside the revolution
+	} {
+		if (s.Evaluate(x.p) < 0) != x.inside || (r.Evaluate(x.p) < 0) != x.round {
+			t.Errorf("FAIL %v", x.p)
+		}
+	}
+	// a full revolution ignores the options
+	f := NewRevolveSDF3(c, 0, WithStartAngle(1), WithRoundEnds())
+	if f.Evaluate(V3{5, 0, 0}) > 0 || f.Evaluate(V3{0, -5, 0}) > 0 {
+		t.Error("FAIL full revolution")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_SorAxis(t *testing.T) {
+	// a torus (major radius 3, minor radius 1) about the x-axis through (0, 2, 0)
+	c := Transform2D(Circle2D(1), Translate2d(V2{3, 0}))
+	s, err := RevolveAxis3D(c, V3{0, 2, 0}, V3{1, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range []struct {
+		p V3
+		d float64
+	}{
+		{V3{0, 5, 0}, -1},
+		{V3{0, 2, 3}, -1},
+		{V3{0, 2, -3}, -1},
+		{V3{0, 2, 0}, 2},
+		{V3{2, 5, 0}, 1},
+	} {
+		if Abs(s.Evaluate(x.p)-x.d) > tolerance {
+			t.Errorf("FAIL %v %f expected %f", x.p, s.Evaluate(x.p), x.d)
+		}
+	}
+	// the profile y is along the axis
+	m := AxisTransform(V3{1, 1, 1}, V3{0, -1, 0})
+	if !m.MulPosition(V3{0, 0, 2}).Equals(V3{1, -1, 1}, tolerance) {
+		t.Error("FAIL axis transform")
+	}
+	if _, err := RevolveAxis3D(c, V3{}, V3{}); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_RotateCopy(t *testing.T) {
+	ball := Transform3D(Sphere3D(1), Translate3d(V3{10, 0, 0}))
+	// 3 copies over a quarter circle
+	s := RotateCopyArc3D(ball, 3, 0.5*Pi)
+	bb := s.BoundingBox()
+	if !bb.Min.Equals(V3{-1, -1, -1}, tolerance) || !bb.Max.Equals(V3{11, 11, 1}, tolerance) {
+		t.Errorf("FAIL bounding box %v", bb)
+	}
+	c := math.Sqrt(50)
+	for _, x := range []struct {
+		p      V3
+		inside bool
+	}{
+		{V3{10, 0, 0}, true},
+		{V3{c, c, 0}, true},
+		{V3{0, 10, 0}, true},
+		{V3{-10, 0, 0}, false},
+		{V3{0, -10, 0}, false},
+	} {
+		if (s.Evaluate(x.p) < 0) != x.inside {
+			t.Errorf("FAIL %v", x.p)
+		}
+	}
+	if Abs(s.Evaluate(V3{-10, 1, 0})-(V2{-10, 1}.Sub(V2{0, 10}).Length()-1)) > tolerance {
+		t.Error("FAIL distance beyond the arc")
+	}
+	if n := len(FindInstances3(s)); n != 3 {
+		t.Errorf("FAIL %d instances", n)
+	}
+	// 2 copies in a full circle have a tight bounding box
+	b2 := RotateCopyArc2D(Transform2D(Circle2D(1), Translate2d(V2{10, 0})), 2, 0).BoundingBox()
+	if !b2.Min.Equals(V2{-11, -1}, tolerance) || !b2.Max.Equals(V2{11, 1}, tolerance) {
+		t.Errorf("FAIL bounding box %v", b2)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_PolarArray(t *testing.T) {
+	// the box points along +x from the origin
+	box := Transform3D(Box3D(V3{2, 1, 1}, 0), Translate3d(V3{1, 0, 0}))
+	out := NewPolarArraySDF3(box, 4, 10)
+	in := NewPolarArraySDF3(box, 4, 10, WithPolarFacing(FaceInward))
+	fixed := NewPolarArraySDF3(box, 4, 10, WithPolarFacing(FaceFixed))
+	arc := NewPolarArraySDF3(box, 3, 10, WithPolarStart(0.5*Pi), WithPolarArc(Pi))
+	for _, x := range []struct {
+		s      SDF3
+		p      V3
+		inside bool
+	}{
+		{out, V3{0, 11, 0}, true},
+		{out, V3{0, 9, 0}, false},
+		{in, V3{0, 9, 0}, true},
+		{in, V3{0, 11, 0}, false},
+		{fixed, V3{1, 10, 0}, true},
+		{fixed, V3{0, 11, 0}, false},
+		{arc, V3{0, 11, 0}, true},
+		{arc, V3{-11, 0, 0}, true},
+		{arc, V3{0, -11, 0}, true},
+		{arc, V3{11, 0, 0}, false},
+	} {
+		if (x.s.Evaluate(x.p) < 0) != x.inside {
+			t.Errorf("FAIL %v", x.p)
+		}
+	}
+	// a spiral staircase of copies
+	spiral := InstanceArray3D(box, 8, func(i int) M44 {
+		return RotateZ(float64(i) * 0.25 * Pi).Mul(Translate3d(V3{5, 0, float64(i)}))
+	})
+	if spiral.Evaluate(V3{0, 6, 2}) > 0 || spiral.Evaluate(V3{0, 6, 0}) < 0 {
+		t.Error("FAIL spiral")
+	}
+	if n := len(FindInstances3(spiral)); n != 8 {
+		t.Errorf("FAIL %d instances", n)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ArrayOptions(t *testing.T) {
+	// brick layout
+	brick := Box2D(V2{4, 1}, 0)
+	s := NewArraySDF2(brick, V2i{3, 2}, V2{5, 2}, WithRowOffset(2.5))
+	bb := s.BoundingBox()
+	if !bb.Min.Equals(V2{-2, -0.5}, tolerance) || !bb.Max.Equals(V2{14.5, 2.5}, tolerance) {
+		t.Errorf("FAIL bounding box %v", bb)
+	}
+	if s.Evaluate(V2{2.5, 2}) > 0 || s.Evaluate(V2{0, 2}) < 0 || s.Evaluate(V2{0, 0}) > 0 {
+		t.Error("FAIL brick layout")
+	}
+	// alternate copies mirrored along x
+	block := Transform2D(Box2D(V2{2, 1}, 0), Translate2d(V2{1, 0}))
+	s = NewArraySDF2(block, V2i{2, 1}, V2{10, 0}, WithMirrorAlternate(true, false, false))
+	bb = s.BoundingBox()
+	if !bb.Min.Equals(V2{-2, -0.5}, tolerance) || !bb.Max.Equals(V2{12, 0.5}, tolerance) {
+		t.Errorf("FAIL bounding box %v", bb)
+	}
+	if s.Evaluate(V2{1, 0}) > 0 || s.Evaluate(V2{9, 0}) > 0 || s.Evaluate(V2{11, 0}) < 0 {
+		t.Error("FAIL mirrored layout")
+	}
+	// 3D, with mirroring and instances
+	box := Transform3D(Box3D(V3{2, 1, 1}, 0), Translate3d(V3{1, 0, 0}))
+	s3 := NewArraySDF3(box, V3i{2, 1, 1}, V3{10, 0, 0}, WithMirrorAlternate(true, false, false))
+	if s3.Evaluate(V3{9, 0, 0}) > 0 || s3.Evaluate(V3{11, 0, 0}) < 0 {
+		t.Error("FAIL mirrored layout")
+	}
+	instances := FindInstances3(s3)
+	if len(instances) != 2 || !instances[1].Matrix.MulPosition(V3{0, 0, 0}).Equals(V3{9, 0, 0}, tolerance) {
+		t.Error("FAIL mirrored instances")
+	}
+	// rebuilding keeps the options
+	r, err := Rebuild3(s3, func(s SDF3) SDF3 {
+		if s == box {
+			return Transform3D(Box3D(V3{2, 1, 1}, 0), Translate3d(V3{1, 0, 0}))
+		}
+		return s
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Evaluate(V3{9, 0, 0}) > 0 {
+		t.Error("FAIL rebuilt array")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ParameterSweep(t *testing.T) {
+	k := &SweepParms{
+		Build: func(p map[string]float64) (SDF3, error) {
+			if p["size"] < 0 {
+				return nil, fmt.Errorf("size < 0")
+			}
+			return Box3D(V3{p["size"], p["size"], p["height"]}, 0), nil
+		},
+		Grid: map[string][]float64{
+			"size":   {-1, 1, 2},
+			"height": {1, 3},
+		},
+		Metrics:   append([]SweepMetric{VolumeMetric()}, SizeMetrics()...),
+		MeshCells: 30,
+	}
+	var b strings.Builder
+	results, err := ParameterSweep(k, &b)
+	if err != nil || len(results) != 6 {
+		t.Fatal("FAIL")
+	}
+	// height changes slowest (sorted by name)
+	r := results[5]
+	if r.Params["height"] != 3 || r.Params["size"] != 2 || Abs(r.Metrics[0]-12) > 0.2 || Abs(r.Metrics[3]-3) > 0.01 {
+		t.Errorf("FAIL %v", r)
+	}
+	if results[0].Err == nil || results[3].Err == nil {
+		t.Error("FAIL")
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 7 || lines[0] != "variant,height,size,volume,size_x,size_y,size_z,error" || lines[1] != "0,1,-1,,,,,size < 0" {
+		t.Errorf("FAIL %v", lines[:2])
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Optimize(t *testing.T) {
+	build := func(p map[string]float64) (SDF3, error) {
+		return Box3D(V3{p["x"], p["y"], 1}, 0), nil
+	}
+	// sensitivity of the volume
+	d, err := Sensitivities(build, map[string]float64{"x": 2, "y": 3}, []SweepMetric{VolumeMetric()}, nil, 40)
+	if err != nil || Abs(d["x"][0]-3) > 0.1 || Abs(d["y"][0]-2) > 0.1 {
+		t.Errorf("FAIL %v", d)
+	}
+	// minimize the volume with a minimum x size
+	k := &OptimizeParms{
+		Build:       build,
+		Params:      map[string]float64{"x": 4, "y": 2},
+		Bounds:      map[string][2]float64{"y": {2, 5}},
+		Objective:   VolumeMetric(),
+		Constraints: []OptimizeConstraint{{SizeMetrics()[0], 2}},
+		MeshCells:   20,
+	}
+	p, v, err := Optimize(k)
+	if err != nil || Abs(p["x"]-2) > 0.05 || p["y"] != 2 || Abs(v-4) > 0.2 {
+		t.Errorf("FAIL %v %f %v", p, v, err)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Collision(t *testing.T) {
+	// convex hull of a cube with an interior point
+	var p []V3
+	for _, x := range []float64{-1, 1} {
+		for _, y := range []float64{-1, 1} {
+			for _, z := range []float64{-1, 1} {
+				p = append(p, V3{x, y, z})
+			}
+		}
+	}
+	p = append(p, V3{0.1, 0.2, 0.3}, V3{1, 0, 0})
+	hull := ConvexHull(p)
+	if Abs(MeshVolume(hull)-8) > tolerance {
+		t.Errorf("FAIL %f", MeshVolume(hull))
+	}
+	// an L shape decomposes into (about) two boxes
+	l := Union3D(Box3D(V3{4, 1, 1}, 0), Transform3D(Box3D(V3{1, 4, 1}, 0), Translate3d(V3{-1.5, 1.5, 0})))
+	hulls, err := ConvexDecomposition(l, &ConvexParms{MeshCells: 30, MaxConcavity: 0.05})
+	if err != nil || len(hulls) < 2 || len(hulls) > 4 {
+		t.Errorf("FAIL %d %v", len(hulls), err)
+	}
+	v := 0.0
+	for _, h := range hulls {
+		v += MeshVolume(h)
+	}
+	if Abs(v-7) > 0.5 {
+		t.Errorf("FAIL %f", v)
+	}
+	// primitive fits
+	c := Transform3D(Cylinder3D(6, 1, 1), RotateY(DtoR(90)))
+	sphere := FitSphere3(c, 40)
+	if Abs(sphere.Radius-3) > 0.1 || sphere.Center.Length() > 0.1 {
+		t.Errorf("FAIL %v", sphere)
+	}
+	box := FitBox3(c, 40)
+	if Abs(Abs(box.Axes[0].X)-1) > 1e-3 || Abs(box.HalfSize.X-3) > 0.1 || Abs(box.HalfSize.Y-1) > 0.1 {
+		t.Errorf("FAIL %v", box)
+	}
+	capsule := FitCapsule3(c, 40)
+	if Abs(capsule.Radius-1) > 0.1 || Abs(capsule.P1.Sub(capsule.P0).Length()-4) > 0.2 {
+		t.Errorf("FAIL %v", capsule)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_SampleSurface(t *testing.T) {
+	s := Box3D(V3{2, 4, 6}, 0)
+	p, n := SampleSurfaceNormals(s, 2000)
+	if len(p) != 2000 || len(n) != 2000 {
+		t.Fatal("FAIL")
+	}
+	// count the points on the +z face (area 8 of total area 88)
+	top := 0
+	for i := range p {
+		if Abs(s.Evaluate(p[i])) > 1e-4 {
+			t.Fatal("FAIL")
+		}
+		if Abs(p[i].Z-3) < 1e-6 {
+			top++
+			if !n[i].Equals(V3{0, 0, 1}, 1e-3) {
+				t.Fatal("FAIL")
+			}
+		}
+	}
+	if top < 100 || top > 270 {
+		t.Errorf("FAIL %d", top)
+	}
+	path := t.TempDir() + "/box.ply"
+	if err := SavePLY(path, p, n); err != nil {
+		t.Error(err)
+	}
+	// no surface, no samples
+	p, n = SampleSurfaceNormals(Offset3D(Sphere3D(1), -2), 10)
+	if len(p) != 0 || len(n) != 0 {
+		t.Errorf("%d samples on an empty surface", len(p))
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_PoissonDisk(t *testing.T) {
+	r := 1.0
+	b2 := Box2{V2{0, 0}, V2{10, 10}}
+	p2 := b2.PoissonDisk(r)
+	// maximal packing is ~0.9 samples per r^2, random close packing much less
+	if len(p2) < 50 || len(p2) > 115 {
+		t.Errorf("FAIL %d", len(p2))
+	}
+	for i := range p2 {
+		if !b2.Contains(p2[i]) {
+			t.Fatal("FAIL")
+		}
+		for j := i + 1; j < len(p2); j++ {
+			if p2[i].Sub(p2[j]).Length() < r {
+				t.Fatal("FAIL")
+			}
+		}
+	}
+	c := Circle2D(5)
+	for _, p := range PoissonDiskSDF2(c, r) {
+		if c.Evaluate(p) >= 0 {
+			t.Fatal("FAIL")
+		}
+	}
+	s := Sphere3D(3)
+	p3 := PoissonDiskSDF3(s, r)
+	for i := range p3 {
+		if s.Evaluate(p3[i]) >= 0 {
+			t.Fatal("FAIL")
+		}
+		for j := i + 1; j < len(p3); j++ {
+			if p3[i].Sub(p3[j]).Length() < r {
+				t.Fatal("FAIL")
+			}
+		}
+	}
+	ps := PoissonDiskSurface(s, r)
+	// sphere area 113, dart throwing gives ~ 0.5 - 0.8 samples per r^2
+	if len(ps) < 50 || len(ps) > 100 {
+		t.Errorf("FAIL %d", len(ps))
+	}
+	for i := range ps {
+		for j := i + 1; j < len(ps); j++ {
+			if ps[i].Sub(ps[j]).Length() < r {
+				t.Fatal("FAIL")
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Curvature(t *testing.T) {
+	r := 5.0
+	s := Sphere3D(r)
+	for _, p := range []V3{{r, 0, 0}, {0, -r, 0}, {3, 0, 4}} {
+		mean, gaussian := Curvature3(s, p, 1e-3)
+		if math.Abs(mean-1/r) > 1e-3 || math.Abs(gaussian-1/(r*r)) > 1e-3 {
+			t.Errorf("FAIL sphere mean %f gaussian %f", mean, gaussian)
+		}
+	}
+	// flat face of a box
+	b := Box3D(V3{10, 10, 10}, 0)
+	mean, gaussian := Curvature3(b, V3{5, 1, 2}, 1e-3)
+	if math.Abs(mean) > 1e-6 || math.Abs(gaussian) > 1e-6 {
+		t.Errorf("FAIL box mean %f gaussian %f", mean, gaussian)
+	}
+	if CurvatureColor(1, 1) != (color.RGBA{255, 0, 0, 255}) || CurvatureColor(0, 1) != (color.RGBA{255, 255, 255, 255}) {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_MedialAxis(t *testing.T) {
+	// rectangle: a center line and 4 corner bisectors
+	k := MedialAxis2(Box2D(V2{20, 6}, 0), 100)
+	branches := k.Branches()
+	if len(branches) != 5 {
+		t.Errorf("FAIL %d branches", len(branches))
+	}
+	for _, n := range k.Nodes {
+		if Abs(n.Position.Y) < 0.1 && Abs(n.Position.X) < 6 && Abs(n.Radius-3) > 0.3 {
+			t.Errorf("FAIL %v", n)
+		}
+	}
+	if len(k.Segments()) != len(k.Edges) {
+		t.Error("FAIL")
+	}
+	// annulus: a closed loop at the mid radius
+	k = MedialAxis2(Difference2D(Circle2D(10), Circle2D(6)), 100)
+	branches = k.Branches()
+	if len(branches) != 1 || branches[0][0] != branches[0][len(branches[0])-1] {
+		t.Errorf("FAIL %d branches", len(branches))
+	}
+	for _, n := range k.Nodes {
+		if Abs(n.Position.Length()-8) > 0.5 || Abs(n.Radius-2) > 0.5 {
+			t.Errorf("FAIL %v", n)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Pocket(t *testing.T) {
+	s := Box2D(V2{40, 20}, 4)
+	for _, strategy := range []PocketStrategy{PocketContour, PocketSpiral} {
+		k := &PocketParms{
+			ToolRadius: 3,
+			Stepover:   2,
+			Depth:      5,
+			StepDown:   2,
+			SafeZ:      5,
+			Feed:       600,
+			PlungeFeed: 100,
+			Strategy:   strategy,
+		}
+		paths, err := PocketToolpath(s, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the tool never cuts the wall
+		var segs []Line
+		for _, p := range paths {
+			for i := 0; i < len(p)-1; i++ {
+				if s.Evaluate(p[i]) > -k.ToolRadius+0.05 {
+					t.Fatalf("FAIL %v", p[i])
+				}
+				segs = append(segs, Line{p[i], p[i+1]})
+			}
+		}
+		// the pocket is cleared
+		for x := -19.0; x <= 19; x++ {
+			for y := -9.0; y <= 9; y++ {
+				p := V2{x, y}
+				if s.Evaluate(p) > -0.2 {
+					continue
+				}
+				d := math.Inf(1)
+				for _, l := range segs {
+					d = math.Min(d, pointSegmentDistance(p, l[0], l[1]))
+				}
+				if d > k.ToolRadius {
+					t.Fatalf("FAIL %v not cleared (%f)", p, d)
+				}
+			}
+		}
+		var sb strings.Builder
+		if err := PocketGCode(s, k, &sb); err != nil {
+			t.Fatal(err)
+		}
+		g := sb.String()
+		if !strings.Contains(g, "G1 Z-5.0000") || strings.Contains(g, "G1 Z-5.5") || !strings.HasSuffix(g, "M2\n") {
+			t.Error("FAIL")
+		}
+	}
+	_, err := PocketToolpath(s, &PocketParms{ToolRadius: 1, Stepover: 3})
+	if err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Relief(t *testing.T) {
+	base := Box3D(V3{40, 40, 4}, 0)
+	bump := Transform3D(Sphere3D(8), Translate3d(V3{0, 0, 2}))
+	s := Union3D(base, bump)
+	k := &ReliefParms{
+		ToolRadius:   2,
+		BallEnd:      true,
+		Stepover:     1,
+		Resolution:   0.25,
+		StepDown:     3,
+		StockToLeave: 0.5,
+		SafeZ:        5,
+		Feed:         1000,
+		PlungeFeed:   200,
+	}
+	r := k.ToolRadius
+	finish, err := ReliefFinishing(s, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range finish {
+		for _, p := range path {
+			// the ball doesn't gouge the surface (allow for the sampling)
+			if s.Evaluate(p.Add(V3{0, 0, r})) < r-0.1 {
+				t.Fatalf("FAIL gouge at %v", p)
+			}
+		}
+	}
+	// the tip touches the top of the bump
+	top := finish[len(finish)/2][len(finish[0])/2]
+	if Abs(top.X) > 0.01 || Abs(top.Y) > 0.01 || Abs(top.Z-10) > 0.01 {
+		t.Errorf("FAIL %v", top)
+	}
+	rough, err := ReliefRoughing(s, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	height := make(map[V2]float64)
+	for _, path := range finish {
+		for _, p := range path {
+			height[V2{p.X, p.Y}] = p.Z
+		}
+	}
+	levels := make(map[float64]bool)
+	for _, path := range rough {
+		for _, p := range path {
+			if p.Z < height[V2{p.X, p.Y}]+k.StockToLeave-tolerance {
+				t.Fatalf("FAIL rough gouge at %v", p)
+			}
+			levels[math.Round(p.Z*1000)] = true
+		}
+	}
+	// bounding box z = -6..10, 6 levels, the base (z = 2) stops the lower levels
+	if !levels[7333] || !levels[4667] || levels[2000] {
+		t.Error("FAIL")
+	}
+	var sb strings.Builder
+	if err := ReliefGCode(s, k, &sb); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "G0 Z15.0000") {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Laser(t *testing.T) {
+	// plate with 2 holes and an island inside one hole
+	plate := Box2D(V2{40, 20}, 0)
+	hole0 := Transform2D(Circle2D(6), Translate2d(V2{-10, 0}))
+	hole1 := Transform2D(Circle2D(2), Translate2d(V2{10, 0}))
+	island := Transform2D(Circle2D(2), Translate2d(V2{-10, 0}))
+	s := Union2D(Difference2D(plate, Union2D(hole0, hole1)), island)
+	k := &LaserParms{
+		Material:   LaserMaterial{Name: "3mm ply", Kerf: 0.2, Feed: 600, Power: 1000, Passes: 2},
+		Resolution: 0.1,
+	}
+	paths, err := LaserContours(s, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 4 {
+		t.Fatalf("FAIL %d paths", len(paths))
+	}
+	// the beam center is offset by half the kerf
+	for _, p := range paths {
+		if p[0] != p[len(p)-1] {
+			t.Error("FAIL not closed")
+		}
+		for _, v := range p {
+			if Abs(s.Evaluate(v)-0.1) > 0.01 {
+				t.Fatalf("FAIL %v %f", v, s.Evaluate(v))
+			}
+		}
+	}
+	// island, holes, plate
+	radius := func(p []V2, c V2) float64 { return p[0].Sub(c).Length() }
+	if Abs(radius(paths[0], V2{-10, 0})-2.1) > 0.01 {
+		t.Error("FAIL island")
+	}
+	if Abs(paths[3][0].X) < 19 && Abs(paths[3][0].Y) < 9 {
+		t.Error("FAIL plate")
+	}
+	var sb strings.Builder
+	if err := LaserGCode(paths, &k.Material, &sb); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(sb.String(), "S1000") != 8 {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_FindHoles(t *testing.T) {
+	plate := Box3D(V3{40, 30, 5}, 0)
+	small := MultiCylinder3D(10, 2, V2Set{{-10, -5}, {10, 5}})
+	large := Cylinder3D(10, 3, 0)
+	blind := Transform3D(Cylinder3D(4, 3, 0), Translate3d(V3{10, -8, 2.5}))
+	slot := Transform3D(Box3D(V3{8, 3, 10}, 0), Translate3d(V3{-10, 8, 0}))
+	s := Difference3D(plate, Union3D(small, large, blind, slot))
+	holes, err := FindHoles(s, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(holes) != 3 {
+		t.Fatalf("FAIL %d holes %v", len(holes), holes)
+	}
+	expected := append(small.(*MultiCylinderSDF3).Holes(), DrillHole{V2{0, 0}, 6})
+	for _, e := range expected {
+		found := false
+		for _, h := range holes {
+			if h.Position.Equals(e.Position, 0.05) && Abs(h.Diameter-e.Diameter) < 0.05 {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("FAIL %v not found in %v", e, holes)
+		}
+	}
+	var sb strings.Builder
+	if err := WriteExcellon(&sb, expected); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "T1C4.000\nT2C6.000\n") || !strings.Contains(sb.String(), "T1\nX-10.000Y-5.000\nX10.000Y5.000\nT2\nX0.000Y0.000\nM30\n") {
+		t.Errorf("FAIL\n%s", sb.String())
+	}
+	sb.Reset()
+	if err := WriteDrillCSV(&sb, expected); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sb.String(), "x,y,diameter\n-10.000,-5.000,4.000\n") {
+		t.Errorf("FAIL\n%s", sb.String())
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_HPGL(t *testing.T) {
+	// a grid of circles in a scrambled order
+	var positions V2Set
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 5; j++ {
+			positions = append(positions, V2{float64((i*3)%5) * 10, float64((j*2)%5) * 10})
+		}
+	}
+	s := MultiCircle2D(2, positions)
+	contours := Contours2(s, 0.2)
+	if len(contours) != 25 {
+		t.Fatalf("FAIL %d contours", len(contours))
+	}
+	scrambled := make([][]V2, len(contours))
+	for i := range contours {
+		scrambled[i] = contours[(i*7)%len(contours)]
+	}
+	ordered := OrderPaths(scrambled, V2{}, true)
+	if len(ordered) != 25 {
+		t.Fatal("FAIL")
+	}
+	if PenUpDistance(ordered, V2{}) > 0.5*PenUpDistance(scrambled, V2{}) {
+		t.Errorf("FAIL %f %f", PenUpDistance(ordered, V2{}), PenUpDistance(scrambled, V2{}))
+	}
+	// open paths are reversed when the end is closer
+	open := OrderPaths([][]V2{{{10, 0}, {0, 0}}}, V2{}, false)
+	if open[0][0] != (V2{0, 0}) {
+		t.Error("FAIL")
+	}
+	var sb strings.Builder
+	layers := []HPGLLayer{
+		{Pen: 2, Paths: [][]V2{{{0, 0}, {1, 1}}}},
+		{Pen: 1, Paths: contours, Closed: true},
+	}
+	if err := WriteHPGL(&sb, layers); err != nil {
+		t.Fatal(err)
+	}
+	g := sb.String()
+	if !strings.HasPrefix(g, "IN;\nSP1;\n") || !strings.Contains(g, "SP2;\nPU40,40;\nPD0,0;\n") || strings.Count(g, "PU") != 27 {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Orient(t *testing.T) {
+	// a mushroom: a thin stem with a wide cap on top
+	stem := Transform3D(Cylinder3D(10, 2, 0), Translate3d(V3{0, 0, 5}))
+	cap := Transform3D(Cylinder3D(2, 8, 0), Translate3d(V3{0, 0, 11}))
+	s := Union3D(stem, cap)
+	mesh := RenderMesh(s, 60)
+	o := AnalyzeOverhang(mesh, DtoR(45))
+	// the underside of the cap needs support
+	if Abs(o.Area-Pi*(64-4)) > 0.1*Pi*60 || Abs(o.SupportVolume-Pi*60*10) > 0.15*Pi*600 {
+		t.Errorf("FAIL %+v", o)
+	}
+	// marching cubes chamfers the rim of the small contact disk
+	if Abs(o.ContactArea-Pi*4) > 0.3*Pi*4 || Abs(o.Height-12) > 0.1 {
+		t.Errorf("FAIL %+v", o)
+	}
+	k := &OrientParms{
+		MeshCells:     40,
+		MaxOverhang:   DtoR(45),
+		Steps:         4,
+		SupportWeight: 1,
+		ContactWeight: 1,
+	}
+	best, err := OrientForPrint(s, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// upside down: the cap is on the bed
+	top := best.Transform.MulPosition(V3{0, 0, 0})
+	capCenter := best.Transform.MulPosition(V3{0, 0, 11})
+	if Abs(capCenter.Z-1) > 0.1 || Abs(top.Z-12) > 0.1 || best.SupportVolume > 1 {
+		t.Errorf("FAIL %v %v %+v", capCenter, top, best.Overhang)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_PackParts(t *testing.T) {
+	plate := Box2{V2{0, 0}, V2{100, 80}}
+	var parts []SDF3
+	for i := 0; i < 6; i++ {
+		parts = append(parts, Box3D(V3{30, 20, 10}, 0))
+	}
+	parts = append(parts, Cylinder3D(5, 8, 0))
+	spacing := 4.0
+	transforms, err := PackParts(parts, plate, spacing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var boxes []Box3
+	for i, m := range transforms {
+		bb := m.MulBox(parts[i].BoundingBox())
+		if bb.Min.X < 0 || bb.Min.Y < 0 || bb.Max.X > 100 || bb.Max.Y > 80 || Abs(bb.Min.Z) > tolerance {
+			t.Errorf("FAIL %v", bb)
+		}
+		boxes = append(boxes, bb)
+	}
+	// the parts are at least the spacing apart
+	for i := 0; i < len(boxes); i++ {
+		for j := i + 1; j < len(boxes); j++ {
+			a, b := boxes[i], boxes[j]
+			dx := math.Max(a.Min.X-b.Max.X, b.Min.X-a.Max.X)
+			dy := math.Max(a.Min.Y-b.Max.Y, b.Min.Y-a.Max.Y)
+			if math.Max(dx, dy) < spacing-0.1 {
+				t.Errorf("FAIL %d %d %v %v", i, j, a, b)
+			}
+		}
+	}
+	// too many parts
+	_, err = PackParts(append(parts, parts...), plate, spacing)
+	if err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_NestShapes(t *testing.T) {
+	// L shapes nest better with rotation
+	l := Difference2D(Box2D(V2{40, 40}, 0), Transform2D(Box2D(V2{30, 30}, 0), Translate2d(V2{5, 5})))
+	var shapes []SDF2
+	for i := 0; i < 8; i++ {
+		shapes = append(shapes, l)
+	}
+	shapes = append(shapes, Circle2D(8))
+	k := &NestParms{
+		Sheet:     V2{100, 100},
+		Spacing:   2,
+		Rotations: 4,
+	}
+	placements, err := NestShapes(shapes, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheets := 0
+	for i, p := range placements {
+		bb := Transform2D(shapes[i], p.Transform).BoundingBox()
+		if bb.Min.X < -tolerance || bb.Min.Y < -tolerance || bb.Max.X > 100+tolerance || bb.Max.Y > 100+tolerance {
+			t.Errorf("FAIL %v", bb)
+		}
+		sheets = maxInt(sheets, p.Sheet+1)
+	}
+	// the shapes don't come closer than the spacing
+	for n := 0; n < sheets; n++ {
+		var placed []SDF2
+		for i, p := range placements {
+			if p.Sheet == n {
+				placed = append(placed, Transform2D(shapes[i], p.Transform))
+			}
+		}
+		for x := 0.5; x < 100; x++ {
+			for y := 0.5; y < 100; y++ {
+				near := 0
+				for _, s := range placed {
+					if s.Evaluate(V2{x, y}) < k.Spacing/2-0.05 {
+						near++
+					}
+				}
+				if near > 1 {
+					t.Fatalf("FAIL sheet %d %f %f", n, x, y)
+				}
+			}
+		}
+	}
+	if sheets != 2 {
+		t.Errorf("FAIL %d sheets", sheets)
+	}
+	if NestLayout(shapes, placements, 5) != nil {
+		t.Error("FAIL")
+	}
+	_, err = NestShapes([]SDF2{Circle2D(60)}, k)
+	if err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Section(t *testing.T) {
+	// a tube section: hatching only in the wall
+	tube := Difference3D(Cylinder3D(20, 10, 0), Cylinder3D(22, 6, 0))
+	k := &SectionParms{Resolution: 0.1, HatchSpacing: 1, HatchAngle: DtoR(45)}
+	slice := Slice2D(tube, V3{}, V3{0, 1, 0})
+	lines := HatchLines(slice, k.HatchAngle, k.HatchSpacing, k.Resolution)
+	if len(lines) == 0 {
+		t.Fatal("FAIL")
+	}
+	for _, l := range lines {
+		m := l[0].Add(l[1]).MulScalar(0.5)
+		if slice.Evaluate(m) > 0 || Abs(slice.Evaluate(l[0])) > 0.01 || Abs(slice.Evaluate(l[1])) > 0.01 {
+			t.Fatalf("FAIL %v", l)
+		}
+		if Abs(l[1].Sub(l[0]).Normalize().Dot(V2{1, 1}.Normalize())) < 0.999 {
+			t.Fatalf("FAIL %v", l)
+		}
+	}
+	d := SectionDrawing(tube, V3{}, V3{0, 1, 0}, k)
+	var sb strings.Builder
+	if err := d.WriteSVG(&sb, 5); err != nil {
+		t.Fatal(err)
+	}
+	svg := sb.String()
+	if !strings.Contains(svg, "width=\"30.00") || strings.Count(svg, "<path") != len(lines)+1 {
+		t.Error("FAIL")
+	}
+	// cutaway: the cut face is 2 rectangles (less the marching cubes chamfer at the edges)
+	part, face := CutawayMesh(tube, V3{}, V3{0, 1, 0}, 60)
+	area := 0.0
+	for _, t := range face {
+		area += triangleArea(t)
+	}
+	if len(part) == 0 || Abs(area-2*4*20) > 0.15*160 {
+		t.Errorf("FAIL area %f", area)
+	}
+	// automatic mesh resolution
+	part, face = CutawayMesh(tube, V3{}, V3{0, 1, 0}, 0)
+	area = 0.0
+	for _, t := range face {
+		area += triangleArea(t)
+	}
+	if len(part) == 0 || Abs(area-2*4*20) > 0.15*160 {
+		t.Errorf("FAIL auto area %f", area)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Projection(t *testing.T) {
+	s := Box3D(V3{30, 20, 10}, 0)
+	views := []struct {
+		view View
+		size V2
+	}{
+		{ViewTop, V2{30, 20}},
+		{ViewFront, V2{30, 10}},
+		{ViewRight, V2{20, 10}},
+	}
+	for _, v := range views {
+		p := Project2D(s, v.view)
+		if p.Evaluate(V2{0, 0}) >= 0 || p.Evaluate(v.size) <= 0 {
+			t.Errorf("FAIL view %d inside/outside", v.view)
+		}
+		if c := p.(Parent3).Children(); len(c) != 1 || c[0] != s {
+			t.Errorf("FAIL view %d children", v.view)
+		}
+		size := ViewDrawing(s, v.view, 0.25).Extents().Size()
+		if Abs(size.X-v.size.X) > 0.5 || Abs(size.Y-v.size.Y) > 0.5 {
+			t.Errorf("FAIL view %d size %v", v.view, size)
+		}
+	}
+
+	// a hole through the part along z shows in the top view only
+	s = Difference3D(s, Cylinder3D(12, 4, 0))
+	if Project2D(s, ViewTop).Evaluate(V2{0, 0}) <= 0 {
+		t.Error("FAIL top view hole")
+	}
+	if Project2D(s, ViewFront).Evaluate(V2{0, 0}) >= 0 {
+		t.Error("FAIL front view hole")
+	}
+
+	part := &Part{Name: "block", SDF: s}
+	part.AddAnchor("left", V3{-15, -10, 0}, V3{})
+	part.AddAnchor("right", V3{15, -10, 0}, V3{})
+	d, err := PartDrawing(part, ViewTop, []AnchorDimension{{"left", "right", -5, ""}}, 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := d.WriteSVG(&sb, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), ">30.00</text>") {
+		t.Error("FAIL dimension text")
+	}
+	// the dimension is below the part
+	if d.Extents().Min.Y > -15 {
+		t.Errorf("FAIL dimension extents %v", d.Extents())
+	}
+	if _, err := PartDrawing(part, ViewTop, []AnchorDimension{{"left", "top", 5, ""}}, 0.25); err == nil {
+		t.Error("FAIL expected missing anchor error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Sheet(t *testing.T) {
+	s := Box3D(V3{100, 60, 40}, 0)
+
+	img := RenderIsometric(s, 100)
+	b := img.Bounds()
+	if _, _, _, a := img.At(b.Dx()/2, b.Dy()/2).RGBA(); a == 0 {
+		t.Error("FAIL isometric center")
+	}
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+		t.Error("FAIL isometric background")
+	}
+
+	k := &SheetParms{Title: "BLOCK", Paper: PaperA4, Resolution: 1, Pixels: 100}
+	d, err := SheetDrawing(s, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := d.Extents()
+	if bb.Min != (V2{}) || bb.Max != PaperA4 {
+		t.Errorf("FAIL extents %v", bb)
+	}
+	var sb strings.Builder
+	if err := d.WriteSVG(&sb, 0); err != nil {
+		t.Fatal(err)
+	}
+	svg := sb.String()
+	for _, x := range []string{">SCALE 1:1</text>", ">UNITS mm</text>", ">BLOCK</text>", "<image "} {
+		if !strings.Contains(svg, x) {
+			t.Errorf("FAIL missing %s", x)
+		}
+	}
+
+	// a larger part is drawn at a smaller scale
+	d, err = SheetDrawing(Box3D(V3{300, 100, 100}, 0), k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb.Reset()
+	d.WriteSVG(&sb, 0)
+	if !strings.Contains(sb.String(), ">SCALE 1:2</text>") {
+		t.Error("FAIL scale")
+	}
+
+	k.Scale = 5
+	if _, err := SheetDrawing(s, k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_PDF(t *testing.T) {
+	d := NewDrawing()
+	d.Polygons([][]V2{{{-10, -10}, {10, -10}, {10, 10}, {-10, 10}}}, OutlineStyle)
+	d.Text(V2{0, -15}, 2.5, "(R10)", TextMiddle)
+	d.Image(Box2{V2{-5, -5}, V2{5, 5}}, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	var sb strings.Builder
+	if err := d.WritePDF(&sb, 5); err != nil {
+		t.Fatal(err)
+	}
+	pdf := sb.String()
+	if !strings.HasPrefix(pdf, "%PDF-1.4") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Error("FAIL header/trailer")
+	}
+	// 20 x 25 mm plus the margins
+	if !strings.Contains(pdf, fmt.Sprintf("/MediaBox [0 0 %.3f %.3f]", 30*pdfPointsPerMM, 35*pdfPointsPerMM)) {
+		t.Error("FAIL media box")
+	}
+	if !strings.Contains(pdf, `(\(R10\)) Tj`) {
+		t.Error("FAIL text")
+	}
+	// the cross reference offsets point at the objects
+	var n, xref int
+	fmt.Sscanf(pdf[strings.LastIndex(pdf, "startxref"):], "startxref\n%d", &xref)
+	fmt.Sscanf(pdf[xref:], "xref\n0 %d", &n)
+	lines := strings.Split(pdf[xref:], "\n")
+	for i := 1; i < n; i++ {
+		var ofs int
+		fmt.Sscanf(lines[2+i], "%d", &ofs)
+		if !strings.HasPrefix(pdf[ofs:], fmt.Sprintf("%d 0 obj", i)) {
+			t.Errorf("FAIL object %d offset", i)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Gerber(t *testing.T) {
+	// a square with a hole that has an island
+	s := Difference2D(Box2D(V2{20, 20}, 0), Circle2D(6))
+	s = Union2D(s, Circle2D(3))
+	var sb strings.Builder
+	if err := WriteGerber(&sb, s, 0.25, "Paste,Top"); err != nil {
+		t.Fatal(err)
+	}
+	g := sb.String()
+	if !strings.Contains(g, "%TF.FileFunction,Paste,Top*%") || !strings.Contains(g, "%MOMM*%") || !strings.HasSuffix(g, "M02*\n") {
+		t.Error("FAIL header/trailer")
+	}
+	if strings.Count(g, "G36*") != 3 || strings.Count(g, "G37*") != 3 {
+		t.Error("FAIL regions")
+	}
+	// dark, clear then dark
+	lpd := strings.Index(g, "%LPD*%")
+	lpc := strings.Index(g, "%LPC*%")
+	if lpd < 0 || lpc < lpd || strings.LastIndex(g, "%LPD*%") < lpc {
+		t.Error("FAIL polarity")
+	}
+	// the outer corner is at 10 mm
+	if !strings.Contains(g, "X10000000") {
+		t.Error("FAIL coordinates")
+	}
+
+	sb.Reset()
+	if err := WriteGerberProfile(&sb, Box2D(V2{20, 20}, 0), 0.25, 0.1); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "%ADD10C,0.1*%") || strings.Count(sb.String(), "D02*") != 1 {
+		t.Error("FAIL profile")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_FrontPanel(t *testing.T) {
+	k := RackPanel(1)
+	if Abs(k.Size.Y-43.66) > 1e-6 || len(k.Holes) != 4 {
+		t.Errorf("FAIL rack panel %v %d", k.Size, len(k.Holes))
+	}
+	k.Cutouts = []PanelCutout{{"DB9", V2{-100, 0}, 0}, {"USB-C", V2{0, 0}, Pi / 2}}
+	k.Labels = []PanelLabel{{Box2D(V2{20, 4}, 0), V2{0, 15}}}
+	s, err := FrontPanel2D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []V2{k.Holes[0], {-100, 0}, {-112.5, 0}, {0, 4}} {
+		if s.Evaluate(p) <= 0 {
+			t.Errorf("FAIL expected a hole at %v", p)
+		}
+	}
+	// the USB-C cutout is rotated
+	if s.Evaluate(V2{4, 0}) >= 0 || s.Evaluate(V2{50, 0}) >= 0 {
+		t.Error("FAIL expected material")
+	}
+
+	s3, err := FrontPanel3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := 0.5*k.Thickness - 0.5*k.EngraveDepth
+	if s3.Evaluate(V3{0, 15, z}) <= 0 || s3.Evaluate(V3{0, 20, z}) >= 0 {
+		t.Error("FAIL engraving")
+	}
+
+	e := EurorackPanel(10)
+	if Abs(e.Size.X-50.5) > 1e-6 || len(e.Holes) != 4 || len(EurorackPanel(4).Holes) != 2 {
+		t.Error("FAIL eurorack panel")
+	}
+	e.Cutouts = []PanelCutout{{Component: "DB99"}}
+	if _, err := FrontPanel2D(e); err == nil {
+		t.Error("FAIL expected unknown component error")
+	}
+	if len(PanelComponentNames()) != len(PanelComponents) {
+		t.Error("FAIL component names")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_BoardCase(t *testing.T) {
+	k := &CaseParms{
+		Board:     &RaspberryPi4,
+		Wall:      2,
+		Floor:     2,
+		Clearance: 0.5,
+		Standoff:  3,
+		LipHeight: 2,
+		Fit:       0.2,
+	}
+	base, lid, err := BoardCase3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := base.BoundingBox()
+	if !bb.Min.Equals(V3{-2.5, -2.5, 0}, 1e-6) || !bb.Max.Equals(V3{87.5, 58.5, 22.4}, 1e-6) {
+		t.Errorf("FAIL base bounding box %v", bb)
+	}
+	// the floor is solid and the inside of the case is empty
+	if base.Evaluate(V3{30, 30, 1}) >= 0 || base.Evaluate(V3{30, 30, 10}) <= 0 {
+		t.Error("FAIL base")
+	}
+	// a standoff with a pilot hole
+	h := RaspberryPi4.Holes[0]
+	if base.Evaluate(V3{h.X + 2, h.Y, 4}) >= 0 || base.Evaluate(V3{h.X, h.Y, 4}) <= 0 {
+		t.Error("FAIL standoff")
+	}
+	// the ethernet port passes through the right wall, the wall is solid elsewhere
+	if base.Evaluate(V3{86.5, 45.75, 6.4 + 6.75}) <= 0 || base.Evaluate(V3{86.5, 45.75, 3}) >= 0 {
+		t.Error("FAIL port cutout")
+	}
+	// the lid sits on top of the walls with the lip inside
+	if lid.Evaluate(V3{30, 30, 23.4}) >= 0 || lid.Evaluate(V3{0.5, 30, 21.4}) >= 0 || lid.Evaluate(V3{30, 30, 21.4}) <= 0 {
+		t.Error("FAIL lid")
+	}
+	k.Board = nil
+	if _, _, err := BoardCase3D(k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Springs(t *testing.T) {
+	k := &CompressionSpringParms{
+		WireDiameter:  1,
+		OuterDiameter: 10,
+		Pitch:         3,
+		ActiveCoils:   5,
+		Ends:          SpringClosed,
+	}
+	s, err := CompressionSpring3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Abs(k.FreeLength()-18) > tolerance || Abs(s.BoundingBox().Max.Z-18) > tolerance {
+		t.Errorf("FAIL free length %f", s.BoundingBox().Max.Z)
+	}
+	h := s.(*HelixSDF3)
+	for _, x := range []float64{0, 0.5, 1, 2.25, 6.5, 7} {
+		if d := s.Evaluate(h.Point(x)); Abs(d+0.5) > 1e-6 {
+			t.Errorf("FAIL wire center %f %f", x, d)
+		}
+	}
+	// the closed end coils touch, the active coils don't
+	if s.Evaluate(V3{4.5, 0, 1}) > 1e-6 || s.Evaluate(V3{4.5, 0, 3}) <= 0 {
+		t.Error("FAIL coil spacing")
+	}
+
+	k.Ends = SpringClosedGround
+	s, err = CompressionSpring3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s.BoundingBox()
+	if Abs(bb.Min.Z) > tolerance || Abs(bb.Max.Z-17) > tolerance || s.Evaluate(V3{4.5, 0, 0.01}) >= 0 {
+		t.Errorf("FAIL ground ends %v", bb)
+	}
+	k.Pitch = 0.5
+	if _, err := CompressionSpring3D(k); err == nil {
+		t.Error("FAIL expected error")
+	}
+
+	ts, err := TorsionSpring3D(&TorsionSpringParms{WireDiameter: 1, OuterDiameter: 10, Coils: 3.25, ArmLength: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first arm runs along -y from the start of the coil, the second along -x
+	if ts.Evaluate(V3{4.5, -19, 0.5}) >= 0 || ts.Evaluate(V3{-19, 4.5, 3.75}) >= 0 {
+		t.Error("FAIL torsion spring arms")
+	}
+
+	ww, err := WaveWasher3D(&WaveWasherParms{InnerDiameter: 20, OuterDiameter: 30, Thickness: 0.5, Waves: 3, Height: 2.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ww.Evaluate(V3{12.5, 0, 1}) >= 0 || ww.Evaluate(V3{12.5, 0, -1}) <= 0 || ww.Evaluate(V3{-12.5, 0, -1}) >= 0 {
+		t.Error("FAIL wave washer")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Flexures(t *testing.T) {
+	pf, err := ParallelFlexure2D(&ParallelFlexureParms{BladeThickness: 0.8, BladeLength: 30, BladeSpacing: 10, Block: V2{8, 14}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf.Evaluate(V2{0, 5}) >= 0 || pf.Evaluate(V2{0, 5.5}) <= 0 || pf.Evaluate(V2{0, 0}) <= 0 || pf.Evaluate(V2{17, 0}) >= 0 {
+		t.Error("FAIL parallel flexure")
+	}
+	if Abs(pf.BoundingBox().Size().X-46) > tolerance {
+		t.Errorf("FAIL parallel flexure size %v", pf.BoundingBox())
+	}
+
+	nh, err := NotchHinge2D(&NotchHingeParms{Thickness: 1, Radius: 3, Width: 8, Length: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the web is 1 mm thick at the notch
+	if nh.Evaluate(V2{0, 0.4}) >= 0 || nh.Evaluate(V2{0, 0.6}) <= 0 || nh.Evaluate(V2{10, 3.5}) >= 0 {
+		t.Error("FAIL notch hinge")
+	}
+
+	sf, err := SpiralFlexure2D(&SpiralFlexureParms{BladeThickness: 1, HubRadius: 5, RingRadius: 20, RingWidth: 3, Turns: 1, Blades: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the blades starting at 120 and 240 degrees cross the +x axis at 1/3 and 2/3 of the way to the ring
+	if sf.Evaluate(V2{10, 0}) >= 0 || sf.Evaluate(V2{15, 0}) >= 0 || sf.Evaluate(V2{12.5, 0}) <= 0 || sf.Evaluate(V2{21, 0}) >= 0 {
+		t.Error("FAIL spiral flexure")
+	}
+	if _, err := SpiralFlexure2D(&SpiralFlexureParms{BladeThickness: 1, HubRadius: 5, RingRadius: 5}); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Hinges(t *testing.T) {
+	k := &HingeParms{
+		Length:        40,
+		Knuckles:      5,
+		Radius:        3,
+		PinRadius:     1,
+		LeafWidth:     15,
+		LeafThickness: 2,
+		Clearance:     0.3,
+	}
+	a, b, err := BarrelHinge3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x0, x1 := k.knuckleCenter(0), k.knuckleCenter(1)
+	if Abs(x1-x0-(k.knuckleLength()+k.Clearance)) > tolerance {
+		t.Error("FAIL knuckle spacing")
+	}
+	// knuckles alternate between the halves and have a pin hole
+	if a.Evaluate(V3{x0, 0, 2.5}) >= 0 || b.Evaluate(V3{x0, 0, 2.5}) <= 0 || a.Evaluate(V3{x0, 0, 0}) <= 0 {
+		t.Error("FAIL barrel knuckles")
+	}
+	if b.Evaluate(V3{x1, 0, 2.5}) >= 0 || a.Evaluate(V3{x1, 0, 2.5}) <= 0 {
+		t.Error("FAIL barrel knuckles")
+	}
+	// leaves, with leaf A cleared around the leaf B knuckles
+	if a.Evaluate(V3{0, -10, -2}) >= 0 || b.Evaluate(V3{0, 10, -2}) >= 0 || a.Evaluate(V3{x1, -2, -2.3}) <= 0 {
+		t.Error("FAIL barrel leaves")
+	}
+
+	s, err := PrintInPlaceHinge3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pin runs through the bored leaf B knuckles
+	if s.Evaluate(V3{x1, 0, 0}) >= 0 || s.Evaluate(V3{x1, 0, 1.15}) <= 0 || s.Evaluate(V3{x1, 0, 2}) >= 0 {
+		t.Error("FAIL print in place")
+	}
+
+	a0 := Anchor{"e0", V3{0, 0, 0}, V3{0, 0, 1}}
+	a1 := Anchor{"e1", V3{50, 0, 0}, V3{0, 0, 1}}
+	m, err := HingeOnEdge(k, a0, a1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.Length != 50 || !m.MulPosition(V3{0, 0, -k.Radius}).Equals(V3{25, 0, 0}, tolerance) || !m.MulPosition(V3{-25, 0, -k.Radius}).Equals(V3{0, 0, 0}, tolerance) {
+		t.Error("FAIL hinge on edge")
+	}
+	k.Knuckles = 1
+	if _, _, err := BarrelHinge3D(k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_CableManagement(t *testing.T) {
+	clip, err := CableClip3D(&CableClipParms{
+		Diameter:      6,
+		Wall:          1.5,
+		Width:         8,
+		Opening:       4.5,
+		BaseLength:    24,
+		BaseThickness: 2,
+		ScrewDiameter: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// cable space, ring wall, snap opening, base and screw hole
+	if clip.Evaluate(V3{0, 0, 5}) <= 0 || clip.Evaluate(V3{3.75, 0, 5}) >= 0 || clip.Evaluate(V3{0, 0, 8.75}) <= 0 {
+		t.Error("FAIL cable clip")
+	}
+	if clip.Evaluate(V3{-11.5, 0, 0.5}) >= 0 || clip.Evaluate(V3{7.875, 0, 1}) <= 0 {
+		t.Error("FAIL cable clip base")
+	}
+	if _, err := CableClip3D(&CableClipParms{Diameter: 6, Wall: 1.5, Width: 8, Opening: 7, BaseLength: 24, BaseThickness: 2}); err == nil {
+		t.Error("FAIL expected error")
+	}
+
+	mount, err := ZipTieMount3D(&ZipTieMountParms{
+		Size:          20,
+		Height:        6,
+		BaseThickness: 2,
+		TieWidth:      8,
+		TieThickness:  2,
+		Round:         2,
+		ScrewDiameter: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tie channel, screw hole, head bore and body
+	if mount.Evaluate(V3{0, 8, 3}) <= 0 || mount.Evaluate(V3{0, 0, 1}) <= 0 || mount.Evaluate(V3{0, 0, 5}) <= 0 || mount.Evaluate(V3{7, 7, 3}) >= 0 {
+		t.Error("FAIL zip-tie mount")
+	}
+
+	saddle, err := StrapSaddle3D(&StrapSaddleParms{
+		Diameter:       10,
+		Wall:           2,
+		Width:          30,
+		StrapWidth:     12,
+		StrapThickness: 2,
+		BaseThickness:  2,
+		ScrewDiameter:  3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// cradle, strap tunnel, screw holes and body
+	if saddle.Evaluate(V3{0, 0, 7}) <= 0 || saddle.Evaluate(V3{6.5, 0, 3}) <= 0 || saddle.Evaluate(V3{0, 11, 3}) <= 0 || saddle.Evaluate(V3{0, 0, 5}) >= 0 {
+		t.Error("FAIL strap saddle")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Bin(t *testing.T) {
+	k := &BinParms{
+		UnitsX:      2,
+		UnitsY:      1,
+		HeightUnits: 3,
+		Wall:        1.2,
+		Floor:       1,
+		Fillet:      1,
+		MagnetHoles: true,
+		ScrewHoles:  true,
+		LabelLedge:  10,
+	}
+	s, err := Bin3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// walls, floor, feet, holes and label ledge
+	for _, x := range []struct {
+		p      V3
+		inside bool
+	}{
+		{V3{41.7, 0, 10}, true},     // wall
+		{V3{41.8, 0, 10}, false},    // outside the wall
+		{V3{40, 0, 10}, false},      // cavity
+		{V3{0, 0, 5.5}, true},       // floor
+		{V3{0, 0, 21.1}, false},     // above the bin
+		{V3{-21, 0, 0.1}, true},     // foot center
+		{V3{0, 0, 0.1}, false},      // gap between the feet
+		{V3{-21, 20.7, 0.1}, false}, // foot bottom chamfer
+		{V3{-21, 20.7, 4.7}, true},  // foot top
+		{V3{-8, 13, 1}, false},      // magnet hole
+		{V3{-8, 13, 5.5}, false},    // screw hole
+		{V3{0, 16, 20.5}, true},     // label ledge
+		{V3{0, 12, 15}, false},      // under the label ledge
+	} {
+		if (s.Evaluate(x.p) < 0) != x.inside {
+			t.Errorf("FAIL %v", x.p)
+		}
+	}
+	k.HeightUnits = 0
+	if _, err := Bin3D(k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Polyhedron(t *testing.T) {
+	for _, x := range []struct {
+		kind  Polyhedron
+		faces int
+		edges int
+	}{
+		{Tetrahedron, 4, 6},
+		{Cube, 6, 12},
+		{Octahedron, 8, 12},
+		{Dodecahedron, 12, 30},
+		{Icosahedron, 20, 30},
+		{RhombicDodecahedron, 12, 24},
+		{DeltoidalIcositetrahedron, 24, 48},
+		{RhombicTriacontahedron, 30, 60},
+	} {
+		s, err := Polyhedron3D(x.kind, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		faces := s.Faces()
+		edges := 0
+		for _, f := range faces {
+			edges += len(f)
+		}
+		if len(faces) != x.faces || edges/2 != x.edges {
+			t.Errorf("FAIL polyhedron %d: %d faces, %d edges", x.kind, len(faces), edges/2)
+		}
+		// the face centers are at the inradius
+		for _, n := range s.Normals() {
+			if Abs(s.Evaluate(n)) > tolerance || Abs(s.Evaluate(n.MulScalar(2))-1) > tolerance {
+				t.Errorf("FAIL polyhedron %d: face %v", x.kind, n)
+			}
+		}
+	}
+	// exact distances outside a cube
+	s, _ := Polyhedron3D(Cube, 1)
+	for _, x := range []struct {
+		p V3
+		d float64
+	}{
+		{V3{0, 0, 0}, -1},
+		{V3{2, 0, 0}, 1},
+		{V3{2, 2, 0}, math.Sqrt2},
+		{V3{2, 2, 2}, math.Sqrt(3)},
+		{V3{0.5, 0.2, 0}, -0.5},
+	} {
+		if Abs(s.Evaluate(x.p)-x.d) > tolerance {
+			t.Errorf("FAIL %v %f expected %f", x.p, s.Evaluate(x.p), x.d)
+		}
+	}
+	// half-spaces that don't bound a solid
+	if _, err := newPolyhedron([]V3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {-1, -1, 0}}, []float64{1, 1, 1, 1}); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_RoundPolyhedron(t *testing.T) {
+	p, _ := Polyhedron3D(Cube, 1)
+	s, err := RoundPolyhedron3D(p, 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := V3{0.75, 0.75, 0.75}
+	for _, x := range []struct {
+		p V3
+		d float64
+	}{
+		{V3{0, 0, 0}, -1},  // center
+		{V3{1, 0, 0}, 0},   // face center
+		{V3{2, 0.5, 0}, 1}, // in front of the face
+		{V3{1, 1, 0}, V2{0.25, 0.25}.Length() - 0.25},     // rounded edge
+		{V3{2, 2, 2}, V3{2, 2, 2}.Sub(c).Length() - 0.25}, // rounded vertex
+	} {
+		if Abs(s.Evaluate(x.p)-x.d) > tolerance {
+			t.Errorf("FAIL %v %f expected %f", x.p, s.Evaluate(x.p), x.d)
+		}
+	}
+	if _, err := RoundPolyhedron3D(p, 1.5); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ConvexSDF3(t *testing.T) {
+	// square frustum: 2x2 base on z = 0, 1x1 top on z = 1
+	n := math.Sqrt(0.5)
+	planes := []Plane{
+		{V3{0, 0, 0}, V3{0, 0, -1}},
+		{V3{0, 0, 1}, V3{0, 0, 1}},
+		{V3{1, 0, 0}, V3{n, 0, 0.5 * n}},
+		{V3{-1, 0, 0}, V3{-n, 0, 0.5 * n}},
+		{V3{0, 1, 0}, V3{0, n, 0.5 * n}},
+		{V3{0, -1, 0}, V3{0, -n, 0.5 * n}},
+	}
+	s, err := Convex3D(planes, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s.BoundingBox()
+	if !bb.Min.Equals(V3{-1, -1, 0}, tolerance) || !bb.Max.Equals(V3{1, 1, 1}, tolerance) {
+		t.Errorf("FAIL bounding box %v", bb)
+	}
+	if Abs(s.Evaluate(V3{0, 0, 2})-1) > tolerance || Abs(s.Evaluate(V3{0, 0, 0.5})+0.5) > tolerance {
+		t.Error("FAIL frustum distance")
+	}
+	r, err := Convex3D(planes, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Evaluate(V3{0.99, 0.99, 0.01}) < 0 || r.Evaluate(V3{0, 0, 0.01}) > 0 {
+		t.Error("FAIL rounded frustum")
+	}
+	// too few planes, open at the bottom
+	if _, err := Convex3D(planes[:3], 0); err == nil {
+		t.Error("FAIL expected error")
+	}
+	if _, err := Convex3D(planes[1:], 0); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Dice(t *testing.T) {
+	// opposite faces sum to 7
+	s, _ := Polyhedron3D(Cube, 1)
+	normals := s.Normals()
+	numbers := faceNumbers(normals)
+	for i := range normals {
+		for j := range normals {
+			if normals[i].Add(normals[j]).Length() < tolerance && numbers[i]+numbers[j] != 7 {
+				t.Errorf("FAIL faces %v %v", normals[i], normals[j])
+			}
+		}
+	}
+	k := &DiceParms{
+		Shape: Cube,
+		Size:  16,
+		Round: 1,
+		Depth: 1,
+	}
+	d, err := Dice3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// +x is 1, -x is 6
+	for _, x := range []struct {
+		p      V3
+		inside bool
+	}{
+		{V3{7.9, 0, 0}, false},         // 1 pip
+		{V3{7.9, 2, 2}, true},          // 1 face
+		{V3{7.9, 7.9, 7.9}, false},     // rounded corner
+		{V3{-7.9, 0, 0}, true},         // 6 face
+		{V3{-7.5, 3.15, -3.15}, false}, // 6 pip
+		{V3{-7.7, 3.15, -3.15}, false}, // 6 pip
+	} {
+		if (d.Evaluate(x.p) < 0) != x.inside {
+			t.Errorf("FAIL %v", x.p)
+		}
+	}
+	// the balanced die engraves the 6 pips at 1/6 of the depth
+	k.Balanced = true
+	d, err = Dice3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Evaluate(V3{-7.7, 3.15, -3.15}) > 0 || d.Evaluate(V3{-7.9, 3.15, -3.15}) < 0 {
+		t.Error("FAIL balanced die")
+	}
+	// too many faces for pips
+	k.Shape = Icosahedron
+	if _, err := Dice3D(k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_CamProfile(t *testing.T) {
+	k := &CamParms{
+		Segments: []CamSegment{
+			{Pi / 2, 10, CamCycloidal},
+			{Pi / 2, 0, CamUniform},
+			{Pi / 2, -10, CamPolynomial},
+			{Pi / 2, 0, CamUniform},
+		},
+		BaseRadius:   20,
+		RollerRadius: 5,
+		Steps:        720,
+		Thickness:    6,
+		HubDiameter:  16,
+		HubLength:    8,
+		BoreDiameter: 8,
+	}
+	for _, x := range []struct{ theta, s float64 }{{0, 0}, {Pi / 4, 5}, {3 * Pi / 4, 10}, {5 * Pi / 4, 5}, {7 * Pi / 4, 0}} {
+		if s := CamDisplacement(k.Segments, x.theta); Abs(s-x.s) > 1e-9 {
+			t.Errorf("FAIL displacement %f %f", x.theta, s)
+		}
+	}
+	s, err := CamProfile2D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the follower touches the cam at Pi/2 - theta
+	for _, x := range []struct{ theta, r float64 }{{3 * Pi / 4, 30}, {7 * Pi / 4, 20}} {
+		a := 0.5*Pi - x.theta
+		if d := s.Evaluate(V2{math.Cos(a), math.Sin(a)}.MulScalar(x.r)); Abs(d) > 0.01 {
+			t.Errorf("FAIL profile %f %f", x.theta, d)
+		}
+	}
+
+	c, err := Cam3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Evaluate(V3{0, 0, 3}) <= 0 || c.Evaluate(V3{6, 0, 10}) >= 0 || c.Evaluate(V3{15, 0, 3}) >= 0 {
+		t.Error("FAIL cam 3D")
+	}
+
+	k.Segments[3].Angle = Pi
+	if _, err := CamProfile2D(k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Ratchet(t *testing.T) {
+	k := &RatchetParms{
+		NumberTeeth:     12,
+		OuterRadius:     20,
+		ToothDepth:      4,
+		EngagementAngle: DtoR(10),
+		BoreRadius:      4,
+		PawlLength:      15,
+		PawlWidth:       5,
+		PawlBoreRadius:  1.5,
+		Clearance:       0.2,
+		Thickness:       5,
+	}
+	wheel, pawl, err := Ratchet3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tooth tip on the +x axis, bore at the center
+	if wheel.Evaluate(V3{19.9, -0.1, 0}) >= 0 || wheel.Evaluate(V3{0, 0, 0}) <= 0 {
+		t.Error("FAIL wheel")
+	}
+	// the locking face undercuts the tip
+	if root := k.ratchetRoot(); root.Y >= 0 || Abs(root.Length()-16) > 1e-9 {
+		t.Errorf("FAIL root %v", root)
+	}
+	// the pawl bears on the locking face and has a pivot bore
+	if pawl.Evaluate(V3{18, 0.5, 0}) >= 0 || pawl.Evaluate(k.PawlPivot().ToV3(0)) <= 0 {
+		t.Error("FAIL pawl")
+	}
+	// the pawl and wheel don't overlap
+	for x := 10.0; x < 26; x += 0.25 {
+		for y := -4.0; y < 16; y += 0.25 {
+			p := V3{x, y, 0}
+			if wheel.Evaluate(p) < 0 && pawl.Evaluate(p) < 0 {
+				t.Fatalf("FAIL overlap %v", p)
+			}
+		}
+	}
+	k.EngagementAngle = DtoR(60)
+	if _, err := Ratchet2D(k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Iris(t *testing.T) {
+	k := &IrisParms{
+		Blades:         6,
+		MinAperture:    2,
+		MaxAperture:    15,
+		PivotRadius:    22,
+		PinRadius:      1.5,
+		Wall:           2,
+		BladeThickness: 1,
+		RingThickness:  3,
+		Clearance:      0.2,
+	}
+	iris, err := NewIris(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(iris.Blades) != k.Blades {
+		t.Error("FAIL blades")
+	}
+	// the aperture of the closed blades
+	pivot := V2{k.PivotRadius, 0}
+	m := irisBladeRotate(pivot, iris.BladeAngle)
+	var blades []SDF2
+	for i := 0; i < k.Blades; i++ {
+		blades = append(blades, Transform2D(iris.Blade, Rotate2d(Tau*float64(i)/float64(k.Blades)).Mul(m)))
+	}
+	closed := Union2D(blades...)
+	covered := false
+	for i := 0; i < 360; i++ {
+		a := DtoR(float64(i))
+		u := V2{math.Cos(a), math.Sin(a)}
+		if closed.Evaluate(u.MulScalar(k.MinAperture-0.05)) < 0 {
+			t.Fatalf("FAIL aperture at %d degrees", i)
+		}
+		if closed.Evaluate(u.MulScalar(k.MinAperture+0.05)) < 0 {
+			covered = true
+		}
+	}
+	if !covered {
+		t.Error("FAIL aperture")
+	}
+	// the drive pin stays in its slot as the actuator ring turns
+	z := 2*k.RingThickness + k.BladeThickness + k.Clearance - 0.5*k.RingThickness
+	pin := m.MulPosition(V2{k.PivotRadius + 2*(k.PinRadius+k.Wall) + k.Clearance, 0})
+	ring := Transform3D(iris.ActuatorRing, RotateZ(iris.RingAngle))
+	if ring.Evaluate(pin.ToV3(z)) <= 0 || iris.ActuatorRing.Evaluate(V3{iris.OuterRadius - 1, 0, z}) >= 0 {
+		t.Error("FAIL actuator ring")
+	}
+	// pivot pins on the pivot ring
+	if iris.PivotRing.Evaluate(V3{k.PivotRadius, 0, k.RingThickness + 0.5*k.BladeThickness}) >= 0 {
+		t.Error("FAIL pivot ring")
+	}
+	k.MinAperture = 20
+	if _, err := NewIris(k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Sprocket(t *testing.T) {
+	c, err := ChainLookup("ISO08B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ChainLookup("ANSI99"); err == nil {
+		t.Error("FAIL expected lookup error")
+	}
+	n := 20
+	rp := 0.5 * c.PitchDiameter(n)
+	if Abs(rp-40.5914) > 1e-3 {
+		t.Errorf("FAIL pitch diameter %f", 2*rp)
+	}
+	s, err := Sprocket2D(c, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the rollers seat on the pitch circle
+	step := Tau / float64(n)
+	for i := 0; i < n; i++ {
+		a := step * float64(i)
+		if s.Evaluate(V2{math.Cos(a), math.Sin(a)}.MulScalar(rp)) <= 0.5*c.RollerDiameter {
+			t.Errorf("FAIL roller %d", i)
+		}
+	}
+	// the teeth are between the rollers and reach the tip diameter
+	a := 0.5 * step
+	rt := 0.5 * c.TipDiameter(n)
+	if s.Evaluate(V2{math.Cos(a), math.Sin(a)}.MulScalar(rt-0.05)) >= 0 || s.Evaluate(V2{math.Cos(a), math.Sin(a)}.MulScalar(rt+0.05)) <= 0 {
+		t.Error("FAIL tooth tip")
+	}
+
+	s3, err := Sprocket3D(&SprocketParms{Chain: c, Teeth: n, HubDiameter: 30, HubLength: 10, BoreDiameter: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s3.BoundingBox()
+	if Abs(bb.Max.Z-(0.93*c.InnerWidth+10)) > 1e-6 || s3.Evaluate(V3{0, 0, 5}) <= 0 || s3.Evaluate(V3{10, 0, 12}) >= 0 {
+		t.Errorf("FAIL sprocket 3D %v", bb)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Pulley(t *testing.T) {
+	for _, x := range []struct {
+		belt  string
+		teeth int
+		pd    float64 // pitch diameter
+	}{
+		{"GT2_2mm", 20, 12.732},
+		{"GT2_3mm", 24, 22.918},
+		{"GT3_5mm", 30, 47.746},
+		{"HTD_5M", 18, 28.648},
+		{"HTD_8M", 22, 56.023},
+	} {
+		b, err := BeltLookup(x.belt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if Abs(2*b.PitchRadius(x.teeth)-x.pd) > 1e-3 {
+			t.Errorf("FAIL %s pitch diameter %f", x.belt, 2*b.PitchRadius(x.teeth))
+		}
+		r := b.OuterRadius(x.teeth)
+		s := Pulley2D(b, x.teeth)
+		bb := s.BoundingBox()
+		if !bb.Min.Equals(V2{-r, -r}, tolerance) || !bb.Max.Equals(V2{r, r}, tolerance) {
+			t.Errorf("FAIL %s bounding box %v", x.belt, bb)
+		}
+		// count the grooves around the mid tooth height
+		rm := r - 0.5*b.ToothHeight
+		n := 0
+		const samples = 3600
+		inside := s.Evaluate(V2{rm, 0}) < 0
+		for i := 1; i <= samples; i++ {
+			in := s.Evaluate(PolarToXY(rm, Tau*float64(i)/samples)) < 0
+			if in && !inside {
+				n++
+			}
+			inside = in
+		}
+		if n != x.teeth {
+			t.Errorf("FAIL %s %d grooves, expected %d", x.belt, n, x.teeth)
+		}
+	}
+	k := &PulleyParms{
+		Belt:            "GT2_2mm",
+		NumberTeeth:     20,
+		BeltWidth:       6,
+		FlangeHeight:    1,
+		FlangeThickness: 1,
+		BoreRadius:      2.5,
+		HubRadius:       8,
+		HubHeight:       5,
+		SetScrewRadius:  1.5,
+	}
+	s, err := Pulley3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the hub is larger than the flanges
+	bb := s.BoundingBox()
+	if !bb.Min.Equals(V3{-8, -8, 0}, tolerance) || !bb.Max.Equals(V3{8, 8, 13}, tolerance) {
+		t.Errorf("FAIL bounding box %v", bb)
+	}
+	for _, x := range []struct {
+		p      V3
+		inside bool
+	}{
+		{V3{0, 0, 6}, false},    // bore
+		{V3{3, 0, 8}, true},     // teeth
+		{V3{6.5, 0, 5.5}, true}, // flange
+		{V3{6.5, 0, 8}, false},  // outside the teeth
+		{V3{6, 0, 2.5}, false},  // set screw
+		{V3{0, 6, 2.5}, true},   // hub
+	} {
+		if (s.Evaluate(x.p) < 0) != x.inside {
+			t.Errorf("FAIL %v", x.p)
+		}
+	}
+	k.Belt = "XL"
+	if _, err := Pulley3D(k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_TSlot(t *testing.T) {
+	for _, name := range []string{"2020", "3030", "4040"} {
+		k, err := TSlotLookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := TSlot2D(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := 0.5 * k.Size
+		bb := s.BoundingBox()
+		if !bb.Min.Equals(V2{-h, -h}, tolerance) || !bb.Max.Equals(V2{h, h}, tolerance) {
+			t.Errorf("FAIL %s bounding box %v", name, bb)
+		}
+		w := 0.5 * k.SlotWidth
+		cw := 0.5 * k.CavityWidth
+		x1 := h - k.LipThickness
+		for _, x := range []struct {
+			p      V2
+			inside bool
+		}{
+			{V2{h - 0.1, 0}, false},                                    // slot opening
+			{V2{0, h - 0.1}, false},                                    // slot opening (+y face)
+			{V2{h - 0.5*k.LipThickness, 0.5 * (w + cw)}, true},         // lip
+			{V2{x1 - 0.1, 0.5 * (w + cw)}, false},                      // cavity behind the lip
+			{V2{h - k.SlotDepth - 0.1, 0}, true},                       // below the slot
+			{V2{0, 0}, false},                                          // center bore
+			{V2{h - 0.5*k.LipThickness, h - 0.5*k.LipThickness}, true}, // corner
+		} {
+			if (s.Evaluate(x.p) < 0) != x.inside {
+				t.Errorf("FAIL %s %v", name, x.p)
+			}
+		}
+	}
+	if _, err := TSlot2D("1010"); err == nil {
+		t.Error("FAIL expected error")
+	}
+	// the nut fits in the slot
+	nut, err := TSlotNut3D(&TSlotNutParms{"2020", 10, 2, 0.2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := nut.BoundingBox()
+	if !bb.Min.Equals(V3{-5, -5.3, -5.9}, tolerance) || !bb.Max.Equals(V3{5, 5.3, 0}, tolerance) {
+		t.Errorf("FAIL nut bounding box %v", bb)
+	}
+	if nut.Evaluate(V3{0, 0, -3}) < 0 || nut.Evaluate(V3{0, 4, -3}) > 0 {
+		t.Error("FAIL nut")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_HoseBarb(t *testing.T) {
+	k := &HoseBarbParms{
+		OuterRadius: 4,
+		InnerRadius: 2,
+		NumberBarbs: 3,
+		BarbLength:  3,
+		BarbAngle:   DtoR(20),
+		Taper:       0.5,
+	}
+	s, err := HoseBarb3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := 4 + 3*math.Tan(DtoR(20))
+	bb := s.BoundingBox()
+	if !bb.Min.Equals(V3{-r, -r, 0}, tolerance) || !bb.Max.Equals(V3{r, r, 9}, tolerance) {
+		t.Errorf("FAIL bounding box %v", bb)
+	}
+	for _, x := range []struct {
+		p      V3
+		inside bool
+	}{
+		{V3{3, 0, 1}, true},      // wall
+		{V3{1, 0, 1}, false},     // bore
+		{V3{4.9, 0, 0.1}, true},  // barb crest
+		{V3{4.9, 0, 2.9}, false}, // barb ramp
+		{V3{3.8, 0, 2.9}, true},  // barb root
+		{V3{3, 0, 9.1}, false},   // beyond the tip
+	} {
+		if (s.Evaluate(x.p) < 0) != x.inside {
+			t.Errorf("FAIL %v", x.p)
+		}
+	}
+	// reducer with a collar
+	k1 := *k
+	k1.OuterRadius = 3
+	k1.InnerRadius = 1.5
+	c, err := HoseCoupler3D(&HoseCouplerParms{k, &k1, 6, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb = c.BoundingBox()
+	if !EqualFloat64(bb.Min.Z, -11, tolerance) || !EqualFloat64(bb.Max.Z, 11, tolerance) {
+		t.Errorf("FAIL coupler bounding box %v", bb)
+	}
+	for _, x := range []struct {
+		p      V3
+		inside bool
+	}{
+		{V3{5.5, 0, 0}, true},  // collar
+		{V3{0, 0, 0}, false},   // bore through the collar
+		{V3{1.8, 0, 5}, false}, // +z bore
+		{V3{1.8, 0, -5}, true}, // -z wall
+	} {
+		if (c.Evaluate(x.p) < 0) != x.inside {
+			t.Errorf("FAIL coupler %v", x.p)
+		}
+	}
+	k.Taper = 2
+	if _, err := HoseBarb3D(k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Impeller(t *testing.T) {
+	// cambered section: the arc passes through the chord ends and the camber point
+	b := CamberedBlade2D(20, 0.1, 1)
+	for _, p := range []V2{{0, -10}, {0, 10}, {2, 0}} {
+		if d := b.Evaluate(p); Abs(d+0.5) > 1e-9 {
+			t.Errorf("FAIL camber %v %f", p, d)
+		}
+	}
+	if b.Evaluate(V2{0, 0}) <= 0 || CamberedBlade2D(20, -0.1, 1).Evaluate(V2{-2, 0}) >= 0 {
+		t.Error("FAIL camber")
+	}
+	// flat plate section
+	flat := CamberedBlade2D(20, 0, 1)
+	for _, x := range []struct {
+		p V2
+		d float64
+	}{{V2{0, 0}, -0.5}, {V2{3, 0}, 2.5}, {V2{0, 14}, 3.5}} {
+		if d := flat.Evaluate(x.p); !EqualFloat64(d, x.d, tolerance) {
+			t.Errorf("FAIL flat %v %f expected %f", x.p, d, x.d)
+		}
+	}
+
+	// the blade loft sections are its children
+	tip := CamberedBlade2D(30, 0.1, 1)
+	loft, err := BladeLoft3D(b, tip, 10, 40, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := loft.(Parent2).Children2(); len(c) != 2 || c[0] != b || c[1] != tip {
+		t.Error("FAIL blade loft children")
+	}
+
+	k := &ImpellerParms{
+		Blades:       5,
+		HubRadius:    10,
+		HubHeight:    12,
+		TipRadius:    40,
+		BoreDiameter: 5,
+		Thickness:    1.5,
+		HubChord:     10,
+		TipChord:     14,
+		HubStagger:   DtoR(20),
+		TipStagger:   DtoR(60),
+	}
+	s, err := Impeller3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the blade chords pass through the blade axes
+	step := Tau / float64(k.Blades)
+	for i := 0; i < k.Blades; i++ {
+		a := step * float64(i)
+		for _, r := range []float64{15, 25, 39} {
+			if s.Evaluate(V3{r * math.Cos(a), r * math.Sin(a), 0}) >= 0 {
+				t.Errorf("FAIL blade %d at %f", i, r)
+			}
+		}
+	}
+	// the tip section is staggered by 60 degrees from the axis
+	r := 39.0
+	u := V2{math.Sin(k.TipStagger), math.Cos(k.TipStagger)}.MulScalar(5)
+	if s.Evaluate(V3{r * math.Cos(-u.X/r), r * math.Sin(-u.X/r), u.Y}) >= 0 {
+		t.Error("FAIL tip stagger")
+	}
+	if s.Evaluate(V3{0, 0, 0}) <= 0 || s.Evaluate(V3{25, 0, 8}) <= 0 {
+		t.Error("FAIL impeller")
+	}
+	k.TipChord = 100
+	if _, err := Impeller3D(k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Jar(t *testing.T) {
+	k := &JarParms{
+		Diameter:    50,
+		Height:      60,
+		Wall:        3,
+		Floor:       3,
+		NeckHeight:  12,
+		ThreadPitch: 4,
+		Starts:      2,
+		Tolerance:   0.4,
+		KnurlPitch:  6,
+		ORing:       2,
+	}
+	jar, lid, err := Jar3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := 0.5 * k.LidDiameter()
+	// floor, wall and neck
+	for _, p := range []V3{{0, 0, 1.5}, {r - 1.5, 0, 20}, {26.5, 0, 55}} {
+		if jar.Evaluate(p) >= 0 {
+			t.Errorf("FAIL jar %v", p)
+		}
+	}
+	if jar.Evaluate(V3{0, 0, 30}) <= 0 || jar.Evaluate(V3{0, 0, 61}) <= 0 {
+		t.Error("FAIL jar cavity")
+	}
+	// lid top, and the groove over the rim
+	if lid.Evaluate(V3{0, 0, 62}) >= 0 || lid.Evaluate(V3{26.5, 0, 60.5}) <= 0 {
+		t.Error("FAIL lid")
+	}
+	// the threads mesh without interference
+	for z := 48.0; z <= 60; z += 0.25 {
+		for a := 0.0; a < Tau; a += Tau / 36 {
+			for x := 27.0; x <= 34; x += 0.25 {
+				p := V3{x * math.Cos(a), x * math.Sin(a), z}
+				if jar.Evaluate(p) < -1e-6 && lid.Evaluate(p) < -1e-6 {
+					t.Fatalf("FAIL interference at %v", p)
+				}
+			}
+		}
+	}
+	k.Height = 20
+	if _, _, err := Jar3D(k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ORingGroove(t *testing.T) {
+	o, err := ORingLookup("AS568-210")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ORingLookup("AS568-999"); err == nil {
+		t.Error("FAIL expected error")
+	}
+	// face gland: 25% squeeze with the O-ring inner diameter on the groove wall
+	k := &ORingGrooveParms{ORing: o, Gland: GlandFace, Diameter: o.ID}
+	g, err := k.Groove()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Abs(g.Depth-0.75*o.CS) > tolerance || g.Stretch != 0 {
+		t.Errorf("FAIL face groove %+v", g)
+	}
+	if Abs(o.Squeeze(g.Depth, 0)-0.25) > tolerance {
+		t.Error("FAIL squeeze")
+	}
+	// the O-ring fills 80% of the gland
+	if Abs(0.25*Pi*o.CS*o.CS/(g.Width*g.Depth)-0.8) > tolerance {
+		t.Errorf("FAIL face groove width %f", g.Width)
+	}
+	s, err := ORingGroove3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := 0.5*o.ID + 0.5*g.Width
+	if s.Evaluate(V3{r, 0, -0.5 * g.Depth}) >= 0 || s.Evaluate(V3{r, 0, -1.1 * g.Depth}) <= 0 {
+		t.Error("FAIL face groove")
+	}
+
+	// piston gland: the stretched O-ring is squeezed by 20% against the bore
+	k = &ORingGrooveParms{ORing: MetricORing(20, 2), Gland: GlandPiston, Diameter: 25, Gap: 0.1}
+	g, err = k.Groove()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Stretch <= 0 || g.CS >= 2 {
+		t.Errorf("FAIL piston stretch %+v", g)
+	}
+	if sq := k.ORing.Squeeze(0.5*(k.Diameter-g.InnerDiameter), g.Stretch); Abs(sq-0.2) > 1e-6 {
+		t.Errorf("FAIL piston squeeze %f", sq)
+	}
+	if Abs(g.Depth-0.5*(g.OuterDiameter-g.InnerDiameter)) > tolerance {
+		t.Errorf("FAIL piston depth %+v", g)
+	}
+
+	// rod gland: the groove is cut into the bore
+	k = &ORingGrooveParms{ORing: MetricORing(10, 2), Gland: GlandRod, Diameter: 10, Gap: 0.1}
+	s, err = ORingGroove3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Evaluate(V3{6, 0, 0}) >= 0 || s.Evaluate(V3{6, 0, 2}) <= 0 || s.Evaluate(V3{7, 0, 0}) <= 0 {
+		t.Error("FAIL rod groove")
+	}
+	k.Gap = 2
+	if _, err := k.Groove(); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Tag(t *testing.T) {
+	k := &TagParms{
+		Size:         V2{60, 20},
+		CornerRadius: 3,
+		Thickness:    2,
+		HoleDiameter: 5,
+		Margin:       2,
+		Border:       1,
+		Depth:        0.6,
+	}
+	// a label with a 2:1 aspect ratio
+	label := Box2D(V2{4, 2}, 0)
+	s, err := Tag3D(k, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The border is inside x = -10..28, y = -8..8. The label area is
+	// x = -7..25, y = -5..5 so the label is 20 x 10 centered on x = 9.
+	for _, p := range []V3{{9, 0, 1.8}, {18.9, 4.9, 1.8}, {9, 7.5, 1.8}, {-20, 0, 1}} {
+		if s.Evaluate(p) <= 0 {
+			t.Errorf("FAIL engraved %v", p)
+		}
+	}
+	for _, p := range []V3{{9, 0, 1}, {9, 6, 1.8}, {20, 0, 1.8}, {-20, 4, 1.8}} {
+		if s.Evaluate(p) >= 0 {
+			t.Errorf("FAIL tag %v", p)
+		}
+	}
+	k.Emboss = true
+	s, err = Tag3D(k, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Evaluate(V3{9, 0, 2.3}) >= 0 || s.Evaluate(V3{9, 6, 2.3}) <= 0 || s.Evaluate(V3{9, 7.5, 2.3}) >= 0 {
+		t.Error("FAIL embossed")
+	}
+	k.HoleDiameter = 20
+	if _, err := Tag3D(k, label); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Braille(t *testing.T) {
+	lines, err := BrailleCells("Ab 10\n2a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// capital sign (6), a (1), b (12), space, number sign (3456), a (1), j (245)
+	// number sign, b (12), letter sign (56), a (1)
+	expected := [][]BrailleCell{
+		{0x20, 0x01, 0x03, 0x00, 0x3c, 0x01, 0x1a},
+		{0x3c, 0x03, 0x30, 0x01},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("FAIL lines %v", lines)
+	}
+	for i := range expected {
+		if fmt.Sprint(lines[i]) != fmt.Sprint(expected[i]) {
+			t.Errorf("FAIL line %d %v", i, lines[i])
+		}
+	}
+	if _, err := BrailleCells("tab\t"); err == nil {
+		t.Error("FAIL expected error")
+	}
+
+	k := BrailleStandard
+	s, err := Braille3D("b\nc", &k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b is dots 1 and 2, c (on the next line) is dots 1 and 4
+	z := 0.5 * k.DotHeight
+	for _, p := range []V3{{0, 0, z}, {0, -2.5, z}, {0, -10, z}, {2.5, -10, z}} {
+		if s.Evaluate(p) >= 0 {
+			t.Errorf("FAIL dot %v", p)
+		}
+	}
+	for _, p := range []V3{{2.5, 0, z}, {0, -5, z}, {0, -12.5, z}, {0, 0, 0.6}, {0, 0, -0.1}} {
+		if s.Evaluate(p) <= 0 {
+			t.Errorf("FAIL no dot %v", p)
+		}
+	}
+	// the dot is a spherical cap
+	if Abs(s.Evaluate(V3{0, 0, 1})-0.5) > tolerance || Abs(s.Evaluate(V3{0.75, 0, 0})) > tolerance {
+		t.Error("FAIL dome")
+	}
+	k.CellSpacing = 3
+	if _, err := Braille3D("a", &k); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// tiffEntry is a TIFF field for testTIFF.
+type tiffEntry struct {
+	tag, typ uint16
+	v        []float64
+	s        string
+}
+
+// testTIFF returns a little endian TIFF file with the image data at offset 8.
+func testTIFF(entries []tiffEntry, data []byte) []byte {
+	bo := binary.LittleEndian
+	ifd := 8 + len(data)
+	extra := ifd + 2 + 12*len(entries) + 4
+	var b, x bytes.Buffer
+	b.WriteString("II")
+	binary.Write(&b, bo, uint16(42))
+	binary.Write(&b, bo, uint32(ifd))
+	b.Write(data)
+	binary.Write(&b, bo, uint16(len(entries)))
+	for _, e := range entries {
+		var v bytes.Buffer
+		count := len(e.v)
+		if e.typ == 2 {
+			v.WriteString(e.s + "\x00")
+			count = v.Len()
+		}
+		for _, f := range e.v {
+			switch e.typ {
+			case 3:
+				binary.Write(&v, bo, uint16(f))
+			case 4:
+				binary.Write(&v, bo, uint32(f))
+			case 12:
+				binary.Write(&v, bo, f)
+			}
+		}
+		binary.Write(&b, bo, e.tag)
+		binary.Write(&b, bo, e.typ)
+		binary.Write(&b, bo, uint32(count))
+		if v.Len() > 4 {
+			binary.Write(&b, bo, uint32(extra+x.Len()))
+			x.Write(v.Bytes())
+		} else {
+			b.Write(append(v.Bytes(), make([]byte, 4-v.Len())...))
+		}
+	}
+	binary.Write(&b, bo, uint32(0))
+	b.Write(x.Bytes())
+	return b.Bytes()
+}
+
+func Test_DEM(t *testing.T) {
+	// float32 samples with no data
+	var data bytes.Buffer
+	for _, h := range []float32{1, 2, 3, -9999, 5, 6} {
+		binary.Write(&data, binary.LittleEndian, h)
+	}
+	b := testTIFF([]tiffEntry{
+		{256, 3, []float64{3}, ""},
+		{257, 3, []float64{2}, ""},
+		{258, 3, []float64{32}, ""},
+		{273, 4, []float64{8}, ""},
+		{279, 4, []float64{24}, ""},
+		{339, 3, []float64{3}, ""},
+		{33550, 12, []float64{10, 20, 0}, ""},
+		{42113, 2, nil, "-9999"},
+	}, data.Bytes())
+	d, err := ReadGeoTIFF(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Cols != 3 || d.Rows != 2 || d.CellSize != (V2{10, 20}) || d.At(2, 1) != 6 || !math.IsNaN(d.At(0, 1)) {
+		t.Errorf("FAIL float32 GeoTIFF %+v", d)
+	}
+	// a truncated file
+	for n := range b {
+		if _, err := ReadGeoTIFF(bytes.NewReader(b[:n])); err == nil {
+			t.Errorf("FAIL expected error for a GeoTIFF truncated to %d bytes", n)
+		}
+	}
+	// a truncated strip (the byte count covers the first row only)
+	b = testTIFF([]tiffEntry{
+		{256, 3, []float64{3}, ""},
+		{257, 3, []float64{2}, ""},
+		{258, 3, []float64{32}, ""},
+		{273, 4, []float64{8}, ""},
+		{279, 4, []float64{8}, ""},
+		{339, 3, []float64{3}, ""},
+		{33550, 12, []float64{10, 20, 0}, ""},
+	}, data.Bytes())
+	if _, err := ReadGeoTIFF(bytes.NewReader(b)); err == nil {
+		t.Error("FAIL expected truncated GeoTIFF error")
+	}
+
+	// deflate compressed int16 samples with horizontal differencing, geographic
+	data.Reset()
+	z := zlib.NewWriter(&data)
+	for _, row := range [][]int16{{-5, 10, 3, -32768}, {100, 90, 80, 70}} {
+		prev := int16(0)
+		for _, h := range row {
+			binary.Write(z, binary.LittleEndian, h-prev)
+			prev = h
+		}
+	}
+	z.Close()
+	b = testTIFF([]tiffEntry{
+		{256, 3, []float64{4}, ""},
+		{257, 3, []float64{2}, ""},
+		{258, 3, []float64{16}, ""},
+		{259, 3, []float64{8}, ""},
+		{273, 4, []float64{8}, ""},
+		{278, 3, []float64{2}, ""},
+		{279, 4, []float64{float64(data.Len())}, ""},
+		{317, 3, []float64{2}, ""},
+		{339, 3, []float64{2}, ""},
+		{33550, 12, []float64{1.0 / 3600, 1.0 / 3600, 0}, ""},
+		{33922, 12, []float64{0, 0, 0, 10, 1.0 / 3600, 0}, ""},
+		{34735, 3, []float64{1, 1, 0, 1, 1024, 0, 1, 2}, ""},
+	}, data.Bytes())
+	d, err = ReadGeoTIFF(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(d.Elevation) != "[-5 10 3 -32768 100 90 80 70]" {
+		t.Errorf("FAIL int16 GeoTIFF %v", d.Elevation)
+	}
+	if Abs(d.CellSize.X-111320.0/3600) > 1e-3 || Abs(d.CellSize.Y-110540.0/3600) > 1e-3 {
+		t.Errorf("FAIL geographic cell size %v", d.CellSize)
+	}
+
+	// ASCII grid
+	asc := "ncols 3\nnrows 3\nxllcorner 0\nyllcorner 0\ncellsize 5\nNODATA_value -1\n 10 10 10\n 5 5 -1\n 0 0 0\n"
+	d, err = ReadASCIIGrid(strings.NewReader(asc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Cols != 3 || d.Rows != 3 || d.CellSize != (V2{5, 5}) || d.At(0, 0) != 10 || !math.IsNaN(d.At(2, 1)) {
+		t.Errorf("FAIL ASCII grid %+v", d)
+	}
+	c, err := d.Crop(1, 1, 2, 2)
+	if err != nil || fmt.Sprint(c.Elevation) != "[5 NaN 0 0]" {
+		t.Errorf("FAIL crop %v", c)
+	}
+
+	// terrain: 10 mm wide, the north edge is 10 m higher than the south edge
+	s, err := Terrain3D(d, &TerrainParms{Width: 10, Exaggeration: 0.4, Base: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s.BoundingBox()
+	if !bb.Equals(Box3{V3{0, 0, 0}, V3{10, 10, 5}}, tolerance) {
+		t.Errorf("FAIL terrain bb %v", bb)
+	}
+	// the height at the center is 1 + 5 * 0.4 = 3
+	if s.Evaluate(V3{2.5, 5, 2.9}) >= 0 || s.Evaluate(V3{2.5, 5, 3.1}) <= 0 {
+		t.Error("FAIL terrain height")
+	}
+	// no data is the lowest elevation
+	if s.Evaluate(V3{9.9, 5, 1.2}) <= 0 || s.Evaluate(V3{5, 0.1, 0.9}) >= 0 {
+		t.Error("FAIL terrain")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Fractals(t *testing.T) {
+	s, err := MengerSponge3D(3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the center and the face tunnels are empty, the corners and edges are solid
+	for _, p := range []V3{{0, 0, 0}, {0, 0, 1.4}, {1, 0, 0}} {
+		if s.Evaluate(p) <= 0 {
+			t.Errorf("FAIL sponge hole %v", p)
+		}
+	}
+	// the second level removes the center of the corner cubes
+	for _, p := range []V3{{1.4, 1.4, 1.4}, {1.4, 1.4, 0.6}} {
+		if s.Evaluate(p) >= 0 {
+			t.Errorf("FAIL sponge solid %v", p)
+		}
+	}
+	if s.Evaluate(V3{1, 1, 1}) <= 0 {
+		t.Error("FAIL sponge level 2")
+	}
+	if Abs(s.Evaluate(V3{3, 0, 0})-1.5) > tolerance {
+		t.Error("FAIL sponge distance")
+	}
+
+	b, err := Mandelbulb3D(&MandelbulbParms{Power: 8, Iterations: 12, Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Evaluate(V3{5, 0, 0}) >= 0 || b.Evaluate(V3{3, 2, 1}) >= 0 {
+		t.Error("FAIL mandelbulb inside")
+	}
+	// the distance estimate is within the Lipschitz bound of the true distance
+	l := b.(*MandelbulbSDF3).Lipschitz()
+	for _, x := range []float64{12, 15, 20} {
+		d := b.Evaluate(V3{x, 0, 0})
+		if d <= 0 || d/l > x-9 {
+			t.Errorf("FAIL mandelbulb distance %f at %f", d, x)
+		}
+	}
+	if _, err := Mandelbulb3D(&MandelbulbParms{Power: 8, Size: 1}); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_LSystem(t *testing.T) {
+	algae := &LSystem{Axiom: "A", Rules: map[rune]string{'A': "AB", 'B': "A"}}
+	if s := algae.Expand(4); s != "ABAABABA" {
+		t.Errorf("FAIL expand %s", s)
+	}
+
+	k := &TurtleParms{Angle: DtoR(90), Length: 10, Radius: 1, LengthScale: 0.5, RadiusScale: 0.5}
+	bones, err := Turtle("F[+F][-!\"F]&F", k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Bone{
+		{V3{0, 0, 0}, V3{0, 0, 10}, 1},
+		{V3{0, 0, 10}, V3{-10, 0, 10}, 1},
+		{V3{0, 0, 10}, V3{5, 0, 10}, 0.5},
+		{V3{0, 0, 10}, V3{0, 10, 10}, 1},
+	}
+	if len(bones) != len(expected) {
+		t.Fatalf("FAIL bones %v", bones)
+	}
+	for i, b := range bones {
+		e := expected[i]
+		if !b.A.Equals(e.A, tolerance) || !b.B.Equals(e.B, tolerance) || b.Radius != e.Radius {
+			t.Errorf("FAIL bone %d %v", i, b)
+		}
+	}
+	if _, err := Turtle("F[F", k); err == nil {
+		t.Error("FAIL expected error")
+	}
+
+	s, err := Skeleton3D(bones, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []V3{{0, 0, 5}, {-9, 0, 10}, {4.5, 0, 10}, {0, 9, 10}} {
+		if s.Evaluate(p) >= 0 {
+			t.Errorf("FAIL skeleton %v", p)
+		}
+	}
+	if s.Evaluate(V3{5, 0, 5}) <= 0 || s.Evaluate(V3{4.5, 0, 10.7}) <= 0 {
+		t.Error("FAIL skeleton")
+	}
+	// the joint is filleted
+	p := V3{1.1, 0, 9.1}
+	s0, _ := Skeleton3D(bones, 0)
+	if s.Evaluate(p) >= 0 || s0.Evaluate(p) <= 0 {
+		t.Error("FAIL fillet")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Ribs(t *testing.T) {
+	// a rib between the floor and a sloping face
+	s, err := Rib3D(V3{0, 0, 0}, V3{10, 0, 0}, V3{0, 0, 5}, V3{10, 0, 8}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []V3{{5, 0, 3}, {5, 0.4, 6}, {9, -0.4, 0.5}} {
+		if s.Evaluate(p) >= 0 {
+			t.Errorf("FAIL rib %v", p)
+		}
+	}
+	for _, p := range []V3{{5, 0, 7}, {5, 0.6, 3}, {11, 0, 3}, {5, 0, -0.5}} {
+		if s.Evaluate(p) <= 0 {
+			t.Errorf("FAIL no rib %v", p)
+		}
+	}
+	if _, err := Rib3D(V3{0, 0, 0}, V3{10, 0, 0}, V3{0, 0, 5}, V3{10, 1, 8}, 1); err == nil {
+		t.Error("FAIL expected error")
+	}
+	// a gusset in the corner of a floor (z = 0) and a wall (x = 0)
+	s, err = Gusset3D(V3{0, 5, 0}, V3{1, 0, 0}, V3{0, 0, 1}, 10, 20, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Evaluate(V3{2, 5, 2}) >= 0 || s.Evaluate(V3{2, 5.9, 10}) >= 0 {
+		t.Error("FAIL gusset")
+	}
+	if s.Evaluate(V3{6, 5, 10}) <= 0 || s.Evaluate(V3{2, 6.1, 2}) <= 0 || s.Evaluate(V3{-0.5, 5, 2}) <= 0 {
+		t.Error("FAIL no gusset")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ThickenBelow(t *testing.T) {
+	// a plate with a thin (1 mm) and a thick (6 mm) part
+	thin := Box3D(V3{20, 20, 1}, 0)
+	thick := Transform3D(Box3D(V3{20, 20, 6}, 0), Translate3d(V3{20, 0, 0}))
+	s := Union3D(thin, thick)
+	if w := WallThickness3(s, V3{-5, 0, 0.6}); Abs(w-1) > 1e-3 {
+		t.Errorf("FAIL thin wall %f", w)
+	}
+	if w := WallThickness3(s, V3{20, 0, 3.2}); Abs(w-6) > 1e-3 {
+		t.Errorf("FAIL thick wall %f", w)
+	}
+	s = ThickenBelow(s, 3)
+	// the thin part is now 3 mm thick
+	if s.Evaluate(V3{-5, 0, 1.4}) >= 0 || s.Evaluate(V3{-5, 0, -1.4}) >= 0 || s.Evaluate(V3{-5, 0, 1.6}) <= 0 {
+		t.Error("FAIL thickened")
+	}
+	// the thick part is unchanged
+	if Abs(s.Evaluate(V3{20, 0, 3.5})-0.5) > 1e-6 {
+		t.Error("FAIL unchanged")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_EscapeHoles(t *testing.T) {
+	// a hollow cube with 2 mm walls
+	s := Difference3D(Box3D(V3{20, 20, 20}, 0), Box3D(V3{16, 16, 16}, 0))
+	s1, points, err := EscapeHoles3D(s, 2, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 {
+		t.Fatalf("FAIL %d voids", len(points))
+	}
+	if points[0].Sub(V3{0, 0, -8}).Length() > 0.5 {
+		t.Errorf("FAIL hole at %v", points[0])
+	}
+	// the floor is drilled through
+	p := V3{points[0].X, points[0].Y, -9}
+	if s.Evaluate(p) >= 0 || s1.Evaluate(p) <= 0 {
+		t.Error("FAIL no hole")
+	}
+	// the rest of the floor and the walls are intact
+	if s1.Evaluate(V3{5, 5, -9}) >= 0 || s1.Evaluate(V3{0, 0, 9}) >= 0 {
+		t.Error("FAIL walls")
+	}
+	// two voids
+	s = Union3D(s, Transform3D(s, Translate3d(V3{30, 0, 5})))
+	_, points, _ = EscapeHoles3D(s, 2, 40)
+	if len(points) != 2 {
+		t.Errorf("FAIL %d voids", len(points))
+	}
+	// a solid part has no voids
+	_, points, _ = EscapeHoles3D(Sphere3D(10), 2, 20)
+	if len(points) != 0 {
+		t.Errorf("FAIL %d voids", len(points))
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_SplitForPrinting(t *testing.T) {
+	part := Box3D(V3{100, 40, 20}, 0)
+	maxBox := V3{60, 60, 60}
+	for _, c := range []KeyType{KeyPin, KeyDovetail, KeySphere} {
+		k := KeySpec{
+			Type:      c,
+			Size:      4,
+			Length:    8,
+			Clearance: 0.2,
+			Number:    2,
+		}
+		pieces, err := SplitForPrinting(part, maxBox, &k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pieces) != 2 {
+			t.Fatalf("FAIL %d pieces", len(pieces))
+		}
+		for _, s := range pieces {
+			if s.BoundingBox().Size().Sub(maxBox).MaxComponent() > 0 {
+				t.Errorf("FAIL piece size %v", s.BoundingBox().Size())
+			}
+		}
+		// away from the keys each point is in one piece
+		for _, p := range []V3{{-30, 0, 0}, {30, 0, 0}, {-1, 18, 8}, {1, -18, -8}} {
+			d0, d1 := pieces[0].Evaluate(p), pieces[1].Evaluate(p)
+			if (d0 < 0) == (d1 < 0) {
+				t.Errorf("FAIL %v %f %f", p, d0, d1)
+			}
+		}
+		// the keys on the lower piece fit the sockets in the upper piece
+		n := 0
+		for y := -20.0; y <= 20; y += 0.5 {
+			for z := -10.0; z <= 10; z += 0.5 {
+				p := V3{1, y, z}
+				if pieces[0].Evaluate(p) < 0 {
+					n++
+					if pieces[1].Evaluate(p) < k.Clearance*0.5 {
+						t.Errorf("FAIL key interference at %v", p)
+					}
+				}
+			}
+		}
+		if n == 0 {
+			t.Error("FAIL no keys")
+		}
+	}
+	// a part that fits is not split
+	pieces, _ := SplitForPrinting(Sphere3D(10), maxBox, &KeySpec{})
+	if len(pieces) != 1 {
+		t.Errorf("FAIL %d pieces", len(pieces))
+	}
+	// the keys are placed automatically
+	k := KeySpec{Type: KeySphere, Size: 4, Clearance: 0.2, Points: []V3{{0, 0, 0}}}
+	if _, err := SplitForPrinting(part, maxBox, &k); err == nil {
+		t.Error("FAIL expected a Points error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_CutWithKeys(t *testing.T) {
+	part := Box3D(V3{40, 40, 20}, 0)
+	// an oblique plane
+	n := V3{0.2, 0.1, 1}.Normalize()
+	plane := Plane{V3{1, 2, 0}, n}
+	for _, c := range []KeyType{KeyPin, KeyDovetail, KeySphere} {
+		keys := KeySpec{
+			Type:      c,
+			Size:      4,
+			Length:    6,
+			Clearance: 0.2,
+			Number:    3,
+		}
+		top, bottom, err := CutWithKeys(part, plane, keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the halves are on each side of the plane
+		for _, p := range []V3{{15, -15, 8}, {-15, 15, -8}} {
+			d0, d1 := top.Evaluate(p), bottom.Evaluate(p)
+			if (d0 < 0) == (d1 < 0) || (d0 < 0) != (p.Sub(plane.Point).Dot(n) > 0) {
+				t.Errorf("FAIL %v %f %f", p, d0, d1)
+			}
+		}
+		// the male keys fit the sockets
+		m := 0
+		for x := -20.0; x <= 20; x += 0.5 {
+			for y := -20.0; y <= 20; y += 0.5 {
+				p := V3{x, y, 0}
+				// 1 mm above the plane
+				p = p.Add(n.MulScalar(plane.Point.Sub(p).Dot(n) + 1))
+				if bottom.Evaluate(p) < 0 {
+					m++
+					if top.Evaluate(p) < 0.5*keys.Clearance {
+						t.Errorf("FAIL key interference at %v", p)
+					}
+				}
+			}
+		}
+		if m == 0 {
+			t.Error("FAIL no keys")
+		}
+	}
+	// explicit key positions
+	keys := KeySpec{Type: KeySphere, Size: 4, Clearance: 0.2, Points: []V3{{10, 10, 0}}}
+	top, bottom, _ := CutWithKeys(part, Plane{V3{}, V3{0, 0, 1}}, keys)
+	if bottom.Evaluate(V3{10, 10, 1.9}) >= 0 || top.Evaluate(V3{10, 10, 2.1}) <= 0 || top.Evaluate(V3{10, 10, 2.3}) >= 0 {
+		t.Error("FAIL sphere key")
+	}
+	if _, _, err := CutWithKeys(part, Plane{}, keys); err == nil {
+		t.Error("FAIL zero normal")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_MakeMold(t *testing.T) {
+	part := Sphere3D(5)
+	for _, n := range []V3{{0, 0, 1}, {1, 0, 1}} {
+		halves, err := MakeMold(part, &MoldParms{
+			Normal:       n,
+			Margin:       4,
+			KeyRadius:    1,
+			KeyClearance: 0.2,
+			SprueRadius:  1,
+			VentRadius:   0.5,
+			NumberVents:  2,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		top, bottom := halves[0], halves[1]
+		bb := top.BoundingBox().Extend(bottom.BoundingBox())
+		size := bb.Size()
+		const steps = 30
+		for i := 0; i <= steps; i++ {
+			for j := 0; j <= steps; j++ {
+				for k := 0; k <= steps; k++ {
+					p := bb.Min.Add(size.Mul(V3{float64(i), float64(j), float64(k)}).DivScalar(steps))
+					d0 := top.Evaluate(p)
+					d1 := bottom.Evaluate(p)
+					// the halves don't overlap
+					if d0 < -tolerance && d1 < -tolerance {
+						t.Fatalf("FAIL normal %v: halves overlap at %v", n, p)
+					}
+					// the part cavity is empty
+					if part.Evaluate(p) < 0 && (d0 < 0 || d1 < 0) {
+						t.Fatalf("FAIL normal %v: mold material in the cavity at %v", n, p)
+					}
+				}
+			}
+		}
+		// mold material either side of the parting plane
+		u := n.Normalize()
+		w := u.Cross(V3{0, 1, 0}).Normalize()
+		if top.Evaluate(w.MulScalar(7).Add(u.MulScalar(3))) > 0 || bottom.Evaluate(w.MulScalar(7).Sub(u.MulScalar(3))) > 0 {
+			t.Errorf("FAIL normal %v: mold block", n)
+		}
+		// the sprue opens the cavity to the top of the block
+		if top.Evaluate(u.MulScalar(7)) < 0 {
+			t.Errorf("FAIL normal %v: sprue", n)
+		}
+	}
+	if _, err := MakeMold(part, &MoldParms{Point: V3{0, 0, 6}, Normal: V3{0, 0, 1}, Margin: 4}); err == nil {
+		t.Error("FAIL expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_RenderMeshAdaptive(t *testing.T) {
+	const chordError = 0.01
+	s := Sphere3D(10)
+	mesh := RenderMeshAdaptive(s, chordError)
+	// the mesh is closed
+	edges := make(map[[2]V3]int)
+	for _, tr := range mesh {
+		for i := 0; i < 3; i++ {
+			edges[[2]V3{tr.V[i], tr.V[(i+1)%3]}]++
+		}
+	}
+	for e, n := range edges {
+		if n != 1 || edges[[2]V3{e[1], e[0]}] != 1 {
+			t.Fatalf("FAIL open edge %v", e)
+		}
+	}
+	// the mesh is within the chord error
+	for _, tr := range mesh {
+		c := tr.V[0].Add(tr.V[1]).Add(tr.V[2]).DivScalar(3)
+		if Abs(s.Evaluate(c)) > chordError || Abs(s.Evaluate(tr.V[0])) > 1e-4 {
+			t.Fatalf("FAIL deviation %f", s.Evaluate(c))
+		}
+	}
+	v := MeshVolume(mesh)
+	if v0 := 4.0 / 3.0 * Pi * 1000; Abs(v-v0)/v0 > 0.01 {
+		t.Errorf("FAIL volume %f %f", v, v0)
+	}
+	// flat faces keep the coarse triangles
+	n := 0
+	for _, tr := range RenderMeshAdaptive(Box3D(V3{20, 20, 20}, 0), chordError) {
+		c := tr.V[0].Add(tr.V[1]).Add(tr.V[2]).DivScalar(3)
+		if Abs(c.X) < 5 && Abs(c.Y) < 5 && c.Z > 9 {
+			n++
+		}
+	}
+	// (10/0.63)^2 * 2 triangles at the coarse resolution
+	if n > 1000 {
+		t.Errorf("FAIL %d triangles on the face", n)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_EstimateMinFeatureSize(t *testing.T) {
+	box := Box3D(V3{20, 20, 20}, 0)
+	// a thin fin on a block
+	fin := Transform3D(Box3D(V3{10, 0.5, 10}, 0), Translate3d(V3{14, 0, 0}))
+	// a gap between two blocks
+	gap := Union3D(box, Transform3D(box, Translate3d(V3{21, 0, 0})))
+	tests := []struct {
+		s    SDF3
+		size float64
+	}{
+		{box, 20},
+		{Sphere3D(5), 10},
+		{Union3D(box, fin), 0.5},
+		{gap, 1},
+	}
+	for i, test := range tests {
+		if f := EstimateMinFeatureSize(test.s); Abs(f-test.size) > 0.05*test.size {
+			t.Errorf("FAIL test %d: feature size %f expected %f", i, f, test.size)
+		}
+	}
+	if n := AutoMeshCells(Sphere3D(5)); n != autoMinCells {
+		t.Errorf("FAIL sphere mesh cells %d", n)
+	}
+	// 4 cells across the fin
+	if n := AutoMeshCells(Union3D(box, fin)); n != 4*29/0.5+1 && n != 4*29/0.5 {
+		t.Errorf("FAIL fin mesh cells %d", n)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_CheckField3(t *testing.T) {
+	sphere := func(p V3) float64 {
+		return p.Length() - 5
+	}
+	bb := Box3{V3{-5, -5, -5}, V3{5, 5, 5}}
+	tests := []struct {
+		s     SDF3
+		issue FieldIssueType
+		at    func(p V3) bool
+	}{
+		// 3x too steep
+		{Func3D(func(p V3) float64 { return 3 * sphere(p) }, bb), FieldLipschitz, func(p V3) bool { return true }},
+		// a wrong sign region in the middle
+		{Func3D(func(p V3) float64 {
+			if p.Length() < 3 {
+				return -sphere(p)
+			}
+			return sphere(p)
+		}, bb), FieldSignFlip, func(p V3) bool { return p.Length() < 3.5 }},
+		// the bounding box is too small
+		{Func3D(sphere, Box3{V3{-3, -3, -3}, V3{3, 3, 3}}), FieldBoundingBox, func(p V3) bool { return p.Length() < 5 }},
+	}
+	for i, test := range tests {
+		r, err := CheckField3(test.s, 30)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Valid() {
+			t.Errorf("FAIL test %d: no issues", i)
+			continue
+		}
+		for _, x := range r.Issues {
+			if x.Type != test.issue || !test.at(x.Point) {
+				t.Errorf("FAIL test %d: %s", i, x.String())
+			}
+		}
+	}
+	// valid fields
+	for _, s := range []SDF3{Sphere3D(5), Box3D(V3{10, 20, 5}, 1), Capsule3D(10, 2)} {
+		r, _ := CheckField3(s, 30)
+		if !r.Valid() {
+			t.Errorf("FAIL %s", r)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_FuncSDF(t *testing.T) {
+	s3 := Func3D(func(p V3) float64 {
+		return p.Length() - 5
+	}, Box3{V3{-5, -5, -5}, V3{5, 5, 5}})
+	// it works with the operators
+	s3 = Transform3D(s3, Translate3d(V3{10, 0, 0}))
+	if Abs(s3.Evaluate(V3{0, 0, 0})-5) > tolerance {
+		t.Error("FAIL Func3D")
+	}
+	if !s3.BoundingBox().Equals(Box3{V3{5, -5, -5}, V3{15, 5, 5}}, tolerance) {
+		t.Error("FAIL Func3D bounding box")
+	}
+	s2 := Func2D(func(p V2) float64 {
+		return p.Length() - 1
+	}, Box2{V2{-1, -1}, V2{1, 1}})
+	if Abs(Extrude3D(s2, 2).Evaluate(V3{0, 0, 3})-2) > tolerance {
+		t.Error("FAIL Func2D")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ThreadedRod(t *testing.T) {
+	const r, p, l = 5.0, 1.0, 10.0
+	thread := ISOThread(r, p, "external")
+	root, major := threadRadii(thread, p)
+	if Abs(major-r) > 1e-6 || Abs(root-(r-0.6134*p)) > 0.01 {
+		t.Errorf("FAIL radii %f %f", root, major)
+	}
+	plain := Screw3D(thread, l, p, 1)
+	rod, err := ThreadedRod3D(&ThreadedRodParms{
+		Thread: thread,
+		Length: l,
+		Pitch:  p,
+		Starts: 1,
+		Bottom: ThreadEnd{Chamfer: 1, Higbee: true},
+		Top:    ThreadEnd{Runout: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(rho, theta, z float64) V3 {
+		return V3{rho * math.Cos(theta), rho * math.Sin(theta), z}
+	}
+	// count the angles where the points are inside the plain and finished rods
+	count := func(rho, z float64) (int, int) {
+		n0, n1 := 0, 0
+		for i := 0; i < 64; i++ {
+			q := at(rho, Tau*float64(i)/64, z)
+			if plain.Evaluate(q) < 0 {
+				n0++
+			}
+			if rod.Evaluate(q) < 0 {
+				n1++
+			}
+		}
+		return n0, n1
+	}
+	// chamfer
+	if n0, n1 := count(4.5, -4.9); n0 == 0 || n1 != 0 {
+		t.Errorf("FAIL chamfer %d %d", n0, n1)
+	}
+	if _, n1 := count(3.5, -4.9); n1 != 64 {
+		t.Error("FAIL chamfer core")
+	}
+	// higbee, no partial thread ridges at the bottom
+	if n0, n1 := count(4.6, -4.7); n0 == 0 || n1 != 0 {
+		t.Errorf("FAIL higbee %d %d", n0, n1)
+	}
+	// the thread is unchanged in the middle
+	for _, z := range []float64{-2, 0, 1} {
+		if n0, n1 := count(4.9, z); n0 != n1 {
+			t.Errorf("FAIL middle %d %d", n0, n1)
+		}
+	}
+	// runout
+	if n0, n1 := count(4.8, 4.9); n0 == 64 || n1 != 64 {
+		t.Errorf("FAIL runout %d %d", n0, n1)
+	}
+	// countersunk internal thread
+	tap, err := ThreadedRod3D(&ThreadedRodParms{
+		Thread:   ISOThread(r, p, "internal"),
+		Length:   l,
+		Pitch:    p,
+		Starts:   1,
+		Internal: true,
+		Top:      ThreadEnd{Chamfer: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tap.Evaluate(at(5.8, 0, 4.9)) >= 0 || tap.Evaluate(at(5.8, 0, 4)) <= 0 {
+		t.Error("FAIL countersink")
+	}
+	if _, err := ThreadedRod3D(&ThreadedRodParms{Thread: thread, Length: l, Pitch: p, Starts: 1, Internal: true, Top: ThreadEnd{Higbee: true}}); err == nil {
+		t.Error("FAIL internal higbee")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ThreadProfiles(t *testing.T) {
+	// Tr8x2
+	tr, err := ThreadLookup("Tr8x2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Form != "trapezoidal" {
+		t.Errorf("FAIL form %s", tr.Form)
+	}
+	ext, err := tr.Profile(tr.Radius, "external")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, major := threadRadii(ext, tr.Pitch)
+	if Abs(major-4) > 1e-6 || Abs(root-2.75) > 1e-6 {
+		t.Errorf("FAIL external radii %f %f", root, major)
+	}
+	// the thread width is pitch/2 at the pitch radius
+	if ext.Evaluate(V2{0.49, 3.5}) >= 0 || ext.Evaluate(V2{0.51, 3.5}) <= 0 {
+		t.Error("FAIL pitch radius width")
+	}
+	internal, err := tr.Profile(tr.Radius, "internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, major = threadRadii(internal, tr.Pitch)
+	if Abs(major-4.25) > 1e-6 || Abs(root-3) > 1e-6 {
+		t.Errorf("FAIL internal radii %f %f", root, major)
+	}
+	if _, err := tr.Profile(tr.Radius, "foo"); err == nil {
+		t.Error("FAIL bad mode")
+	}
+	// acme
+	acme, err := ThreadLookup("acme_1/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acme.Profile(acme.Radius, "external"); err != nil || acme.Form != "acme" {
+		t.Error("FAIL acme")
+	}
+	// buttress
+	const r, p = 10.0, 2.0
+	root, major = threadRadii(MetricButtressThread(r, p), p)
+	if Abs(major-r) > 1e-6 || Abs(root-(r-0.86777*p)) > 0.02*p {
+		t.Errorf("FAIL buttress radii %f %f", root, major)
+	}
+}
+
+//-----------------------------------------------------------------------------
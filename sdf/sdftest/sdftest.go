@@ -0,0 +1,98 @@
+//-----------------------------------------------------------------------------
+/*
+
+Package sdftest provides assertions for unit testing SDF designs.
+
+These allow parametric part libraries to be validated in CI. E.g.
+
+	func TestBracket(t *testing.T) {
+		s := Bracket(k)
+		sdftest.AssertInside(t, s, sdf.V3{0, 0, 0})
+		sdftest.AssertClearance(t, s, Bolt(k), 0.2)
+		sdftest.AssertVolumeBetween(t, s, 1000, 1200)
+		sdftest.AssertGoldenMesh(t, s, "testdata/bracket.stl", 0.01)
+	}
+
+Golden mesh files are only written when the SDFTEST_UPDATE environment
+variable is set. E.g.
+
+	SDFTEST_UPDATE=1 go test ./...
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdftest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+// MeshCells is the number of cells on the longest axis used for meshing.
+var MeshCells = 100
+
+//-----------------------------------------------------------------------------
+
+// AssertInside fails the test if the point is not inside the SDF3.
+func AssertInside(t testing.TB, s sdf.SDF3, p sdf.V3) {
+	t.Helper()
+	if d := s.Evaluate(p); d >= 0 {
+		t.Errorf("point %v is not inside (distance %g)", p, d)
+	}
+}
+
+// AssertOutside fails the test if the point is not outside the SDF3.
+func AssertOutside(t testing.TB, s sdf.SDF3, p sdf.V3) {
+	t.Helper()
+	if d := s.Evaluate(p); d <= 0 {
+		t.Errorf("point %v is not outside (distance %g)", p, d)
+	}
+}
+
+// AssertClearance fails the test if the distance between two SDF3s is less than min.
+func AssertClearance(t testing.TB, a, b sdf.SDF3, min float64) {
+	t.Helper()
+	d, pa, pb := sdf.Distance3(a, b)
+	if d < min {
+		t.Errorf("clearance %g < %g (between %v and %v)", d, min, pa, pb)
+	}
+}
+
+// AssertVolumeBetween fails the test if the volume of the SDF3 is not within [lo, hi].
+// The volume is measured from a mesh of the SDF3 (see MeshCells).
+func AssertVolumeBetween(t testing.TB, s sdf.SDF3, lo, hi float64) {
+	t.Helper()
+	v := sdf.MeshVolume(sdf.RenderMesh(s, MeshCells))
+	if v < lo || v > hi {
+		t.Errorf("volume %g is not within [%g, %g]", v, lo, hi)
+	}
+}
+
+// AssertGoldenMesh fails the test if the mesh of the SDF3 differs from a golden STL file.
+// The meshes must be within tol of each other (see sdf.MeshDiff).
+func AssertGoldenMesh(t testing.TB, s sdf.SDF3, path string, tol float64) {
+	t.Helper()
+	mesh := sdf.RenderMesh(s, MeshCells)
+	if os.Getenv("SDFTEST_UPDATE") != "" {
+		if err := sdf.SaveSTL(path, mesh); err != nil {
+			t.Fatalf("can't write golden mesh: %s", err)
+			return
+		}
+		t.Logf("wrote golden mesh %s", path)
+		return
+	}
+	golden, err := sdf.LoadSTL(path)
+	if err != nil {
+		t.Fatalf("can't read golden mesh (set SDFTEST_UPDATE to write it): %s", err)
+		return
+	}
+	if r := sdf.MeshDiff(golden, mesh, tol); !r.Equal() {
+		t.Errorf("mesh differs from golden %s: %s", path, r)
+	}
+}
+
+//-----------------------------------------------------------------------------
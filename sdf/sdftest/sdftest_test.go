@@ -0,0 +1,113 @@
+//-----------------------------------------------------------------------------
+/*
+
+SDF Test Assertion Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdftest
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+// fakeTB records the failures of an assertion.
+type fakeTB struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (t *fakeTB) Helper() {}
+
+func (t *fakeTB) Logf(format string, args ...interface{}) {}
+
+func (t *fakeTB) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeTB) Fatalf(format string, args ...interface{}) {
+	t.Errorf(format, args...)
+	t.fatal = true
+}
+
+func (t *fakeTB) failed() bool {
+	return len(t.errors) != 0
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Assertions(t *testing.T) {
+	s := sdf.Sphere3D(10)
+	other := sdf.Transform3D(sdf.Sphere3D(10), sdf.Translate3d(sdf.V3{X: 25}))
+	tests := []struct {
+		name   string
+		assert func(t testing.TB)
+		fail   bool
+	}{
+		{"inside", func(t testing.TB) { AssertInside(t, s, sdf.V3{}) }, false},
+		{"not inside", func(t testing.TB) { AssertInside(t, s, sdf.V3{X: 20}) }, true},
+		{"outside", func(t testing.TB) { AssertOutside(t, s, sdf.V3{X: 20}) }, false},
+		{"not outside", func(t testing.TB) { AssertOutside(t, s, sdf.V3{}) }, true},
+		{"clearance", func(t testing.TB) { AssertClearance(t, s, other, 4) }, false},
+		{"no clearance", func(t testing.TB) { AssertClearance(t, s, other, 6) }, true},
+		{"volume", func(t testing.TB) { AssertVolumeBetween(t, s, 4000, 4300) }, false},
+		{"volume too small", func(t testing.TB) { AssertVolumeBetween(t, s, 5000, 6000) }, true},
+		{"volume too large", func(t testing.TB) { AssertVolumeBetween(t, s, 1000, 2000) }, true},
+	}
+	for _, tc := range tests {
+		ft := &fakeTB{}
+		tc.assert(ft)
+		if ft.failed() != tc.fail {
+			t.Errorf("%s: failed %v, expected %v %v", tc.name, ft.failed(), tc.fail, ft.errors)
+		}
+	}
+}
+
+func Test_AssertGoldenMesh(t *testing.T) {
+	s := sdf.Box3D(sdf.V3{X: 10, Y: 10, Z: 10}, 1)
+	path := filepath.Join(t.TempDir(), "golden.stl")
+	// keep the meshes small, mesh comparison is slow
+	defer func(n int) { MeshCells = n }(MeshCells)
+	MeshCells = 20
+
+	// a missing golden file is fatal
+	t.Setenv("SDFTEST_UPDATE", "")
+	ft := &fakeTB{}
+	AssertGoldenMesh(ft, s, path, 0.01)
+	if !ft.fatal {
+		t.Errorf("missing golden mesh: expected a fatal error")
+	}
+
+	// write the golden file
+	t.Setenv("SDFTEST_UPDATE", "1")
+	ft = &fakeTB{}
+	AssertGoldenMesh(ft, s, path, 0.01)
+	if ft.failed() {
+		t.Fatalf("update golden mesh: %v", ft.errors)
+	}
+
+	// the same SDF3 matches
+	t.Setenv("SDFTEST_UPDATE", "")
+	ft = &fakeTB{}
+	AssertGoldenMesh(ft, s, path, 0.01)
+	if ft.failed() {
+		t.Errorf("same mesh: %v", ft.errors)
+	}
+
+	// a different SDF3 doesn't match
+	ft = &fakeTB{}
+	AssertGoldenMesh(ft, sdf.Sphere3D(5), path, 0.01)
+	if !ft.failed() || ft.fatal {
+		t.Errorf("different mesh: expected an error")
+	}
+}
+
+//-----------------------------------------------------------------------------
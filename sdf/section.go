@@ -0,0 +1,139 @@
+//-----------------------------------------------------------------------------
+/*
+
+Section Views
+
+Cross-section drawings and cutaway meshes for documentation.
+
+A section drawing slices an SDF3 with a plane and draws the outline of the
+cut with the solid regions hatched. A cutaway mesh removes the part on one
+side of the plane and colors the cut face.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"image/color"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// HatchLines returns hatching lines for the inside of an SDF2.
+// The lines are at the given angle (radians) and spacing apart. The line ends
+// are found to within the resolution.
+func HatchLines(s SDF2, angle, spacing, resolution float64) []Line {
+	bb := s.BoundingBox()
+	c := bb.Center()
+	r := 0.5 * bb.Size().Length()
+	u := V2{math.Cos(angle), math.Sin(angle)} // along the lines
+	v := V2{-u.Y, u.X}                        // across the lines
+	inside := func(p V2) bool { return s.Evaluate(p) < 0 }
+	// find the inside/outside boundary between two points
+	edge := func(a, b V2) V2 {
+		ia := inside(a)
+		for b.Sub(a).Length() > 0.01*resolution {
+			m := a.Add(b).MulScalar(0.5)
+			if inside(m) == ia {
+				a = m
+			} else {
+				b = m
+			}
+		}
+		return a.Add(b).MulScalar(0.5)
+	}
+	n := int(math.Ceil(r / spacing))
+	steps := int(math.Ceil(2 * r / resolution))
+	var lines []Line
+	for i := -n; i <= n; i++ {
+		o := c.Add(v.MulScalar(float64(i) * spacing))
+		p0 := o.Sub(u.MulScalar(r))
+		prev := p0
+		in := inside(prev)
+		var start V2
+		if in {
+			start = prev
+		}
+		for k := 1; k <= steps; k++ {
+			p := p0.Add(u.MulScalar(2 * r * float64(k) / float64(steps)))
+			if inside(p) != in {
+				e := edge(prev, p)
+				if in {
+					lines = append(lines, Line{start, e})
+				} else {
+					start = e
+				}
+				in = !in
+			}
+			prev = p
+		}
+		if in {
+			lines = append(lines, Line{start, prev})
+		}
+	}
+	return lines
+}
+
+//-----------------------------------------------------------------------------
+
+// SectionParms defines the parameters for a section drawing.
+type SectionParms struct {
+	Resolution   float64 // contour and hatching resolution
+	HatchSpacing float64 // distance between hatching lines
+	HatchAngle   float64 // angle of the hatching lines (radians)
+}
+
+// SectionDrawing returns a drawing of the section of an SDF3 on a plane
+// through a with normal n. The drawing coordinates are those of Slice2D.
+func SectionDrawing(s SDF3, a, n V3, k *SectionParms) *Drawing {
+	slice := Slice2D(s, a, n)
+	d := NewDrawing()
+	for _, l := range HatchLines(slice, k.HatchAngle, k.HatchSpacing, k.Resolution) {
+		d.Line(l[0], l[1], ThinStyle)
+	}
+	d.Polygons(Contours2(slice, k.Resolution), OutlineStyle)
+	return d
+}
+
+//-----------------------------------------------------------------------------
+
+// CutawayMesh returns the mesh of an SDF3 cut on a plane through a with normal n.
+// The part on the same side as the normal remains (see Cut3D). The triangles of
+// the cut face are returned separately. meshCells <= 0 uses AutoMeshCells.
+func CutawayMesh(s SDF3, a, n V3, meshCells int) (part, face []*Triangle3) {
+	if meshCells <= 0 {
+		meshCells = AutoMeshCells(s)
+	}
+	mesh := RenderMesh(Cut3D(s, a, n), meshCells)
+	n = n.Normalize()
+	// the cut face faces away from the normal and is within a cell of the plane
+	eps := 0.5 * s.BoundingBox().Size().MaxComponent() / float64(meshCells)
+	for _, t := range mesh {
+		onPlane := t.Normal().Dot(n) < -0.9
+		for _, v := range t.V {
+			if Abs(v.Sub(a).Dot(n)) > eps {
+				onPlane = false
+				break
+			}
+		}
+		if onPlane {
+			face = append(face, t)
+		} else {
+			part = append(part, t)
+		}
+	}
+	return part, face
+}
+
+// RenderCutawayOBJ renders a cutaway of an SDF3 to an OBJ file with the cut face colored.
+func RenderCutawayOBJ(s SDF3, a, n V3, meshCells int, faceColor color.RGBA, path string) error {
+	part, face := CutawayMesh(s, a, n, meshCells)
+	return SaveOBJ(path, []OBJGroup{
+		{part, &Material{"part", color.RGBA{200, 200, 200, 255}}},
+		{face, &Material{"cut", faceColor}},
+	})
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,233 @@
+//-----------------------------------------------------------------------------
+/*
+
+Drawing Sheets
+
+A drawing sheet has the front, top and right orthographic views of a part
+(third angle projection), a shaded isometric preview and a title block with
+the scale and units. The scale is chosen from the standard drawing scales so
+the views fit on the paper.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// RenderIsometric returns a shaded isometric image of an SDF3 viewed from the
+// front right and above. The longest side of the image has the given number of
+// pixels and the background is transparent.
+func RenderIsometric(s SDF3, pixels int) image.Image {
+	f := V3{-1, 1, -1}.Normalize() // view direction
+	r := V3{1, 1, 0}.Normalize()   // image x-axis
+	u := r.Cross(f)                // image y-axis
+	light := V3{0.5, -1, 2}.Normalize()
+
+	// image extents
+	bb := s.BoundingBox()
+	c := bb.Center()
+	radius := 0.5 * bb.Size().Length()
+	var v2 V2Set
+	for _, v := range bb.Vertices() {
+		v = v.Sub(c)
+		v2 = append(v2, V2{v.Dot(r), v.Dot(u)})
+	}
+	box := Box2{v2.Min(), v2.Max()}
+	size := box.Size()
+	h := size.MaxComponent() / float64(pixels)
+	nx := int(math.Ceil(size.X / h))
+	ny := int(math.Ceil(size.Y / h))
+
+	img := image.NewRGBA(image.Rect(0, 0, nx, ny))
+	eps := 1e-4 * radius
+	for j := 0; j < ny; j++ {
+		for i := 0; i < nx; i++ {
+			a := box.Min.X + (float64(i)+0.5)*h
+			b := box.Max.Y - (float64(j)+0.5)*h
+			p := c.Add(r.MulScalar(a)).Add(u.MulScalar(b)).Sub(f.MulScalar(radius))
+			// sphere trace along the view direction
+			hit := false
+			for t, k := 0.0, 0; t < 2*radius && k < 256; k++ {
+				d := s.Evaluate(p)
+				if d < eps {
+					hit = true
+					break
+				}
+				t += d
+				p = p.Add(f.MulScalar(d))
+			}
+			if !hit {
+				continue
+			}
+			n := Normal3(s, p, eps)
+			k := 0.25 + 0.75*math.Max(0, n.Dot(light))
+			g := uint8(Clamp(230*k, 0, 255))
+			img.SetRGBA(i, j, color.RGBA{g, g, g, 255})
+		}
+	}
+	return img
+}
+
+//-----------------------------------------------------------------------------
+
+// Paper sizes (landscape, mm).
+var (
+	PaperA4     = V2{297, 210}
+	PaperA3     = V2{420, 297}
+	PaperLetter = V2{279.4, 215.9}
+)
+
+// drawingScales are the standard drawing scales (paper size/model size).
+var drawingScales = []float64{10, 5, 2, 1, 0.5, 0.2, 0.1, 0.05, 0.02, 0.01}
+
+// ScaleString returns a drawing scale as a ratio, e.g. "1:2".
+func ScaleString(scale float64) string {
+	if scale >= 1 {
+		return fmt.Sprintf("%g:1", scale)
+	}
+	return fmt.Sprintf("1:%g", 1/scale)
+}
+
+// SheetParms defines the parameters for a drawing sheet.
+type SheetParms struct {
+	Title      string  // drawing title
+	Paper      V2      // paper size (mm)
+	Scale      float64 // paper size/model size (0 = the largest standard scale that fits)
+	Units      string  // model units shown in the title block (default "mm")
+	Resolution float64 // view contour resolution in model units (0 = 1/200 of the largest dimension)
+	Pixels     int     // size of the isometric preview image (0 = 400)
+}
+
+// sheet layout constants (mm)
+const (
+	sheetBorder     = 10.0  // border around the drawing frame
+	sheetGap        = 15.0  // gap between views
+	sheetTitleW     = 110.0 // title block width
+	sheetTitleH     = 24.0  // title block height
+	sheetTextHeight = 3.5   // title block text height
+)
+
+// SheetDrawing returns a drawing sheet with three orthographic views of an
+// SDF3, an isometric preview and a title block. The drawing units are mm on
+// the paper with the origin at the lower left corner.
+func SheetDrawing(s SDF3, k *SheetParms) (*Drawing, error) {
+	if k.Paper.X <= 0 || k.Paper.Y <= 0 {
+		return nil, errors.New("Paper <= 0")
+	}
+	if k.Scale < 0 {
+		return nil, errors.New("Scale < 0")
+	}
+	bb := s.BoundingBox()
+	size := bb.Size()
+	resolution := k.Resolution
+	if resolution == 0 {
+		resolution = size.MaxComponent() / 200
+	}
+	pixels := k.Pixels
+	if pixels == 0 {
+		pixels = 400
+	}
+	units := k.Units
+	if units == "" {
+		units = "mm"
+	}
+
+	// The front view is at the lower left with the top view above it and the
+	// right view to its right. The isometric view is at the upper right.
+	frame := Box2{V2{sheetBorder, sheetBorder}, k.Paper.SubScalar(sheetBorder)}
+	area := Box2{frame.Min.Add(V2{0, sheetTitleH}), frame.Max}.Size().SubScalar(3 * sheetGap)
+	fits := func(scale float64) bool {
+		return scale*(size.X+size.Y) <= area.X && scale*(size.Z+size.Y) <= area.Y
+	}
+	scale := k.Scale
+	if scale == 0 {
+		for _, x := range drawingScales {
+			scale = x
+			if fits(x) {
+				break
+			}
+		}
+	}
+	if !fits(scale) {
+		return nil, fmt.Errorf("the views don't fit on the paper at %s", ScaleString(scale))
+	}
+
+	d := NewDrawing()
+	// the drawing extents are the paper
+	d.extend(V2{}, k.Paper)
+	d.Polygons([][]V2{{frame.Min, V2{frame.Max.X, frame.Min.Y}, frame.Max, V2{frame.Min.X, frame.Max.Y}}}, OutlineStyle)
+
+	// view cells
+	x0 := frame.Min.X + sheetGap
+	y0 := frame.Min.Y + sheetTitleH + sheetGap
+	x1 := x0 + scale*size.X + sheetGap
+	y1 := y0 + scale*size.Z + sheetGap
+	views := []struct {
+		view View
+		min  V2 // lower left corner of the view on the paper
+	}{
+		{ViewFront, V2{x0, y0}},
+		{ViewTop, V2{x0, y1}},
+		{ViewRight, V2{x1, y0}},
+	}
+	for _, v := range views {
+		x := ViewDrawing(s, v.view, resolution)
+		// place the projected bounding box of the part at the cell corner
+		p := Project2D(s, v.view).BoundingBox()
+		m := Translate2d(v.min).Mul(Scale2d(V2{scale, scale})).Mul(Translate2d(p.Min.Neg()))
+		d.Add(x, m)
+	}
+
+	// isometric preview in the upper right cell, keeping the image aspect ratio
+	img := RenderIsometric(s, pixels)
+	cell := Box2{V2{x1, y1}, V2{frame.Max.X - sheetGap, frame.Max.Y - sheetGap}}
+	if cell.Size().X > 0 && cell.Size().Y > 0 {
+		isize := V2{float64(img.Bounds().Dx()), float64(img.Bounds().Dy())}
+		fit := math.Min(cell.Size().X/isize.X, cell.Size().Y/isize.Y)
+		isize = isize.MulScalar(fit)
+		min := cell.Center().Sub(isize.MulScalar(0.5))
+		d.Image(Box2{min, min.Add(isize)}, img)
+	}
+
+	// title block at the lower right of the frame
+	tb := Box2{V2{frame.Max.X - sheetTitleW, frame.Min.Y}, V2{frame.Max.X, frame.Min.Y + sheetTitleH}}
+	d.Polygons([][]V2{{tb.Min, V2{tb.Max.X, tb.Min.Y}, tb.Max, V2{tb.Min.X, tb.Max.Y}}}, OutlineStyle)
+	ym := tb.Min.Y + sheetTitleH/2
+	d.Line(V2{tb.Min.X, ym}, V2{tb.Max.X, ym}, ThinStyle)
+	xm := tb.Min.X + sheetTitleW/2
+	d.Line(V2{xm, tb.Min.Y}, V2{xm, ym}, ThinStyle)
+	pad := (sheetTitleH/2 - sheetTextHeight) / 2
+	d.Text(V2{tb.Min.X + 2, ym + pad}, sheetTextHeight, k.Title, TextStart)
+	d.Text(V2{tb.Min.X + 2, tb.Min.Y + pad}, sheetTextHeight, "SCALE "+ScaleString(scale), TextStart)
+	d.Text(V2{xm + 2, tb.Min.Y + pad}, sheetTextHeight, "UNITS "+units, TextStart)
+	return d, nil
+}
+
+// SaveSheetSVG writes a drawing sheet of an SDF3 to an SVG file.
+func SaveSheetSVG(path string, s SDF3, k *SheetParms) error {
+	d, err := SheetDrawing(s, k)
+	if err != nil {
+		return err
+	}
+	return d.SaveSVG(path, 0)
+}
+
+// SaveSheetPDF writes a drawing sheet of an SDF3 to a PDF file.
+func SaveSheetPDF(path string, s SDF3, k *SheetParms) error {
+	d, err := SheetDrawing(s, k)
+	if err != nil {
+		return err
+	}
+	return d.SavePDF(path, 0)
+}
+
+//-----------------------------------------------------------------------------
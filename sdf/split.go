@@ -0,0 +1,176 @@
+//-----------------------------------------------------------------------------
+/*
+
+Split for Printing
+
+Cut a model that is too big for the printer into pieces that fit the build
+volume. The cuts are on a grid of axis aligned planes, and registration keys
+(see key.go) are added on the cut faces so the pieces can be aligned and
+glued.
+
+The male keys are on the lower piece (the -ve side of the cut) and fit into
+sockets in the upper piece. Dovetail slots run out of the piece on the +ve
+side of the face (z for x/y cuts, y for z cuts).
+
+Keys are placed where the part is solid enough around them, faces without
+room for a key are left plain.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// splitAxes are the cut face frames for x, y and z cuts.
+var splitAxes = [3]keyFrame{
+	{V3{0, 1, 0}, V3{0, 0, 1}, V3{1, 0, 0}},
+	{V3{-1, 0, 0}, V3{0, 0, 1}, V3{0, 1, 0}},
+	{V3{1, 0, 0}, V3{0, 1, 0}, V3{0, 0, 1}},
+}
+
+// facePoints returns the key positions on an axis aligned cut face.
+func facePoints(part SDF3, face Box3, f *keyFrame, k *KeySpec) []V3 {
+	du, dv := f.u.Abs(), f.v.Abs()
+	size := face.Size()
+	return k.keyPoints(part, face.Min, du, dv, f.n, size.Dot(du), size.Dot(dv))
+}
+
+// emptyCell returns true if there is no part within a cell.
+func emptyCell(part SDF3, cell Box3) bool {
+	const n = 8
+	step := cell.Size().DivScalar(n)
+	r := 0.5 * step.Length()
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			for k := 0; k < n; k++ {
+				t := V3{float64(i) + 0.5, float64(j) + 0.5, float64(k) + 0.5}
+				if part.Evaluate(cell.Min.Add(step.Mul(t))) <= r {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// SplitForPrinting cuts a part into pieces that fit within a build volume of
+// size maxBox. The pieces are left in position. Empty pieces are not returned.
+// The keys are placed automatically, so k.Points must be nil.
+func SplitForPrinting(part SDF3, maxBox V3, k *KeySpec) ([]SDF3, error) {
+	if k.Points != nil {
+		return nil, errors.New("Points are not supported, use Number")
+	}
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	if maxBox.MinComponent() <= k.protrusion() {
+		return nil, errors.New("maxBox is too small for the keys")
+	}
+	bb := part.BoundingBox()
+	size := bb.Size()
+	// the number of pieces on each axis
+	var num [3]int
+	s := [3]float64{size.X, size.Y, size.Z}
+	m := [3]float64{maxBox.X, maxBox.Y, maxBox.Z}
+	for i := range num {
+		num[i] = int(math.Ceil(s[i] / m[i]))
+		if num[i] > 1 {
+			// the lower pieces have keys sticking out
+			num[i] = int(math.Ceil(s[i] / (m[i] - k.protrusion())))
+		}
+	}
+	step := size.Div(V3{float64(num[0]), float64(num[1]), float64(num[2])})
+	cell := func(i, j, l int) Box3 {
+		c0 := bb.Min.Add(step.Mul(V3{float64(i), float64(j), float64(l)}))
+		return Box3{c0, c0.Add(step)}
+	}
+	// the pieces are grown past the outside of the part
+	pad := V3{1, 1, 1}.MulScalar(0.01 * size.MaxComponent())
+	var pieces []SDF3
+	for l := 0; l < num[2]; l++ {
+		for j := 0; j < num[1]; j++ {
+			for i := 0; i < num[0]; i++ {
+				idx := [3]int{i, j, l}
+				c := cell(i, j, l)
+				if emptyCell(part, c) {
+					continue
+				}
+				box := c
+				if i == 0 {
+					box.Min.X -= pad.X
+				}
+				if j == 0 {
+					box.Min.Y -= pad.Y
+				}
+				if l == 0 {
+					box.Min.Z -= pad.Z
+				}
+				if i == num[0]-1 {
+					box.Max.X += pad.X
+				}
+				if j == num[1]-1 {
+					box.Max.Y += pad.Y
+				}
+				if l == num[2]-1 {
+					box.Max.Z += pad.Z
+				}
+				piece := Intersect3D(Transform3D(Box3D(box.Size(), 0), Translate3d(box.Center())), part)
+				var males, females []SDF3
+				for a := 0; a < 3 && k.Type != KeyNone; a++ {
+					f := &splitAxes[a]
+					// the face on the +ve side of this piece has male keys
+					if idx[a] < num[a]-1 {
+						face := c
+						face.Min = face.Min.Add(f.n.Mul(face.Size()))
+						for _, p := range facePoints(part, face, f, k) {
+							male, _ := k.key(0)
+							males = append(males, Transform3D(male, f.at(p)))
+						}
+					}
+					// the face on the -ve side of this piece has female keys
+					if idx[a] > 0 {
+						face := c
+						face.Max = face.Max.Sub(f.n.Mul(face.Size()))
+						for _, p := range facePoints(part, face, f, k) {
+							far := c.Max.Sub(p).Dot(f.v) + pad.X
+							_, female := k.key(far)
+							females = append(females, Transform3D(female, f.at(p)))
+						}
+					}
+				}
+				if len(males) != 0 {
+					piece = Union3D(piece, Union3D(males...))
+				}
+				if len(females) != 0 {
+					piece = Difference3D(piece, Union3D(females...))
+				}
+				pieces = append(pieces, piece)
+			}
+		}
+	}
+	return pieces, nil
+}
+
+// RenderPiecesSTL renders each piece of a split part as an STL file.
+// The files are named <path>_<n>.stl.
+func RenderPiecesSTL(
+	pieces []SDF3, //pieces to render
+	meshCells int, //number of cells on the longest axis. e.g 200
+	path string, //path to filename
+) {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for i, s := range pieces {
+		RenderSTL(s, meshCells, fmt.Sprintf("%s_%d.stl", base, i))
+	}
+}
+
+//-----------------------------------------------------------------------------
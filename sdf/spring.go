@@ -0,0 +1,312 @@
+//-----------------------------------------------------------------------------
+/*
+
+Springs
+
+Compression springs, torsion springs and wave washers.
+
+Coil springs are made by sweeping a round wire along a helix. The helix has
+a variable pitch so the end coils of a compression spring can be closed.
+The wire distance is measured in the meridian plane through the point (as
+for Screw3D), which is accurate when the pitch is small compared to the coil
+circumference.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// HelixSDF3 is a round wire swept along a helix about the z-axis.
+type HelixSDF3 struct {
+	radius float64 // helix radius
+	wire   float64 // wire radius
+	knots  []V2    // (turns, z) knots of the centerline height
+	bb     Box3
+}
+
+// Helix3D returns a round wire swept along a helix about the z-axis starting
+// on the +x axis. The knots are (turns, z) pairs with increasing turns and z.
+// The centerline height is linear between the knots, so each span has a
+// constant pitch. The wire ends are rounded.
+func Helix3D(radius, wireDiameter float64, knots []V2) (SDF3, error) {
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	if wireDiameter <= 0 || wireDiameter >= 2*radius {
+		return nil, errors.New("wireDiameter must be > 0 and < 2 * radius")
+	}
+	if len(knots) < 2 {
+		return nil, errors.New("len(knots) < 2")
+	}
+	for i := 1; i < len(knots); i++ {
+		if knots[i].X <= knots[i-1].X || knots[i].Y < knots[i-1].Y {
+			return nil, errors.New("knots must be increasing")
+		}
+	}
+	s := HelixSDF3{
+		radius: radius,
+		wire:   0.5 * wireDiameter,
+		knots:  knots,
+	}
+	r := radius + s.wire
+	s.bb = Box3{V3{-r, -r, knots[0].Y - s.wire}, V3{r, r, knots[len(knots)-1].Y + s.wire}}
+	return &s, nil
+}
+
+// height returns the centerline height at t turns.
+func (s *HelixSDF3) height(t float64) float64 {
+	k := s.knots
+	i := sort.Search(len(k)-2, func(i int) bool { return k[i+1].X >= t })
+	a, b := k[i], k[i+1]
+	return a.Y + (t-a.X)*(b.Y-a.Y)/(b.X-a.X)
+}
+
+// turns returns the turns at a centerline height (clamped to the helix).
+func (s *HelixSDF3) turns(z float64) float64 {
+	k := s.knots
+	if z <= k[0].Y {
+		return k[0].X
+	}
+	if z >= k[len(k)-1].Y {
+		return k[len(k)-1].X
+	}
+	i := sort.Search(len(k)-2, func(i int) bool { return k[i+1].Y >= z })
+	a, b := k[i], k[i+1]
+	if b.Y == a.Y {
+		return a.X
+	}
+	return a.X + (z-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+}
+
+// Point returns the centerline point at t turns.
+func (s *HelixSDF3) Point(t float64) V3 {
+	a := Tau * t
+	return V3{s.radius * math.Cos(a), s.radius * math.Sin(a), s.height(t)}
+}
+
+// Evaluate returns the minimum distance to the helix.
+func (s *HelixSDF3) Evaluate(p V3) float64 {
+	t0, t1 := s.knots[0].X, s.knots[len(s.knots)-1].X
+	// rounded wire ends
+	d := math.Min(p.Sub(s.Point(t0)).Length(), p.Sub(s.Point(t1)).Length())
+	// The wire crosses the meridian plane through p at whole turns from the
+	// angle of p. The closest crossing is one of those either side of p.z.
+	r := math.Sqrt(p.X*p.X + p.Y*p.Y)
+	theta := math.Atan2(p.Y, p.X) / Tau
+	if theta < 0 {
+		theta++
+	}
+	k := math.Floor(s.turns(p.Z) - theta)
+	for _, t := range []float64{theta + k, theta + k + 1} {
+		if t < t0 || t > t1 {
+			continue
+		}
+		d = math.Min(d, math.Hypot(r-s.radius, p.Z-s.height(t)))
+	}
+	return d - s.wire
+}
+
+// BoundingBox returns the bounding box of the helix.
+func (s *HelixSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
+// SpringEnds is the end type of a compression spring.
+type SpringEnds int
+
+// Compression spring end types.
+const (
+	SpringOpen         SpringEnds = iota // the active coils run to the wire ends
+	SpringClosed                         // one closed (zero gap) coil at each end
+	SpringClosedGround                   // closed ends ground flat
+)
+
+// CompressionSpringParms defines the parameters for a compression spring.
+type CompressionSpringParms struct {
+	WireDiameter  float64    // wire diameter
+	OuterDiameter float64    // outer diameter of the coils
+	Pitch         float64    // pitch of the active coils
+	ActiveCoils   float64    // number of active coils
+	Ends          SpringEnds // end type
+}
+
+func (k *CompressionSpringParms) validate() error {
+	if k.WireDiameter <= 0 {
+		return errors.New("WireDiameter <= 0")
+	}
+	if k.OuterDiameter <= 2*k.WireDiameter {
+		return errors.New("OuterDiameter <= 2 * WireDiameter")
+	}
+	if k.Pitch < k.WireDiameter {
+		return errors.New("Pitch < WireDiameter")
+	}
+	if k.ActiveCoils <= 0 {
+		return errors.New("ActiveCoils <= 0")
+	}
+	return nil
+}
+
+// FreeLength returns the uncompressed length of a compression spring.
+func (k *CompressionSpringParms) FreeLength() float64 {
+	d := k.WireDiameter
+	l := k.ActiveCoils * k.Pitch
+	switch k.Ends {
+	case SpringClosed:
+		return l + 3*d
+	case SpringClosedGround:
+		return l + 2*d
+	}
+	return l + d
+}
+
+// CompressionSpring3D returns a compression spring along the z-axis with its base at z = 0.
+func CompressionSpring3D(k *CompressionSpringParms) (SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	d := k.WireDiameter
+	n := k.ActiveCoils
+	l := n * k.Pitch
+	radius := 0.5 * (k.OuterDiameter - d)
+	knots := []V2{{0, 0.5 * d}, {n, 0.5*d + l}}
+	if k.Ends != SpringOpen {
+		// a closed turn at each end
+		knots = []V2{{0, 0.5 * d}, {1, 1.5 * d}, {1 + n, 1.5*d + l}, {2 + n, 2.5*d + l}}
+	}
+	s, err := Helix3D(radius, d, knots)
+	if err != nil {
+		return nil, err
+	}
+	if k.Ends == SpringClosedGround {
+		// grind the ends flat to the wire centerline
+		// (the slab is first so the bounding box is the slab)
+		h := l + 2*d
+		slab := Box3D(V3{k.OuterDiameter, k.OuterDiameter, h}, 0)
+		s = Intersect3D(Transform3D(slab, Translate3d(V3{0, 0, 0.5 * (d + h)})), s)
+		s = Transform3D(s, Translate3d(V3{0, 0, -0.5 * d}))
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// TorsionSpringParms defines the parameters for a torsion spring.
+type TorsionSpringParms struct {
+	WireDiameter  float64 // wire diameter
+	OuterDiameter float64 // outer diameter of the coils
+	Coils         float64 // number of coils, the fraction sets the angle between the arms
+	ArmLength     float64 // length of the straight arms
+}
+
+// TorsionSpring3D returns a close wound torsion spring along the z-axis with
+// its base at z = 0. The arms are tangent to the coil at each end.
+func TorsionSpring3D(k *TorsionSpringParms) (SDF3, error) {
+	if k.WireDiameter <= 0 {
+		return nil, errors.New("WireDiameter <= 0")
+	}
+	if k.OuterDiameter <= 2*k.WireDiameter {
+		return nil, errors.New("OuterDiameter <= 2 * WireDiameter")
+	}
+	if k.Coils <= 0 {
+		return nil, errors.New("Coils <= 0")
+	}
+	if k.ArmLength < 0 {
+		return nil, errors.New("ArmLength < 0")
+	}
+	d := k.WireDiameter
+	radius := 0.5 * (k.OuterDiameter - d)
+	h, err := Helix3D(radius, d, []V2{{0, 0.5 * d}, {k.Coils, 0.5*d + k.Coils*d}})
+	if err != nil {
+		return nil, err
+	}
+	if k.ArmLength == 0 {
+		return h, nil
+	}
+	helix := h.(*HelixSDF3)
+	arm := func(t, dir float64) SDF3 {
+		a := Tau * t
+		p := helix.Point(t)
+		v := V3{-math.Sin(a), math.Cos(a), 0}.MulScalar(dir)
+		s := Cylinder3D(k.ArmLength, 0.5*d, 0)
+		m := Translate3d(p.Add(v.MulScalar(0.5 * k.ArmLength))).Mul(RotateToVector(V3{0, 0, 1}, v))
+		return Transform3D(s, m)
+	}
+	return Union3D(h, arm(0, -1), arm(k.Coils, 1)), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// WaveWasherParms defines the parameters for a wave washer (wave spring).
+type WaveWasherParms struct {
+	InnerDiameter float64 // inner diameter
+	OuterDiameter float64 // outer diameter
+	Thickness     float64 // material thickness
+	Waves         int     // number of waves around the washer
+	Height        float64 // free height of the washer (> Thickness)
+}
+
+// WaveWasherSDF3 is a wave washer.
+type WaveWasherSDF3 struct {
+	rm, w     float64 // mean radius and half width of the band
+	t         float64 // half thickness
+	amplitude float64 // wave amplitude
+	waves     float64
+	lipschitz float64 // distance correction for the wave slope
+	bb        Box3
+}
+
+// WaveWasher3D returns a wave washer centered on the origin.
+func WaveWasher3D(k *WaveWasherParms) (SDF3, error) {
+	if k.InnerDiameter <= 0 || k.OuterDiameter <= k.InnerDiameter {
+		return nil, errors.New("diameters must be > 0 with OuterDiameter > InnerDiameter")
+	}
+	if k.Thickness <= 0 {
+		return nil, errors.New("Thickness <= 0")
+	}
+	if k.Waves <= 0 {
+		return nil, errors.New("Waves <= 0")
+	}
+	if k.Height < k.Thickness {
+		return nil, errors.New("Height < Thickness")
+	}
+	s := WaveWasherSDF3{
+		rm:        0.25 * (k.InnerDiameter + k.OuterDiameter),
+		w:         0.25 * (k.OuterDiameter - k.InnerDiameter),
+		t:         0.5 * k.Thickness,
+		amplitude: 0.5 * (k.Height - k.Thickness),
+		waves:     float64(k.Waves),
+	}
+	// the steepest slope is on the inner edge
+	slope := s.amplitude * s.waves / (0.5 * k.InnerDiameter)
+	s.lipschitz = math.Sqrt(1 + slope*slope)
+	r := 0.5 * k.OuterDiameter
+	h := 0.5 * k.Height
+	s.bb = Box3{V3{-r, -r, -h}, V3{r, r, h}}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a wave washer.
+func (s *WaveWasherSDF3) Evaluate(p V3) float64 {
+	r := math.Sqrt(p.X*p.X + p.Y*p.Y)
+	z := s.amplitude * math.Cos(s.waves*math.Atan2(p.Y, p.X))
+	d0 := Abs(r-s.rm) - s.w
+	d1 := (Abs(p.Z-z) - s.t) / s.lipschitz
+	return math.Max(d0, d1)
+}
+
+// BoundingBox returns the bounding box of a wave washer.
+func (s *WaveWasherSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
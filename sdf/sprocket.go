@@ -0,0 +1,171 @@
+//-----------------------------------------------------------------------------
+/*
+
+Roller Chain Sprockets
+
+Sprockets for ANSI (ASME B29.1) and ISO (ISO 606) roller chains.
+
+The tooth form is the ISO 606 form using the mean of the minimum and maximum
+tooth forms: a seating arc for the roller and convex flank arcs tangent to
+it. The flank arcs are centered on the line from the roller center through
+the end of the seating arc.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+// Chain Database - lookup standard roller chains by name
+
+// ChainParameters stores the dimensions of a roller chain (mm).
+type ChainParameters struct {
+	Name           string  // name of chain
+	Pitch          float64 // roller to roller distance
+	RollerDiameter float64 // roller diameter
+	InnerWidth     float64 // width between the inner plates
+}
+
+type chainDatabase map[string]*ChainParameters
+
+var chainDB = initChainLookup()
+
+func (m chainDatabase) add(name string, pitch, roller, width float64) {
+	m[name] = &ChainParameters{name, pitch, roller, width}
+}
+
+func initChainLookup() chainDatabase {
+	m := make(chainDatabase)
+	// ANSI
+	m.add("ANSI25", 6.35, 3.30, 3.18)
+	m.add("ANSI35", 9.525, 5.08, 4.77)
+	m.add("ANSI40", 12.7, 7.92, 7.85)
+	m.add("ANSI41", 12.7, 7.77, 6.38)
+	m.add("ANSI50", 15.875, 10.16, 9.40)
+	m.add("ANSI60", 19.05, 11.91, 12.57)
+	m.add("ANSI80", 25.4, 15.88, 15.75)
+	// ISO (British standard)
+	m.add("ISO05B", 8.0, 5.0, 3.0)
+	m.add("ISO06B", 9.525, 6.35, 5.72)
+	m.add("ISO08B", 12.7, 8.51, 7.75)
+	m.add("ISO10B", 15.875, 10.16, 9.65)
+	m.add("ISO12B", 19.05, 12.07, 11.68)
+	// bicycle
+	m.add("bicycle1/8", 12.7, 7.75, 3.18)
+	m.add("bicycle3/32", 12.7, 7.75, 2.38)
+	return m
+}
+
+// ChainLookup lookups the parameters for a roller chain by name.
+func ChainLookup(name string) (*ChainParameters, error) {
+	if c, ok := chainDB[name]; ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("chain \"%s\" not found", name)
+}
+
+// PitchDiameter returns the pitch diameter of a sprocket with the given number of teeth.
+func (c *ChainParameters) PitchDiameter(teeth int) float64 {
+	return c.Pitch / math.Sin(Pi/float64(teeth))
+}
+
+// TipDiameter returns the tip diameter of a sprocket with the given number of teeth.
+func (c *ChainParameters) TipDiameter(teeth int) float64 {
+	z := float64(teeth)
+	// mean of the ISO 606 maximum (d + 1.25p - d1) and minimum (d + p(1 - 1.6/z) - d1)
+	return c.PitchDiameter(teeth) + c.Pitch*(1.125-0.8/z) - c.RollerDiameter
+}
+
+//-----------------------------------------------------------------------------
+
+// Sprocket2D returns the 2D profile of a sprocket for a roller chain.
+// A roller is seated on the +x axis.
+func Sprocket2D(c *ChainParameters, teeth int) (SDF2, error) {
+	if teeth < 6 {
+		return nil, errors.New("teeth < 6")
+	}
+	z := float64(teeth)
+	d1 := c.RollerDiameter
+	// mean ISO 606 tooth form
+	ri := 0.505*d1 + 0.5*0.069*math.Cbrt(d1)         // roller seating radius
+	re := 0.5 * (0.008*d1*(z*z+180) + 0.12*d1*(z+2)) // flank radius
+	alpha := DtoR(0.5*((140-90/z)+(120-90/z))) * 0.5 // half the seating angle
+	rp := 0.5 * c.PitchDiameter(teeth)               // pitch radius
+	rt := 0.5 * c.TipDiameter(teeth)                 // tip radius
+	if ri >= 0.5*c.Pitch {
+		return nil, errors.New("the roller is too large for the pitch")
+	}
+
+	// seating arc end points and flank arc centers for the roller on the +x axis
+	center := V2{rp, 0}
+	var e [2]V2     // seating arc end points (lower, upper)
+	var flank [2]V2 // flank arc centers (lower, upper)
+	for i, sign := range []float64{1, -1} {
+		a := Pi + sign*alpha // from the inward direction
+		n := V2{math.Cos(a), math.Sin(a)}
+		e[i] = center.Add(n.MulScalar(ri))
+		flank[i] = center.Add(n.MulScalar(ri + re))
+	}
+	root := e[0].Length()
+
+	// A tooth is between the rollers at 0 and 1 pitch angles. Its flanks are
+	// the upper flank of roller 0 and the lower flank of roller 1.
+	step := Tau / z
+	f0 := Transform2D(Circle2D(re), Translate2d(flank[1]))
+	f1 := Transform2D(Circle2D(re), Rotate2d(step).Mul(Translate2d(flank[0])))
+	// center the tooth on the +x axis for RotateCopy2D
+	tooth := Transform2D(Intersect2D(f0, f1), Rotate2d(-0.5*step))
+	teethSDF := Transform2D(RotateCopy2D(tooth, teeth), Rotate2d(0.5*step))
+	teethSDF = Intersect2D(teethSDF, Circle2D(rt))
+	body := Union2D(Circle2D(root), teethSDF)
+	seat := RotateCopy2D(Transform2D(Circle2D(ri), Translate2d(center)), teeth)
+	return Difference2D(body, seat), nil
+}
+
+// SprocketParms defines the parameters for a sprocket.
+type SprocketParms struct {
+	Chain        *ChainParameters
+	Teeth        int     // number of teeth
+	Thickness    float64 // tooth width (0 = 0.93 * chain inner width)
+	HubDiameter  float64 // hub diameter (0 for no hub)
+	HubLength    float64 // hub length beyond the teeth
+	BoreDiameter float64 // shaft bore diameter (0 for no bore)
+}
+
+// Sprocket3D returns a sprocket with the teeth on z = 0 to Thickness and the
+// hub extending along +z.
+func Sprocket3D(k *SprocketParms) (SDF3, error) {
+	if k.Chain == nil {
+		return nil, errors.New("Chain == nil")
+	}
+	if k.Thickness < 0 || k.HubDiameter < 0 || k.HubLength < 0 || k.BoreDiameter < 0 {
+		return nil, errors.New("dimensions must be >= 0")
+	}
+	profile, err := Sprocket2D(k.Chain, k.Teeth)
+	if err != nil {
+		return nil, err
+	}
+	t := k.Thickness
+	if t == 0 {
+		t = 0.93 * k.Chain.InnerWidth
+	}
+	s := Transform3D(Extrude3D(profile, t), Translate3d(V3{0, 0, 0.5 * t}))
+	if k.HubDiameter > 0 && k.HubLength > 0 {
+		hub := Cylinder3D(k.HubLength, 0.5*k.HubDiameter, 0)
+		s = Union3D(s, Transform3D(hub, Translate3d(V3{0, 0, t + 0.5*k.HubLength})))
+	}
+	if k.BoreDiameter > 0 {
+		l := t + k.HubLength
+		bore := Cylinder3D(2*l, 0.5*k.BoreDiameter, 0)
+		s = Difference3D(s, Transform3D(bore, Translate3d(V3{0, 0, 0.5 * l})))
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
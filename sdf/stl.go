@@ -72,6 +72,44 @@ func SaveSTL(path string, mesh []*Triangle3) error {
 
 //-----------------------------------------------------------------------------
 
+// LoadSTL reads a triangle mesh from a binary STL file.
+func LoadSTL(path string) ([]*Triangle3, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bufio.NewReader(file)
+	header := STLHeader{}
+	if err := binary.Read(buf, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	// check the triangle count against the file size before allocating the mesh
+	if info.Size() < int64(binary.Size(header))+int64(header.Count)*int64(binary.Size(STLTriangle{})) {
+		return nil, fmt.Errorf("STL file is too short for %d triangles", header.Count)
+	}
+
+	mesh := make([]*Triangle3, header.Count)
+	var d STLTriangle
+	for i := range mesh {
+		if err := binary.Read(buf, binary.LittleEndian, &d); err != nil {
+			return nil, err
+		}
+		v0 := V3{float64(d.Vertex1[0]), float64(d.Vertex1[1]), float64(d.Vertex1[2])}
+		v1 := V3{float64(d.Vertex2[0]), float64(d.Vertex2[1]), float64(d.Vertex2[2])}
+		v2 := V3{float64(d.Vertex3[0]), float64(d.Vertex3[1]), float64(d.Vertex3[2])}
+		mesh[i] = NewTriangle3(v0, v1, v2)
+	}
+	return mesh, nil
+}
+
+//-----------------------------------------------------------------------------
+
 // WriteSTL writes a stream of triangles to an STL file.
 func WriteSTL(wg *sync.WaitGroup, path string) (chan<- *Triangle3, error) {
 
@@ -0,0 +1,238 @@
+//-----------------------------------------------------------------------------
+/*
+
+Parameter Sweeps
+
+Build a model for every combination of values in a parameter grid, mesh
+the variants in parallel, compute metrics (volume, bounding box, clearance)
+and write the results as CSV. Optionally save an STL for each variant.
+
+This is useful for tolerance studies and design optimization.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//-----------------------------------------------------------------------------
+
+// SweepVariant is a model built for one set of parameter values.
+type SweepVariant struct {
+	Index  int                // variant number
+	Params map[string]float64 // parameter values
+	SDF    SDF3               // the model
+	Mesh   []*Triangle3       // the meshed model
+}
+
+// SweepMetric is a named measurement of a variant.
+type SweepMetric struct {
+	Name    string
+	Measure func(v *SweepVariant) float64
+}
+
+// SweepResult is the result of a variant.
+type SweepResult struct {
+	Params  map[string]float64 // parameter values
+	Metrics []float64          // metric values, in the order of SweepParms.Metrics
+	Err     error              // error building the variant
+}
+
+// SweepParms defines the parameters for a parameter sweep.
+type SweepParms struct {
+	Build     func(params map[string]float64) (SDF3, error) // model builder
+	Grid      map[string][]float64                          // values for each parameter
+	Metrics   []SweepMetric                                 // metrics to compute
+	MeshCells int                                           // number of cells on the longest axis. e.g 200
+	STLDir    string                                        // directory for variant STL files (optional)
+	Workers   int                                           // number of parallel workers (0 for the number of CPUs)
+}
+
+//-----------------------------------------------------------------------------
+// Metrics
+
+// VolumeMetric measures the volume of the meshed model.
+func VolumeMetric() SweepMetric {
+	return SweepMetric{"volume", func(v *SweepVariant) float64 {
+		return MeshVolume(v.Mesh)
+	}}
+}
+
+// SizeMetrics measure the x, y and z size of the meshed model.
+func SizeMetrics() []SweepMetric {
+	size := func(i int) func(v *SweepVariant) float64 {
+		return func(v *SweepVariant) float64 {
+			s := MeshBoundingBox(v.Mesh).Size()
+			return []float64{s.X, s.Y, s.Z}[i]
+		}
+	}
+	return []SweepMetric{{"size_x", size(0)}, {"size_y", size(1)}, {"size_z", size(2)}}
+}
+
+// ClearanceMetric measures the clearance between two SDF3s derived from a variant
+// (E.g. two parts of an assembly). See Distance3.
+func ClearanceMetric(name string, parts func(v *SweepVariant) (SDF3, SDF3)) SweepMetric {
+	return SweepMetric{name, func(v *SweepVariant) float64 {
+		a, b := parts(v)
+		d, _, _ := Distance3(a, b)
+		return d
+	}}
+}
+
+//-----------------------------------------------------------------------------
+
+// sweepNames returns the sorted parameter names.
+func sweepNames(grid map[string][]float64) []string {
+	var names []string
+	for k := range grid {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sweepGrid returns every combination of parameter values.
+// The last parameter (by name) changes fastest.
+func sweepGrid(grid map[string][]float64) []map[string]float64 {
+	names := sweepNames(grid)
+	params := []map[string]float64{{}}
+	for _, name := range names {
+		var next []map[string]float64
+		for _, p := range params {
+			for _, x := range grid[name] {
+				q := map[string]float64{name: x}
+				for k, v := range p {
+					q[k] = v
+				}
+				next = append(next, q)
+			}
+		}
+		params = next
+	}
+	return params
+}
+
+// sweepVariant builds, meshes and measures a variant.
+func sweepVariant(k *SweepParms, i int, params map[string]float64) SweepResult {
+	r := SweepResult{Params: params}
+	s, err := k.Build(params)
+	if err == nil && s == nil {
+		err = fmt.Errorf("no model")
+	}
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	v := &SweepVariant{
+		Index:  i,
+		Params: params,
+		SDF:    s,
+		Mesh:   RenderMesh(s, k.MeshCells),
+	}
+	if k.STLDir != "" {
+		path := filepath.Join(k.STLDir, fmt.Sprintf("variant_%04d.stl", i))
+		if err := SaveSTL(path, v.Mesh); err != nil {
+			r.Err = err
+			return r
+		}
+	}
+	r.Metrics = make([]float64, len(k.Metrics))
+	for j, m := range k.Metrics {
+		r.Metrics[j] = m.Measure(v)
+	}
+	return r
+}
+
+// ParameterSweep builds and measures a model for every combination of parameter values.
+// The results are written as CSV (if w is not nil) and returned in grid order.
+func ParameterSweep(k *SweepParms, w io.Writer) ([]SweepResult, error) {
+	if k.Build == nil {
+		return nil, fmt.Errorf("no model builder")
+	}
+	if k.MeshCells <= 0 {
+		return nil, fmt.Errorf("MeshCells <= 0")
+	}
+	workers := k.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	params := sweepGrid(k.Grid)
+	results := make([]SweepResult, len(params))
+	// run the variants in parallel
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = sweepVariant(k, i, params[i])
+			}
+		}()
+	}
+	for i := range params {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if w != nil {
+		if err := writeSweepCSV(w, k, results); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// writeSweepCSV writes the sweep results as CSV.
+func writeSweepCSV(w io.Writer, k *SweepParms, results []SweepResult) error {
+	names := sweepNames(k.Grid)
+	cw := csv.NewWriter(w)
+	header := []string{"variant"}
+	header = append(header, names...)
+	for _, m := range k.Metrics {
+		header = append(header, m.Name)
+	}
+	header = append(header, "error")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	fmtFloat := func(x float64) string {
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	for i, r := range results {
+		row := []string{strconv.Itoa(i)}
+		for _, name := range names {
+			row = append(row, fmtFloat(r.Params[name]))
+		}
+		for j := range k.Metrics {
+			if r.Err != nil {
+				row = append(row, "")
+			} else {
+				row = append(row, fmtFloat(r.Metrics[j]))
+			}
+		}
+		msg := ""
+		if r.Err != nil {
+			msg = strings.TrimSpace(r.Err.Error())
+		}
+		row = append(row, msg)
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+//-----------------------------------------------------------------------------
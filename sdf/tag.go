@@ -0,0 +1,108 @@
+//-----------------------------------------------------------------------------
+/*
+
+Tags and Labels
+
+A rounded plate with an optional hanging hole and border, and a label that
+is embossed on or engraved into the top face. The label is any 2D shape
+(usually from TextSDF2), it is scaled to fit the plate.
+
+The hole is centered in a square at the left hand end of the plate and the
+label fills the rest of the plate.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+// TagParms defines the parameters for a tag.
+type TagParms struct {
+	Size         V2      // plate size
+	CornerRadius float64 // plate corner radius
+	Thickness    float64 // plate thickness
+	HoleDiameter float64 // hanging hole diameter (0 for no hole)
+	Margin       float64 // space around the label (and the border)
+	Border       float64 // border width (0 for no border)
+	Depth        float64 // height (embossed) or depth (engraved) of the label and border
+	Emboss       bool    // raise the label, else engrave it
+}
+
+func (k *TagParms) validate() error {
+	if k.Size.X <= 0 || k.Size.Y <= 0 {
+		return errors.New("Size <= 0")
+	}
+	if k.CornerRadius < 0 || k.HoleDiameter < 0 || k.Margin < 0 || k.Border < 0 {
+		return errors.New("dimensions must be >= 0")
+	}
+	if k.Thickness <= 0 {
+		return errors.New("Thickness <= 0")
+	}
+	if k.Depth <= 0 {
+		return errors.New("Depth <= 0")
+	}
+	if !k.Emboss && k.Depth >= k.Thickness {
+		return errors.New("Depth >= Thickness")
+	}
+	if k.HoleDiameter >= k.Size.Y-2*k.Margin {
+		return errors.New("the hole is too large for the tag")
+	}
+	return nil
+}
+
+// Tag3D returns a tag with its base on z = 0. The label is scaled to fit the
+// area to the right of the hole and centered on it.
+func Tag3D(k *TagParms, label SDF2) (SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	// the area for the border and label
+	x0 := -0.5*k.Size.X + k.Margin
+	if k.HoleDiameter > 0 {
+		x0 = -0.5*k.Size.X + k.Size.Y
+	}
+	area := Box2{V2{x0, -0.5*k.Size.Y + k.Margin}, V2{0.5*k.Size.X - k.Margin, 0.5*k.Size.Y - k.Margin}}
+	var border SDF2
+	if k.Border > 0 {
+		size := area.Size()
+		r := Max(k.CornerRadius-k.Margin, 0)
+		border = Difference2D(Box2D(size, r), Box2D(size.SubScalar(2*k.Border), Max(r-k.Border, 0)))
+		border = Transform2D(border, Translate2d(area.Center()))
+		inset := k.Border + k.Margin
+		area = Box2{area.Min.AddScalar(inset), area.Max.SubScalar(inset)}
+	}
+	size := area.Size()
+	if size.X <= 0 || size.Y <= 0 {
+		return nil, errors.New("no room for the label")
+	}
+
+	// scale the label to fit the area
+	lsize := label.BoundingBox().Size()
+	if lsize.X <= 0 || lsize.Y <= 0 {
+		return nil, errors.New("empty label")
+	}
+	scale := Min(size.X/lsize.X, size.Y/lsize.Y)
+	label = Transform2D(CenterAndScale2D(label, scale), Translate2d(area.Center()))
+	if border != nil {
+		label = Union2D(label, border)
+	}
+
+	plate := Box2D(k.Size, k.CornerRadius)
+	if k.HoleDiameter > 0 {
+		hole := Transform2D(Circle2D(0.5*k.HoleDiameter), Translate2d(V2{0.5 * (k.Size.Y - k.Size.X), 0}))
+		plate = Difference2D(plate, hole)
+	}
+	s := Transform3D(Extrude3D(plate, k.Thickness), Translate3d(V3{0, 0, 0.5 * k.Thickness}))
+	if k.Emboss {
+		raised := Extrude3D(label, k.Depth)
+		return Union3D(s, Transform3D(raised, Translate3d(V3{0, 0, k.Thickness + 0.5*k.Depth}))), nil
+	}
+	// engrave through the top face
+	cut := Extrude3D(label, 2*k.Depth)
+	return Difference3D(s, Transform3D(cut, Translate3d(V3{0, 0, k.Thickness}))), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -193,3 +193,14 @@ func TextSDF2(f *truetype.Font, t *Text, h float64) (SDF2, error) {
 }
 
 //-----------------------------------------------------------------------------
+
+// TextTag3D returns a tag (see Tag3D) labelled with a string.
+func TextTag3D(f *truetype.Font, s string, k *TagParms) (SDF3, error) {
+	label, err := TextSDF2(f, NewText(s), 1)
+	if err != nil {
+		return nil, err
+	}
+	return Tag3D(k, label)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,132 @@
+//-----------------------------------------------------------------------------
+/*
+
+Raster Image Tracing
+
+Convert a bitmap image into polygon contours.
+
+1) Threshold the image into filled/empty pixels.
+2) Follow the pixel boundaries to get closed contours. Filled pixels are on
+the left of the contour, so outer contours are counter clockwise and holes are
+clockwise (as required for the non-zero fill rule of MultiPolygon2D).
+3) Simplify the pixel stair steps and smooth the result.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"image"
+)
+
+//-----------------------------------------------------------------------------
+
+// TraceParms defines the parameters for tracing an image.
+type TraceParms struct {
+	Threshold float64 // luminance threshold [0,1], pixels darker than this are filled
+	Invert    bool    // fill the pixels lighter than the threshold
+	PixelSize float64 // size of a pixel in the output units
+	Simplify  float64 // simplification tolerance in pixels (E.g. 0.7)
+	Smooth    int     // number of smoothing iterations
+}
+
+// traceGrid returns the filled state of each pixel (y-axis up).
+func traceGrid(img image.Image, k *TraceParms) [][]bool {
+	r := img.Bounds()
+	w := r.Dx()
+	h := r.Dy()
+	grid := make([][]bool, w)
+	for x := range grid {
+		grid[x] = make([]bool, h)
+		for y := range grid[x] {
+			// flip the image y-axis
+			l := luminance(img.At(r.Min.X+x, r.Max.Y-1-y))
+			grid[x][y] = (l < k.Threshold) != k.Invert
+		}
+	}
+	return grid
+}
+
+// traceEdges returns the directed pixel boundary edges with the filled pixels on the left.
+func traceEdges(grid [][]bool) []clipEdge {
+	w := len(grid)
+	h := 0
+	if w > 0 {
+		h = len(grid[0])
+	}
+	filled := func(x, y int) bool {
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return false
+		}
+		return grid[x][y]
+	}
+	var edges []clipEdge
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			if !grid[x][y] {
+				continue
+			}
+			x0, y0 := float64(x), float64(y)
+			x1, y1 := x0+1, y0+1
+			if !filled(x, y-1) {
+				edges = append(edges, clipEdge{V2{x0, y0}, V2{x1, y0}})
+			}
+			if !filled(x+1, y) {
+				edges = append(edges, clipEdge{V2{x1, y0}, V2{x1, y1}})
+			}
+			if !filled(x, y+1) {
+				edges = append(edges, clipEdge{V2{x1, y1}, V2{x0, y1}})
+			}
+			if !filled(x-1, y) {
+				edges = append(edges, clipEdge{V2{x0, y1}, V2{x0, y0}})
+			}
+		}
+	}
+	return edges
+}
+
+// TraceImage returns the polygon contours for the filled regions of an image.
+// The origin is at the bottom left corner of the image.
+func TraceImage(img image.Image, k *TraceParms) ([][]V2, error) {
+	if k.PixelSize <= 0 {
+		return nil, errors.New("PixelSize <= 0")
+	}
+	if k.Simplify < 0 {
+		return nil, errors.New("Simplify < 0")
+	}
+	if k.Smooth < 0 {
+		return nil, errors.New("Smooth < 0")
+	}
+	contours := chainEdges(traceEdges(traceGrid(img, k)))
+	var out [][]V2
+	for _, c := range contours {
+		if k.Simplify > 0 {
+			c = SimplifyContour(c, k.Simplify, true)
+		}
+		c = SmoothContour(c, k.Smooth, true)
+		if len(c) < 3 {
+			continue
+		}
+		for i := range c {
+			c[i] = c[i].MulScalar(k.PixelSize)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// TraceImage2D returns an SDF2 for the filled regions of an image.
+func TraceImage2D(img image.Image, k *TraceParms) (SDF2, error) {
+	contours, err := TraceImage(img, k)
+	if err != nil {
+		return nil, err
+	}
+	if len(contours) == 0 {
+		return nil, errors.New("no filled regions in image")
+	}
+	return MultiPolygon2D(contours, NonZero), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,319 @@
+//-----------------------------------------------------------------------------
+/*
+
+SDF Tree Inspection
+
+Operator nodes expose their children so SDF trees can be walked without
+reflection (E.g. for serialization, export to other formats, debugging).
+
+Children returns the SDF3 children of a node.
+Children2 returns the SDF2 children of a node. SDF2 operators and the SDF3
+nodes built from SDF2s (extrude, revolve, etc.) have SDF2 children.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// Parent3 is an SDF node with SDF3 children.
+type Parent3 interface {
+	Children() []SDF3
+}
+
+// Parent2 is an SDF node with SDF2 children.
+type Parent2 interface {
+	Children2() []SDF2
+}
+
+// children returns the SDF2/SDF3 children of a node.
+func children(node interface{}) []interface{} {
+	var c []interface{}
+	if p, ok := node.(Parent3); ok {
+		for _, x := range p.Children() {
+			c = append(c, x)
+		}
+	}
+	if p, ok := node.(Parent2); ok {
+		for _, x := range p.Children2() {
+			c = append(c, x)
+		}
+	}
+	return c
+}
+
+// Walk visits the nodes of an SDF2/SDF3 tree in depth first order.
+// The function is called for each node with its depth in the tree.
+// If it returns false the children of the node are not visited.
+func Walk(node interface{}, fn func(node interface{}, depth int) bool) {
+	walk(node, 0, fn)
+}
+
+func walk(node interface{}, depth int, fn func(node interface{}, depth int) bool) {
+	if !fn(node, depth) {
+		return
+	}
+	for _, c := range children(node) {
+		walk(c, depth+1, fn)
+	}
+}
+
+// NodeName returns the type name of an SDF node (E.g. "UnionSDF3").
+func NodeName(node interface{}) string {
+	t := reflect.TypeOf(node)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// DumpTree writes an indented listing of an SDF2/SDF3 tree with the bounding box of each node.
+func DumpTree(w io.Writer, node interface{}) {
+	Walk(node, func(n interface{}, depth int) bool {
+		var bb string
+		switch s := n.(type) {
+		case SDF3:
+			bb = fmt.Sprintf("%v", s.BoundingBox())
+		case SDF2:
+			bb = fmt.Sprintf("%v", s.BoundingBox())
+		}
+		fmt.Fprintf(w, "%s%s %s\n", strings.Repeat("  ", depth), NodeName(n), bb)
+		return true
+	})
+}
+
+//-----------------------------------------------------------------------------
+// SDF3 children
+
+// Children returns the SDF3 children of an array node.
+func (s *ArraySDF3) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of a blend region node.
+func (s *BlendRegionSDF3) Children() []SDF3 {
+	return []SDF3{s.s0, s.s1, s.zone}
+}
+
+// Children returns the SDF3 children of a cut node.
+func (s *CutSDF3) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of a difference node.
+func (s *DifferenceSDF3) Children() []SDF3 {
+	return []SDF3{s.s0, s.s1}
+}
+
+// Children returns the SDF3 children of a draft node.
+func (s *DraftSDF3) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of an elongate node.
+func (s *ElongateSDF3) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of an intersection node.
+func (s *IntersectionSDF3) Children() []SDF3 {
+	return []SDF3{s.s0, s.s1}
+}
+
+// Children returns the SDF3 children of a material node.
+func (s *MaterialSDF3) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of an offset node.
+func (s *OffsetSDF3) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of a profile node.
+func (s *ProfileSDF3) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of a projection node.
+func (s *ProjectionSDF2) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of a rotate copy node.
+func (s *RotateCopySDF3) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of a rotate union node.
+func (s *RotateUnionSDF3) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of a scale uniform node.
+func (s *ScaleUniformSDF3) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of a slice node.
+func (s *SliceSDF2) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of a transform node.
+func (s *TransformSDF3) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of an union node.
+func (s *UnionSDF3) Children() []SDF3 {
+	return s.sdf
+}
+
+// Children returns the SDF3 children of a variable offset node.
+func (s *VariableOffsetSDF3) Children() []SDF3 {
+	return []SDF3{s.sdf}
+}
+
+// Children returns the SDF3 children of a xor node.
+func (s *XorSDF3) Children() []SDF3 {
+	return []SDF3{s.s0, s.s1}
+}
+
+//-----------------------------------------------------------------------------
+// SDF2 children
+
+// Children2 returns the SDF2 children of an array node.
+func (s *ArraySDF2) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of a blade loft node.
+func (s *BladeLoftSDF3) Children2() []SDF2 {
+	return []SDF2{s.sdf0, s.sdf1}
+}
+
+// Children2 returns the SDF2 children of a cut node.
+func (s *CutSDF2) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of a difference node.
+func (s *DifferenceSDF2) Children2() []SDF2 {
+	return []SDF2{s.s0, s.s1}
+}
+
+// Children2 returns the SDF2 children of an elongate node.
+func (s *ElongateSDF2) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of an extrude rounded rims node.
+func (s *ExtrudeRoundedRimsSDF3) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of an extrude rounded node.
+func (s *ExtrudeRoundedSDF3) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of an extrude node.
+func (s *ExtrudeSDF3) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of a gear rack node.
+func (s *GearRackSDF2) Children2() []SDF2 {
+	return []SDF2{s.tooth}
+}
+
+// Children2 returns the SDF2 children of an intersection node.
+func (s *IntersectionSDF2) Children2() []SDF2 {
+	return []SDF2{s.s0, s.s1}
+}
+
+// Children2 returns the SDF2 children of a loft node.
+func (s *LoftSDF3) Children2() []SDF2 {
+	return []SDF2{s.sdf0, s.sdf1}
+}
+
+// Children2 returns the SDF2 children of an offset node.
+func (s *OffsetSDF2) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of a profile node.
+func (s *ProfileSDF2) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of a rotate copy node.
+func (s *RotateCopySDF2) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of a rotate union node.
+func (s *RotateUnionSDF2) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of a scale node.
+func (s *ScaleSDF2) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of a scale uniform node.
+func (s *ScaleUniformSDF2) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of a screw node.
+func (s *ScrewSDF3) Children2() []SDF2 {
+	return []SDF2{s.thread}
+}
+
+// Children2 returns the SDF2 children of a shell node.
+func (s *ShellSDF2) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of a solid of revolution node.
+func (s *SorSDF3) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of a threaded rod node.
+func (s *ThreadedRodSDF3) Children2() []SDF2 {
+	return []SDF2{s.screw.thread}
+}
+
+// Children2 returns the SDF2 children of a transform node.
+func (s *TransformSDF2) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of an union node.
+func (s *UnionSDF2) Children2() []SDF2 {
+	return s.sdf
+}
+
+// Children2 returns the SDF2 children of a variable offset node.
+func (s *VariableOffsetSDF2) Children2() []SDF2 {
+	return []SDF2{s.sdf}
+}
+
+// Children2 returns the SDF2 children of a xor node.
+func (s *XorSDF2) Children2() []SDF2 {
+	return []SDF2{s.s0, s.s1}
+}
+
+//-----------------------------------------------------------------------------
@@ -8,6 +8,8 @@ Triangles
 
 package sdf
 
+import "sort"
+
 //-----------------------------------------------------------------------------
 
 // Triangle2 is a 2D triangle
@@ -53,3 +55,72 @@ func (t *Triangle3) Degenerate(tolerance float64) bool {
 }
 
 //-----------------------------------------------------------------------------
+
+// MeshVolume returns the volume enclosed by a closed triangle mesh.
+func MeshVolume(mesh []*Triangle3) float64 {
+	v := 0.0
+	for _, t := range mesh {
+		// signed volume of the tetrahedron with the origin
+		v += t.V[0].Dot(t.V[1].Cross(t.V[2]))
+	}
+	return v / 6
+}
+
+// MeshBoundingBox returns the bounding box of a triangle mesh.
+func MeshBoundingBox(mesh []*Triangle3) Box3 {
+	var v V3Set
+	for _, t := range mesh {
+		v = append(v, t.V[:]...)
+	}
+	if len(v) == 0 {
+		return Box3{}
+	}
+	return Box3{v.Min(), v.Max()}
+}
+
+//-----------------------------------------------------------------------------
+
+// v3Less returns true if a < b in lexicographic (x, y, z) order.
+func v3Less(a, b V3) bool {
+	if a.X != b.X {
+		return a.X < b.X
+	}
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.Z < b.Z
+}
+
+// normalizeZero replaces -0 with 0 so equal vertices have identical bytes.
+func normalizeZero(v V3) V3 {
+	return V3{v.X + 0, v.Y + 0, v.Z + 0}
+}
+
+// SortMesh puts a triangle mesh into a canonical order. Each triangle is rotated
+// (keeping the winding) so its least vertex is first, and the triangles are sorted.
+// Meshes of the same geometry produced in any order become identical.
+func SortMesh(mesh []*Triangle3) {
+	for _, t := range mesh {
+		for i := range t.V {
+			t.V[i] = normalizeZero(t.V[i])
+		}
+		for !v3Less(t.V[0], t.V[1]) || !v3Less(t.V[0], t.V[2]) {
+			if t.V[0] == t.V[1] || t.V[0] == t.V[2] {
+				// degenerate, leave it as is
+				break
+			}
+			t.V[0], t.V[1], t.V[2] = t.V[1], t.V[2], t.V[0]
+		}
+	}
+	sort.SliceStable(mesh, func(i, j int) bool {
+		a, b := mesh[i], mesh[j]
+		for k := range a.V {
+			if a.V[k] != b.V[k] {
+				return v3Less(a.V[k], b.V[k])
+			}
+		}
+		return false
+	})
+}
+
+//-----------------------------------------------------------------------------
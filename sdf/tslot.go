@@ -0,0 +1,268 @@
+//-----------------------------------------------------------------------------
+/*
+
+T-Slot Aluminum Extrusions
+
+Profiles for common T-slot extrusions, and the slot nuts and brackets that
+mate with them.
+
+The slot is defined by the opening width, the thickness of the lips either side
+of the opening, the width of the cavity behind the lips and the total depth of
+the slot. The cavity tapers at 45 degrees down to the opening width at the
+bottom of the slot.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+//-----------------------------------------------------------------------------
+// Extrusion Database - lookup standard T-slot extrusions by name
+
+// TSlotParameters stores the values that define a T-slot extrusion.
+type TSlotParameters struct {
+	Name         string  // name of extrusion
+	Size         float64 // side length of the square extrusion
+	SlotWidth    float64 // width of the slot opening
+	LipThickness float64 // thickness of the lips at the slot opening
+	CavityWidth  float64 // width of the cavity behind the lips
+	SlotDepth    float64 // depth of the slot from the outside face
+	BoreDiameter float64 // diameter of the center bore
+}
+
+type tslotDatabase map[string]*TSlotParameters
+
+var tslotDB = initTSlotLookup()
+
+// add adds an extrusion to the extrusion database.
+func (m tslotDatabase) add(
+	name string, // extrusion name
+	size float64, // side length
+	slotWidth float64, // slot opening width
+	lip float64, // lip thickness
+	cavity float64, // cavity width
+	depth float64, // slot depth
+	bore float64, // center bore diameter
+) {
+	m[name] = &TSlotParameters{
+		Name:         name,
+		Size:         size,
+		SlotWidth:    slotWidth,
+		LipThickness: lip,
+		CavityWidth:  cavity,
+		SlotDepth:    depth,
+		BoreDiameter: bore,
+	}
+}
+
+// initTSlotLookup adds a collection of standard extrusions to the extrusion database.
+func initTSlotLookup() tslotDatabase {
+	m := make(tslotDatabase)
+	m.add("2020", 20, 6.2, 1.8, 11, 6.1, 4.2)
+	m.add("3030", 30, 8.2, 2.2, 16.5, 9, 6.8)
+	m.add("4040", 40, 8.2, 4.3, 20, 12.25, 6.8)
+	return m
+}
+
+// TSlotLookup lookups the parameters for a T-slot extrusion by name.
+func TSlotLookup(name string) (*TSlotParameters, error) {
+	if t, ok := tslotDB[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("extrusion \"%s\" not found", name)
+}
+
+//-----------------------------------------------------------------------------
+
+// tslotCavity2D returns the 2D shape of a single slot on the +x face of the extrusion.
+func tslotCavity2D(t *TSlotParameters) SDF2 {
+	w := 0.5 * t.SlotWidth
+	cw := 0.5 * t.CavityWidth
+	x0 := 0.5*t.Size + 1 // beyond the outside face
+	x1 := 0.5*t.Size - t.LipThickness
+	x2 := 0.5*t.Size - t.SlotDepth
+	taper := cw - w
+	p := NewPolygon()
+	p.Add(x0, w)
+	p.Add(x1, w)
+	p.Add(x1, cw)
+	p.Add(x2+taper, cw)
+	p.Add(x2, w)
+	p.Add(x2, -w)
+	p.Add(x2+taper, -cw)
+	p.Add(x1, -cw)
+	p.Add(x1, -w)
+	p.Add(x0, -w)
+	return Polygon2D(p.Vertices())
+}
+
+// TSlot2D returns the 2D profile of a T-slot extrusion.
+func TSlot2D(name string) (SDF2, error) {
+	t, err := TSlotLookup(name)
+	if err != nil {
+		return nil, err
+	}
+	body := Box2D(V2{t.Size, t.Size}, 0.05*t.Size)
+	slots := RotateCopy2D(tslotCavity2D(t), 4)
+	s := Difference2D(body, slots)
+	return Difference2D(s, Circle2D(0.5*t.BoreDiameter)), nil
+}
+
+// TSlot3D returns a length of T-slot extrusion along the z-axis.
+func TSlot3D(name string, length float64) (SDF3, error) {
+	s, err := TSlot2D(name)
+	if err != nil {
+		return nil, err
+	}
+	return Extrude3D(s, length), nil
+}
+
+//-----------------------------------------------------------------------------
+// Slot Nuts
+
+// TSlotNutParms defines the parameters for a T-slot nut.
+type TSlotNutParms struct {
+	Extrusion  string  // name of extrusion
+	Length     float64 // length of the nut along the slot
+	HoleRadius float64 // radius of the bolt hole
+	Clearance  float64 // clearance between the nut and the slot
+}
+
+// TSlotNut3D returns a nut that slides in the slot of a T-slot extrusion.
+// The slot runs along the x-axis, the outside face of the extrusion is at z = 0
+// and the nut extends in the -z direction.
+func TSlotNut3D(k *TSlotNutParms) (SDF3, error) {
+	// validate parameters
+	t, err := TSlotLookup(k.Extrusion)
+	if err != nil {
+		return nil, err
+	}
+	if k.Length <= 0 {
+		return nil, errors.New("Length <= 0")
+	}
+	if k.Clearance < 0 {
+		return nil, errors.New("Clearance < 0")
+	}
+	c := k.Clearance
+	w := 0.5*t.SlotWidth - c
+	cw := 0.5*t.CavityWidth - c
+	z1 := -t.LipThickness - c
+	z2 := -t.SlotDepth + c
+	taper := cw - w
+	if w <= 0 || z2+taper >= z1 {
+		return nil, errors.New("Clearance too large")
+	}
+	if k.HoleRadius < 0 || k.HoleRadius >= w {
+		return nil, errors.New("invalid HoleRadius")
+	}
+
+	// nut profile in the y/z plane
+	p := NewPolygon()
+	p.Add(w, 0)
+	p.Add(w, z1)
+	p.Add(cw, z1)
+	p.Add(cw, z2+taper)
+	p.Add(w, z2)
+	p.Add(-w, z2)
+	p.Add(-cw, z2+taper)
+	p.Add(-cw, z1)
+	p.Add(-w, z1)
+	p.Add(-w, 0)
+	s := Extrude3D(Polygon2D(p.Vertices()), k.Length)
+	// the profile y-axis is the z-axis, the extrusion z-axis is the x-axis
+	s = Transform3D(s, RotateZ(DtoR(90)).Mul(RotateX(DtoR(90))))
+
+	if k.HoleRadius > 0 {
+		hole := Cylinder3D(t.SlotDepth, k.HoleRadius, 0)
+		hole = Transform3D(hole, Translate3d(V3{0, 0, -0.5 * t.SlotDepth}))
+		s = Difference3D(s, hole)
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
+// Corner Brackets
+
+// TSlotBracketParms defines the parameters for a T-slot corner bracket.
+type TSlotBracketParms struct {
+	Extrusion  string  // name of extrusion
+	Length     float64 // length of each bracket leg
+	Thickness  float64 // thickness of each bracket leg
+	HoleRadius float64 // radius of the bolt holes
+	Clearance  float64 // clearance between the locating keys and the slot opening
+	Gussets    bool    // add gussets to stiffen the bracket
+}
+
+// TSlotBracket3D returns an inside corner bracket for joining two T-slot extrusions at right angles.
+// The mounting faces are on the z = 0 and x = 0 planes and the bracket occupies +x, +z.
+// Locating keys project from the mounting faces into the slot openings.
+func TSlotBracket3D(k *TSlotBracketParms) (SDF3, error) {
+	// validate parameters
+	t, err := TSlotLookup(k.Extrusion)
+	if err != nil {
+		return nil, err
+	}
+	if k.Thickness <= 0 {
+		return nil, errors.New("Thickness <= 0")
+	}
+	if k.Length <= k.Thickness {
+		return nil, errors.New("Length <= Thickness")
+	}
+	if k.Clearance < 0 || k.Clearance >= 0.5*t.SlotWidth {
+		return nil, errors.New("invalid Clearance")
+	}
+	if k.HoleRadius < 0 || k.HoleRadius >= 0.5*t.SlotWidth-k.Clearance {
+		return nil, errors.New("invalid HoleRadius")
+	}
+
+	l := k.Length
+	th := k.Thickness
+	size := t.Size
+
+	// the legs
+	leg0 := Box3D(V3{l, size, th}, 0)
+	leg0 = Transform3D(leg0, Translate3d(V3{0.5 * l, 0, 0.5 * th}))
+	leg1 := Box3D(V3{th, size, l}, 0)
+	leg1 = Transform3D(leg1, Translate3d(V3{0.5 * th, 0, 0.5 * l}))
+	parts := []SDF3{leg0, leg1}
+
+	// the locating keys
+	kw := t.SlotWidth - 2*k.Clearance
+	kh := 0.5 * t.LipThickness
+	key0 := Box3D(V3{l - th, kw, kh}, 0)
+	key0 = Transform3D(key0, Translate3d(V3{0.5 * (l + th), 0, -0.5 * kh}))
+	key1 := Box3D(V3{kh, kw, l - th}, 0)
+	key1 = Transform3D(key1, Translate3d(V3{-0.5 * kh, 0, 0.5 * (l + th)}))
+	parts = append(parts, key0, key1)
+
+	// the gussets
+	if k.Gussets {
+		p := NewPolygon()
+		p.Add(0, 0)
+		p.Add(l, 0)
+		p.Add(0, l)
+		g := Extrude3D(Polygon2D(p.Vertices()), th)
+		g = Transform3D(g, RotateX(DtoR(90)))
+		yOfs := 0.5 * (size - th)
+		parts = append(parts, Transform3D(g, Translate3d(V3{0, yOfs, 0})))
+		parts = append(parts, Transform3D(g, Translate3d(V3{0, -yOfs, 0})))
+	}
+	s := Union3D(parts...)
+
+	// the bolt holes
+	if k.HoleRadius > 0 {
+		m := 0.5 * (l + th)
+		h := Cylinder3D(3*th, k.HoleRadius, 0)
+		h0 := Transform3D(h, Translate3d(V3{m, 0, 0}))
+		h1 := Transform3D(h, Translate3d(V3{0, 0, m}).Mul(RotateY(DtoR(90))))
+		s = Difference3D(s, Union3D(h0, h1))
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -78,6 +78,30 @@ func Min(a, b float64) float64 {
 	return b
 }
 
+// maxInt returns the maximum of integers a and b
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// minInt returns the minimum of integers a and b
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// absInt returns the absolute value of integer x
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
 //-----------------------------------------------------------------------------
 
 // Abs returns the absolute value of x